@@ -2,19 +2,32 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/joho/godotenv"
 	discordstayonline "github.com/pyyupsk/discord-stayonline"
 	"github.com/pyyupsk/discord-stayonline/internal/api"
+	"github.com/pyyupsk/discord-stayonline/internal/api/handlers"
+	"github.com/pyyupsk/discord-stayonline/internal/api/middleware"
+	"github.com/pyyupsk/discord-stayonline/internal/audit"
+	"github.com/pyyupsk/discord-stayonline/internal/backup"
 	"github.com/pyyupsk/discord-stayonline/internal/config"
 	"github.com/pyyupsk/discord-stayonline/internal/config/store"
+	"github.com/pyyupsk/discord-stayonline/internal/deadletter"
+	"github.com/pyyupsk/discord-stayonline/internal/gateway"
 	"github.com/pyyupsk/discord-stayonline/internal/manager"
+	"github.com/pyyupsk/discord-stayonline/internal/metrics"
+	"github.com/pyyupsk/discord-stayonline/internal/runtimeconfig"
+	"github.com/pyyupsk/discord-stayonline/internal/telemetry"
+	"github.com/pyyupsk/discord-stayonline/internal/ui"
 	"github.com/pyyupsk/discord-stayonline/internal/webhook"
 	"github.com/pyyupsk/discord-stayonline/internal/ws"
 )
@@ -25,26 +38,68 @@ func main() {
 	logger := initLogger()
 	token := getEnvOrDefault("DISCORD_TOKEN", "")
 	port := getEnvOrDefault("PORT", "8080")
-	webhookURL := os.Getenv("DISCORD_WEBHOOK_URL")
+	// Both accept comma-separated URLs; notifications go to all of them.
+	webhookURLs := os.Getenv("DISCORD_WEBHOOK_URL") + "," + os.Getenv("WEBHOOK_URLS")
 
 	if token == "" {
 		slog.Warn("DISCORD_TOKEN not set - connections will fail until token is configured")
 	}
 
-	webhookNotifier := webhook.NewNotifier(webhookURL, logger)
+	if allowed := config.ParseGuildIDs(getEnvOrDefault("ALLOWED_GUILD_IDS", "")); len(allowed) > 0 {
+		config.SetAllowedGuildIDs(allowed)
+		slog.Info("Guild allowlist enabled", "guilds", len(allowed))
+	}
+
+	config.SetMaxServerEntries(getEnvInt("MAX_SERVER_ENTRIES", config.MaxServerEntries))
+
+	deadLetterSize := getEnvInt("DEAD_LETTER_SIZE", 0)
+	deadLetters := deadletter.New(deadLetterSize)
+	if deadLetters != nil {
+		slog.Info("Dead-letter buffer enabled", "size", deadLetterSize)
+	}
+
+	webhookNotifier := webhook.NewNotifier(webhookURLs, logger)
 	if webhookNotifier != nil {
-		slog.Info("Discord webhook notifications enabled")
+		webhookType, err := webhook.ParseType(getEnvOrDefault("WEBHOOK_TYPE", string(webhook.TypeDiscord)))
+		if err != nil {
+			slog.Warn("Invalid WEBHOOK_TYPE, sending Discord payloads", "error", err)
+			webhookType = webhook.TypeDiscord
+		}
+		webhookNotifier.SetType(webhookType)
+		slog.Info("Webhook notifications enabled", "type", webhookType)
+		webhookNotifier.SetDeadLetters(deadLetters)
+		if raw := os.Getenv("WEBHOOK_HEADERS"); raw != "" {
+			if headers, err := webhook.ParseHeaders(raw); err == nil {
+				webhookNotifier.SetHeaders(headers)
+			} else {
+				slog.Warn("Invalid WEBHOOK_HEADERS, sending without custom headers", "error", err)
+			}
+		}
+		webhookNotifier.SetSigningSecret(os.Getenv("WEBHOOK_SIGNING_SECRET"))
+		webhookNotifier.SetTimeout(time.Duration(getEnvInt("WEBHOOK_TIMEOUT_SECONDS", 10)) * time.Second)
+		webhookNotifier.SetMaxRetries(getEnvInt("WEBHOOK_MAX_RETRIES", webhook.DefaultMaxRetries))
 	}
 
 	store, dbStore := initStore()
-	cfg, err := store.Load()
+	if getEnvBool("BACKUP_RESTORE_ON_EMPTY", false) {
+		restoreFromBackup(store)
+	}
+	if getEnvBool("ACKNOWLEDGE_TOS", false) {
+		acknowledgeTOSFromEnv(store)
+	}
+	cfg, err := config.LoadConfig(store)
 	if err != nil {
 		slog.Error("Failed to load config", "error", err)
 		os.Exit(1)
 	}
 	slog.Info("Configuration loaded", "servers", len(cfg.Servers), "tos_acknowledged", cfg.TOSAcknowledged)
 
-	hub := initHub(logger, dbStore)
+	var hub *ws.Hub
+	if getEnvBool("WS_ENABLED", true) {
+		hub = initHub(logger, dbStore, deadLetters, webhookNotifier)
+	} else {
+		slog.Info("WebSocket hub disabled - /ws, /api/logs, and /api/dead-letters are unavailable")
+	}
 	sessionMgr := initSessionManager(token, store, dbStore, hub, webhookNotifier, logger)
 
 	webFS, err := discordstayonline.GetWebFS()
@@ -58,13 +113,93 @@ func main() {
 		slog.Error("Failed to create router", "error", err)
 		os.Exit(1)
 	}
+	router.PublicStatus = getEnvBool("PUBLIC_STATUS", false)
+	if router.PublicStatus {
+		slog.Info("Public status page enabled at /api/public/status")
+	}
+	router.MinimalHealth = getEnvBool("HEALTH_MINIMAL", false)
+	router.MetricsPublic = getEnvBool("METRICS_PUBLIC", false)
+	router.GenerateServerIDs = getEnvBool("GENERATE_SERVER_IDS", false)
+	router.DiscordMaxRetries = getEnvInt("DISCORD_API_MAX_RETRIES", handlers.DefaultDiscordMaxRetries)
+	router.DiscordBreakerThreshold = getEnvInt("DISCORD_API_BREAKER_THRESHOLD", handlers.DefaultDiscordBreakerThreshold)
+	router.DiscordBreakerCooldown = time.Duration(getEnvInt("DISCORD_API_BREAKER_COOLDOWN_SECONDS", int(handlers.DefaultDiscordBreakerCooldown/time.Second))) * time.Second
+	router.Backups = initBackups(store)
+	router.Audit = initAudit(dbStore)
+	if getEnvBool("OPENAPI_ENABLED", true) {
+		router.OpenAPISpec = discordstayonline.OpenAPISpec
+	}
+	if proxies, err := middleware.ParseTrustedProxies(getEnvOrDefault("TRUSTED_PROXIES", "")); err == nil {
+		router.TrustedProxies = proxies
+	} else {
+		slog.Warn("Invalid TRUSTED_PROXIES, ignoring forwarded client IPs", "error", err)
+	}
+	router.RuntimeConfig = runtimeConfig
+	router.SPARoutes = ui.ParseRoutes(getEnvOrDefault("SPA_ROUTES", ""))
 	srv := createServer(port, router.Setup())
 
+	bgCtx, stopBackground := context.WithCancel(context.Background())
+	go router.Backups.Run(bgCtx, time.Duration(getEnvInt("BACKUP_INTERVAL_MINUTES", 0))*time.Minute, logger)
+	if getEnvBool("CONFIG_WATCH", false) {
+		watchConfig(bgCtx, store, sessionMgr)
+	}
 	go startSessionManager(sessionMgr)
 	go startHTTPServer(srv, port)
 
 	waitForShutdown()
-	shutdown(srv, sessionMgr, hub, dbStore)
+	stopBackground()
+	shutdown(srv, router, sessionMgr, hub, dbStore)
+}
+
+// watchConfig reloads the config file when it changes and has the manager
+// apply it. Only the file store can be watched.
+func watchConfig(ctx context.Context, cfgStore config.ConfigStore, sessionMgr *manager.SessionManager) {
+	fileStore, ok := cfgStore.(*store.File)
+	if !ok {
+		slog.Warn("CONFIG_WATCH only applies to the file store - ignoring")
+		return
+	}
+	updates, err := fileStore.Watch(ctx)
+	if err != nil {
+		slog.Error("Failed to watch config file", "path", fileStore.Path(), "error", err)
+		return
+	}
+	sessionMgr.WatchConfig(updates)
+	slog.Info("Watching config file for changes", "path", fileStore.Path())
+}
+
+// restoreFromBackup fills an empty store from the newest backup in
+// BACKUP_DIR, for recovering after the store's volume was lost.
+func restoreFromBackup(store config.ConfigStore) {
+	dir := getEnvOrDefault("BACKUP_DIR", "")
+	if dir == "" {
+		slog.Warn("BACKUP_RESTORE_ON_EMPTY is set without BACKUP_DIR - nothing to restore from")
+		return
+	}
+	name, err := backup.RestoreIfEmpty(dir, store)
+	switch {
+	case err != nil:
+		slog.Error("Failed to restore config from backup", "dir", dir, "error", err)
+	case name != "":
+		slog.Warn("Store was empty - restored config from backup", "file", name)
+	}
+}
+
+func initBackups(store config.ConfigStore) *backup.Backups {
+	backups := backup.New(getEnvOrDefault("BACKUP_DIR", ""), getEnvInt("BACKUP_KEEP", backup.DefaultKeep), store)
+	if backups != nil {
+		slog.Info("Config backups enabled at /api/config/backup", "dir", os.Getenv("BACKUP_DIR"))
+	}
+	return backups
+}
+
+// initAudit returns where configuration changes are recorded: the database
+// when there is one, else an in-memory ring.
+func initAudit(dbStore *store.Database) *audit.Log {
+	size := getEnvInt("AUDIT_LOG_SIZE", audit.DefaultSize)
+	if dbStore != nil {
+		return audit.NewStore(&dbAuditStore{db: dbStore}, size)
+	}
+	return audit.New(size)
 }
 
 func initLogger() *slog.Logger {
@@ -75,48 +210,272 @@ func initLogger() *slog.Logger {
 	return logger
 }
 
+// runtimeConfig records every setting read through the getEnv helpers for
+// GET /api/runtime-config.
+var runtimeConfig = runtimeconfig.New()
+
 func getEnvOrDefault(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+	return runtimeConfig.String(key, defaultValue)
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	return runtimeConfig.Int(key, defaultValue)
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	return runtimeConfig.Bool(key, defaultValue)
+}
+
+func acknowledgeTOSFromEnv(store config.ConfigStore) {
+	changed, err := config.AcknowledgeTOS(store)
+	if err != nil {
+		slog.Error("Failed to acknowledge TOS from environment", "error", err)
+		os.Exit(1)
+	}
+	if changed {
+		slog.Warn("TOS ACKNOWLEDGED VIA ACKNOWLEDGE_TOS ENVIRONMENT VARIABLE - automated use of user tokens may violate Discord's Terms of Service")
+	}
+}
+
+// openStore opens the backend selected by STORAGE_URL. Without it,
+// SQLITE_PATH selects SQLite, DATABASE_URL selects SQLite for a sqlite: URL
+// and PostgreSQL for anything else (a URL or keyword DSN), and otherwise the
+// file at CONFIG_PATH is used, as before STORAGE_URL existed.
+func openStore() (config.ConfigStore, string, error) {
+	if raw := os.Getenv("STORAGE_URL"); raw != "" {
+		return openStoreURL(raw)
+	}
+	if path := getEnvOrDefault("SQLITE_PATH", ""); path != "" {
+		cfgStore, err := store.NewSQLite(path)
+		return cfgStore, "sqlite", err
+	}
+	if databaseURL := os.Getenv("DATABASE_URL"); databaseURL != "" {
+		if strings.HasPrefix(databaseURL, "sqlite:") {
+			return openStoreURL(databaseURL)
+		}
+		cfgStore, err := store.NewPostgres(databaseURL)
+		return cfgStore, "postgres", err
+	}
+	return store.NewFile(getEnvOrDefault("CONFIG_PATH", "config.json")), "file", nil
+}
+
+func openStoreURL(raw string) (config.ConfigStore, string, error) {
+	u, err := store.ParseURL(raw)
+	if err != nil {
+		return nil, "", err
 	}
-	return defaultValue
+	cfgStore, err := store.Open(raw)
+	return cfgStore, u.Scheme, err
 }
 
-func initStore() (config.ConfigStore, *store.Postgres) {
-	databaseURL := os.Getenv("DATABASE_URL")
-	if databaseURL != "" {
-		slog.Info("Using PostgreSQL for configuration storage")
-		dbStore, err := store.NewPostgres(databaseURL)
+func initStore() (config.ConfigStore, *store.Database) {
+	if key := os.Getenv("ENCRYPTION_KEY"); key != "" {
+		cipher, err := store.NewCipher(key)
 		if err != nil {
-			slog.Error("Failed to connect to database", "error", err)
+			slog.Error("Invalid ENCRYPTION_KEY", "error", err)
 			os.Exit(1)
 		}
-		return dbStore, dbStore
+		store.UseEncryption(cipher)
 	}
 
-	slog.Info("Using file for configuration storage")
-	configPath := getEnvOrDefault("CONFIG_PATH", "config.json")
-	return store.NewFile(configPath), nil
+	cfgStore, backend, err := openStore()
+	if err != nil {
+		slog.Error("Failed to open configuration storage", "backend", backend, "error", err)
+		os.Exit(1)
+	}
+	slog.Info("Using configuration storage", "backend", backend)
+
+	dbStore, ok := cfgStore.(*store.Database)
+	if !ok {
+		return cfgStore, nil
+	}
+	if os.Getenv("ENCRYPTION_KEY") != "" {
+		slog.Info("Encryption at rest enabled for session data")
+	}
+	if err := dbStore.SetMaxLogEntries(getEnvInt("MAX_LOG_ENTRIES", store.MaxLogEntries)); err != nil {
+		slog.Warn("Invalid MAX_LOG_ENTRIES, using default", "error", err, "default", store.MaxLogEntries)
+	}
+	return dbStore, dbStore
 }
 
-func initHub(logger *slog.Logger, dbStore *store.Postgres) *ws.Hub {
+func initHub(logger *slog.Logger, dbStore *store.Database, deadLetters *deadletter.Buffer, webhookNotifier *webhook.Notifier) *ws.Hub {
 	var logStore ws.LogStore
 	if dbStore != nil {
 		logStore = &dbLogStore{db: dbStore}
 	}
 	hub := ws.NewHub(logger, logStore)
+	hub.SetDeadLetters(deadLetters)
+	hub.SetStatusInterval(time.Duration(getEnvInt("WS_STATUS_INTERVAL_MS", 0)) * time.Millisecond)
+	hub.SetAppPong(getEnvBool("WS_APP_PONG", true))
+	hub.SetReplaySize(getEnvInt("WS_REPLAY_SIZE", ws.DefaultReplaySize))
+	if raw := getEnvOrDefault("LOG_PERSIST_LEVEL", ""); raw != "" {
+		if level, ok := ws.ParseLogLevel(raw); ok {
+			hub.SetMinPersistLevel(level)
+		} else {
+			slog.Warn("Invalid LOG_PERSIST_LEVEL, persisting all levels", "value", raw)
+		}
+	}
+	if webhookNotifier != nil && getEnvBool("WEBHOOK_NOTIFY_DASHBOARD", false) {
+		hub.SetMilestoneNotifier(webhookNotifier)
+	}
 	go hub.Run()
 	return hub
 }
 
-func initSessionManager(token string, store config.ConfigStore, dbStore *store.Postgres, hub *ws.Hub, webhookNotifier *webhook.Notifier, logger *slog.Logger) *manager.SessionManager {
-	var sessionStore manager.SessionStore
+// lookupBotGateway fetches the recommended Gateway URL for a bot token,
+// returning "" to fall back to the default URL on failure.
+func lookupBotGateway(token string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	gw, err := gateway.FetchBotGateway(ctx, nil, "", token)
+	if err != nil {
+		slog.Warn("Failed to fetch /gateway/bot, using default Gateway URL", "error", err)
+		return ""
+	}
+
+	limit := gw.SessionStartLimit
+	slog.Info("Using Gateway URL from /gateway/bot",
+		"url", gw.URL,
+		"session_starts_remaining", limit.Remaining,
+		"session_starts_total", limit.Total,
+		"reset_after_ms", limit.ResetAfter,
+		"max_concurrency", limit.MaxConcurrency,
+	)
+	if limit.Remaining == 0 {
+		slog.Warn("No session starts remaining, new connections will fail until the limit resets", "reset_after_ms", limit.ResetAfter)
+	}
+	return gw.ConnectURL()
+}
+
+// presenceActivity returns the activity set by ACTIVITY_TEXT, ACTIVITY_TYPE
+// and, for streaming, ACTIVITY_URL, or nil when no text is set.
+func presenceActivity() *gateway.Activity {
+	text := getEnvOrDefault("ACTIVITY_TEXT", "")
+	if text == "" {
+		return nil
+	}
+	activityType, err := gateway.ParseActivityType(getEnvOrDefault("ACTIVITY_TYPE", "custom"))
+	if err != nil {
+		slog.Warn("Invalid ACTIVITY_TYPE, showing a custom status", "error", err)
+		activityType = gateway.ActivityCustom
+	}
+	if activityType == gateway.ActivityStreaming {
+		activity, err := gateway.NewStreamingActivity(text, getEnvOrDefault("ACTIVITY_URL", ""))
+		if err == nil {
+			return &activity
+		}
+		slog.Warn("Invalid ACTIVITY_URL for streaming, showing as playing instead", "error", err)
+		activityType = gateway.ActivityPlaying
+	}
+	activity := gateway.NewActivity(activityType, text)
+	return &activity
+}
+
+// initTelemetry starts OpenTelemetry export to the OTLP collector configured
+// by the standard OTEL_EXPORTER_OTLP_* variables. It returns nil, leaving
+// telemetry off, if the exporters cannot be created.
+func initTelemetry(sessionMgr *manager.SessionManager) *telemetry.Telemetry {
+	t, err := telemetry.New(context.Background())
+	if err != nil {
+		slog.Warn("Failed to start OpenTelemetry export, continuing without it", "error", err)
+		return nil
+	}
+	if err := t.SetHeartbeatAges(sessionMgr.HeartbeatAges); err != nil {
+		slog.Warn("Failed to export heartbeat ages over OpenTelemetry", "error", err)
+	}
+	slog.Info("OpenTelemetry export enabled")
+	return t
+}
+
+// initSessionStore returns where Gateway sessions are persisted for resume:
+// the database when there is one, otherwise a sessions file beside the config
+// file unless FILE_SESSION_STORE is false.
+func initSessionStore(cfgStore config.ConfigStore, dbStore *store.Database) manager.SessionStore {
 	if dbStore != nil {
-		sessionStore = &dbSessionStore{db: dbStore}
+		return &dbSessionStore{db: dbStore}
 	}
-	sessionMgr := manager.NewSessionManager(token, store, sessionStore, webhookNotifier, logger)
-	sessionMgr.OnStatusChange = func(serverID string, status manager.ConnectionStatus, message string) {
-		hub.BroadcastStatus(serverID, string(status), message)
+	fileStore, ok := cfgStore.(*store.File)
+	if !ok || !getEnvBool("FILE_SESSION_STORE", true) {
+		return nil
+	}
+	path := getEnvOrDefault("SESSIONS_PATH", filepath.Join(filepath.Dir(fileStore.Path()), "sessions.json"))
+	slog.Info("Using file for session storage", "path", path)
+	return store.NewFileSessions(path)
+}
+
+func initSessionManager(token string, store config.ConfigStore, dbStore *store.Database, hub *ws.Hub, webhookNotifier *webhook.Notifier, logger *slog.Logger) *manager.SessionManager {
+	sessionMgr := manager.NewSessionManager(token, store, initSessionStore(store, dbStore), webhookNotifier, logger)
+	sessionMgr.FrameLogSize = getEnvInt("GATEWAY_FRAME_LOG_SIZE", 0)
+	sessionMgr.KeepAlive = time.Duration(getEnvInt("GATEWAY_TCP_KEEPALIVE_SECONDS", 0)) * time.Second
+	sessionMgr.MaxMissedHeartbeatAcks = getEnvInt("GATEWAY_MAX_MISSED_ACKS", gateway.DefaultMaxMissedHeartbeatAcks)
+	sessionMgr.ImmediateFirstHeartbeat = getEnvBool("GATEWAY_IMMEDIATE_FIRST_HEARTBEAT", false)
+	sessionMgr.Intents = getEnvInt("GATEWAY_INTENTS", 0)
+	sessionMgr.CompressZlibStream = getEnvBool("GATEWAY_ZLIB_STREAM", false)
+	sessionMgr.Activity = presenceActivity()
+	if raw := getEnvOrDefault("GATEWAY_SHARD", ""); raw != "" {
+		if shard, err := gateway.ParseShard(raw); err == nil {
+			sessionMgr.Shard = &shard
+		} else {
+			slog.Warn("Invalid GATEWAY_SHARD, identifying without a shard", "error", err)
+		}
+	}
+	sessionMgr.ReadTimeout = time.Duration(getEnvInt("GATEWAY_READ_TIMEOUT_SECONDS", 0)) * time.Second
+	sessionMgr.QuickConnectRetries = getEnvInt("GATEWAY_QUICK_RETRIES", 2)
+	sessionMgr.QuickConnectRetryDelay = time.Duration(getEnvInt("GATEWAY_QUICK_RETRY_MS", 500)) * time.Millisecond
+	sessionMgr.MaxReconnectAttempts = getEnvInt("MAX_RECONNECT_ATTEMPTS", 0)
+	sessionMgr.MaintenanceReconnectDelay = time.Duration(getEnvInt("GATEWAY_MAINTENANCE_RECONNECT_MS", 1000)) * time.Millisecond
+	sessionMgr.CleanupOrphanedSessions = getEnvBool("CLEANUP_ORPHANED_SESSIONS", true)
+	sessionMgr.MaxConnections = getEnvInt("MAX_CONNECTIONS", config.MaxServers())
+	sessionMgr.MaxConnectionsPerGuild = getEnvInt("MAX_CONNECTIONS_PER_GUILD", 0)
+	if raw := getEnvOrDefault("GUILD_CONNECTION_LIMITS", ""); raw != "" {
+		if limits, err := manager.ParseGuildLimits(raw); err == nil {
+			sessionMgr.GuildConnectionLimits = limits
+		} else {
+			slog.Warn("Invalid GUILD_CONNECTION_LIMITS, using MAX_CONNECTIONS_PER_GUILD for every guild", "error", err)
+		}
+	}
+	sessionMgr.MaxConcurrentConnects = getEnvInt("MAX_CONCURRENT_CONNECTS", 0)
+	sessionMgr.PreemptLowerPriority = getEnvBool("PREEMPT_LOWER_PRIORITY", false)
+	sessionMgr.SharedPresenceConnection = getEnvBool("SHARED_PRESENCE_CONNECTION", false)
+	sessionMgr.ConfirmVoiceState = getEnvBool("CONFIRM_VOICE_STATE", false)
+	sessionMgr.VoiceJoinDelay = time.Duration(getEnvInt("VOICE_JOIN_DELAY_MS", 0)) * time.Millisecond
+	sessionMgr.StartupSummary = getEnvBool("WEBHOOK_STARTUP_SUMMARY", false)
+	// WEBHOOK_NOTIFY_ON_CONNECT is another name for NOTIFY_CONNECTED.
+	notifyConnected := getEnvBool("WEBHOOK_NOTIFY_ON_CONNECT", false)
+	sessionMgr.NotifyEvents = manager.NotifyEvents{
+		Down:         getEnvBool("NOTIFY_DOWN", true),
+		Up:           getEnvBool("NOTIFY_UP", true),
+		Reconnecting: getEnvBool("NOTIFY_RECONNECTING", true),
+		Connected:    getEnvBool("NOTIFY_CONNECTED", notifyConnected),
+	}
+	sessionMgr.NotifyWorkers = getEnvInt("WEBHOOK_WORKERS", manager.DefaultNotifyWorkers)
+	sessionMgr.NotifyQueueSize = getEnvInt("WEBHOOK_QUEUE_SIZE", manager.DefaultNotifyQueueSize)
+	sessionMgr.ResumeFailureGrace = time.Duration(getEnvInt("RESUME_FAILURE_GRACE_SECONDS", 15)) * time.Second
+	sessionMgr.SessionResumeTTL = time.Duration(getEnvInt("SESSION_RESUME_TTL_SECONDS", 120)) * time.Second
+	sessionMgr.SequenceFlushInterval = time.Duration(getEnvInt("SEQUENCE_FLUSH_SECONDS", int(manager.DefaultSequenceFlushInterval/time.Second))) * time.Second
+	if url := getEnvOrDefault("GATEWAY_URL", ""); url != "" {
+		sessionMgr.GatewayURL = url
+	} else if getEnvBool("GATEWAY_BOT_LOOKUP", false) {
+		sessionMgr.GatewayURL = lookupBotGateway(token)
+	}
+	if getEnvBool("METRICS_ENABLED", false) {
+		sessionMgr.Metrics = metrics.New()
+		sessionMgr.Metrics.SetHeartbeatAges(sessionMgr.HeartbeatAges)
+		sessionMgr.Metrics.SetSessionStatuses(sessionMgr.StatusCounts)
+		if hub != nil {
+			sessionMgr.Metrics.SetWebSocketClients(hub.ClientCount)
+		}
+		webhookNotifier.SetOnFailure(sessionMgr.Metrics.IncWebhookFailures)
+		slog.Info("Prometheus metrics enabled at /metrics")
+	}
+	if getEnvBool("OTEL_ENABLED", false) {
+		sessionMgr.Telemetry = initTelemetry(sessionMgr)
+	}
+	if hub != nil {
+		sessionMgr.OnStatusChange = func(serverID string, status manager.ConnectionStatus, message string) {
+			hub.BroadcastStatus(serverID, string(status), message)
+		}
 	}
 	return sessionMgr
 }
@@ -151,14 +510,19 @@ func waitForShutdown() {
 	<-quit
 }
 
-func shutdown(srv *http.Server, sessionMgr *manager.SessionManager, hub *ws.Hub, dbStore *store.Postgres) {
+func shutdown(srv *http.Server, router *api.Router, sessionMgr *manager.SessionManager, hub *ws.Hub, dbStore *store.Database) {
 	slog.Info("Shutting down server...")
 
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
 	sessionMgr.Stop()
-	hub.Close()
+	if err := sessionMgr.Telemetry.Shutdown(ctx); err != nil {
+		slog.Warn("Failed to flush OpenTelemetry data", "error", err)
+	}
+	if hub != nil {
+		hub.Close()
+	}
 
 	if dbStore != nil {
 		_ = dbStore.Close()
@@ -167,12 +531,13 @@ func shutdown(srv *http.Server, sessionMgr *manager.SessionManager, hub *ws.Hub,
 	if err := srv.Shutdown(ctx); err != nil {
 		slog.Error("Server forced to shutdown", "error", err)
 	}
+	router.Close()
 
 	slog.Info("Server stopped")
 }
 
 type dbLogStore struct {
-	db *store.Postgres
+	db *store.Database
 }
 
 func (s *dbLogStore) AddLog(level, message string) error {
@@ -196,8 +561,47 @@ func (s *dbLogStore) GetLogs(level string) ([]ws.LogEntry, error) {
 	return result, nil
 }
 
+type dbAuditStore struct {
+	db *store.Database
+}
+
+func (s *dbAuditStore) AddRecord(record audit.Record) error {
+	diff, err := json.Marshal(record.Diff)
+	if err != nil {
+		return err
+	}
+	return s.db.AddAudit(store.AuditEntry{
+		Action:     record.Action,
+		SessionID:  record.SessionID,
+		RemoteAddr: record.RemoteAddr,
+		Diff:       string(diff),
+		Timestamp:  record.Timestamp,
+	})
+}
+
+func (s *dbAuditStore) Records(action string, limit int) ([]audit.Record, error) {
+	entries, err := s.db.GetAudit(action, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]audit.Record, len(entries))
+	for i, entry := range entries {
+		result[i] = audit.Record{
+			Action:     entry.Action,
+			SessionID:  entry.SessionID,
+			RemoteAddr: entry.RemoteAddr,
+			Timestamp:  entry.Timestamp,
+		}
+		if err := json.Unmarshal([]byte(entry.Diff), &result[i].Diff); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
 type dbSessionStore struct {
-	db *store.Postgres
+	db *store.Database
 }
 
 func (s *dbSessionStore) SaveSession(state config.SessionState) error {
@@ -212,6 +616,10 @@ func (s *dbSessionStore) DeleteSession(serverID string) error {
 	return s.db.DeleteSession(serverID)
 }
 
-func (s *dbSessionStore) UpdateSessionSequence(serverID string, sequence int) error {
+func (s *dbSessionStore) ListSessionServerIDs() ([]string, error) {
+	return s.db.ListSessionServerIDs()
+}
+
+func (s *dbSessionStore) UpdateSessionSequence(serverID string, sequence int64) error {
 	return s.db.UpdateSessionSequence(serverID, sequence)
 }
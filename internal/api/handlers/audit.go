@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/pyyupsk/discord-stayonline/internal/api/middleware"
+	"github.com/pyyupsk/discord-stayonline/internal/api/responses"
+	"github.com/pyyupsk/discord-stayonline/internal/audit"
+)
+
+type AuditHandler struct {
+	log    *audit.Log
+	logger *slog.Logger
+}
+
+func NewAuditHandler(log *audit.Log, logger *slog.Logger) *AuditHandler {
+	return &AuditHandler{
+		log:    log,
+		logger: logger.With("handler", "audit"),
+	}
+}
+
+// GetAudit handles GET /api/audit requests.
+func (h *AuditHandler) GetAudit(w http.ResponseWriter, r *http.Request) {
+	records, err := h.log.Records(r.URL.Query().Get("action"))
+	if err != nil {
+		h.logger.Error("Failed to load audit records", "error", err)
+		responses.Error(w, http.StatusInternalServerError, "internal_error", "Failed to load audit records")
+		return
+	}
+	responses.JSON(w, http.StatusOK, records)
+}
+
+// recordAudit adds the change r made to log, attributed to the dashboard
+// session that authenticated it. Changes that altered nothing are skipped.
+func recordAudit(log *audit.Log, logger *slog.Logger, r *http.Request, action string, diff audit.Diff) {
+	if log == nil || diff.Empty() {
+		return
+	}
+	record := audit.Record{Action: action, RemoteAddr: r.RemoteAddr, Diff: diff}
+	if session, ok := middleware.SessionFromContext(r.Context()); ok {
+		record.SessionID = session.ID
+	}
+	if err := log.Add(record); err != nil {
+		logger.Error("Failed to store audit record", "action", action, "session_id", record.SessionID, "diff", diff, "error", err)
+		return
+	}
+	logger.Info("Configuration change audited", "action", action, "session_id", record.SessionID, "diff", diff)
+}
@@ -3,7 +3,6 @@ package handlers
 import (
 	"log/slog"
 	"net/http"
-	"time"
 
 	"github.com/pyyupsk/discord-stayonline/internal/api/middleware"
 	"github.com/pyyupsk/discord-stayonline/internal/api/responses"
@@ -37,17 +36,14 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	http.SetCookie(w, &http.Cookie{
-		Name:     middleware.CookieName,
-		Value:    req.APIKey,
-		Path:     "/",
-		MaxAge:   middleware.CookieMaxAge,
-		HttpOnly: true,
-		SameSite: http.SameSiteStrictMode,
-		Secure:   r.TLS != nil,
-	})
+	session, err := h.auth.Login(w, r)
+	if err != nil {
+		h.logger.Error("Failed to issue session", "error", err)
+		responses.Error(w, http.StatusInternalServerError, "internal_error", "Failed to create session")
+		return
+	}
 
-	h.logger.Info("Successful login")
+	h.logger.Info("Successful login", "session_id", session.ID)
 	responses.JSON(w, http.StatusOK, map[string]any{
 		"success": true,
 		"message": "Logged in successfully",
@@ -56,15 +52,7 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 
 // Logout handles POST /api/auth/logout requests.
 func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
-	http.SetCookie(w, &http.Cookie{
-		Name:     middleware.CookieName,
-		Value:    "",
-		Path:     "/",
-		MaxAge:   -1,
-		HttpOnly: true,
-		SameSite: http.SameSiteStrictMode,
-		Expires:  time.Unix(0, 0),
-	})
+	h.auth.Logout(w, r)
 
 	h.logger.Info("User logged out")
 	responses.JSON(w, http.StatusOK, map[string]any{
@@ -75,11 +63,45 @@ func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
 
 // Check handles GET /api/auth/check requests.
 func (h *AuthHandler) Check(w http.ResponseWriter, r *http.Request) {
-	cookie, err := r.Cookie(middleware.CookieName)
-	authenticated := err == nil && h.auth.ValidateKey(cookie.Value)
+	_, authenticated := h.auth.Authenticate(w, r)
 
 	responses.JSON(w, http.StatusOK, map[string]any{
 		"authenticated": authenticated,
 		"auth_required": true,
 	})
 }
+
+// DashboardSessionInfo is a dashboard session as listed by the API.
+type DashboardSessionInfo struct {
+	middleware.DashboardSession
+	Current bool `json:"current"`
+}
+
+// ListSessions handles GET /api/auth/sessions requests.
+func (h *AuthHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	current, _ := middleware.SessionFromContext(r.Context())
+
+	sessions := h.auth.Sessions().List()
+	result := make([]DashboardSessionInfo, 0, len(sessions))
+	for _, session := range sessions {
+		result = append(result, DashboardSessionInfo{
+			DashboardSession: session,
+			Current:          session.ID == current.ID,
+		})
+	}
+	responses.JSON(w, http.StatusOK, result)
+}
+
+// RevokeSession handles DELETE /api/auth/sessions/{id} requests.
+func (h *AuthHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if !h.auth.Sessions().Revoke(id) {
+		responses.Error(w, http.StatusNotFound, "not_found", "Session not found")
+		return
+	}
+
+	h.logger.Info("Dashboard session revoked", "session_id", id)
+	responses.JSON(w, http.StatusOK, map[string]any{
+		"success": true,
+	})
+}
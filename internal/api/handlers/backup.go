@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"path/filepath"
+
+	"github.com/pyyupsk/discord-stayonline/internal/api/responses"
+	"github.com/pyyupsk/discord-stayonline/internal/backup"
+)
+
+type BackupHandler struct {
+	backups *backup.Backups
+	logger  *slog.Logger
+}
+
+func NewBackupHandler(backups *backup.Backups, logger *slog.Logger) *BackupHandler {
+	return &BackupHandler{
+		backups: backups,
+		logger:  logger.With("handler", "backup"),
+	}
+}
+
+// CreateBackup handles POST /api/config/backup requests.
+func (h *BackupHandler) CreateBackup(w http.ResponseWriter, r *http.Request) {
+	path, err := h.backups.Write()
+	if err != nil {
+		h.logger.Error("Failed to write config backup", "error", err)
+		responses.Error(w, http.StatusInternalServerError, "backup_failed", "Failed to write config backup")
+		return
+	}
+
+	h.logger.Info("Config backup written", "path", path)
+	responses.JSON(w, http.StatusCreated, map[string]any{
+		"success": true,
+		"file":    filepath.Base(path),
+	})
+}
@@ -1,16 +1,38 @@
 package handlers
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"slices"
 
 	"github.com/pyyupsk/discord-stayonline/internal/api/responses"
+	"github.com/pyyupsk/discord-stayonline/internal/audit"
 	"github.com/pyyupsk/discord-stayonline/internal/config"
 )
 
+// serverEntryBodyBytes is the request body allowance per server entry, so
+// imports up to config.MaxServers entries fit when the limit is raised
+// past the default 1MB body cap.
+const serverEntryBodyBytes = 8 << 10
+
 type ConfigHandler struct {
 	store  config.ConfigStore
 	logger *slog.Logger
+
+	// OnChange is called after the configuration has been saved.
+	OnChange func()
+
+	// GenerateIDs gives submitted servers without an ID a random one instead
+	// of rejecting them.
+	GenerateIDs bool
+
+	// Audit, when non-nil, records what each saved change did.
+	Audit *audit.Log
 }
 
 func NewConfigHandler(store config.ConfigStore, logger *slog.Logger) *ConfigHandler {
@@ -22,13 +44,73 @@ func NewConfigHandler(store config.ConfigStore, logger *slog.Logger) *ConfigHand
 
 // GetConfig handles GET /api/config requests.
 func (h *ConfigHandler) GetConfig(w http.ResponseWriter, r *http.Request) {
-	cfg, err := h.store.Load()
+	cfg, err := config.LoadConfig(h.store)
 	if err != nil {
 		h.logger.Error(responses.ErrLoadConfig, "error", err)
 		responses.Error(w, http.StatusInternalServerError, "internal_error", responses.ErrLoadConfigMsg)
 		return
 	}
-	responses.JSON(w, http.StatusOK, cfg)
+	w.Header().Set("ETag", cfg.ETag())
+	h.writeConfig(w, cfg)
+}
+
+// writeConfig encodes cfg like responses.JSON, but streams the server list
+// one entry at a time through a small buffer, so a large list is not held in
+// memory as a single encoded document. The rest of the document is encoded
+// from cfg with an empty server list, which marks where the entries go.
+func (h *ConfigHandler) writeConfig(w http.ResponseWriter, cfg *config.Configuration) {
+	envelope := *cfg
+	envelope.Servers = []config.ServerEntry{}
+	data, err := json.Marshal(&envelope)
+	if err != nil {
+		h.logger.Error("Failed to encode configuration", "error", err)
+		responses.Error(w, http.StatusInternalServerError, "internal_error", responses.ErrLoadConfigMsg)
+		return
+	}
+	head, tail, ok := bytes.Cut(data, []byte(`"servers":[]`))
+	if !ok {
+		h.logger.Error("Failed to encode configuration", "error", "no servers field")
+		responses.Error(w, http.StatusInternalServerError, "internal_error", responses.ErrLoadConfigMsg)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	buf := bufio.NewWriterSize(w, 16<<10)
+	var entry bytes.Buffer
+	enc := json.NewEncoder(&entry)
+	_, _ = buf.Write(head)
+	_, _ = buf.WriteString(`"servers":[`)
+	for i := range cfg.Servers {
+		if i > 0 {
+			_ = buf.WriteByte(',')
+		}
+		entry.Reset()
+		if err := enc.Encode(&cfg.Servers[i]); err != nil {
+			// The status is already sent; drop the connection rather than
+			// end a 200 with a truncated document.
+			h.logger.Error("Failed to encode configuration", "server_id", cfg.Servers[i].ID, "error", err)
+			panic(http.ErrAbortHandler)
+		}
+		_, _ = buf.Write(bytes.TrimSuffix(entry.Bytes(), []byte("\n")))
+	}
+	_ = buf.WriteByte(']')
+	_, _ = buf.Write(tail)
+	_ = buf.WriteByte('\n')
+	if err := buf.Flush(); err != nil {
+		h.logger.Warn("Failed to write configuration", "error", err)
+	}
+}
+
+// decodeServers decodes a config write, allowing a body large enough for
+// config.MaxServers entries.
+func (h *ConfigHandler) decodeServers(w http.ResponseWriter, r *http.Request, v any) bool {
+	return responses.DecodeJSONLimit(w, r, h.logger, v, int64(config.MaxServers())*serverEntryBodyBytes)
+}
+
+func tooManyServers(w http.ResponseWriter) {
+	responses.Error(w, http.StatusBadRequest, "validation_error", fmt.Sprintf("Maximum %d server entries allowed", config.MaxServers()))
 }
 
 // ReplaceConfig handles POST /api/config requests.
@@ -38,34 +120,30 @@ func (h *ConfigHandler) ReplaceConfig(w http.ResponseWriter, r *http.Request) {
 		Status  config.Status        `json:"status,omitempty"`
 	}
 
-	if !responses.DecodeJSON(w, r, h.logger, &input) {
+	if !h.decodeServers(w, r, &input) {
 		return
 	}
+	config.NormalizeServerIDs(input.Servers, h.GenerateIDs)
 
-	if len(input.Servers) > config.MaxServerEntries {
-		responses.Error(w, http.StatusBadRequest, "validation_error", "Maximum 35 server entries allowed")
+	if len(input.Servers) > config.MaxServers() {
+		tooManyServers(w)
 		return
 	}
 
-	cfg, err := h.store.Load()
-	if err != nil {
-		h.logger.Error(responses.ErrLoadConfig, "error", err)
-		responses.Error(w, http.StatusInternalServerError, "internal_error", responses.ErrLoadConfigMsg)
-		return
-	}
-
-	cfg.Servers = input.Servers
-	if input.Status != "" {
-		cfg.Status = input.Status
-	}
-
-	if err := h.store.Save(cfg); err != nil {
-		h.logger.Error(responses.ErrSaveConfig, "error", err)
-		responses.Error(w, http.StatusBadRequest, "validation_error", err.Error())
+	before, cfg, ok := h.update(w, r, func(cfg *config.Configuration) {
+		cfg.Servers = input.Servers
+		if input.Status != "" {
+			cfg.Status = input.Status
+		}
+	})
+	if !ok {
 		return
 	}
 
+	w.Header().Set("ETag", cfg.ETag())
 	h.logger.Info("Configuration replaced", "servers", len(cfg.Servers))
+	recordAudit(h.Audit, h.logger, r, audit.ActionConfigReplace, audit.Compare(before, cfg))
+	h.notifyChange()
 	responses.JSON(w, http.StatusOK, map[string]any{
 		"success": true,
 		"servers": cfg.Servers,
@@ -79,38 +157,84 @@ func (h *ConfigHandler) UpdateConfig(w http.ResponseWriter, r *http.Request) {
 		Status  config.Status        `json:"status,omitempty"`
 	}
 
-	if !responses.DecodeJSON(w, r, h.logger, &input) {
+	if !h.decodeServers(w, r, &input) {
 		return
 	}
+	config.NormalizeServerIDs(input.Servers, h.GenerateIDs)
 
-	cfg, err := h.store.Load()
-	if err != nil {
-		h.logger.Error(responses.ErrLoadConfig, "error", err)
-		responses.Error(w, http.StatusInternalServerError, "internal_error", responses.ErrLoadConfigMsg)
+	before, cfg, ok := h.update(w, r, func(cfg *config.Configuration) {
+		cfg.Servers = mergeServers(cfg.Servers, input.Servers)
+		if input.Status != "" {
+			cfg.Status = input.Status
+		}
+	})
+	if !ok {
 		return
 	}
 
-	cfg.Servers = mergeServers(cfg.Servers, input.Servers)
-	if input.Status != "" {
-		cfg.Status = input.Status
-	}
+	w.Header().Set("ETag", cfg.ETag())
+	h.logger.Info("Configuration updated", "servers", len(cfg.Servers))
+	recordAudit(h.Audit, h.logger, r, audit.ActionConfigUpdate, audit.Compare(before, cfg))
+	h.notifyChange()
+	responses.JSON(w, http.StatusOK, map[string]any{
+		"success": true,
+		"servers": cfg.Servers,
+	})
+}
 
-	if len(cfg.Servers) > config.MaxServerEntries {
-		responses.Error(w, http.StatusBadRequest, "validation_error", "Maximum 35 server entries allowed")
-		return
-	}
+var (
+	errStaleConfig    = errors.New("configuration was modified by another request")
+	errTooManyServers = errors.New("too many server entries")
+)
 
-	if err := h.store.Save(cfg); err != nil {
+// update applies mutate through config.Update, so the If-Match check and the
+// save happen under the same lock as every other configuration writer. It
+// writes the error response itself and returns the configuration from before
+// and after the change. Requests without If-Match are allowed through for
+// backward compatibility.
+func (h *ConfigHandler) update(w http.ResponseWriter, r *http.Request, mutate func(cfg *config.Configuration)) (before, after *config.Configuration, ok bool) {
+	ifMatch := r.Header.Get("If-Match")
+	var current string
+	after, err := config.Update(h.store, func(cfg *config.Configuration) error {
+		if ifMatch != "" && ifMatch != "*" && ifMatch != cfg.ETag() {
+			current = cfg.ETag()
+			return errStaleConfig
+		}
+		// mergeServers updates entries in place, so keep a copy to diff against.
+		snapshot := *cfg
+		snapshot.Servers = slices.Clone(cfg.Servers)
+		before = &snapshot
+		mutate(cfg)
+		if len(cfg.Servers) > config.MaxServers() {
+			return errTooManyServers
+		}
+		return nil
+	})
+	switch {
+	case errors.Is(err, errStaleConfig):
+		h.logger.Warn("Rejected stale configuration write")
+		w.Header().Set("ETag", current)
+		responses.Error(w, http.StatusConflict, "config_conflict", "Configuration was modified by another request; reload and try again")
+		return nil, nil, false
+	case errors.Is(err, errTooManyServers):
+		tooManyServers(w)
+		return nil, nil, false
+	case errors.Is(err, config.ErrConfigLoad):
+		h.logger.Error(responses.ErrLoadConfig, "error", err)
+		responses.Error(w, http.StatusInternalServerError, "internal_error", responses.ErrLoadConfigMsg)
+		return nil, nil, false
+	case err != nil:
 		h.logger.Error(responses.ErrSaveConfig, "error", err)
 		responses.Error(w, http.StatusBadRequest, "validation_error", err.Error())
-		return
+		return nil, nil, false
 	}
+	return before, after, true
+}
 
-	h.logger.Info("Configuration updated", "servers", len(cfg.Servers))
-	responses.JSON(w, http.StatusOK, map[string]any{
-		"success": true,
-		"servers": cfg.Servers,
-	})
+func (h *ConfigHandler) notifyChange() {
+	if h.OnChange != nil {
+		h.OnChange()
+	}
 }
 
 func mergeServers(existing, updates []config.ServerEntry) []config.ServerEntry {
@@ -136,6 +260,9 @@ func mergeServers(existing, updates []config.ServerEntry) []config.ServerEntry {
 }
 
 func updateServerEntry(entry *config.ServerEntry, update config.ServerEntry) {
+	if update.Label != "" {
+		entry.Label = update.Label
+	}
 	if update.GuildID != "" {
 		entry.GuildID = update.GuildID
 	}
@@ -143,7 +270,20 @@ func updateServerEntry(entry *config.ServerEntry, update config.ServerEntry) {
 		entry.ChannelID = update.ChannelID
 	}
 	entry.ConnectOnStart = update.ConnectOnStart
+	entry.PresenceOnly = update.PresenceOnly
 	if update.Priority > 0 {
 		entry.Priority = update.Priority
 	}
+	if update.Status != "" {
+		entry.Status = update.Status
+	}
+	if update.SelfMute != nil {
+		entry.SelfMute = update.SelfMute
+	}
+	if update.SelfDeaf != nil {
+		entry.SelfDeaf = update.SelfDeaf
+	}
+	if update.QuietHours != nil {
+		entry.QuietHours = update.QuietHours
+	}
 }
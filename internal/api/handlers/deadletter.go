@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/pyyupsk/discord-stayonline/internal/api/responses"
+	"github.com/pyyupsk/discord-stayonline/internal/deadletter"
+)
+
+type DeadLetterHandler struct {
+	buffer *deadletter.Buffer
+	logger *slog.Logger
+}
+
+func NewDeadLetterHandler(buffer *deadletter.Buffer, logger *slog.Logger) *DeadLetterHandler {
+	return &DeadLetterHandler{
+		buffer: buffer,
+		logger: logger.With("handler", "deadletter"),
+	}
+}
+
+// GetDeadLetters handles GET /api/dead-letters requests.
+func (h *DeadLetterHandler) GetDeadLetters(w http.ResponseWriter, r *http.Request) {
+	responses.JSON(w, http.StatusOK, h.buffer.Entries())
+}
@@ -2,10 +2,12 @@ package handlers
 
 import (
 	"encoding/json"
-	"fmt"
+	"errors"
 	"log/slog"
+	"math"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -15,13 +17,34 @@ import (
 
 // DiscordHandler handles Discord API lookups.
 type DiscordHandler struct {
-	token  string
-	client *http.Client
-	cache  map[string]*cacheEntry
-	mu     sync.RWMutex
-	logger *slog.Logger
+	token   string
+	client  *http.Client
+	baseURL string
+	limiter *tokenBucket
+	cache   map[string]*cacheEntry
+	mu      sync.RWMutex
+	logger  *slog.Logger
+
+	// MaxRetries is how many times a request answered with 429 is retried
+	// after the requested delay. Zero uses DefaultDiscordMaxRetries.
+	MaxRetries int
+
+	// BreakerThreshold is how many consecutive failed requests open the
+	// circuit breaker, and BreakerCooldown how long it then fails requests
+	// fast. Zero uses DefaultDiscordBreakerThreshold and
+	// DefaultDiscordBreakerCooldown.
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+	breaker          circuitBreaker
+
+	done      chan struct{}
+	closeOnce sync.Once
 }
 
+// errDiscordHandlerClosed aborts a request waiting on the rate limiter or a
+// retry delay when the handler is closed.
+var errDiscordHandlerClosed = errors.New("discord handler closed")
+
 type cacheEntry struct {
 	data      any
 	expiresAt time.Time
@@ -69,18 +92,59 @@ type VoiceChannelInfo struct {
 const (
 	discordAPIBase        = "https://discord.com/api/v10"
 	cacheTTL              = 5 * time.Minute
+	cacheSweepInterval    = 10 * time.Minute
 	channelTypeGuildVoice = 2
 	channelTypeGuildStage = 13
 )
 
+// NewDiscordHandler creates the handler and starts a janitor that evicts
+// expired cache entries until Close is called.
 func NewDiscordHandler(logger *slog.Logger) *DiscordHandler {
-	return &DiscordHandler{
+	h := &DiscordHandler{
 		token: os.Getenv("DISCORD_TOKEN"),
 		client: &http.Client{
 			Timeout: 10 * time.Second,
 		},
-		cache:  make(map[string]*cacheEntry),
-		logger: logger.With("handler", "discord"),
+		baseURL: discordAPIBase,
+		limiter: newTokenBucket(discordRequestsPerSecond, discordRequestBurst),
+		cache:   make(map[string]*cacheEntry),
+		logger:  logger.With("handler", "discord"),
+		done:    make(chan struct{}),
+	}
+	go h.runJanitor(cacheSweepInterval)
+	return h
+}
+
+// Close stops the cache janitor. It is safe to call more than once.
+func (h *DiscordHandler) Close() {
+	h.closeOnce.Do(func() { close(h.done) })
+}
+
+// runJanitor sweeps the cache every interval until Close is called. Reads
+// already ignore expired entries; sweeping keeps looked-up IDs from growing
+// the map without bound.
+func (h *DiscordHandler) runJanitor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-h.done:
+			return
+		case <-ticker.C:
+			h.sweepCache()
+		}
+	}
+}
+
+// sweepCache removes the cache entries that have expired.
+func (h *DiscordHandler) sweepCache() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	now := time.Now()
+	for key, entry := range h.cache {
+		if !now.Before(entry.expiresAt) {
+			delete(h.cache, key)
+		}
 	}
 }
 
@@ -104,24 +168,124 @@ func (h *DiscordHandler) setCache(key string, data any) {
 	}
 }
 
+// getStale returns the cache entry for key even if it has expired, for
+// serving while Discord is unavailable.
+func (h *DiscordHandler) getStale(key string) (any, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if entry, ok := h.cache[key]; ok {
+		return entry.data, true
+	}
+	return nil, false
+}
+
+// fetchFromDiscord GETs endpoint unless the circuit breaker is open, in
+// which case it returns an *UnavailableError without contacting Discord.
 func (h *DiscordHandler) fetchFromDiscord(endpoint string, result any) error {
-	req, err := http.NewRequest("GET", discordAPIBase+endpoint, nil)
-	if err != nil {
+	if err := h.breakerAllow(); err != nil {
 		return err
 	}
-	req.Header.Set("Authorization", h.token)
+	err := h.fetch(endpoint, result)
+	h.breakerRecord(err)
+	return err
+}
+
+// fetch GETs endpoint through the handler's rate limiter, retrying 429
+// answers after the delay Discord asks for. It returns a *RateLimitError
+// once the retries run out.
+func (h *DiscordHandler) fetch(endpoint string, result any) error {
+	maxRetries := h.MaxRetries
+	if maxRetries <= 0 {
+		maxRetries = DefaultDiscordMaxRetries
+	}
 
-	resp, err := h.client.Do(req)
+	for attempt := 0; ; attempt++ {
+		if !h.wait(h.limiter.reserve()) {
+			return errDiscordHandlerClosed
+		}
+
+		resp, err := h.get(endpoint)
+		if err != nil {
+			return err
+		}
+
+		if resp.StatusCode == http.StatusTooManyRequests {
+			_ = resp.Body.Close()
+			delay := rateLimitDelay(resp.Header)
+			if attempt >= maxRetries || delay > maxRateLimitWait {
+				return &RateLimitError{Endpoint: endpoint, Retries: attempt, RetryAfter: delay}
+			}
+			h.logger.Warn("Discord API rate limited, retrying", "endpoint", endpoint, "retry_after", delay, "attempt", attempt+1)
+			if !h.wait(delay) {
+				return errDiscordHandlerClosed
+			}
+			continue
+		}
+
+		defer func() { _ = resp.Body.Close() }()
+		if resp.StatusCode != http.StatusOK {
+			return &discordStatusError{StatusCode: resp.StatusCode}
+		}
+		return json.NewDecoder(resp.Body).Decode(result)
+	}
+}
+
+func (h *DiscordHandler) get(endpoint string) (*http.Response, error) {
+	req, err := http.NewRequest("GET", h.baseURL+endpoint, nil)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer func() { _ = resp.Body.Close() }()
+	req.Header.Set("Authorization", h.token)
+	return h.client.Do(req)
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("discord API returned status %d", resp.StatusCode)
+// wait sleeps for d, returning false if the handler is closed first.
+func (h *DiscordHandler) wait(d time.Duration) bool {
+	if d <= 0 {
+		return true
 	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-h.done:
+		return false
+	}
+}
 
-	return json.NewDecoder(resp.Body).Decode(result)
+// discordError responds to a failed Discord lookup: 429 with Retry-After
+// for a rate limit, 503 with Retry-After while the circuit breaker is open,
+// 500 with message otherwise.
+func (h *DiscordHandler) discordError(w http.ResponseWriter, err error, message string) {
+	var rateErr *RateLimitError
+	if errors.As(err, &rateErr) {
+		w.Header().Set("Retry-After", retryAfterSeconds(rateErr.RetryAfter))
+		responses.Error(w, http.StatusTooManyRequests, "discord_rate_limited", "Discord API rate limit reached; try again later")
+		return
+	}
+	var unavailableErr *UnavailableError
+	if errors.As(err, &unavailableErr) {
+		w.Header().Set("Retry-After", retryAfterSeconds(unavailableErr.RetryAfter))
+		responses.Error(w, http.StatusServiceUnavailable, "discord_unavailable", "Discord API is unavailable; try again later")
+		return
+	}
+	responses.Error(w, http.StatusInternalServerError, "discord_error", message)
+}
+
+// retryAfterSeconds formats d as a Retry-After value in whole seconds.
+func retryAfterSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
+
+// lookupFailed returns the stale cache entry for key when err is the
+// circuit breaker failing the lookup fast.
+func (h *DiscordHandler) lookupFailed(key string, err error) (any, bool) {
+	var unavailableErr *UnavailableError
+	if !errors.As(err, &unavailableErr) {
+		return nil, false
+	}
+	return h.getStale(key)
 }
 
 // GetGuild fetches guild info from Discord API.
@@ -133,6 +297,9 @@ func (h *DiscordHandler) GetGuild(guildID string) (*GuildInfo, error) {
 
 	var guild GuildInfo
 	if err := h.fetchFromDiscord("/guilds/"+guildID, &guild); err != nil {
+		if stale, ok := h.lookupFailed(cacheKey, err); ok {
+			return stale.(*GuildInfo), nil
+		}
 		return nil, err
 	}
 
@@ -149,6 +316,9 @@ func (h *DiscordHandler) GetChannel(channelID string) (*ChannelInfo, error) {
 
 	var channel ChannelInfo
 	if err := h.fetchFromDiscord("/channels/"+channelID, &channel); err != nil {
+		if stale, ok := h.lookupFailed(cacheKey, err); ok {
+			return stale.(*ChannelInfo), nil
+		}
 		return nil, err
 	}
 
@@ -196,8 +366,12 @@ func (h *DiscordHandler) GetCurrentUser(w http.ResponseWriter, r *http.Request)
 
 	var user UserInfo
 	if err := h.fetchFromDiscord("/users/@me", &user); err != nil {
+		if stale, ok := h.lookupFailed(cacheKey, err); ok {
+			responses.JSON(w, http.StatusOK, stale)
+			return
+		}
 		h.logger.Error("Failed to fetch current user", "error", err)
-		responses.Error(w, http.StatusInternalServerError, "discord_error", "Failed to fetch user from Discord")
+		h.discordError(w, err, "Failed to fetch user from Discord")
 		return
 	}
 
@@ -215,8 +389,12 @@ func (h *DiscordHandler) GetUserGuilds(w http.ResponseWriter, r *http.Request) {
 
 	var guilds []GuildInfo
 	if err := h.fetchFromDiscord("/users/@me/guilds", &guilds); err != nil {
+		if stale, ok := h.lookupFailed(cacheKey, err); ok {
+			responses.JSON(w, http.StatusOK, stale)
+			return
+		}
 		h.logger.Error("Failed to fetch user guilds", "error", err)
-		responses.Error(w, http.StatusInternalServerError, "discord_error", "Failed to fetch guilds from Discord")
+		h.discordError(w, err, "Failed to fetch guilds from Discord")
 		return
 	}
 
@@ -250,8 +428,12 @@ func (h *DiscordHandler) GetGuildChannels(w http.ResponseWriter, r *http.Request
 	}
 
 	if err := h.fetchFromDiscord("/guilds/"+guildID+"/channels", &channels); err != nil {
+		if stale, ok := h.lookupFailed(cacheKey, err); ok {
+			responses.JSON(w, http.StatusOK, stale)
+			return
+		}
 		h.logger.Error("Failed to fetch guild channels", "guild_id", guildID, "error", err)
-		responses.Error(w, http.StatusInternalServerError, "discord_error", "Failed to fetch channels from Discord")
+		h.discordError(w, err, "Failed to fetch channels from Discord")
 		return
 	}
 
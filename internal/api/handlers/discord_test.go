@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDiscordCacheJanitorEvictsExpiredEntries(t *testing.T) {
+	h := NewDiscordHandler(slog.Default())
+	defer h.Close()
+
+	h.mu.Lock()
+	h.cache["expiring"] = &cacheEntry{data: "old", expiresAt: time.Now().Add(20 * time.Millisecond)}
+	h.mu.Unlock()
+	h.setCache("fresh", "new")
+
+	go h.runJanitor(10 * time.Millisecond)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		h.mu.RLock()
+		_, expiring := h.cache["expiring"]
+		_, fresh := h.cache["fresh"]
+		h.mu.RUnlock()
+		if !fresh {
+			t.Fatal("expected the unexpired entry to be kept")
+		}
+		if !expiring {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected the expired entry to be swept")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	h.Close()
+	h.Close()
+}
+
+// rateLimitedDiscord serves /guilds/{id}, answering the first limited
+// requests with 429 and the rest with a guild.
+func rateLimitedDiscord(t *testing.T, limited int) (*DiscordHandler, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if int(requests.Add(1)) <= limited {
+			w.Header().Set("Retry-After", "0.01")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(GuildInfo{ID: "1", Name: "Guild"})
+	}))
+	t.Cleanup(server.Close)
+
+	h := NewDiscordHandler(slog.Default())
+	t.Cleanup(h.Close)
+	h.baseURL = server.URL
+	h.MaxRetries = 2
+	return h, &requests
+}
+
+func TestDiscordFetchRetriesAfterRateLimit(t *testing.T) {
+	h, requests := rateLimitedDiscord(t, 1)
+
+	guild, err := h.GetGuild("1")
+	if err != nil {
+		t.Fatalf("GetGuild() error = %v", err)
+	}
+	if guild.Name != "Guild" {
+		t.Errorf("expected the guild after the retry, got %+v", guild)
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("expected a 429 then a retry, got %d requests", got)
+	}
+}
+
+func TestDiscordFetchReturnsRateLimitError(t *testing.T) {
+	h, requests := rateLimitedDiscord(t, 100)
+
+	_, err := h.GetGuild("1")
+	var rateErr *RateLimitError
+	if !errors.As(err, &rateErr) {
+		t.Fatalf("expected a *RateLimitError, got %v", err)
+	}
+	if rateErr.Retries != 2 || rateErr.RetryAfter != 10*time.Millisecond {
+		t.Errorf("expected 2 retries and a 10ms delay, got %+v", rateErr)
+	}
+	if got := requests.Load(); got != 3 {
+		t.Errorf("expected the first request and 2 retries, got %d requests", got)
+	}
+
+	rec := httptest.NewRecorder()
+	h.discordError(rec, err, "failed")
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "1" {
+		t.Errorf("expected 429 with Retry-After, got %d %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+}
+
+func TestDiscordCircuitBreaker(t *testing.T) {
+	var requests atomic.Int32
+	var failing atomic.Bool
+	failing.Store(true)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if failing.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(UserInfo{ID: "1", Username: "user"})
+	}))
+	defer server.Close()
+
+	h := NewDiscordHandler(slog.Default())
+	defer h.Close()
+	h.baseURL = server.URL
+	h.BreakerThreshold = 2
+	h.BreakerCooldown = 50 * time.Millisecond
+
+	// An expired guild stays in the cache until the janitor sweeps it.
+	h.mu.Lock()
+	h.cache["guild:1"] = &cacheEntry{data: &GuildInfo{ID: "1", Name: "Stale"}, expiresAt: time.Now()}
+	h.mu.Unlock()
+
+	getUser := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.GetCurrentUser(rec, httptest.NewRequest(http.MethodGet, "/api/discord/user", nil))
+		return rec
+	}
+
+	for range 2 {
+		if rec := getUser(); rec.Code != http.StatusInternalServerError {
+			t.Fatalf("expected 500 while Discord fails, got %d", rec.Code)
+		}
+	}
+	if status := h.BreakerStatus(); status.State != BreakerOpen || status.ConsecutiveFailures != 2 {
+		t.Fatalf("expected the breaker open after 2 failures, got %+v", status)
+	}
+
+	// While open, requests fail fast or serve stale data without reaching
+	// Discord.
+	rec := getUser()
+	var body struct {
+		Error string `json:"error"`
+	}
+	_ = json.NewDecoder(rec.Body).Decode(&body)
+	if rec.Code != http.StatusServiceUnavailable || body.Error != "discord_unavailable" || rec.Header().Get("Retry-After") != "1" {
+		t.Errorf("expected 503 discord_unavailable with Retry-After, got %d %q %q", rec.Code, body.Error, rec.Header().Get("Retry-After"))
+	}
+	if guild, err := h.GetGuild("1"); err != nil || guild.Name != "Stale" {
+		t.Errorf("expected the stale guild while open, got %+v (err %v)", guild, err)
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("expected no requests while open, got %d", got)
+	}
+
+	// After the cooldown a probe goes through; success closes the breaker.
+	failing.Store(false)
+	time.Sleep(60 * time.Millisecond)
+	if status := h.BreakerStatus(); status.State != BreakerHalfOpen {
+		t.Errorf("expected the breaker half open after the cooldown, got %+v", status)
+	}
+	if rec := getUser(); rec.Code != http.StatusOK {
+		t.Fatalf("expected the probe to succeed, got %d", rec.Code)
+	}
+	if status := h.BreakerStatus(); status.State != BreakerClosed || status.ConsecutiveFailures != 0 {
+		t.Errorf("expected the breaker closed after recovery, got %+v", status)
+	}
+}
+
+func TestDiscordBreakerIgnoresClientErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	h := NewDiscordHandler(slog.Default())
+	defer h.Close()
+	h.baseURL = server.URL
+	h.BreakerThreshold = 1
+
+	if _, err := h.GetGuild("missing"); err == nil {
+		t.Fatal("expected an error for an unknown guild")
+	}
+	if status := h.BreakerStatus(); status.State != BreakerClosed {
+		t.Errorf("expected a 404 not to open the breaker, got %+v", status)
+	}
+}
+
+func TestTokenBucketSpacesRequestsPastBurst(t *testing.T) {
+	b := newTokenBucket(10, 2)
+	if b.reserve() != 0 || b.reserve() != 0 {
+		t.Fatal("expected the burst to pass without waiting")
+	}
+	if wait := b.reserve(); wait < 90*time.Millisecond || wait > 100*time.Millisecond {
+		t.Errorf("expected about 100ms wait past the burst, got %v", wait)
+	}
+}
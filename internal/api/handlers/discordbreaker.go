@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultDiscordBreakerThreshold is how many consecutive failed Discord
+	// REST requests open the circuit breaker.
+	DefaultDiscordBreakerThreshold = 5
+
+	// DefaultDiscordBreakerCooldown is how long an open breaker fails
+	// requests fast before letting one through to probe Discord.
+	DefaultDiscordBreakerCooldown = 30 * time.Second
+)
+
+// Breaker states reported by DiscordHandler.BreakerStatus.
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half_open"
+)
+
+// UnavailableError is returned without contacting Discord while the circuit
+// breaker is open.
+type UnavailableError struct {
+	RetryAfter time.Duration
+}
+
+func (e *UnavailableError) Error() string {
+	return fmt.Sprintf("discord API unavailable, circuit breaker open (retry after %s)", e.RetryAfter)
+}
+
+// BreakerStatus describes the Discord circuit breaker for /health.
+type BreakerStatus struct {
+	State               string    `json:"state"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	OpenUntil           time.Time `json:"open_until,omitzero"`
+}
+
+// circuitBreaker counts consecutive Discord failures. Once they reach the
+// threshold it opens until the cooldown passes, then lets a single probe
+// through: a success closes it, a failure opens it again.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+func (h *DiscordHandler) breakerThreshold() int {
+	if h.BreakerThreshold <= 0 {
+		return DefaultDiscordBreakerThreshold
+	}
+	return h.BreakerThreshold
+}
+
+func (h *DiscordHandler) breakerCooldown() time.Duration {
+	if h.BreakerCooldown <= 0 {
+		return DefaultDiscordBreakerCooldown
+	}
+	return h.BreakerCooldown
+}
+
+// breakerAllow returns an *UnavailableError if a request must not be sent
+// because the breaker is open or another request is already probing.
+func (h *DiscordHandler) breakerAllow() error {
+	b := &h.breaker
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.openUntil.IsZero() {
+		return nil
+	}
+	if wait := time.Until(b.openUntil); wait > 0 {
+		return &UnavailableError{RetryAfter: wait}
+	}
+	if b.probing {
+		return &UnavailableError{RetryAfter: h.breakerCooldown()}
+	}
+	b.probing = true
+	return nil
+}
+
+// breakerRecord updates the breaker with the outcome of a request it
+// allowed.
+func (h *DiscordHandler) breakerRecord(err error) {
+	b := &h.breaker
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	if !isDiscordOutage(err) {
+		if !b.openUntil.IsZero() {
+			h.logger.Info("Discord API recovered, closing circuit breaker")
+		}
+		b.failures = 0
+		b.openUntil = time.Time{}
+		return
+	}
+
+	b.failures++
+	if b.failures >= h.breakerThreshold() {
+		cooldown := h.breakerCooldown()
+		if b.openUntil.IsZero() {
+			h.logger.Warn("Discord API failing, opening circuit breaker", "failures", b.failures, "cooldown", cooldown)
+		}
+		b.openUntil = time.Now().Add(cooldown)
+	}
+}
+
+// BreakerStatus returns the state of the Discord circuit breaker.
+func (h *DiscordHandler) BreakerStatus() BreakerStatus {
+	b := &h.breaker
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status := BreakerStatus{State: BreakerClosed, ConsecutiveFailures: b.failures}
+	switch {
+	case b.openUntil.IsZero():
+	case time.Now().Before(b.openUntil):
+		status.State = BreakerOpen
+		status.OpenUntil = b.openUntil
+	default:
+		status.State = BreakerHalfOpen
+	}
+	return status
+}
+
+// isDiscordOutage reports whether err means Discord itself is failing: a
+// transport error, a 5xx answer, or a rate limit that outlasted the
+// retries. Errors such as an unknown guild are not counted.
+func isDiscordOutage(err error) bool {
+	if err == nil {
+		return false
+	}
+	var rateErr *RateLimitError
+	var statusErr *discordStatusError
+	var netErr net.Error
+	switch {
+	case errors.As(err, &rateErr):
+		return true
+	case errors.As(err, &statusErr):
+		return statusErr.StatusCode >= 500
+	case errors.As(err, &netErr):
+		return true
+	}
+	return false
+}
+
+// discordStatusError is an unexpected HTTP status from the Discord API.
+type discordStatusError struct {
+	StatusCode int
+}
+
+func (e *discordStatusError) Error() string {
+	return fmt.Sprintf("discord API returned status %d", e.StatusCode)
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultDiscordMaxRetries is how many times a Discord REST request
+	// answered with 429 is retried before RateLimitError is returned.
+	DefaultDiscordMaxRetries = 3
+
+	// discordRequestsPerSecond and discordRequestBurst shape the handler's
+	// outbound requests, well under Discord's global limit of 50 per second
+	// so lookups of many servers stay within per-route limits too.
+	discordRequestsPerSecond = 5
+	discordRequestBurst      = 5
+
+	// defaultRateLimitWait is used when a 429 carries no usable delay, and
+	// maxRateLimitWait bounds how long one retry may wait.
+	defaultRateLimitWait = time.Second
+	maxRateLimitWait     = 30 * time.Second
+)
+
+// RateLimitError is returned when Discord keeps answering a REST request
+// with 429 after the handler's retries, or asks for a longer wait than the
+// handler will sleep.
+type RateLimitError struct {
+	Endpoint   string
+	Retries    int
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("discord API rate limited %s after %d retries (retry after %s)", e.Endpoint, e.Retries, e.RetryAfter)
+}
+
+// rateLimitDelay reads how long Discord asks a rate-limited request to wait,
+// from Retry-After or X-RateLimit-Reset-After (seconds, possibly
+// fractional).
+func rateLimitDelay(header http.Header) time.Duration {
+	for _, name := range []string{"Retry-After", "X-RateLimit-Reset-After"} {
+		if seconds, err := strconv.ParseFloat(header.Get(name), 64); err == nil && seconds >= 0 {
+			return time.Duration(seconds * float64(time.Second))
+		}
+	}
+	return defaultRateLimitWait
+}
+
+// tokenBucket allows rate requests per second with bursts up to burst.
+type tokenBucket struct {
+	mu     sync.Mutex
+	tokens float64
+	burst  float64
+	rate   float64
+	last   time.Time
+}
+
+func newTokenBucket(rate, burst float64) *tokenBucket {
+	return &tokenBucket{tokens: burst, burst: burst, rate: rate, last: time.Now()}
+}
+
+// reserve takes a token and returns how long the caller must wait before
+// using it.
+func (b *tokenBucket) reserve() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
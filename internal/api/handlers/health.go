@@ -8,6 +8,7 @@ import (
 
 	"github.com/dustin/go-humanize"
 	"github.com/hako/durafmt"
+	"github.com/pyyupsk/discord-stayonline/internal/api/middleware"
 	"github.com/pyyupsk/discord-stayonline/internal/manager"
 	"github.com/pyyupsk/discord-stayonline/internal/ws"
 )
@@ -22,12 +23,31 @@ type HealthResponse struct {
 	Connections ConnectionsInfo `json:"connections"`
 	Runtime     RuntimeInfo     `json:"runtime"`
 	Memory      MemoryInfo      `json:"memory"`
+
+	// Store is omitted without a manager. While it is down the status is
+	// "degraded", but sessions keep running on the last loaded config.
+	Store *manager.StoreHealth `json:"store,omitempty"`
+
+	// DiscordBreaker is the state of the Discord REST circuit breaker,
+	// omitted without a Discord handler.
+	DiscordBreaker *BreakerStatus `json:"discord_breaker,omitempty"`
+}
+
+// MinimalHealthResponse is the health payload for unauthenticated requests
+// when HealthHandler.Auth is set.
+type MinimalHealthResponse struct {
+	Status string `json:"status"`
+	Uptime string `json:"uptime"`
 }
 
 type ConnectionsInfo struct {
 	ActiveSessions   int               `json:"active_sessions"`
 	WebSocketClients int               `json:"websocket_clients"`
 	SessionStatuses  map[string]string `json:"session_statuses,omitempty"`
+
+	// HeartbeatLatencyMS is the last heartbeat round-trip time of each
+	// connected session with its own connection.
+	HeartbeatLatencyMS map[string]float64 `json:"heartbeat_latency_ms,omitempty"`
 }
 
 type RuntimeInfo struct {
@@ -48,6 +68,13 @@ type MemoryInfo struct {
 type HealthHandler struct {
 	manager *manager.SessionManager
 	hub     *ws.Hub
+
+	// Auth, when non-nil, limits unauthenticated requests to the status and
+	// uptime; the full payload requires a dashboard session.
+	Auth *middleware.Auth
+
+	// Discord, when non-nil, reports its circuit breaker state.
+	Discord *DiscordHandler
 }
 
 func NewHealthHandler(mgr *manager.SessionManager, hub *ws.Hub) *HealthHandler {
@@ -66,6 +93,26 @@ func (h *HealthHandler) Health(w http.ResponseWriter, r *http.Request) {
 
 	uptime := time.Since(startTime)
 
+	status := "healthy"
+	var storeHealth *manager.StoreHealth
+	if h.manager != nil {
+		sh := h.manager.StoreHealth()
+		storeHealth = &sh
+		if !sh.Up {
+			status = "degraded"
+		}
+	}
+
+	if h.Auth != nil {
+		if _, ok := h.Auth.Authenticate(w, r); !ok {
+			writeHealth(w, MinimalHealthResponse{
+				Status: status,
+				Uptime: durafmt.Parse(uptime).String(),
+			})
+			return
+		}
+	}
+
 	var memStats runtime.MemStats
 	runtime.ReadMemStats(&memStats)
 
@@ -81,18 +128,31 @@ func (h *HealthHandler) Health(w http.ResponseWriter, r *http.Request) {
 		for id, status := range statuses {
 			connInfo.SessionStatuses[id] = string(status)
 		}
+		if latencies := h.manager.HeartbeatLatencies(); len(latencies) > 0 {
+			connInfo.HeartbeatLatencyMS = make(map[string]float64, len(latencies))
+			for id, latency := range latencies {
+				connInfo.HeartbeatLatencyMS[id] = float64(latency) / float64(time.Millisecond)
+			}
+		}
 	}
 
 	if h.hub != nil {
 		connInfo.WebSocketClients = h.hub.ClientCount()
 	}
 
+	var breaker *BreakerStatus
+	if h.Discord != nil {
+		status := h.Discord.BreakerStatus()
+		breaker = &status
+	}
+
 	response := HealthResponse{
-		Status:      "healthy",
+		Status:      status,
 		Uptime:      durafmt.Parse(uptime).String(),
 		UptimeSecs:  int64(uptime.Seconds()),
 		Timestamp:   time.Now().UTC().Format(time.RFC3339),
 		Connections: connInfo,
+		Store:       storeHealth,
 		Runtime: RuntimeInfo{
 			GoVersion:    runtime.Version(),
 			NumCPU:       runtime.NumCPU(),
@@ -106,8 +166,13 @@ func (h *HealthHandler) Health(w http.ResponseWriter, r *http.Request) {
 			Sys:        humanize.Bytes(memStats.Sys),
 			NumGC:      memStats.NumGC,
 		},
+		DiscordBreaker: breaker,
 	}
 
+	writeHealth(w, response)
+}
+
+func writeHealth(w http.ResponseWriter, response any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	_ = json.NewEncoder(w).Encode(response)
@@ -0,0 +1,20 @@
+package handlers
+
+import (
+	"net/http"
+)
+
+type OpenAPIHandler struct {
+	spec []byte
+}
+
+func NewOpenAPIHandler(spec []byte) *OpenAPIHandler {
+	return &OpenAPIHandler{spec: spec}
+}
+
+// GetSpec handles GET /api/openapi.json requests.
+func (h *OpenAPIHandler) GetSpec(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(h.spec)
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/pyyupsk/discord-stayonline/internal/api/responses"
+	"github.com/pyyupsk/discord-stayonline/internal/config"
+	"github.com/pyyupsk/discord-stayonline/internal/manager"
+)
+
+// PublicServerStatus is the sanitized, ID-free view of one monitored server.
+type PublicServerStatus struct {
+	Label       string `json:"label,omitempty"`
+	GuildName   string `json:"guild_name,omitempty"`
+	ChannelName string `json:"channel_name,omitempty"`
+	Up          bool   `json:"up"`
+}
+
+type PublicStatusHandler struct {
+	store   config.ConfigStore
+	manager *manager.SessionManager
+	logger  *slog.Logger
+}
+
+func NewPublicStatusHandler(store config.ConfigStore, mgr *manager.SessionManager, logger *slog.Logger) *PublicStatusHandler {
+	return &PublicStatusHandler{
+		store:   store,
+		manager: mgr,
+		logger:  logger.With("handler", "public_status"),
+	}
+}
+
+// GetStatus handles GET /api/public/status requests.
+func (h *PublicStatusHandler) GetStatus(w http.ResponseWriter, r *http.Request) {
+	cfg, err := config.LoadConfig(h.store)
+	if err != nil {
+		h.logger.Error(responses.ErrLoadConfig, "error", err)
+		responses.Error(w, http.StatusInternalServerError, "internal_error", responses.ErrLoadConfigMsg)
+		return
+	}
+
+	var statuses map[string]manager.ConnectionStatus
+	if h.manager != nil {
+		statuses = h.manager.GetAllStatuses()
+	}
+
+	result := make([]PublicServerStatus, 0, len(cfg.Servers))
+	for _, server := range cfg.Servers {
+		result = append(result, PublicServerStatus{
+			Label:       server.Label,
+			GuildName:   server.GuildName,
+			ChannelName: server.ChannelName,
+			Up:          statuses[server.ID].Online(),
+		})
+	}
+
+	responses.JSON(w, http.StatusOK, map[string]any{
+		"servers": result,
+	})
+}
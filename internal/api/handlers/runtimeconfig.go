@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/pyyupsk/discord-stayonline/internal/api/responses"
+	"github.com/pyyupsk/discord-stayonline/internal/config"
+	"github.com/pyyupsk/discord-stayonline/internal/config/store"
+	"github.com/pyyupsk/discord-stayonline/internal/runtimeconfig"
+)
+
+type RuntimeConfigHandler struct {
+	store    config.ConfigStore
+	registry *runtimeconfig.Registry
+	logger   *slog.Logger
+}
+
+// RuntimeConfigResponse is the effective, non-secret configuration.
+type RuntimeConfigResponse struct {
+	StoreBackend string                  `json:"store_backend"`
+	Settings     []runtimeconfig.Setting `json:"settings"`
+}
+
+func NewRuntimeConfigHandler(store config.ConfigStore, registry *runtimeconfig.Registry, logger *slog.Logger) *RuntimeConfigHandler {
+	return &RuntimeConfigHandler{
+		store:    store,
+		registry: registry,
+		logger:   logger.With("handler", "runtime-config"),
+	}
+}
+
+// GetRuntimeConfig handles GET /api/runtime-config requests.
+func (h *RuntimeConfigHandler) GetRuntimeConfig(w http.ResponseWriter, r *http.Request) {
+	responses.JSON(w, http.StatusOK, RuntimeConfigResponse{
+		StoreBackend: storeBackend(h.store),
+		Settings:     h.registry.Settings(),
+	})
+}
+
+func storeBackend(s config.ConfigStore) string {
+	switch s := s.(type) {
+	case *store.Database:
+		return s.Dialect()
+	case *store.File:
+		return "file"
+	case *store.Memory:
+		return "memory"
+	default:
+		return "custom"
+	}
+}
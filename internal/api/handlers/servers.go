@@ -1,17 +1,25 @@
 package handlers
 
 import (
+	"errors"
 	"log/slog"
 	"net/http"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/pyyupsk/discord-stayonline/internal/api/responses"
+	"github.com/pyyupsk/discord-stayonline/internal/audit"
+	"github.com/pyyupsk/discord-stayonline/internal/config"
 	"github.com/pyyupsk/discord-stayonline/internal/manager"
 )
 
 type ServersHandler struct {
 	manager *manager.SessionManager
 	logger  *slog.Logger
+
+	// Audit, when non-nil, records server removals and status overrides.
+	Audit *audit.Log
 }
 
 func NewServersHandler(mgr *manager.SessionManager, logger *slog.Logger) *ServersHandler {
@@ -22,15 +30,254 @@ func NewServersHandler(mgr *manager.SessionManager, logger *slog.Logger) *Server
 }
 
 // GetStatuses handles GET /api/statuses requests.
+// Optional ?status= (repeatable) and ?limit= parameters narrow the result.
 func (h *ServersHandler) GetStatuses(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	limit := 0
+	if raw := query.Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			responses.Error(w, http.StatusBadRequest, "invalid_request", "limit must be a positive integer")
+			return
+		}
+		limit = n
+	}
+
 	statuses := h.manager.GetAllStatuses()
+	responses.JSON(w, http.StatusOK, filterStatuses(statuses, query["status"], limit))
+}
 
-	result := make(map[string]string)
+// filterStatuses keeps only sessions whose status is in wanted (all when empty),
+// taking at most limit entries in server ID order when limit is positive.
+func filterStatuses(statuses map[string]manager.ConnectionStatus, wanted []string, limit int) map[string]string {
+	ids := make([]string, 0, len(statuses))
 	for id, status := range statuses {
-		result[id] = string(status)
+		if len(wanted) == 0 || slices.Contains(wanted, string(status)) {
+			ids = append(ids, id)
+		}
 	}
+	slices.Sort(ids)
 
-	responses.JSON(w, http.StatusOK, result)
+	if limit > 0 && len(ids) > limit {
+		ids = ids[:limit]
+	}
+
+	result := make(map[string]string, len(ids))
+	for _, id := range ids {
+		result[id] = string(statuses[id])
+	}
+	return result
+}
+
+// GetFrames handles GET /api/servers/{id}/frames requests.
+func (h *ServersHandler) GetFrames(w http.ResponseWriter, r *http.Request) {
+	serverID := r.PathValue("id")
+
+	frames, ok := h.manager.GetFrames(serverID)
+	if !ok {
+		responses.Error(w, http.StatusNotFound, "session_not_found", "No active session for this server")
+		return
+	}
+
+	responses.JSON(w, http.StatusOK, frames)
+}
+
+// GetStatusDetail handles GET /api/servers/{id}/status requests.
+func (h *ServersHandler) GetStatusDetail(w http.ResponseWriter, r *http.Request) {
+	serverID := r.PathValue("id")
+
+	detail, ok := h.manager.GetSessionDetail(serverID)
+	if !ok {
+		responses.Error(w, http.StatusNotFound, "session_not_found", "No active session for this server")
+		return
+	}
+
+	responses.JSON(w, http.StatusOK, detail)
+}
+
+// Explain handles GET /api/servers/{id}/explain requests.
+func (h *ServersHandler) Explain(w http.ResponseWriter, r *http.Request) {
+	serverID := r.PathValue("id")
+
+	explanation, err := h.manager.Explain(serverID)
+	switch {
+	case err == nil:
+		responses.JSON(w, http.StatusOK, explanation)
+	case errors.Is(err, manager.ErrServerNotFound):
+		responses.Error(w, http.StatusNotFound, "server_not_found", err.Error())
+	default:
+		h.logger.Error(responses.ErrLoadConfig, "server_id", serverID, "error", err)
+		responses.Error(w, http.StatusInternalServerError, "internal_error", responses.ErrLoadConfigMsg)
+	}
+}
+
+// SetStatusOverride handles PUT /api/servers/{id}/status requests.
+func (h *ServersHandler) SetStatusOverride(w http.ResponseWriter, r *http.Request) {
+	serverID := r.PathValue("id")
+
+	var input struct {
+		Status config.Status `json:"status"`
+	}
+
+	if !responses.DecodeJSON(w, r, h.logger, &input) {
+		return
+	}
+
+	status, ok := config.NormalizeStatus(input.Status)
+	if !ok || strings.TrimSpace(string(input.Status)) == "" {
+		responses.Error(w, http.StatusBadRequest, "invalid_status", "Status must be 'online', 'idle', or 'dnd'")
+		return
+	}
+
+	previous, ok := h.applyStatusOverride(w, serverID, status)
+	if !ok {
+		return
+	}
+
+	h.logger.Info("Status override set", "server_id", serverID, "status", status)
+	recordAudit(h.Audit, h.logger, r, audit.ActionStatusOverride, overrideDiff(serverID, previous, status))
+	responses.JSON(w, http.StatusOK, map[string]any{
+		"success":         true,
+		"server_id":       serverID,
+		"status_override": string(status),
+	})
+}
+
+// ClearStatusOverride handles DELETE /api/servers/{id}/status requests.
+func (h *ServersHandler) ClearStatusOverride(w http.ResponseWriter, r *http.Request) {
+	serverID := r.PathValue("id")
+
+	previous, ok := h.applyStatusOverride(w, serverID, "")
+	if !ok {
+		return
+	}
+
+	h.logger.Info("Status override cleared", "server_id", serverID)
+	recordAudit(h.Audit, h.logger, r, audit.ActionStatusOverride, overrideDiff(serverID, previous, ""))
+	responses.JSON(w, http.StatusOK, map[string]any{
+		"success":   true,
+		"server_id": serverID,
+	})
+}
+
+// applyStatusOverride sets the server's status override, writing an error
+// response on failure. It returns the override that was replaced.
+func (h *ServersHandler) applyStatusOverride(w http.ResponseWriter, serverID string, status config.Status) (config.Status, bool) {
+	previous, err := h.manager.SetStatusOverride(serverID, status)
+	switch {
+	case err == nil:
+		return previous, true
+	case errors.Is(err, manager.ErrServerNotFound):
+		responses.Error(w, http.StatusNotFound, "server_not_found", err.Error())
+	case errors.Is(err, manager.ErrSharedConnection):
+		responses.Error(w, http.StatusConflict, "shared_connection", err.Error())
+	default:
+		h.logger.Error(responses.ErrSaveConfig, "server_id", serverID, "error", err)
+		responses.Error(w, http.StatusInternalServerError, "internal_error", responses.ErrSaveConfigMsg)
+	}
+	return "", false
+}
+
+// overrideDiff describes a server's status override changing from previous
+// to status; it is empty when the override did not change.
+func overrideDiff(serverID string, previous, status config.Status) audit.Diff {
+	if previous == status {
+		return audit.Diff{}
+	}
+	return audit.Diff{
+		Modified:       []string{serverID},
+		StatusOverride: &audit.Change{From: string(previous), To: string(status)},
+	}
+}
+
+// MoveChannel handles PUT /api/servers/{id}/channel requests.
+func (h *ServersHandler) MoveChannel(w http.ResponseWriter, r *http.Request) {
+	serverID := r.PathValue("id")
+
+	var input struct {
+		ChannelID string `json:"channel_id"`
+	}
+
+	if !responses.DecodeJSON(w, r, h.logger, &input) {
+		return
+	}
+
+	channelID := strings.TrimSpace(input.ChannelID)
+	if _, err := strconv.ParseUint(channelID, 10, 64); err != nil {
+		responses.Error(w, http.StatusBadRequest, "invalid_channel_id", "channel_id must be a Discord channel ID")
+		return
+	}
+
+	if !h.applyChannelOverride(w, serverID, channelID) {
+		return
+	}
+
+	h.logger.Info("Channel override set", "server_id", serverID, "channel_id", channelID)
+	responses.JSON(w, http.StatusOK, map[string]any{
+		"success":          true,
+		"server_id":        serverID,
+		"channel_override": channelID,
+	})
+}
+
+// RestoreChannel handles DELETE /api/servers/{id}/channel requests.
+func (h *ServersHandler) RestoreChannel(w http.ResponseWriter, r *http.Request) {
+	serverID := r.PathValue("id")
+
+	if !h.applyChannelOverride(w, serverID, "") {
+		return
+	}
+
+	h.logger.Info("Channel override cleared", "server_id", serverID)
+	responses.JSON(w, http.StatusOK, map[string]any{
+		"success":   true,
+		"server_id": serverID,
+	})
+}
+
+func (h *ServersHandler) applyChannelOverride(w http.ResponseWriter, serverID, channelID string) bool {
+	var err error
+	if channelID == "" {
+		err = h.manager.RestoreChannel(serverID)
+	} else {
+		err = h.manager.MoveChannel(serverID, channelID)
+	}
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, manager.ErrNotConnected):
+		responses.Error(w, http.StatusConflict, "not_connected", err.Error())
+	case errors.Is(err, manager.ErrPresenceOnly):
+		responses.Error(w, http.StatusConflict, "presence_only", err.Error())
+	default:
+		h.logger.Error("Failed to send voice state update", "server_id", serverID, "error", err)
+		responses.Error(w, http.StatusBadGateway, "voice_state_failed", "Failed to send voice state update")
+	}
+	return false
+}
+
+// DeleteServer handles DELETE /api/servers/{id} requests.
+func (h *ServersHandler) DeleteServer(w http.ResponseWriter, r *http.Request) {
+	serverID := r.PathValue("id")
+
+	servers, err := h.manager.RemoveServer(serverID)
+	switch {
+	case errors.Is(err, manager.ErrServerNotFound):
+		responses.Error(w, http.StatusNotFound, "server_not_found", err.Error())
+		return
+	case err != nil:
+		h.logger.Error(responses.ErrSaveConfig, "server_id", serverID, "error", err)
+		responses.Error(w, http.StatusInternalServerError, "internal_error", responses.ErrSaveConfigMsg)
+		return
+	}
+
+	h.logger.Info("Server removed", "server_id", serverID)
+	recordAudit(h.Audit, h.logger, r, audit.ActionServerRemove, audit.Diff{Removed: []string{serverID}})
+	responses.JSON(w, http.StatusOK, map[string]any{
+		"success": true,
+		"servers": servers,
+	})
 }
 
 // ExecuteAction handles POST /api/servers/{id}/action requests.
@@ -84,6 +331,9 @@ func (h *ServersHandler) ExecuteAction(w http.ResponseWriter, r *http.Request) {
 		case manager.ErrTooManyConnections:
 			status = http.StatusConflict
 			errorCode = "too_many_connections"
+		case manager.ErrGuildConnectionLimit:
+			status = http.StatusConflict
+			errorCode = "guild_connection_limit"
 		case manager.ErrTOSNotAcknowledged:
 			status = http.StatusForbidden
 			errorCode = "tos_not_acknowledged"
@@ -93,6 +343,12 @@ func (h *ServersHandler) ExecuteAction(w http.ResponseWriter, r *http.Request) {
 		case manager.ErrNotConnected:
 			status = http.StatusConflict
 			errorCode = "not_connected"
+		case manager.ErrServiceStopping:
+			status = http.StatusServiceUnavailable
+			errorCode = "service_stopping"
+		case config.ErrGuildNotAllowed:
+			status = http.StatusForbidden
+			errorCode = "guild_not_allowed"
 		}
 
 		responses.Error(w, status, errorCode, err.Error())
@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/pyyupsk/discord-stayonline/internal/manager"
+)
+
+func TestFilterStatuses(t *testing.T) {
+	statuses := map[string]manager.ConnectionStatus{
+		"a": manager.StatusConnected,
+		"b": manager.StatusError,
+		"c": manager.StatusBackoff,
+		"d": manager.StatusError,
+	}
+
+	all := filterStatuses(statuses, nil, 0)
+	if len(all) != 4 {
+		t.Errorf("expected all 4 statuses without filter, got %v", all)
+	}
+
+	problems := filterStatuses(statuses, []string{"error", "backoff"}, 0)
+	if len(problems) != 3 || problems["a"] != "" {
+		t.Errorf("expected only error/backoff sessions, got %v", problems)
+	}
+
+	limited := filterStatuses(statuses, []string{"error", "backoff"}, 2)
+	if len(limited) != 2 || limited["b"] != "error" || limited["c"] != "backoff" {
+		t.Errorf("expected first 2 problem sessions by ID, got %v", limited)
+	}
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/pyyupsk/discord-stayonline/internal/api/responses"
+	"github.com/pyyupsk/discord-stayonline/internal/manager"
+	"github.com/pyyupsk/discord-stayonline/internal/ws"
+)
+
+// StatsOverviewResponse is the session overview with the number of connected
+// dashboard WebSocket clients.
+type StatsOverviewResponse struct {
+	manager.Overview
+	WebSocketClients int `json:"websocket_clients"`
+}
+
+type StatsHandler struct {
+	manager *manager.SessionManager
+	hub     *ws.Hub
+	logger  *slog.Logger
+}
+
+func NewStatsHandler(mgr *manager.SessionManager, hub *ws.Hub, logger *slog.Logger) *StatsHandler {
+	return &StatsHandler{
+		manager: mgr,
+		hub:     hub,
+		logger:  logger.With("handler", "stats"),
+	}
+}
+
+// GetOverview handles GET /api/stats/overview requests.
+func (h *StatsHandler) GetOverview(w http.ResponseWriter, r *http.Request) {
+	overview, err := h.manager.Overview()
+	if err != nil {
+		h.logger.Error(responses.ErrLoadConfig, "error", err)
+		responses.Error(w, http.StatusInternalServerError, "internal_error", responses.ErrLoadConfigMsg)
+		return
+	}
+
+	response := StatsOverviewResponse{Overview: overview}
+	if h.hub != nil {
+		response.WebSocketClients = h.hub.ClientCount()
+	}
+	responses.JSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/pyyupsk/discord-stayonline/internal/api/responses"
+	"github.com/pyyupsk/discord-stayonline/internal/audit"
+	"github.com/pyyupsk/discord-stayonline/internal/config"
+	"github.com/pyyupsk/discord-stayonline/internal/manager"
+)
+
+type StatusHandler struct {
+	store   config.ConfigStore
+	manager *manager.SessionManager
+	logger  *slog.Logger
+	mu      sync.Mutex
+
+	// Audit, when non-nil, records global status changes.
+	Audit *audit.Log
+}
+
+func NewStatusHandler(store config.ConfigStore, mgr *manager.SessionManager, logger *slog.Logger) *StatusHandler {
+	return &StatusHandler{
+		store:   store,
+		manager: mgr,
+		logger:  logger.With("handler", "status"),
+	}
+}
+
+// GetStatus handles GET /api/status requests.
+func (h *StatusHandler) GetStatus(w http.ResponseWriter, r *http.Request) {
+	cfg, err := config.LoadConfig(h.store)
+	if err != nil {
+		h.logger.Error(responses.ErrLoadConfig, "error", err)
+		responses.Error(w, http.StatusInternalServerError, "internal_error", responses.ErrLoadConfigMsg)
+		return
+	}
+
+	status, _ := config.NormalizeStatus(cfg.Status)
+	responses.JSON(w, http.StatusOK, map[string]string{
+		"status": string(status),
+	})
+}
+
+// SetStatus handles PUT /api/status requests.
+func (h *StatusHandler) SetStatus(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Status config.Status `json:"status"`
+	}
+
+	if !responses.DecodeJSON(w, r, h.logger, &input) {
+		return
+	}
+
+	status, ok := config.NormalizeStatus(input.Status)
+	if !ok || strings.TrimSpace(string(input.Status)) == "" {
+		responses.Error(w, http.StatusBadRequest, "invalid_status", "Status must be 'online', 'idle', or 'dnd'")
+		return
+	}
+
+	// mu keeps presence updates in the same order as the saves they follow.
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var before config.Configuration
+	cfg, err := config.Update(h.store, func(cfg *config.Configuration) error {
+		before = *cfg
+		cfg.Status = status
+		return nil
+	})
+	if errors.Is(err, config.ErrConfigLoad) {
+		h.logger.Error(responses.ErrLoadConfig, "error", err)
+		responses.Error(w, http.StatusInternalServerError, "internal_error", responses.ErrLoadConfigMsg)
+		return
+	}
+	if err != nil {
+		h.logger.Error(responses.ErrSaveConfig, "error", err)
+		responses.Error(w, http.StatusInternalServerError, "internal_error", responses.ErrSaveConfigMsg)
+		return
+	}
+
+	if h.manager != nil {
+		h.manager.ApplyStatus(string(status))
+	}
+
+	h.logger.Info("Global status updated", "status", status)
+	recordAudit(h.Audit, h.logger, r, audit.ActionStatusSet, audit.Compare(&before, cfg))
+	responses.JSON(w, http.StatusOK, map[string]any{
+		"success": true,
+		"status":  string(status),
+	})
+}
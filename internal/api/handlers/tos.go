@@ -1,16 +1,21 @@
 package handlers
 
 import (
+	"errors"
 	"log/slog"
 	"net/http"
 
 	"github.com/pyyupsk/discord-stayonline/internal/api/responses"
+	"github.com/pyyupsk/discord-stayonline/internal/audit"
 	"github.com/pyyupsk/discord-stayonline/internal/config"
 )
 
 type TOSHandler struct {
 	store  config.ConfigStore
 	logger *slog.Logger
+
+	// Audit, when non-nil, records the acknowledgment.
+	Audit *audit.Log
 }
 
 func NewTOSHandler(store config.ConfigStore, logger *slog.Logger) *TOSHandler {
@@ -35,22 +40,25 @@ func (h *TOSHandler) AcknowledgeTOS(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cfg, err := h.store.Load()
-	if err != nil {
+	var before config.Configuration
+	cfg, err := config.Update(h.store, func(cfg *config.Configuration) error {
+		before = *cfg
+		cfg.TOSAcknowledged = true
+		return nil
+	})
+	if errors.Is(err, config.ErrConfigLoad) {
 		h.logger.Error(responses.ErrLoadConfig, "error", err)
 		responses.Error(w, http.StatusInternalServerError, "internal_error", responses.ErrLoadConfigMsg)
 		return
 	}
-
-	cfg.TOSAcknowledged = true
-
-	if err := h.store.Save(cfg); err != nil {
+	if err != nil {
 		h.logger.Error(responses.ErrSaveConfig, "error", err)
 		responses.Error(w, http.StatusInternalServerError, "internal_error", responses.ErrSaveConfigMsg)
 		return
 	}
 
 	h.logger.Info("TOS acknowledged")
+	recordAudit(h.Audit, h.logger, r, audit.ActionTOSAcknowledge, audit.Compare(&before, cfg))
 	responses.JSON(w, http.StatusOK, map[string]bool{
 		"success": true,
 	})
@@ -1,16 +1,22 @@
 package middleware
 
 import (
+	"context"
 	"crypto/subtle"
 	"errors"
 	"log/slog"
 	"net/http"
 	"os"
+	"time"
 
 	"github.com/pyyupsk/discord-stayonline/internal/api/responses"
 )
 
 const (
+	// SessionCookieName holds the dashboard session token issued on login.
+	SessionCookieName = "session"
+	// CookieName is the legacy cookie that stored the raw API key. It is
+	// still accepted and exchanged for a session cookie on first use.
 	CookieName   = "api_key"
 	CookieMaxAge = 7 * 24 * 60 * 60
 )
@@ -18,8 +24,9 @@ const (
 var ErrAPIKeyRequired = errors.New("API_KEY environment variable is required for security")
 
 type Auth struct {
-	apiKey string
-	logger *slog.Logger
+	apiKey   string
+	sessions *Sessions
+	logger   *slog.Logger
 }
 
 func NewAuth(logger *slog.Logger) (*Auth, error) {
@@ -31,8 +38,9 @@ func NewAuth(logger *slog.Logger) (*Auth, error) {
 		return nil, ErrAPIKeyRequired
 	}
 	return &Auth{
-		apiKey: apiKey,
-		logger: logger.With("middleware", "auth"),
+		apiKey:   apiKey,
+		sessions: NewSessions(CookieMaxAge * time.Second),
+		logger:   logger.With("middleware", "auth"),
 	}, nil
 }
 
@@ -40,26 +48,91 @@ func (m *Auth) ValidateKey(key string) bool {
 	return subtle.ConstantTimeCompare([]byte(key), []byte(m.apiKey)) == 1
 }
 
+// Sessions returns the dashboard session registry.
+func (m *Auth) Sessions() *Sessions {
+	return m.sessions
+}
+
+// Login issues a new dashboard session and sets its cookie.
+func (m *Auth) Login(w http.ResponseWriter, r *http.Request) (DashboardSession, error) {
+	token, session, err := m.sessions.Issue(r)
+	if err != nil {
+		return DashboardSession{}, err
+	}
+	setCookie(w, r, SessionCookieName, token, CookieMaxAge)
+	if _, err := r.Cookie(CookieName); err == nil {
+		clearCookie(w, CookieName)
+	}
+	return session, nil
+}
+
+// Logout revokes the request's dashboard session and clears its cookies.
+func (m *Auth) Logout(w http.ResponseWriter, r *http.Request) {
+	if cookie, err := r.Cookie(SessionCookieName); err == nil {
+		m.sessions.RevokeToken(cookie.Value)
+	}
+	clearCookie(w, SessionCookieName)
+	clearCookie(w, CookieName)
+}
+
+// Authenticate resolves the dashboard session for a request. A valid legacy
+// API key cookie is migrated to a new session.
+func (m *Auth) Authenticate(w http.ResponseWriter, r *http.Request) (DashboardSession, bool) {
+	if cookie, err := r.Cookie(SessionCookieName); err == nil {
+		if session, ok := m.sessions.Lookup(cookie.Value); ok {
+			return session, true
+		}
+	}
+
+	cookie, err := r.Cookie(CookieName)
+	if err != nil || !m.ValidateKey(cookie.Value) {
+		return DashboardSession{}, false
+	}
+	session, err := m.Login(w, r)
+	if err != nil {
+		m.logger.Error("Failed to migrate legacy auth cookie", "error", err)
+		return DashboardSession{}, false
+	}
+	m.logger.Info("Migrated legacy auth cookie to session", "session_id", session.ID)
+	return session, true
+}
+
 func (m *Auth) Protect(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		cookie, err := r.Cookie(CookieName)
-		if err != nil || !m.ValidateKey(cookie.Value) {
+		session, ok := m.Authenticate(w, r)
+		if !ok {
 			responses.Error(w, http.StatusUnauthorized, "unauthorized", "Valid API key required")
 			return
 		}
 
-		next(w, r)
+		next(w, r.WithContext(context.WithValue(r.Context(), sessionContextKey{}, session)))
 	}
 }
 
 func (m *Auth) ProtectHandler(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		cookie, err := r.Cookie(CookieName)
-		if err != nil || !m.ValidateKey(cookie.Value) {
-			responses.Error(w, http.StatusUnauthorized, "unauthorized", "Valid API key required")
-			return
-		}
+	return m.Protect(next.ServeHTTP)
+}
+
+func setCookie(w http.ResponseWriter, r *http.Request, name, value string, maxAge int) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+		Secure:   r.TLS != nil,
+	})
+}
 
-		next.ServeHTTP(w, r)
+func clearCookie(w http.ResponseWriter, name string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+		Expires:  time.Unix(0, 0),
 	})
 }
@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// TrustedProxies resolves the real client IP of requests arriving through
+// reverse proxies. Forwarding headers are only honored when the immediate
+// peer is inside one of the trusted ranges; anyone else could set them to
+// any address. A nil *TrustedProxies trusts no one.
+type TrustedProxies struct {
+	prefixes []netip.Prefix
+}
+
+// ParseTrustedProxies parses a comma-separated list of CIDR ranges or single
+// addresses. It returns nil for an empty list.
+func ParseTrustedProxies(raw string) (*TrustedProxies, error) {
+	var prefixes []netip.Prefix
+	for i, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if !strings.Contains(part, "/") {
+			addr, err := netip.ParseAddr(part)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy (entry %d): %w", i+1, err)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy (entry %d): %w", i+1, err)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	if len(prefixes) == 0 {
+		return nil, nil
+	}
+	return &TrustedProxies{prefixes: prefixes}, nil
+}
+
+func (t *TrustedProxies) trusts(addr netip.Addr) bool {
+	if t == nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, p := range t.prefixes {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP returns the IP address of the client that made r. When the peer
+// is a trusted proxy, X-Forwarded-For is walked from the right, skipping
+// trusted hops, and the first untrusted address is the client; X-Real-IP is
+// used when there is no X-Forwarded-For.
+func (t *TrustedProxies) ClientIP(r *http.Request) string {
+	peer := peerAddr(r.RemoteAddr)
+	if !peer.IsValid() {
+		return r.RemoteAddr
+	}
+	if !t.trusts(peer) {
+		return peer.String()
+	}
+
+	if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+		hops := strings.Split(strings.Join(forwarded, ","), ",")
+		client := peer
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+			if err != nil {
+				break
+			}
+			client = hop.Unmap()
+			if !t.trusts(client) {
+				break
+			}
+		}
+		return client.String()
+	}
+
+	if realIP, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get("X-Real-IP"))); err == nil {
+		return realIP.Unmap().String()
+	}
+	return peer.String()
+}
+
+// Handler replaces each request's RemoteAddr with its client IP, so
+// handlers, logs, and session records see the client rather than the proxy.
+func (t *TrustedProxies) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.RemoteAddr = t.ClientIP(r)
+		next.ServeHTTP(w, r)
+	})
+}
+
+func peerAddr(remoteAddr string) netip.Addr {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}
+	}
+	return addr.Unmap()
+}
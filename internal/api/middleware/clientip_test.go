@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	proxies, err := ParseTrustedProxies("10.0.0.0/8, 192.168.1.1")
+	if err != nil {
+		t.Fatalf("ParseTrustedProxies() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		noProxies bool
+		peer      string
+		forwards  string
+		realIP    string
+		want      string
+	}{
+		{
+			name: "direct client",
+			peer: "203.0.113.7:51234", want: "203.0.113.7",
+		},
+		{
+			name: "untrusted peer spoofing forwarded headers",
+			peer: "203.0.113.7:51234", forwards: "1.1.1.1", realIP: "2.2.2.2", want: "203.0.113.7",
+		},
+		{
+			name: "trusted peer with forwarded client",
+			peer: "10.1.2.3:8080", forwards: "198.51.100.4", want: "198.51.100.4",
+		},
+		{
+			name: "trusted single address",
+			peer: "192.168.1.1:8080", forwards: "198.51.100.4", want: "198.51.100.4",
+		},
+		{
+			name: "client-supplied hops are ignored",
+			peer: "10.1.2.3:8080", forwards: "1.1.1.1, 198.51.100.4, 10.9.9.9", want: "198.51.100.4",
+		},
+		{
+			name: "trusted peer with X-Real-IP",
+			peer: "10.1.2.3:8080", realIP: "198.51.100.4", want: "198.51.100.4",
+		},
+		{
+			name: "garbage forwarded hop stops at the nearest trusted hop",
+			peer: "10.1.2.3:8080", forwards: "not-an-ip", want: "10.1.2.3",
+		},
+		{
+			name: "nil proxies trust no one", noProxies: true,
+			peer: "10.1.2.3:8080", forwards: "198.51.100.4", want: "10.1.2.3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := proxies
+			if tt.noProxies {
+				p = nil
+			}
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.peer
+			if tt.forwards != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwards)
+			}
+			if tt.realIP != "" {
+				req.Header.Set("X-Real-IP", tt.realIP)
+			}
+			if got := p.ClientIP(req); got != tt.want {
+				t.Errorf("ClientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	if p, err := ParseTrustedProxies(" , "); p != nil || err != nil {
+		t.Errorf("expected nil for an empty list, got %v, %v", p, err)
+	}
+	for _, raw := range []string{"10.0.0.0/33", "not-an-ip", "10.0.0.1, bogus/8"} {
+		if _, err := ParseTrustedProxies(raw); err == nil {
+			t.Errorf("ParseTrustedProxies(%q) expected an error", raw)
+		}
+	}
+}
+
+func TestTrustedProxiesHandlerRewritesRemoteAddr(t *testing.T) {
+	proxies, _ := ParseTrustedProxies("10.0.0.0/8")
+	var seen string
+	handler := proxies.Handler(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		seen = r.RemoteAddr
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "10.1.2.3:8080"
+	req.Header.Set("X-Forwarded-For", "198.51.100.4")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if seen != "198.51.100.4" {
+		t.Errorf("expected RemoteAddr to be the forwarded client, got %q", seen)
+	}
+}
@@ -0,0 +1,155 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	IdempotencyHeader     = "Idempotency-Key"
+	DefaultIdempotencyTTL = 10 * time.Minute
+)
+
+// Idempotency replays the recorded response for a repeated Idempotency-Key
+// instead of running the handler again. Keys are scoped to method and path
+// and forgotten after the TTL.
+type Idempotency struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*idempotentResponse
+}
+
+type idempotentResponse struct {
+	done    chan struct{}
+	expires time.Time
+	aborted bool
+
+	status int
+	header http.Header
+	body   []byte
+}
+
+func NewIdempotency(ttl time.Duration) *Idempotency {
+	if ttl <= 0 {
+		ttl = DefaultIdempotencyTTL
+	}
+	return &Idempotency{
+		ttl:     ttl,
+		now:     time.Now,
+		entries: make(map[string]*idempotentResponse),
+	}
+}
+
+func (m *Idempotency) Protect(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(IdempotencyHeader)
+		if key == "" {
+			next(w, r)
+			return
+		}
+		key = r.Method + " " + r.URL.Path + " " + key
+
+		for {
+			entry, owner := m.claim(key)
+			if owner {
+				m.record(key, entry, w, r, next)
+				return
+			}
+			select {
+			case <-entry.done:
+			case <-r.Context().Done():
+				return
+			}
+			if !entry.aborted {
+				replay(w, entry)
+				return
+			}
+			// The first request panicked without a response to replay;
+			// claim the key again and run the handler for this one.
+		}
+	}
+}
+
+// record runs next and stores its response in entry. If next panics the
+// entry is dropped instead, so a half-written response is never replayed,
+// and the panic carries on up the stack.
+func (m *Idempotency) record(key string, entry *idempotentResponse, w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	completed := false
+	defer func() {
+		if !completed {
+			entry.aborted = true
+			m.forget(key, entry)
+		}
+		close(entry.done)
+	}()
+
+	rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+	next(rec, r)
+
+	entry.status = rec.status
+	entry.header = w.Header().Clone()
+	entry.body = rec.body.Bytes()
+	completed = true
+}
+
+// claim returns the entry for key, creating it if needed. owner reports
+// whether the caller created it and must run the handler.
+func (m *Idempotency) claim(key string) (entry *idempotentResponse, owner bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	for k, e := range m.entries {
+		if now.After(e.expires) {
+			delete(m.entries, k)
+		}
+	}
+
+	if entry, ok := m.entries[key]; ok {
+		return entry, false
+	}
+	entry = &idempotentResponse{
+		done:    make(chan struct{}),
+		expires: now.Add(m.ttl),
+	}
+	m.entries[key] = entry
+	return entry, true
+}
+
+// forget removes entry for key unless it has already been replaced.
+func (m *Idempotency) forget(key string, entry *idempotentResponse) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.entries[key] == entry {
+		delete(m.entries, key)
+	}
+}
+
+func replay(w http.ResponseWriter, entry *idempotentResponse) {
+	for k, v := range entry.header {
+		w.Header()[k] = v
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(entry.status)
+	_, _ = w.Write(entry.body)
+}
+
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
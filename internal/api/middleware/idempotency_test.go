@@ -0,0 +1,139 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestIdempotencyReplaysRepeatedKey(t *testing.T) {
+	calls := 0
+	handler := NewIdempotency(time.Minute).Protect(func(w http.ResponseWriter, _ *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"new_status":"connecting"}`))
+	})
+
+	send := func(key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/servers/a/action", nil)
+		if key != "" {
+			req.Header.Set(IdempotencyHeader, key)
+		}
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		return rec
+	}
+
+	first := send("abc")
+	second := send("abc")
+
+	if calls != 1 {
+		t.Fatalf("expected handler to run once for a repeated key, ran %d times", calls)
+	}
+	if second.Code != first.Code || second.Body.String() != first.Body.String() {
+		t.Errorf("expected replayed response %d %q, got %d %q", first.Code, first.Body, second.Code, second.Body)
+	}
+	if second.Header().Get("Idempotent-Replayed") != "true" {
+		t.Error("expected replayed response to be marked")
+	}
+
+	send("other")
+	send("")
+	if calls != 3 {
+		t.Errorf("expected new and missing keys to run the handler, got %d calls", calls)
+	}
+}
+
+func TestIdempotencyKeysExpire(t *testing.T) {
+	calls := 0
+	idem := NewIdempotency(time.Minute)
+	now := time.Now()
+	idem.now = func() time.Time { return now }
+	handler := idem.Protect(func(w http.ResponseWriter, _ *http.Request) {
+		calls++
+	})
+
+	req := func() *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/api/servers/a/action", nil)
+		r.Header.Set(IdempotencyHeader, "abc")
+		return r
+	}
+
+	handler(httptest.NewRecorder(), req())
+	now = now.Add(2 * time.Minute)
+	handler(httptest.NewRecorder(), req())
+
+	if calls != 2 {
+		t.Errorf("expected expired key to run the handler again, got %d calls", calls)
+	}
+}
+
+func TestIdempotencyForgetsPanickedRequest(t *testing.T) {
+	calls := 0
+	handler := NewIdempotency(time.Minute).Protect(func(w http.ResponseWriter, _ *http.Request) {
+		calls++
+		if calls == 1 {
+			_, _ = w.Write([]byte("partial"))
+			panic("boom")
+		}
+		_, _ = w.Write([]byte("ok"))
+	})
+
+	req := func() *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/api/servers/a/action", nil)
+		r.Header.Set(IdempotencyHeader, "abc")
+		return r
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("expected the panic to propagate")
+			}
+		}()
+		handler(httptest.NewRecorder(), req())
+	}()
+
+	rec := httptest.NewRecorder()
+	handler(rec, req())
+	if calls != 2 || rec.Body.String() != "ok" {
+		t.Errorf("expected the retry to run the handler, got %d calls and body %q", calls, rec.Body)
+	}
+	if rec.Header().Get("Idempotent-Replayed") != "" {
+		t.Error("expected the retry not to be a replay")
+	}
+}
+
+func TestIdempotencyWaiterStopsWithRequestContext(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	handler := NewIdempotency(time.Minute).Protect(func(http.ResponseWriter, *http.Request) {
+		close(started)
+		<-release
+	})
+	defer close(release)
+
+	first := httptest.NewRequest(http.MethodPost, "/api/servers/a/action", nil)
+	first.Header.Set(IdempotencyHeader, "abc")
+	go handler(httptest.NewRecorder(), first)
+	<-started
+
+	ctx, cancel := context.WithCancel(context.Background())
+	second := httptest.NewRequest(http.MethodPost, "/api/servers/a/action", nil).WithContext(ctx)
+	second.Header.Set(IdempotencyHeader, "abc")
+	done := make(chan struct{})
+	go func() {
+		handler(httptest.NewRecorder(), second)
+		close(done)
+	}()
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected the waiting request to return once its context was cancelled")
+	}
+}
@@ -0,0 +1,180 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DashboardSession is a logged-in dashboard client. The ID is safe to show
+// and is used for revocation; the token that authenticates the cookie is
+// never exposed.
+type DashboardSession struct {
+	ID         string    `json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeen   time.Time `json:"last_seen"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	RemoteAddr string    `json:"remote_addr"`
+	UserAgent  string    `json:"user_agent"`
+
+	token string
+}
+
+// maxDashboardSessions caps the registry so repeated logins cannot grow it
+// without bound; the oldest session is evicted to make room.
+const maxDashboardSessions = 1000
+
+// Sessions is an in-memory registry of dashboard sessions. Sessions do not
+// survive a restart.
+type Sessions struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	limit   int
+	byToken map[string]*DashboardSession
+}
+
+// NewSessions creates a registry whose sessions expire ttl after login.
+func NewSessions(ttl time.Duration) *Sessions {
+	return &Sessions{
+		ttl:     ttl,
+		limit:   maxDashboardSessions,
+		byToken: make(map[string]*DashboardSession),
+	}
+}
+
+// Issue creates a session for the request and returns the cookie token.
+func (s *Sessions) Issue(r *http.Request) (string, DashboardSession, error) {
+	token, err := randomHex(32)
+	if err != nil {
+		return "", DashboardSession{}, err
+	}
+	id, err := randomHex(8)
+	if err != nil {
+		return "", DashboardSession{}, err
+	}
+
+	now := time.Now()
+	session := &DashboardSession{
+		ID:         id,
+		CreatedAt:  now,
+		LastSeen:   now,
+		ExpiresAt:  now.Add(s.ttl),
+		RemoteAddr: r.RemoteAddr,
+		UserAgent:  r.UserAgent(),
+		token:      token,
+	}
+
+	s.mu.Lock()
+	s.makeRoomLocked(now)
+	s.byToken[token] = session
+	s.mu.Unlock()
+
+	return token, *session, nil
+}
+
+// makeRoomLocked drops expired sessions and, if the registry is still full,
+// the oldest live one. s.mu must be held.
+func (s *Sessions) makeRoomLocked(now time.Time) {
+	if len(s.byToken) < s.limit {
+		return
+	}
+	var oldestToken string
+	var oldest *DashboardSession
+	for token, session := range s.byToken {
+		if now.After(session.ExpiresAt) {
+			delete(s.byToken, token)
+			continue
+		}
+		if oldest == nil || session.CreatedAt.Before(oldest.CreatedAt) {
+			oldestToken, oldest = token, session
+		}
+	}
+	if len(s.byToken) >= s.limit {
+		delete(s.byToken, oldestToken)
+	}
+}
+
+// Lookup returns the live session for a cookie token and records the access.
+func (s *Sessions) Lookup(token string) (DashboardSession, bool) {
+	if token == "" {
+		return DashboardSession{}, false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.byToken[token]
+	if !ok {
+		return DashboardSession{}, false
+	}
+	now := time.Now()
+	if now.After(session.ExpiresAt) {
+		delete(s.byToken, token)
+		return DashboardSession{}, false
+	}
+	session.LastSeen = now
+	return *session, true
+}
+
+// List returns the live sessions, oldest first.
+func (s *Sessions) List() []DashboardSession {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	result := make([]DashboardSession, 0, len(s.byToken))
+	for token, session := range s.byToken {
+		if now.After(session.ExpiresAt) {
+			delete(s.byToken, token)
+			continue
+		}
+		result = append(result, *session)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+	return result
+}
+
+// Revoke removes the session with the given ID. It reports whether a session
+// was found.
+func (s *Sessions) Revoke(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for token, session := range s.byToken {
+		if session.ID == id {
+			delete(s.byToken, token)
+			return true
+		}
+	}
+	return false
+}
+
+// RevokeToken removes the session authenticated by a cookie token.
+func (s *Sessions) RevokeToken(token string) {
+	s.mu.Lock()
+	delete(s.byToken, token)
+	s.mu.Unlock()
+}
+
+type sessionContextKey struct{}
+
+// SessionFromContext returns the dashboard session that authenticated the
+// request, if any.
+func SessionFromContext(ctx context.Context) (DashboardSession, bool) {
+	session, ok := ctx.Value(sessionContextKey{}).(DashboardSession)
+	return session, ok
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
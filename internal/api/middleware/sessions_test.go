@@ -0,0 +1,147 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSessionsIssueListRevoke(t *testing.T) {
+	sessions := NewSessions(time.Hour)
+	req := httptest.NewRequest(http.MethodPost, "/api/auth/login", nil)
+	req.Header.Set("User-Agent", "test-agent")
+
+	token, issued, err := sessions.Issue(req)
+	if err != nil {
+		t.Fatalf("Issue() error = %v", err)
+	}
+	if token == "" || issued.ID == "" || token == issued.ID {
+		t.Fatalf("expected distinct token and ID, got %q and %q", token, issued.ID)
+	}
+
+	if got, ok := sessions.Lookup(token); !ok || got.ID != issued.ID {
+		t.Fatalf("Lookup() = %+v, %v; want session %s", got, ok, issued.ID)
+	}
+
+	list := sessions.List()
+	if len(list) != 1 || list[0].ID != issued.ID || list[0].UserAgent != "test-agent" {
+		t.Fatalf("List() = %+v", list)
+	}
+
+	if sessions.Revoke("missing") {
+		t.Error("Revoke() of unknown ID reported success")
+	}
+	if !sessions.Revoke(issued.ID) {
+		t.Fatal("Revoke() did not find the issued session")
+	}
+	if _, ok := sessions.Lookup(token); ok {
+		t.Error("expected revoked token to be rejected")
+	}
+}
+
+func TestSessionsExpire(t *testing.T) {
+	sessions := NewSessions(-time.Second)
+	token, _, err := sessions.Issue(httptest.NewRequest(http.MethodPost, "/", nil))
+	if err != nil {
+		t.Fatalf("Issue() error = %v", err)
+	}
+
+	if _, ok := sessions.Lookup(token); ok {
+		t.Error("expected expired session to be rejected")
+	}
+	if list := sessions.List(); len(list) != 0 {
+		t.Errorf("expected expired sessions to be dropped, got %+v", list)
+	}
+}
+
+func TestSessionsEvictOldestAtLimit(t *testing.T) {
+	sessions := NewSessions(time.Hour)
+	sessions.limit = 3
+
+	var tokens []string
+	for range 4 {
+		token, _, err := sessions.Issue(httptest.NewRequest(http.MethodPost, "/", nil))
+		if err != nil {
+			t.Fatalf("Issue() error = %v", err)
+		}
+		tokens = append(tokens, token)
+	}
+
+	if _, ok := sessions.Lookup(tokens[0]); ok {
+		t.Error("expected the oldest session to be evicted")
+	}
+	for _, token := range tokens[1:] {
+		if _, ok := sessions.Lookup(token); !ok {
+			t.Error("expected newer sessions to be kept")
+		}
+	}
+}
+
+func TestSessionsIssueDropsExpired(t *testing.T) {
+	sessions := NewSessions(-time.Second)
+	sessions.limit = 2
+	for range 3 {
+		if _, _, err := sessions.Issue(httptest.NewRequest(http.MethodPost, "/", nil)); err != nil {
+			t.Fatalf("Issue() error = %v", err)
+		}
+	}
+
+	sessions.mu.Lock()
+	defer sessions.mu.Unlock()
+	if len(sessions.byToken) != 1 {
+		t.Errorf("expected expired sessions to be dropped on issue, got %d stored", len(sessions.byToken))
+	}
+}
+
+func TestAuthMigratesLegacyCookie(t *testing.T) {
+	t.Setenv("API_KEY", "test-key")
+	auth, err := NewAuth(nil)
+	if err != nil {
+		t.Fatalf("NewAuth() error = %v", err)
+	}
+
+	handler := auth.Protect(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := SessionFromContext(r.Context()); !ok {
+			t.Error("expected session in request context")
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/config", nil)
+	req.AddCookie(&http.Cookie{Name: CookieName, Value: "test-key"})
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected legacy cookie to be accepted, got %d", rec.Code)
+	}
+
+	var sessionCookie *http.Cookie
+	legacyCleared := false
+	for _, c := range rec.Result().Cookies() {
+		switch c.Name {
+		case SessionCookieName:
+			sessionCookie = c
+		case CookieName:
+			legacyCleared = c.MaxAge < 0
+		}
+	}
+	if sessionCookie == nil || sessionCookie.Value == "test-key" {
+		t.Fatalf("expected a session cookie that is not the API key, got %+v", sessionCookie)
+	}
+	if !legacyCleared {
+		t.Error("expected legacy cookie to be cleared")
+	}
+	if len(auth.Sessions().List()) != 1 {
+		t.Errorf("expected one migrated session, got %d", len(auth.Sessions().List()))
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/config", nil)
+	req.AddCookie(&http.Cookie{Name: CookieName, Value: "wrong-key"})
+	rec = httptest.NewRecorder()
+	handler(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected invalid legacy cookie to be rejected, got %d", rec.Code)
+	}
+}
@@ -19,6 +19,12 @@ func LimitBody(r *http.Request) {
 	r.Body = http.MaxBytesReader(nil, r.Body, maxBodySize)
 }
 
+// LimitBodyTo caps the request body at limit, or at the default 1MB when
+// limit is smaller.
+func LimitBodyTo(r *http.Request, limit int64) {
+	r.Body = http.MaxBytesReader(nil, r.Body, max(limit, maxBodySize))
+}
+
 func JSON(w http.ResponseWriter, status int, data any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
@@ -33,7 +39,12 @@ func Error(w http.ResponseWriter, status int, errCode, message string) {
 }
 
 func DecodeJSON(w http.ResponseWriter, r *http.Request, logger *slog.Logger, v any) bool {
-	LimitBody(r)
+	return DecodeJSONLimit(w, r, logger, v, maxBodySize)
+}
+
+// DecodeJSONLimit is DecodeJSON with the body capped as by LimitBodyTo.
+func DecodeJSONLimit(w http.ResponseWriter, r *http.Request, logger *slog.Logger, v any, limit int64) bool {
+	LimitBodyTo(r, limit)
 	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
 		logger.Error("Failed to decode request", "error", err)
 		Error(w, http.StatusBadRequest, "invalid_request", "Invalid JSON request body")
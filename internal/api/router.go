@@ -5,11 +5,15 @@ import (
 	"log/slog"
 	"net/http"
 	"os"
+	"time"
 
 	"github.com/pyyupsk/discord-stayonline/internal/api/handlers"
 	"github.com/pyyupsk/discord-stayonline/internal/api/middleware"
+	"github.com/pyyupsk/discord-stayonline/internal/audit"
+	"github.com/pyyupsk/discord-stayonline/internal/backup"
 	"github.com/pyyupsk/discord-stayonline/internal/config"
 	"github.com/pyyupsk/discord-stayonline/internal/manager"
+	"github.com/pyyupsk/discord-stayonline/internal/runtimeconfig"
 	"github.com/pyyupsk/discord-stayonline/internal/ui"
 	"github.com/pyyupsk/discord-stayonline/internal/ws"
 )
@@ -22,6 +26,53 @@ type Router struct {
 	webFS   fs.FS
 	logger  *slog.Logger
 	auth    *middleware.Auth
+	discord *handlers.DiscordHandler
+
+	// PublicStatus exposes an unauthenticated, sanitized status view at
+	// /api/public/status.
+	PublicStatus bool
+
+	// MinimalHealth limits unauthenticated /health requests to the status
+	// and uptime, keeping session, runtime, and memory detail behind auth.
+	MinimalHealth bool
+
+	// MetricsPublic serves /metrics without authentication, for scrapers
+	// that cannot send the dashboard session cookie.
+	MetricsPublic bool
+
+	// DiscordMaxRetries is how many times a Discord REST lookup answered
+	// with 429 is retried. Zero uses handlers.DefaultDiscordMaxRetries.
+	DiscordMaxRetries int
+
+	// DiscordBreakerThreshold and DiscordBreakerCooldown configure the
+	// circuit breaker for Discord REST lookups. Zero uses the handlers
+	// package defaults.
+	DiscordBreakerThreshold int
+	DiscordBreakerCooldown  time.Duration
+
+	// GenerateServerIDs assigns IDs to servers submitted without one.
+	GenerateServerIDs bool
+
+	// Backups enables POST /api/config/backup when non-nil.
+	Backups *backup.Backups
+
+	// TrustedProxies, when non-nil, replaces each request's RemoteAddr with
+	// the client IP forwarded by a trusted proxy.
+	TrustedProxies *middleware.TrustedProxies
+
+	// OpenAPISpec, when non-nil, is served at GET /api/openapi.json.
+	OpenAPISpec []byte
+
+	// Audit, when non-nil, records configuration changes made through the
+	// API and enables GET /api/audit.
+	Audit *audit.Log
+
+	// RuntimeConfig, when non-nil, enables GET /api/runtime-config.
+	RuntimeConfig *runtimeconfig.Registry
+
+	// SPARoutes limits which extensionless paths serve the web UI; others
+	// return 404. Empty serves the UI for every such path.
+	SPARoutes []string
 }
 
 func NewRouter(store config.ConfigStore, mgr *manager.SessionManager, hub *ws.Hub, webFS fs.FS, logger *slog.Logger) (*Router, error) {
@@ -46,29 +97,88 @@ func NewRouter(store config.ConfigStore, mgr *manager.SessionManager, hub *ws.Hu
 
 func (r *Router) Setup() http.Handler {
 	healthHandler := handlers.NewHealthHandler(r.manager, r.hub)
+	if r.MinimalHealth {
+		healthHandler.Auth = r.auth
+	}
 	r.mux.HandleFunc("GET /health", healthHandler.Health)
 	r.mux.HandleFunc("HEAD /health", healthHandler.Health)
 
+	if r.manager != nil && r.manager.Metrics != nil {
+		if r.MetricsPublic {
+			r.mux.Handle("GET /metrics", r.manager.Metrics.Handler())
+		} else {
+			r.mux.Handle("GET /metrics", r.auth.ProtectHandler(r.manager.Metrics.Handler()))
+		}
+	}
+
+	if r.PublicStatus {
+		publicHandler := handlers.NewPublicStatusHandler(r.store, r.manager, r.logger)
+		r.mux.HandleFunc("GET /api/public/status", publicHandler.GetStatus)
+	}
+
 	authHandler := handlers.NewAuthHandler(r.auth, r.logger)
 	r.mux.HandleFunc("POST /api/auth/login", authHandler.Login)
 	r.mux.HandleFunc("POST /api/auth/logout", authHandler.Logout)
 	r.mux.HandleFunc("GET /api/auth/check", authHandler.Check)
+	r.mux.HandleFunc("GET /api/auth/sessions", r.auth.Protect(authHandler.ListSessions))
+	r.mux.HandleFunc("DELETE /api/auth/sessions/{id}", r.auth.Protect(authHandler.RevokeSession))
 
 	tosHandler := handlers.NewTOSHandler(r.store, r.logger)
+	tosHandler.Audit = r.Audit
 	r.mux.HandleFunc("POST /api/acknowledge-tos", r.auth.Protect(tosHandler.AcknowledgeTOS))
 
 	configHandler := handlers.NewConfigHandler(r.store, r.logger)
+	configHandler.GenerateIDs = r.GenerateServerIDs
+	configHandler.Audit = r.Audit
+	if r.manager != nil && r.manager.CleanupOrphanedSessions {
+		configHandler.OnChange = func() {
+			if err := r.manager.Reconcile(); err != nil {
+				r.logger.Error("Failed to reconcile sessions", "error", err)
+			}
+		}
+	}
 	r.mux.HandleFunc("GET /api/config", r.auth.Protect(configHandler.GetConfig))
 	r.mux.HandleFunc("POST /api/config", r.auth.Protect(configHandler.ReplaceConfig))
 	r.mux.HandleFunc("PUT /api/config", r.auth.Protect(configHandler.UpdateConfig))
 
+	if r.Backups != nil {
+		backupHandler := handlers.NewBackupHandler(r.Backups, r.logger)
+		r.mux.HandleFunc("POST /api/config/backup", r.auth.Protect(backupHandler.CreateBackup))
+	}
+
+	statusHandler := handlers.NewStatusHandler(r.store, r.manager, r.logger)
+	statusHandler.Audit = r.Audit
+	r.mux.HandleFunc("GET /api/status", r.auth.Protect(statusHandler.GetStatus))
+	r.mux.HandleFunc("PUT /api/status", r.auth.Protect(statusHandler.SetStatus))
+
 	if r.manager != nil {
 		serversHandler := handlers.NewServersHandler(r.manager, r.logger)
+		serversHandler.Audit = r.Audit
 		r.mux.HandleFunc("GET /api/statuses", r.auth.Protect(serversHandler.GetStatuses))
-		r.mux.HandleFunc("POST /api/servers/", r.auth.Protect(serversHandler.ExecuteAction))
+		idempotency := middleware.NewIdempotency(middleware.DefaultIdempotencyTTL)
+		r.mux.HandleFunc("POST /api/servers/", r.auth.Protect(idempotency.Protect(serversHandler.ExecuteAction)))
+		r.mux.HandleFunc("DELETE /api/servers/{id}", r.auth.Protect(serversHandler.DeleteServer))
+		r.mux.HandleFunc("GET /api/servers/{id}/status", r.auth.Protect(serversHandler.GetStatusDetail))
+		r.mux.HandleFunc("PUT /api/servers/{id}/status", r.auth.Protect(serversHandler.SetStatusOverride))
+		r.mux.HandleFunc("DELETE /api/servers/{id}/status", r.auth.Protect(serversHandler.ClearStatusOverride))
+		r.mux.HandleFunc("PUT /api/servers/{id}/channel", r.auth.Protect(serversHandler.MoveChannel))
+		r.mux.HandleFunc("DELETE /api/servers/{id}/channel", r.auth.Protect(serversHandler.RestoreChannel))
+		r.mux.HandleFunc("GET /api/servers/{id}/explain", r.auth.Protect(serversHandler.Explain))
+
+		if r.manager.FrameLogSize > 0 {
+			r.mux.HandleFunc("GET /api/servers/{id}/frames", r.auth.Protect(serversHandler.GetFrames))
+		}
+
+		statsHandler := handlers.NewStatsHandler(r.manager, r.hub, r.logger)
+		r.mux.HandleFunc("GET /api/stats/overview", r.auth.Protect(statsHandler.GetOverview))
 	}
 
 	discordHandler := handlers.NewDiscordHandler(r.logger)
+	discordHandler.MaxRetries = r.DiscordMaxRetries
+	discordHandler.BreakerThreshold = r.DiscordBreakerThreshold
+	discordHandler.BreakerCooldown = r.DiscordBreakerCooldown
+	r.discord = discordHandler
+	healthHandler.Discord = discordHandler
 	r.mux.HandleFunc("GET /api/discord/user", r.auth.Protect(discordHandler.GetCurrentUser))
 	r.mux.HandleFunc("GET /api/discord/server-info", r.auth.Protect(discordHandler.GetServerInfo))
 	r.mux.HandleFunc("POST /api/discord/bulk-info", r.auth.Protect(discordHandler.GetBulkServerInfo))
@@ -78,6 +188,26 @@ func (r *Router) Setup() http.Handler {
 	if r.hub != nil {
 		logsHandler := handlers.NewLogsHandler(r.hub, r.logger)
 		r.mux.HandleFunc("GET /api/logs", r.auth.Protect(logsHandler.GetLogs))
+
+		if deadLetters := r.hub.DeadLetters(); deadLetters != nil {
+			deadLetterHandler := handlers.NewDeadLetterHandler(deadLetters, r.logger)
+			r.mux.HandleFunc("GET /api/dead-letters", r.auth.Protect(deadLetterHandler.GetDeadLetters))
+		}
+	}
+
+	if r.Audit != nil {
+		auditHandler := handlers.NewAuditHandler(r.Audit, r.logger)
+		r.mux.HandleFunc("GET /api/audit", r.auth.Protect(auditHandler.GetAudit))
+	}
+
+	if r.RuntimeConfig != nil {
+		runtimeConfigHandler := handlers.NewRuntimeConfigHandler(r.store, r.RuntimeConfig, r.logger)
+		r.mux.HandleFunc("GET /api/runtime-config", r.auth.Protect(runtimeConfigHandler.GetRuntimeConfig))
+	}
+
+	if r.OpenAPISpec != nil {
+		openAPIHandler := handlers.NewOpenAPIHandler(r.OpenAPISpec)
+		r.mux.HandleFunc("GET /api/openapi.json", r.auth.Protect(openAPIHandler.GetSpec))
 	}
 
 	if r.hub != nil {
@@ -87,12 +217,24 @@ func (r *Router) Setup() http.Handler {
 	}
 
 	if r.webFS != nil {
-		r.mux.Handle("/", ui.SPAHandler(r.webFS))
+		r.mux.Handle("/", ui.SPAHandler(r.webFS, r.SPARoutes))
 	}
 
-	return r.mux
+	return r.Handler()
 }
 
+// Handler returns the handler Setup serves: the routes, behind client IP
+// resolution when TrustedProxies is set.
 func (r *Router) Handler() http.Handler {
+	if r.TrustedProxies != nil {
+		return r.TrustedProxies.Handler(r.mux)
+	}
 	return r.mux
 }
+
+// Close stops the background work started by Setup.
+func (r *Router) Close() {
+	if r.discord != nil {
+		r.discord.Close()
+	}
+}
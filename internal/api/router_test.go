@@ -0,0 +1,522 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/pyyupsk/discord-stayonline/internal/api/middleware"
+	"github.com/pyyupsk/discord-stayonline/internal/audit"
+	"github.com/pyyupsk/discord-stayonline/internal/backup"
+	"github.com/pyyupsk/discord-stayonline/internal/config"
+	"github.com/pyyupsk/discord-stayonline/internal/config/store"
+	"github.com/pyyupsk/discord-stayonline/internal/deadletter"
+	"github.com/pyyupsk/discord-stayonline/internal/manager"
+	"github.com/pyyupsk/discord-stayonline/internal/metrics"
+	"github.com/pyyupsk/discord-stayonline/internal/runtimeconfig"
+	"github.com/pyyupsk/discord-stayonline/internal/ws"
+)
+
+func newTestRouter(t *testing.T, publicStatus bool) http.Handler {
+	t.Helper()
+	router, err := NewRouter(newTestStore(t), nil, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("NewRouter() error = %v", err)
+	}
+	router.PublicStatus = publicStatus
+	return router.Setup()
+}
+
+func newTestStore(t *testing.T) config.ConfigStore {
+	t.Helper()
+	t.Setenv("API_KEY", "test-key")
+
+	s := store.NewFile(filepath.Join(t.TempDir(), "config.json"))
+	cfg := &config.Configuration{
+		Servers: []config.ServerEntry{{
+			ID:          "srv-secret-id",
+			Label:       "Lounge",
+			GuildID:     "123456789012345678",
+			GuildName:   "My Guild",
+			ChannelID:   "234567890123456789",
+			ChannelName: "General",
+			Priority:    1,
+		}},
+		Status:          config.StatusOnline,
+		TOSAcknowledged: true,
+	}
+	if err := s.Save(cfg); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	return s
+}
+
+func TestPublicStatusDisabledByDefault(t *testing.T) {
+	handler := newTestRouter(t, false)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/public/status", nil))
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 when PUBLIC_STATUS is off, got %d", rec.Code)
+	}
+}
+
+func TestPublicStatusIsUnauthenticatedAndSanitized(t *testing.T) {
+	handler := newTestRouter(t, true)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/public/status", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 without credentials, got %d", rec.Code)
+	}
+
+	body := rec.Body.String()
+	for _, want := range []string{`"Lounge"`, `"My Guild"`, `"General"`, `"up":false`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %s in response, got %s", want, body)
+		}
+	}
+	for _, secret := range []string{"srv-secret-id", "123456789012345678", "234567890123456789", "test-key", `"id"`, "guild_id", "channel_id"} {
+		if strings.Contains(body, secret) {
+			t.Errorf("response leaks %q: %s", secret, body)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/public/status", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected POST to be rejected, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/config", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected /api/config to stay protected, got %d", rec.Code)
+	}
+}
+
+func TestDashboardSessionsListAndRevoke(t *testing.T) {
+	handler := newTestRouter(t, false)
+
+	login := func() *http.Cookie {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/auth/login", strings.NewReader(`{"api_key": "test-key"}`)))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("login failed: %d %s", rec.Code, rec.Body)
+		}
+		for _, c := range rec.Result().Cookies() {
+			if c.Name == "session" {
+				if c.Value == "test-key" {
+					t.Fatal("session cookie must not contain the API key")
+				}
+				return c
+			}
+		}
+		t.Fatal("login did not set a session cookie")
+		return nil
+	}
+	send := func(method, path string, cookie *http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.AddCookie(cookie)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := login()
+	second := login()
+
+	rec := send(http.MethodGet, "/api/auth/sessions", first)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 listing sessions, got %d", rec.Code)
+	}
+	var sessions []struct {
+		ID      string `json:"id"`
+		Current bool   `json:"current"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &sessions); err != nil {
+		t.Fatalf("decode sessions: %v", err)
+	}
+	if len(sessions) != 2 || !sessions[0].Current || sessions[1].Current {
+		t.Fatalf("expected two sessions with the first marked current, got %+v", sessions)
+	}
+	if strings.Contains(rec.Body.String(), first.Value) || strings.Contains(rec.Body.String(), second.Value) {
+		t.Fatal("session listing leaks cookie tokens")
+	}
+
+	rec = send(http.MethodDelete, "/api/auth/sessions/"+sessions[1].ID, first)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 revoking session, got %d", rec.Code)
+	}
+	if rec := send(http.MethodGet, "/api/config", second); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected revoked session to be rejected, got %d", rec.Code)
+	}
+	if rec := send(http.MethodGet, "/api/config", first); rec.Code != http.StatusOK {
+		t.Errorf("expected remaining session to stay valid, got %d", rec.Code)
+	}
+	if rec := send(http.MethodDelete, "/api/auth/sessions/"+sessions[1].ID, first); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 revoking an unknown session, got %d", rec.Code)
+	}
+}
+
+func TestHandlerResolvesForwardedClientIP(t *testing.T) {
+	router, err := NewRouter(newTestStore(t), nil, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("NewRouter() error = %v", err)
+	}
+	router.TrustedProxies, err = middleware.ParseTrustedProxies("192.0.2.1")
+	if err != nil {
+		t.Fatalf("ParseTrustedProxies() error = %v", err)
+	}
+	router.Setup()
+	handler := router.Handler()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/auth/login", strings.NewReader(`{"api_key": "test-key"}`))
+	req.Header.Set("X-Forwarded-For", "203.0.113.7")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("login failed: %d %s", rec.Code, rec.Body)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/auth/sessions", nil)
+	for _, c := range rec.Result().Cookies() {
+		req.AddCookie(c)
+	}
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if !strings.Contains(rec.Body.String(), `"remote_addr":"203.0.113.7`) {
+		t.Errorf("expected the session to record the forwarded client IP, got %s", rec.Body)
+	}
+}
+
+func TestMetricsRequireAuthUnlessPublic(t *testing.T) {
+	s := newTestStore(t)
+	mgr := manager.NewSessionManager("token", s, nil, nil, nil)
+	mgr.Metrics = metrics.New()
+	defer mgr.Stop()
+
+	for _, public := range []bool{false, true} {
+		router, err := NewRouter(s, mgr, nil, nil, nil)
+		if err != nil {
+			t.Fatalf("NewRouter() error = %v", err)
+		}
+		router.MetricsPublic = public
+		handler := router.Setup()
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		want := http.StatusUnauthorized
+		if public {
+			want = http.StatusOK
+		}
+		if rec.Code != want {
+			t.Errorf("MetricsPublic=%v: expected %d without credentials, got %d", public, want, rec.Code)
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		req.AddCookie(&http.Cookie{Name: middleware.CookieName, Value: "test-key"})
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Errorf("MetricsPublic=%v: expected 200 with credentials, got %d", public, rec.Code)
+		}
+	}
+}
+
+func TestMinimalHealthHidesDetailWithoutAuth(t *testing.T) {
+	s := newTestStore(t)
+	mgr := manager.NewSessionManager("token", s, nil, nil, nil)
+	defer mgr.Stop()
+
+	health := func(handler http.Handler, authenticated bool) map[string]any {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/health", nil)
+		if authenticated {
+			req.AddCookie(&http.Cookie{Name: middleware.CookieName, Value: "test-key"})
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200 from /health, got %d", rec.Code)
+		}
+		var body map[string]any
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("decode /health: %v", err)
+		}
+		return body
+	}
+
+	for _, minimal := range []bool{false, true} {
+		router, err := NewRouter(s, mgr, nil, nil, nil)
+		if err != nil {
+			t.Fatalf("NewRouter() error = %v", err)
+		}
+		router.MinimalHealth = minimal
+		handler := router.Setup()
+
+		public := health(handler, false)
+		if minimal {
+			if len(public) != 2 || public["status"] == nil || public["uptime"] == nil {
+				t.Errorf("MinimalHealth: expected only status and uptime without credentials, got %v", public)
+			}
+		} else if public["runtime"] == nil || public["connections"] == nil {
+			t.Errorf("expected full detail without MinimalHealth, got %v", public)
+		}
+
+		if full := health(handler, true); full["runtime"] == nil || full["memory"] == nil || full["connections"] == nil {
+			t.Errorf("MinimalHealth=%v: expected full detail with credentials, got %v", minimal, full)
+		}
+	}
+}
+
+func TestWebSocketDisabled(t *testing.T) {
+	s := newTestStore(t)
+	mgr := manager.NewSessionManager("token", s, nil, nil, nil)
+	mgr.GatewayURL = "ws://127.0.0.1:1"
+	defer mgr.Stop()
+
+	webFS := fstest.MapFS{"index.html": {Data: []byte("<html></html>")}}
+	router, err := NewRouter(s, mgr, nil, webFS, nil)
+	if err != nil {
+		t.Fatalf("NewRouter() error = %v", err)
+	}
+	handler := router.Setup()
+
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.AddCookie(&http.Cookie{Name: middleware.CookieName, Value: "test-key"})
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := send(http.MethodGet, "/ws", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for /ws without a hub, got %d", rec.Code)
+	}
+
+	if rec := send(http.MethodPost, "/api/servers/srv-secret-id/action", `{"action":"join"}`); rec.Code != http.StatusOK {
+		t.Fatalf("expected join to work without a hub, got %d %s", rec.Code, rec.Body)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if status, _ := mgr.GetStatus("srv-secret-id"); status != manager.StatusDisconnected {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected the joined session to be running")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func readOpenAPISpec(t *testing.T) []byte {
+	t.Helper()
+	spec, err := os.ReadFile(filepath.Join("..", "..", "docs", "openapi.json"))
+	if err != nil {
+		t.Fatalf("read spec: %v", err)
+	}
+	return spec
+}
+
+func TestOpenAPISpecMatchesRoutes(t *testing.T) {
+	var spec struct {
+		OpenAPI string                                `json:"openapi"`
+		Paths   map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(readOpenAPISpec(t), &spec); err != nil {
+		t.Fatalf("spec is not valid JSON: %v", err)
+	}
+	if !strings.HasPrefix(spec.OpenAPI, "3.") || len(spec.Paths) == 0 {
+		t.Fatalf("expected an OpenAPI 3 document with paths, got version %q and %d paths", spec.OpenAPI, len(spec.Paths))
+	}
+
+	// Enable every optional route so each documented one can be resolved.
+	s := newTestStore(t)
+	mgr := manager.NewSessionManager("token", s, nil, nil, nil)
+	mgr.FrameLogSize = 1
+	defer mgr.Stop()
+	hub := ws.NewHub(nil, nil)
+	hub.SetDeadLetters(deadletter.New(1))
+
+	router, err := NewRouter(s, mgr, hub, nil, nil)
+	if err != nil {
+		t.Fatalf("NewRouter() error = %v", err)
+	}
+	router.PublicStatus = true
+	router.Backups = backup.New(t.TempDir(), 1, s)
+	router.OpenAPISpec = readOpenAPISpec(t)
+	router.RuntimeConfig = runtimeconfig.New()
+	router.Audit = audit.New(1)
+	router.Setup()
+
+	for path, item := range spec.Paths {
+		for method := range item {
+			req := httptest.NewRequest(strings.ToUpper(method), strings.ReplaceAll(path, "{id}", "x"), nil)
+			if _, pattern := router.Handler().(*http.ServeMux).Handler(req); pattern == "" {
+				t.Errorf("spec documents %s %s, but no such route is registered", strings.ToUpper(method), path)
+			}
+		}
+	}
+}
+
+func TestOpenAPISpecIsServed(t *testing.T) {
+	router, err := NewRouter(newTestStore(t), nil, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("NewRouter() error = %v", err)
+	}
+	router.OpenAPISpec = readOpenAPISpec(t)
+	handler := router.Setup()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/openapi.json", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected the spec to require authentication, got %d", rec.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/openapi.json", nil)
+	req.AddCookie(&http.Cookie{Name: middleware.CookieName, Value: "test-key"})
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("expected application/json, got %q", ct)
+	}
+	if !json.Valid(rec.Body.Bytes()) {
+		t.Error("served spec is not valid JSON")
+	}
+}
+
+func TestRuntimeConfigOmitsSecrets(t *testing.T) {
+	s := newTestStore(t)
+	t.Setenv("PORT", "9090")
+	registry := runtimeconfig.New()
+	registry.String("PORT", "8080")
+	registry.String("API_KEY", "")
+	registry.Bool("PUBLIC_STATUS", false)
+
+	router, err := NewRouter(s, nil, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("NewRouter() error = %v", err)
+	}
+	router.RuntimeConfig = registry
+	handler := router.Setup()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/runtime-config", nil)
+	req.AddCookie(&http.Cookie{Name: middleware.CookieName, Value: "test-key"})
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if strings.Contains(rec.Body.String(), "test-key") || strings.Contains(rec.Body.String(), "API_KEY") {
+		t.Fatalf("runtime config leaks the API key: %s", rec.Body)
+	}
+
+	var resp struct {
+		StoreBackend string                  `json:"store_backend"`
+		Settings     []runtimeconfig.Setting `json:"settings"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	want := []runtimeconfig.Setting{
+		{Name: "PORT", Value: "9090", Source: runtimeconfig.SourceEnv},
+		{Name: "PUBLIC_STATUS", Value: false, Source: runtimeconfig.SourceDefault},
+	}
+	if resp.StoreBackend != "file" || len(resp.Settings) != len(want) ||
+		resp.Settings[0] != want[0] || resp.Settings[1] != want[1] {
+		t.Errorf("unexpected runtime config: %+v", resp)
+	}
+}
+
+func TestConfigChangesAreAudited(t *testing.T) {
+	s := newTestStore(t)
+	cfg, _ := s.Load()
+	cfg.TOSAcknowledged = false
+	if err := s.Save(cfg); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	mgr := manager.NewSessionManager("token", s, nil, nil, nil)
+	defer mgr.Stop()
+	router, err := NewRouter(s, mgr, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("NewRouter() error = %v", err)
+	}
+	router.Audit = audit.New(10)
+	handler := router.Setup()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/auth/login", strings.NewReader(`{"api_key": "test-key"}`)))
+	cookies := rec.Result().Cookies()
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s %s: expected 200, got %d: %s", method, path, rec.Code, rec.Body)
+		}
+		return rec
+	}
+
+	send(http.MethodPost, "/api/acknowledge-tos", `{"acknowledged": true}`)
+	send(http.MethodPut, "/api/status", `{"status": "dnd"}`)
+	// Setting the status it already has changes nothing and is not audited.
+	send(http.MethodPut, "/api/status", `{"status": "dnd"}`)
+	send(http.MethodPost, "/api/config", `{"servers": [
+		{"id": "srv-secret-id", "label": "Renamed", "guild_id": "123456789012345678", "channel_id": "234567890123456789", "priority": 1},
+		{"id": "b", "guild_id": "345678901234567890", "channel_id": "456789012345678901", "priority": 2}]}`)
+	send(http.MethodPut, "/api/config", `{"servers": [{"id": "c", "guild_id": "567890123456789012", "channel_id": "678901234567890123", "priority": 3}]}`)
+	send(http.MethodPut, "/api/servers/b/status", `{"status": "idle"}`)
+	send(http.MethodDelete, "/api/servers/c", "")
+
+	var records []audit.Record
+	if err := json.Unmarshal(send(http.MethodGet, "/api/audit", "").Body.Bytes(), &records); err != nil {
+		t.Fatalf("decode audit: %v", err)
+	}
+	var sessions []struct {
+		ID string `json:"id"`
+	}
+	_ = json.Unmarshal(send(http.MethodGet, "/api/auth/sessions", "").Body.Bytes(), &sessions)
+
+	want := []struct {
+		action string
+		diff   string
+	}{
+		{audit.ActionTOSAcknowledge, `{"tos_acknowledged":true}`},
+		{audit.ActionStatusSet, `{"status":{"from":"online","to":"dnd"}}`},
+		{audit.ActionConfigReplace, `{"added":["b"],"modified":["srv-secret-id"]}`},
+		{audit.ActionConfigUpdate, `{"added":["c"]}`},
+		{audit.ActionStatusOverride, `{"modified":["b"],"status_override":{"from":"","to":"idle"}}`},
+		{audit.ActionServerRemove, `{"removed":["c"]}`},
+	}
+	if len(records) != len(want) {
+		t.Fatalf("expected %d audit records, got %+v", len(want), records)
+	}
+	for i, w := range want {
+		diff, _ := json.Marshal(records[i].Diff)
+		if records[i].Action != w.action || string(diff) != w.diff {
+			t.Errorf("record %d: expected %s %s, got %s %s", i, w.action, w.diff, records[i].Action, diff)
+		}
+		if len(sessions) != 1 || records[i].SessionID != sessions[0].ID {
+			t.Errorf("record %d: expected session %+v, got %q", i, sessions, records[i].SessionID)
+		}
+	}
+
+	if filtered := send(http.MethodGet, "/api/audit?action="+audit.ActionStatusSet, "").Body.String(); strings.Count(filtered, `"action"`) != 1 {
+		t.Errorf("expected one record for the action filter, got %s", filtered)
+	}
+}
@@ -0,0 +1,175 @@
+// Package audit records configuration changes and who made them.
+package audit
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pyyupsk/discord-stayonline/internal/config"
+)
+
+// DefaultSize is the number of records kept when AUDIT_LOG_SIZE is unset.
+const DefaultSize = 500
+
+// Actions recorded by the API.
+const (
+	ActionConfigReplace  = "config.replace"
+	ActionConfigUpdate   = "config.update"
+	ActionStatusSet      = "status.set"
+	ActionTOSAcknowledge = "tos.acknowledge"
+	ActionServerRemove   = "server.remove"
+	ActionStatusOverride = "server.status_override"
+)
+
+// Change is a setting's value before and after a change.
+type Change struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Diff describes what a change did to the configuration.
+type Diff struct {
+	Added           []string `json:"added,omitempty"`
+	Removed         []string `json:"removed,omitempty"`
+	Modified        []string `json:"modified,omitempty"`
+	Status          *Change  `json:"status,omitempty"`
+	StatusOverride  *Change  `json:"status_override,omitempty"`
+	TOSAcknowledged bool     `json:"tos_acknowledged,omitempty"`
+}
+
+// Empty reports whether the diff records no change.
+func (d Diff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Modified) == 0 &&
+		d.Status == nil && d.StatusOverride == nil && !d.TOSAcknowledged
+}
+
+// Compare returns the server IDs added, removed, and modified between before
+// and after, and any change to the global status or TOS acknowledgment.
+func Compare(before, after *config.Configuration) Diff {
+	var d Diff
+	existing := make(map[string]bool, len(before.Servers))
+	for i := range before.Servers {
+		existing[before.Servers[i].ID] = true
+	}
+	changed, removed := config.ChangedServers(before.Servers, after.Servers)
+	for i := range changed {
+		if existing[changed[i].ID] {
+			d.Modified = append(d.Modified, changed[i].ID)
+		} else {
+			d.Added = append(d.Added, changed[i].ID)
+		}
+	}
+	d.Removed = removed
+	if before.Status != after.Status {
+		d.Status = &Change{From: string(before.Status), To: string(after.Status)}
+	}
+	d.TOSAcknowledged = after.TOSAcknowledged && !before.TOSAcknowledged
+	return d
+}
+
+// Record is one audited change. SessionID identifies the dashboard session
+// that made it.
+type Record struct {
+	Action     string    `json:"action"`
+	SessionID  string    `json:"session_id,omitempty"`
+	RemoteAddr string    `json:"remote_addr,omitempty"`
+	Diff       Diff      `json:"diff"`
+	Timestamp  time.Time `json:"timestamp"`
+}
+
+// Store persists audit records. Records returns at most limit of the newest
+// records, oldest first, keeping only those of action when it is non-empty.
+type Store interface {
+	AddRecord(record Record) error
+	Records(action string, limit int) ([]Record, error)
+}
+
+// Log holds audit records, either in a Store or, without one, in a
+// fixed-size in-memory ring. A nil *Log is valid and discards everything, so
+// callers don't need to check whether auditing is enabled.
+type Log struct {
+	store Store
+	size  int
+
+	records []Record
+	next    int
+	full    bool
+	mu      sync.Mutex
+}
+
+// New returns a log holding at most size records, or nil when size is not positive.
+func New(size int) *Log {
+	if size <= 0 {
+		return nil
+	}
+	return &Log{
+		records: make([]Record, size),
+	}
+}
+
+// NewStore returns a log that persists records to store and returns at most
+// size of them, or nil when size is not positive.
+func NewStore(store Store, size int) *Log {
+	if size <= 0 {
+		return nil
+	}
+	return &Log{
+		store: store,
+		size:  size,
+	}
+}
+
+// Add records a change, stamping it with the current time.
+func (l *Log) Add(record Record) error {
+	if l == nil {
+		return nil
+	}
+
+	record.Timestamp = time.Now()
+	if l.store != nil {
+		return l.store.AddRecord(record)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.records[l.next] = record
+	l.next = (l.next + 1) % len(l.records)
+	if l.next == 0 {
+		l.full = true
+	}
+	return nil
+}
+
+// Records returns the recorded changes, oldest first. A non-empty action
+// keeps only records of that action.
+func (l *Log) Records(action string) ([]Record, error) {
+	if l == nil {
+		return []Record{}, nil
+	}
+	if l.store != nil {
+		records, err := l.store.Records(action, l.size)
+		if err != nil {
+			return nil, err
+		}
+		if records == nil {
+			records = []Record{}
+		}
+		return records, nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	ordered := l.records[:l.next]
+	if l.full {
+		ordered = append(append([]Record{}, l.records[l.next:]...), l.records[:l.next]...)
+	}
+	result := make([]Record, 0, len(ordered))
+	for _, record := range ordered {
+		if action == "" || record.Action == action {
+			result = append(result, record)
+		}
+	}
+	return result, nil
+}
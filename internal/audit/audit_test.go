@@ -0,0 +1,119 @@
+package audit
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/pyyupsk/discord-stayonline/internal/config"
+)
+
+func TestNewDisabled(t *testing.T) {
+	if New(0) != nil {
+		t.Error("expected nil log for size 0")
+	}
+
+	var l *Log
+	if err := l.Add(Record{Action: ActionStatusSet}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if records, err := l.Records(""); err != nil || len(records) != 0 {
+		t.Errorf("expected nil log to discard records, got %+v (err %v)", records, err)
+	}
+}
+
+func TestLogBounded(t *testing.T) {
+	l := New(2)
+	for _, action := range []string{ActionConfigReplace, ActionStatusSet, ActionConfigUpdate} {
+		if err := l.Add(Record{Action: action}); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+
+	records, _ := l.Records("")
+	if len(records) != 2 || records[0].Action != ActionStatusSet || records[1].Action != ActionConfigUpdate {
+		t.Fatalf("expected the two newest records oldest first, got %+v", records)
+	}
+	if records[0].Timestamp.IsZero() {
+		t.Error("expected records to be timestamped")
+	}
+	if filtered, _ := l.Records(ActionStatusSet); len(filtered) != 1 {
+		t.Errorf("expected one %s record, got %+v", ActionStatusSet, filtered)
+	}
+}
+
+// memoryStore is a Store that keeps every record and reports the limit it was
+// asked for.
+type memoryStore struct {
+	records []Record
+	limit   int
+}
+
+func (s *memoryStore) AddRecord(record Record) error {
+	s.records = append(s.records, record)
+	return nil
+}
+
+func (s *memoryStore) Records(action string, limit int) ([]Record, error) {
+	s.limit = limit
+	var result []Record
+	for _, record := range s.records {
+		if action == "" || record.Action == action {
+			result = append(result, record)
+		}
+	}
+	return result, nil
+}
+
+func TestLogWritesThroughStore(t *testing.T) {
+	if NewStore(&memoryStore{}, 0) != nil {
+		t.Error("expected nil log for size 0")
+	}
+
+	store := &memoryStore{}
+	l := NewStore(store, 3)
+	if records, err := l.Records(""); err != nil || records == nil || len(records) != 0 {
+		t.Errorf("expected an empty list before any change, got %#v (err %v)", records, err)
+	}
+	for _, action := range []string{ActionConfigReplace, ActionStatusSet} {
+		if err := l.Add(Record{Action: action}); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+	if len(store.records) != 2 || store.records[0].Timestamp.IsZero() {
+		t.Fatalf("expected timestamped records in the store, got %+v", store.records)
+	}
+
+	records, err := l.Records(ActionStatusSet)
+	if err != nil || len(records) != 1 || records[0].Action != ActionStatusSet {
+		t.Errorf("expected the %s record from the store, got %+v (err %v)", ActionStatusSet, records, err)
+	}
+	if store.limit != 3 {
+		t.Errorf("expected the store to be asked for 3 records, got %d", store.limit)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	before := &config.Configuration{
+		Servers: []config.ServerEntry{{ID: "a", Priority: 1}, {ID: "b", Priority: 1}, {ID: "c", Priority: 1}},
+		Status:  config.StatusOnline,
+	}
+	after := &config.Configuration{
+		Servers:         []config.ServerEntry{{ID: "a", Priority: 1}, {ID: "b", Priority: 2}, {ID: "d", Priority: 1}},
+		Status:          config.StatusDND,
+		TOSAcknowledged: true,
+	}
+
+	d := Compare(before, after)
+	if !slices.Equal(d.Added, []string{"d"}) || !slices.Equal(d.Removed, []string{"c"}) || !slices.Equal(d.Modified, []string{"b"}) {
+		t.Errorf("unexpected server diff: %+v", d)
+	}
+	if d.Status == nil || d.Status.From != "online" || d.Status.To != "dnd" {
+		t.Errorf("expected the status change, got %+v", d.Status)
+	}
+	if !d.TOSAcknowledged {
+		t.Error("expected the TOS acknowledgment")
+	}
+	if !Compare(after, after).Empty() {
+		t.Error("expected no diff between identical configurations")
+	}
+}
@@ -0,0 +1,171 @@
+// Package backup writes rotating snapshots of the configuration to a directory.
+package backup
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pyyupsk/discord-stayonline/internal/config"
+	"github.com/pyyupsk/discord-stayonline/internal/config/store"
+)
+
+const (
+	// DefaultKeep is how many backups are retained when no limit is given.
+	DefaultKeep = 10
+
+	filePrefix = "config-"
+	fileSuffix = ".json"
+
+	// timeFormat sorts lexically in time order and is precise enough that
+	// back-to-back backups do not collide.
+	timeFormat = "20060102T150405.000000000Z"
+)
+
+// Backups snapshots a config store into timestamped files in dir. A nil
+// *Backups is valid and does nothing, so callers don't need to check whether
+// backups are enabled.
+type Backups struct {
+	dir   string
+	keep  int
+	store config.ConfigStore
+	mu    sync.Mutex
+}
+
+// New returns a Backups writing to dir and keeping at most keep files, or nil
+// when dir is empty. A keep below one uses DefaultKeep.
+func New(dir string, keep int, source config.ConfigStore) *Backups {
+	if dir == "" {
+		return nil
+	}
+	if keep < 1 {
+		keep = DefaultKeep
+	}
+	return &Backups{
+		dir:   dir,
+		keep:  keep,
+		store: source,
+	}
+}
+
+// Write saves the current configuration to a new backup file, in the same
+// format as the JSON file store, and removes the oldest backups beyond the
+// retention limit. It returns the new file's path.
+func (b *Backups) Write() (string, error) {
+	if b == nil {
+		return "", nil
+	}
+
+	cfg, err := config.LoadConfig(b.store)
+	if err != nil {
+		return "", err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	path := filepath.Join(b.dir, filePrefix+time.Now().UTC().Format(timeFormat)+fileSuffix)
+	if err := store.NewFile(path).Save(cfg); err != nil {
+		return "", err
+	}
+	return path, b.rotate()
+}
+
+// Run writes a backup every interval until ctx is done.
+func (b *Backups) Run(ctx context.Context, interval time.Duration, logger *slog.Logger) {
+	if b == nil || interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			path, err := b.Write()
+			if err != nil {
+				logger.Error("Scheduled config backup failed", "error", err)
+				continue
+			}
+			logger.Info("Config backup written", "path", path)
+		}
+	}
+}
+
+func (b *Backups) rotate() error {
+	files, err := List(b.dir)
+	if err != nil {
+		return err
+	}
+	if len(files) <= b.keep {
+		return nil
+	}
+	for _, name := range files[:len(files)-b.keep] {
+		if err := os.Remove(filepath.Join(b.dir, name)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// List returns the backup file names in dir, oldest first. A missing
+// directory has no backups.
+func List(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var names []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.Type().IsRegular() && strings.HasPrefix(name, filePrefix) && strings.HasSuffix(name, fileSuffix) {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names, nil
+}
+
+// RestoreIfEmpty copies the newest valid backup in dir into target when
+// target holds no configuration yet: no servers and the Terms of Service not
+// acknowledged, as after a volume was recreated. It never overwrites existing
+// data. Backups that fail to load or validate are skipped in favor of older
+// ones. It returns the restored file's name, or "" when nothing was restored.
+func RestoreIfEmpty(dir string, target config.ConfigStore) (string, error) {
+	var restored string
+	_, err := config.Update(target, func(current *config.Configuration) error {
+		if len(current.Servers) > 0 || current.TOSAcknowledged {
+			return config.ErrUnchanged
+		}
+
+		files, err := List(dir)
+		if err != nil {
+			return err
+		}
+		for _, name := range slices.Backward(files) {
+			cfg, err := store.NewFile(filepath.Join(dir, name)).Load()
+			if err != nil || cfg.Validate() != nil {
+				continue
+			}
+			*current = *cfg
+			restored = name
+			return nil
+		}
+		return config.ErrUnchanged
+	})
+	if err != nil {
+		return "", err
+	}
+	return restored, nil
+}
@@ -0,0 +1,135 @@
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pyyupsk/discord-stayonline/internal/config"
+	"github.com/pyyupsk/discord-stayonline/internal/config/store"
+)
+
+func newSource(t *testing.T) *store.File {
+	t.Helper()
+	source := store.NewFile(filepath.Join(t.TempDir(), "config.json"))
+	cfg := &config.Configuration{
+		Servers: []config.ServerEntry{
+			{ID: "srv-1", GuildID: "123456789012345678", ChannelID: "234567890123456789", Priority: 1},
+		},
+		Status:          config.StatusIdle,
+		TOSAcknowledged: true,
+	}
+	if err := source.Save(cfg); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	return source
+}
+
+func TestWriteSavesCurrentConfig(t *testing.T) {
+	dir := t.TempDir()
+	b := New(dir, 3, newSource(t))
+
+	path, err := b.Write()
+	if err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if filepath.Dir(path) != dir {
+		t.Errorf("expected backup in %s, got %s", dir, path)
+	}
+
+	restored, err := store.NewFile(path).Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if restored.Status != config.StatusIdle || !restored.TOSAcknowledged ||
+		len(restored.Servers) != 1 || restored.Servers[0].ID != "srv-1" {
+		t.Errorf("unexpected backup content: %+v", restored)
+	}
+}
+
+func TestWriteRotatesOldBackups(t *testing.T) {
+	dir := t.TempDir()
+	b := New(dir, 2, newSource(t))
+
+	var paths []string
+	for range 4 {
+		path, err := b.Write()
+		if err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+		paths = append(paths, filepath.Base(path))
+	}
+
+	files, err := List(dir)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(files) != 2 || files[0] != paths[2] || files[1] != paths[3] {
+		t.Errorf("expected only the newest two backups %v, got %v", paths[2:], files)
+	}
+}
+
+func TestNilBackupsDoesNothing(t *testing.T) {
+	var b *Backups
+	if b != New("", 0, nil) {
+		t.Fatal("expected New with an empty dir to return nil")
+	}
+	if path, err := b.Write(); path != "" || err != nil {
+		t.Errorf("expected nil Backups to write nothing, got %q, %v", path, err)
+	}
+}
+
+func TestRestoreIfEmptyRestoresNewestValidBackup(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := New(dir, 3, newSource(t)).Write(); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	// A newer backup that fails validation is skipped.
+	corrupt := filepath.Join(dir, filePrefix+"99999999T999999.999999999Z"+fileSuffix)
+	if err := os.WriteFile(corrupt, []byte(`{"servers": [{"id": "a/b"}], "status": "online"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	target := store.NewFile(filepath.Join(t.TempDir(), "config.json"))
+	name, err := RestoreIfEmpty(dir, target)
+	if err != nil {
+		t.Fatalf("RestoreIfEmpty() error = %v", err)
+	}
+	if name == "" || name == filepath.Base(corrupt) {
+		t.Fatalf("expected the valid backup to be restored, got %q", name)
+	}
+
+	cfg, err := target.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(cfg.Servers) != 1 || cfg.Servers[0].ID != "srv-1" || !cfg.TOSAcknowledged {
+		t.Errorf("unexpected restored config: %+v", cfg)
+	}
+}
+
+func TestRestoreIfEmptySkipsPopulatedStore(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := New(dir, 3, newSource(t)).Write(); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	target := store.NewFile(filepath.Join(t.TempDir(), "config.json"))
+	existing := config.Default()
+	existing.TOSAcknowledged = true
+	if err := target.Save(existing); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	name, err := RestoreIfEmpty(dir, target)
+	if err != nil || name != "" {
+		t.Fatalf("expected no restore into a populated store, got %q, %v", name, err)
+	}
+	cfg, err := target.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(cfg.Servers) != 0 {
+		t.Errorf("expected existing config to be kept, got %+v", cfg.Servers)
+	}
+}
@@ -0,0 +1,47 @@
+package config
+
+import (
+	"strings"
+	"sync/atomic"
+)
+
+// allowedGuilds is the guild allowlist set by SetAllowedGuildIDs; nil allows
+// every guild.
+var allowedGuilds atomic.Pointer[map[string]struct{}]
+
+// SetAllowedGuildIDs restricts the guilds server entries may use to ids, for
+// managed deployments. Entries for other guilds fail validation, so they can
+// neither be saved nor joined. An empty list allows every guild.
+func SetAllowedGuildIDs(ids []string) {
+	if len(ids) == 0 {
+		allowedGuilds.Store(nil)
+		return
+	}
+	set := make(map[string]struct{}, len(ids))
+	for _, id := range ids {
+		set[id] = struct{}{}
+	}
+	allowedGuilds.Store(&set)
+}
+
+// GuildAllowed reports whether server entries may use guildID.
+func GuildAllowed(guildID string) bool {
+	set := allowedGuilds.Load()
+	if set == nil {
+		return true
+	}
+	_, ok := (*set)[guildID]
+	return ok
+}
+
+// ParseGuildIDs splits a comma-separated list of guild IDs, dropping
+// surrounding whitespace and empty items.
+func ParseGuildIDs(raw string) []string {
+	var ids []string
+	for id := range strings.SplitSeq(raw, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
@@ -1,5 +1,15 @@
 package config
 
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
 type Status string
 
 const (
@@ -10,6 +20,7 @@ const (
 
 type ServerEntry struct {
 	ID             string `json:"id"`
+	Label          string `json:"label,omitempty"`
 	GuildID        string `json:"guild_id"`
 	GuildName      string `json:"guild_name,omitempty"`
 	GuildIcon      string `json:"guild_icon,omitempty"`
@@ -17,6 +28,27 @@ type ServerEntry struct {
 	ChannelName    string `json:"channel_name,omitempty"`
 	ConnectOnStart bool   `json:"connect_on_start"`
 	Priority       int    `json:"priority"`
+
+	// PresenceOnly keeps the account online without joining a voice
+	// channel; ChannelID may be empty.
+	PresenceOnly bool `json:"presence_only,omitempty"`
+
+	// Status is the server's own presence status, used instead of the
+	// global status when set.
+	Status Status `json:"status,omitempty"`
+
+	// StatusOverride replaces Status and the global status for this server
+	// until it is cleared.
+	StatusOverride Status `json:"status_override,omitempty"`
+
+	// SelfMute and SelfDeaf are sent with the voice join. Nil means true,
+	// which entries saved before they existed rely on.
+	SelfMute *bool `json:"self_mute,omitempty"`
+	SelfDeaf *bool `json:"self_deaf,omitempty"`
+
+	// QuietHours are daily windows that replace the server's status,
+	// including StatusOverride, while they last.
+	QuietHours []QuietHours `json:"quiet_hours,omitempty"`
 }
 
 type Configuration struct {
@@ -25,37 +57,138 @@ type Configuration struct {
 	TOSAcknowledged bool          `json:"tos_acknowledged"`
 }
 
+// MaxServerEntries is the default limit on server entries; see
+// SetMaxServerEntries.
 const MaxServerEntries = 35
 
+// MaxServerIDLength matches the width of the servers table's primary key.
+const MaxServerIDLength = 32
+
+// DisplayName returns a human-friendly name for logs: the configured label,
+// else the guild/channel names when known, else the entry ID.
+func (s *ServerEntry) DisplayName() string {
+	switch {
+	case s.Label != "":
+		return s.Label
+	case s.GuildName != "" && s.ChannelName != "":
+		return s.GuildName + " / " + s.ChannelName
+	case s.GuildName != "":
+		return s.GuildName
+	case s.ChannelName != "":
+		return s.ChannelName
+	default:
+		return s.ID
+	}
+}
+
+// SelfMuted reports whether the account joins voice muted.
+func (s *ServerEntry) SelfMuted() bool {
+	return s.SelfMute == nil || *s.SelfMute
+}
+
+// SelfDeafened reports whether the account joins voice deafened.
+func (s *ServerEntry) SelfDeafened() bool {
+	return s.SelfDeaf == nil || *s.SelfDeaf
+}
+
 func (s *ServerEntry) Validate() error {
 	if s.ID == "" {
 		return ErrEmptyID
 	}
+	if !ValidServerID(s.ID) {
+		return ErrInvalidID
+	}
 	if s.GuildID == "" {
 		return ErrEmptyGuildID
 	}
-	if s.ChannelID == "" {
+	if !GuildAllowed(s.GuildID) {
+		return fmt.Errorf("%w: %s", ErrGuildNotAllowed, s.GuildID)
+	}
+	if s.ChannelID == "" && !s.PresenceOnly {
 		return ErrEmptyChannelID
 	}
 	if s.Priority < 1 {
 		return ErrInvalidPriority
 	}
+	for _, status := range []Status{s.Status, s.StatusOverride} {
+		if status == "" {
+			continue
+		}
+		if _, ok := NormalizeStatus(status); !ok {
+			return ErrInvalidStatus
+		}
+	}
+	for i := range s.QuietHours {
+		if err := s.QuietHours[i].Validate(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
 func (c *Configuration) Validate() error {
-	if len(c.Servers) > MaxServerEntries {
-		return ErrTooManyServers
+	return c.ValidateChanged(nil)
+}
+
+// ValidServerID reports whether id is safe to use as a storage key and a URL
+// path segment: 1 to MaxServerIDLength ASCII letters, digits, or dashes.
+func ValidServerID(id string) bool {
+	if id == "" || len(id) > MaxServerIDLength {
+		return false
 	}
-	if c.Status != "" && c.Status != StatusOnline && c.Status != StatusIdle && c.Status != StatusDND {
-		return ErrInvalidStatus
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-':
+		default:
+			return false
+		}
 	}
-	for i := range c.Servers {
-		if err := c.Servers[i].Validate(); err != nil {
-			return err
+	return true
+}
+
+// NewServerID returns a random 8-character hex ID, the same shape the
+// dashboard generates for new servers.
+func NewServerID() string {
+	var b [4]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// NormalizeServerIDs trims whitespace around each entry's ID. When generate
+// is true, entries left without an ID are given one from NewServerID.
+func NormalizeServerIDs(servers []ServerEntry, generate bool) {
+	for i := range servers {
+		servers[i].ID = strings.TrimSpace(servers[i].ID)
+		if servers[i].ID == "" && generate {
+			servers[i].ID = NewServerID()
 		}
 	}
-	return nil
+}
+
+// ETag returns a strong HTTP entity tag derived from the configuration's
+// content, used to detect concurrent modifications.
+func (c *Configuration) ETag() string {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// NormalizeStatus trims surrounding whitespace from s and defaults an empty
+// value to StatusOnline. ok is false when s is not a known status, in which case
+// StatusOnline is returned so callers always have a safe value to send.
+func NormalizeStatus(s Status) (status Status, ok bool) {
+	status = Status(strings.TrimSpace(string(s)))
+	switch status {
+	case "":
+		return StatusOnline, true
+	case StatusOnline, StatusIdle, StatusDND:
+		return status, true
+	default:
+		return StatusOnline, false
+	}
 }
 
 func Default() *Configuration {
@@ -69,6 +202,25 @@ func Default() *Configuration {
 type SessionState struct {
 	ServerID  string `json:"server_id"`
 	SessionID string `json:"session_id"`
-	Sequence  int    `json:"sequence"`
+	Sequence  int64  `json:"sequence"`
 	ResumeURL string `json:"resume_url"`
+
+	// UpdatedAt is when the state was last written, set by the store. It is
+	// zero for stores that do not track it.
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// AcknowledgeTOS marks the Terms of Service as acknowledged in the store.
+// It reports whether the stored configuration had to be updated.
+func AcknowledgeTOS(store ConfigStore) (bool, error) {
+	changed := false
+	_, err := Update(store, func(cfg *Configuration) error {
+		if cfg.TOSAcknowledged {
+			return ErrUnchanged
+		}
+		cfg.TOSAcknowledged = true
+		changed = true
+		return nil
+	})
+	return changed && err == nil, err
 }
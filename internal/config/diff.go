@@ -0,0 +1,85 @@
+package config
+
+import (
+	"reflect"
+	"slices"
+)
+
+// Equal reports whether s and other hold the same values. SelfMute and
+// SelfDeaf are compared by value, so a nil flag only equals another nil.
+func (s *ServerEntry) Equal(other *ServerEntry) bool {
+	if !boolPtrEqual(s.SelfMute, other.SelfMute) || !boolPtrEqual(s.SelfDeaf, other.SelfDeaf) ||
+		!slices.Equal(s.QuietHours, other.QuietHours) {
+		return false
+	}
+	a, b := *s, *other
+	a.SelfMute, a.SelfDeaf, a.QuietHours = nil, nil, nil
+	b.SelfMute, b.SelfDeaf, b.QuietHours = nil, nil, nil
+	return reflect.DeepEqual(a, b)
+}
+
+func boolPtrEqual(a, b *bool) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// ChangedServers returns the servers in next that are new or differ from the
+// entry with the same ID in prev, and the IDs of servers in prev that next
+// no longer has.
+func ChangedServers(prev, next []ServerEntry) (changed []ServerEntry, removed []string) {
+	previous := make(map[string]*ServerEntry, len(prev))
+	for i := range prev {
+		previous[prev[i].ID] = &prev[i]
+	}
+	for i := range next {
+		if old, ok := previous[next[i].ID]; !ok || !old.Equal(&next[i]) {
+			changed = append(changed, next[i])
+		}
+		delete(previous, next[i].ID)
+	}
+	for i := range prev {
+		if _, ok := previous[prev[i].ID]; ok {
+			removed = append(removed, prev[i].ID)
+		}
+	}
+	return changed, removed
+}
+
+// ValidateChanged validates c like Validate, but skips servers unchanged from
+// prev, so an entry saved under earlier rules (such as a since-tightened
+// guild allowlist) does not block edits to other servers. A nil prev
+// validates every server.
+func (c *Configuration) ValidateChanged(prev *Configuration) error {
+	if len(c.Servers) > MaxServers() {
+		return ErrTooManyServers
+	}
+	if _, ok := NormalizeStatus(c.Status); !ok {
+		return ErrInvalidStatus
+	}
+	servers := c.Servers
+	if prev != nil {
+		servers, _ = ChangedServers(prev.Servers, c.Servers)
+	}
+	for i := range servers {
+		if err := servers[i].Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Equal reports whether c and other hold the same settings and the same
+// servers in the same order.
+func (c *Configuration) Equal(other *Configuration) bool {
+	if c.Status != other.Status || c.TOSAcknowledged != other.TOSAcknowledged || len(c.Servers) != len(other.Servers) {
+		return false
+	}
+	for i := range c.Servers {
+		if !c.Servers[i].Equal(&other.Servers[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -4,10 +4,14 @@ import "errors"
 
 var (
 	ErrEmptyID         = errors.New("server entry ID cannot be empty")
+	ErrInvalidID       = errors.New("server entry ID must be 1-32 letters, digits, or dashes")
 	ErrEmptyGuildID    = errors.New("guild_id cannot be empty")
 	ErrEmptyChannelID  = errors.New("channel_id cannot be empty")
 	ErrInvalidStatus   = errors.New("status must be online, idle, or dnd")
 	ErrInvalidPriority = errors.New("priority must be a positive integer")
-	ErrTooManyServers  = errors.New("maximum 35 server entries allowed")
+	ErrTooManyServers  = errors.New("too many server entries")
 	ErrConfigNotFound  = errors.New("configuration file not found")
+	ErrGuildNotAllowed = errors.New("guild is not in ALLOWED_GUILD_IDS")
+
+	ErrInvalidQuietHours = errors.New("invalid quiet hours")
 )
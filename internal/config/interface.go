@@ -1,6 +1,70 @@
 package config
 
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ConfigStore persists the application configuration.
+//
+// Load must return a non-nil configuration when err is nil; implementations
+// return Default() when nothing has been stored yet. Callers should still go
+// through LoadConfig, which guards against stores that break this contract.
 type ConfigStore interface {
 	Load() (*Configuration, error)
 	Save(cfg *Configuration) error
 }
+
+// LoadConfig loads the configuration from store, substituting Default() if
+// the store returns a nil configuration without an error.
+func LoadConfig(store ConfigStore) (*Configuration, error) {
+	cfg, err := store.Load()
+	if err != nil {
+		return nil, err
+	}
+	if cfg == nil {
+		return Default(), nil
+	}
+	if cfg.Servers == nil {
+		cfg.Servers = []ServerEntry{}
+	}
+	return cfg, nil
+}
+
+// ErrConfigLoad wraps the error Update returns when the configuration could
+// not be loaded.
+var ErrConfigLoad = errors.New("failed to load configuration")
+
+// ErrUnchanged may be returned by an Update mutate function to leave the
+// stored configuration as it is; Update then returns no error.
+var ErrUnchanged = errors.New("configuration unchanged")
+
+// updateMu serializes Update. A process has a single configuration store,
+// reached through several references, so one lock covers every writer.
+var updateMu sync.Mutex
+
+// Update loads the configuration from store, passes it to mutate, and saves
+// the result, returning the saved configuration. Updates never interleave,
+// so every writer sees the changes of those before it rather than
+// overwriting them. Any error from mutate other than ErrUnchanged is
+// returned as is and nothing is saved. mutate must not call Update.
+func Update(store ConfigStore, mutate func(cfg *Configuration) error) (*Configuration, error) {
+	updateMu.Lock()
+	defer updateMu.Unlock()
+
+	cfg, err := LoadConfig(store)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrConfigLoad, err)
+	}
+	if err := mutate(cfg); err != nil {
+		if errors.Is(err, ErrUnchanged) {
+			return cfg, nil
+		}
+		return nil, err
+	}
+	if err := store.Save(cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
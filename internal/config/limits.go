@@ -0,0 +1,22 @@
+package config
+
+import "sync/atomic"
+
+// maxServers is the server entry limit set by SetMaxServerEntries; zero
+// means MaxServerEntries.
+var maxServers atomic.Int64
+
+// SetMaxServerEntries changes how many server entries a configuration may
+// hold, for deployments running more sessions than the default allows. Zero
+// or a negative n restores MaxServerEntries.
+func SetMaxServerEntries(n int) {
+	maxServers.Store(int64(max(n, 0)))
+}
+
+// MaxServers returns the current server entry limit.
+func MaxServers() int {
+	if n := maxServers.Load(); n > 0 {
+		return int(n)
+	}
+	return MaxServerEntries
+}
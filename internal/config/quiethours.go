@@ -0,0 +1,92 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// QuietHours is a daily window in the service's local time during which a
+// server shows Status instead of its usual status. An End before Start
+// wraps past midnight, so 23:00-07:00 covers the night.
+type QuietHours struct {
+	Start  string `json:"start"`
+	End    string `json:"end"`
+	Status Status `json:"status"`
+}
+
+// Validate checks that Start and End are distinct HH:MM times and Status is
+// a valid presence status.
+func (q *QuietHours) Validate() error {
+	start, err := parseClock(q.Start)
+	if err != nil {
+		return err
+	}
+	end, err := parseClock(q.End)
+	if err != nil {
+		return err
+	}
+	if start == end {
+		return fmt.Errorf("%w: start and end are both %s", ErrInvalidQuietHours, q.Start)
+	}
+	if _, ok := NormalizeStatus(q.Status); !ok {
+		return fmt.Errorf("%w: invalid status %q", ErrInvalidQuietHours, q.Status)
+	}
+	return nil
+}
+
+// active reports whether now falls in the window.
+func (q *QuietHours) active(now time.Time) bool {
+	start, err1 := parseClock(q.Start)
+	end, err2 := parseClock(q.End)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	minute := now.Hour()*60 + now.Minute()
+	if start < end {
+		return minute >= start && minute < end
+	}
+	return minute >= start || minute < end
+}
+
+// QuietStatus returns the status of the first of the server's quiet hours
+// windows that contains now, reporting false when none does.
+func (s *ServerEntry) QuietStatus(now time.Time) (Status, bool) {
+	for i := range s.QuietHours {
+		if s.QuietHours[i].active(now) {
+			status, _ := NormalizeStatus(s.QuietHours[i].Status)
+			return status, true
+		}
+	}
+	return "", false
+}
+
+// NextQuietBoundary returns the first start or end of the server's quiet
+// hours after now, or the zero time when it has none.
+func (s *ServerEntry) NextQuietBoundary(now time.Time) time.Time {
+	var next time.Time
+	for _, q := range s.QuietHours {
+		for _, clock := range []string{q.Start, q.End} {
+			minute, err := parseClock(clock)
+			if err != nil {
+				continue
+			}
+			at := time.Date(now.Year(), now.Month(), now.Day(), minute/60, minute%60, 0, 0, now.Location())
+			if !at.After(now) {
+				at = time.Date(now.Year(), now.Month(), now.Day()+1, minute/60, minute%60, 0, 0, now.Location())
+			}
+			if next.IsZero() || at.Before(next) {
+				next = at
+			}
+		}
+	}
+	return next
+}
+
+// parseClock parses an HH:MM time into minutes after midnight.
+func parseClock(clock string) (int, error) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q is not an HH:MM time", ErrInvalidQuietHours, clock)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
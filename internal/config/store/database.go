@@ -0,0 +1,572 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/pyyupsk/discord-stayonline/internal/config"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Database dialects.
+const (
+	DialectPostgres = "postgres"
+	DialectSQLite   = "sqlite"
+)
+
+// Database stores the configuration, sessions, logs, and audit records in
+// PostgreSQL or SQLite through GORM.
+type Database struct {
+	db      *gorm.DB
+	mu      sync.RWMutex
+	dialect string
+
+	maxLogEntries int
+}
+
+func NewPostgres(databaseURL string) (*Database, error) {
+	return openDatabase(postgres.Open(databaseURL), DialectPostgres)
+}
+
+// NewSQLite opens, creating if needed, the SQLite database file at path.
+func NewSQLite(path string) (*Database, error) {
+	// One connection serializes writers, which SQLite would otherwise
+	// reject with "database is locked"; the busy timeout covers other
+	// processes holding the file.
+	dsn := path + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+	store, err := openDatabase(sqlite.Open(dsn), DialectSQLite)
+	if err != nil {
+		return nil, err
+	}
+	sqlDB, err := store.db.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(1)
+	return store, nil
+}
+
+func openDatabase(dialector gorm.Dialector, dialect string) (*Database, error) {
+	db, err := gorm.Open(dialector, &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	store := &Database{db: db, dialect: dialect, maxLogEntries: MaxLogEntries}
+
+	if err := store.migrate(); err != nil {
+		return nil, err
+	}
+
+	return store, nil
+}
+
+// Dialect returns DialectPostgres or DialectSQLite.
+func (s *Database) Dialect() string {
+	return s.dialect
+}
+
+func (s *Database) migrate() error {
+	if err := s.db.AutoMigrate(&Setting{}, &Server{}, &Log{}, &Session{}, &AuditRecord{}); err != nil {
+		return err
+	}
+
+	// The constraints and the pre-GORM schema only ever existed in
+	// PostgreSQL, and the DO blocks are PostgreSQL syntax.
+	if s.dialect != DialectPostgres {
+		return s.seedSettings()
+	}
+
+	s.db.Exec(`
+		DO $$
+		BEGIN
+			IF NOT EXISTS (
+				SELECT 1 FROM pg_constraint WHERE conname = 'single_settings_row'
+			) THEN
+				ALTER TABLE settings ADD CONSTRAINT single_settings_row CHECK (id = 1);
+			END IF;
+		END $$;
+	`)
+
+	s.db.Exec(`
+		DO $$
+		BEGIN
+			IF NOT EXISTS (
+				SELECT 1 FROM pg_constraint WHERE conname = 'fk_sessions_server'
+			) THEN
+				ALTER TABLE sessions ADD CONSTRAINT fk_sessions_server
+				FOREIGN KEY (server_id) REFERENCES servers(id) ON DELETE CASCADE;
+			END IF;
+		END $$;
+	`)
+
+	if err := s.migrateFromOldSchema(); err != nil {
+		return err
+	}
+
+	return s.seedSettings()
+}
+
+// seedSettings creates the settings row if there is none.
+func (s *Database) seedSettings() error {
+	var count int64
+	s.db.Model(&Setting{}).Count(&count)
+	if count == 0 {
+		s.db.Create(&Setting{
+			ID:              1,
+			Status:          "online",
+			TOSAcknowledged: false,
+		})
+	}
+
+	return nil
+}
+
+type oldConfigData struct {
+	Servers []struct {
+		ID             string `json:"id"`
+		GuildID        string `json:"guild_id"`
+		GuildName      string `json:"guild_name"`
+		ChannelID      string `json:"channel_id"`
+		ChannelName    string `json:"channel_name"`
+		ConnectOnStart bool   `json:"connect_on_start"`
+		Priority       int    `json:"priority"`
+	} `json:"servers"`
+	Status          string `json:"status"`
+	TOSAcknowledged bool   `json:"tos_acknowledged"`
+}
+
+func (s *Database) migrateFromOldSchema() error {
+	if s.shouldSkipMigration() {
+		return nil
+	}
+
+	oldConfig, ok := s.loadOldConfig()
+	if !ok {
+		return nil
+	}
+
+	if err := s.migrateSettings(oldConfig); err != nil {
+		return err
+	}
+
+	return s.migrateServers(oldConfig)
+}
+
+func (s *Database) shouldSkipMigration() bool {
+	var exists bool
+	s.db.Raw(`
+		SELECT EXISTS (
+			SELECT FROM information_schema.tables
+			WHERE table_name = 'configuration'
+		)
+	`).Scan(&exists)
+
+	if !exists {
+		return true
+	}
+
+	var settingsCount, serversCount int64
+	s.db.Model(&Setting{}).Count(&settingsCount)
+	s.db.Model(&Server{}).Count(&serversCount)
+
+	return settingsCount > 0 || serversCount > 0
+}
+
+func (s *Database) loadOldConfig() (*oldConfigData, bool) {
+	var data []byte
+	result := s.db.Raw("SELECT data FROM configuration WHERE id = 1").Scan(&data)
+	if result.Error != nil || len(data) == 0 {
+		return nil, false
+	}
+
+	var oldConfig oldConfigData
+	if err := json.Unmarshal(data, &oldConfig); err != nil {
+		return nil, false
+	}
+
+	return &oldConfig, true
+}
+
+func (s *Database) migrateSettings(oldConfig *oldConfigData) error {
+	status := oldConfig.Status
+	if status == "" {
+		status = "online"
+	}
+	return s.db.Save(&Setting{
+		ID:              1,
+		Status:          status,
+		TOSAcknowledged: oldConfig.TOSAcknowledged,
+	}).Error
+}
+
+func (s *Database) migrateServers(oldConfig *oldConfigData) error {
+	for _, srv := range oldConfig.Servers {
+		priority := max(srv.Priority, 1)
+		server := Server{
+			ID:             srv.ID,
+			GuildID:        srv.GuildID,
+			GuildName:      stringToPtr(srv.GuildName),
+			ChannelID:      srv.ChannelID,
+			ChannelName:    stringToPtr(srv.ChannelName),
+			ConnectOnStart: srv.ConnectOnStart,
+			Priority:       priority,
+		}
+		if err := s.db.FirstOrCreate(&server, Server{ID: srv.ID}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Database) Load() (*config.Configuration, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	cfg := &config.Configuration{
+		Servers: []config.ServerEntry{},
+		Status:  config.StatusOnline,
+	}
+
+	var setting Setting
+	if err := s.db.First(&setting).Error; err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+	if setting.Status != "" {
+		cfg.Status = config.Status(setting.Status)
+	}
+	cfg.TOSAcknowledged = setting.TOSAcknowledged
+
+	var servers []Server
+	if err := s.db.Order("priority ASC, created_at ASC").Find(&servers).Error; err != nil {
+		return nil, err
+	}
+
+	for _, srv := range servers {
+		cfg.Servers = append(cfg.Servers, srv.entry())
+	}
+
+	return cfg, nil
+}
+
+// entry converts a servers row to its configuration entry.
+func (srv *Server) entry() config.ServerEntry {
+	return config.ServerEntry{
+		ID:             srv.ID,
+		Label:          ptrToString(srv.Label),
+		GuildID:        srv.GuildID,
+		GuildName:      ptrToString(srv.GuildName),
+		GuildIcon:      ptrToString(srv.GuildIcon),
+		ChannelID:      srv.ChannelID,
+		ChannelName:    ptrToString(srv.ChannelName),
+		ConnectOnStart: srv.ConnectOnStart,
+		Priority:       srv.Priority,
+		PresenceOnly:   srv.PresenceOnly,
+		Status:         config.Status(ptrToString(srv.Status)),
+		StatusOverride: config.Status(ptrToString(srv.StatusOverride)),
+		SelfMute:       srv.SelfMute,
+		SelfDeaf:       srv.SelfDeaf,
+		QuietHours:     srv.QuietHours,
+	}
+}
+
+// serverRow converts a configuration entry to a servers row.
+func serverRow(srv config.ServerEntry) Server {
+	return Server{
+		ID:             srv.ID,
+		Label:          stringToPtr(srv.Label),
+		GuildID:        srv.GuildID,
+		GuildName:      stringToPtr(srv.GuildName),
+		GuildIcon:      stringToPtr(srv.GuildIcon),
+		ChannelID:      srv.ChannelID,
+		ChannelName:    stringToPtr(srv.ChannelName),
+		ConnectOnStart: srv.ConnectOnStart,
+		Priority:       srv.Priority,
+		PresenceOnly:   srv.PresenceOnly,
+		Status:         stringToPtr(string(srv.Status)),
+		StatusOverride: stringToPtr(string(srv.StatusOverride)),
+		SelfMute:       srv.SelfMute,
+		SelfDeaf:       srv.SelfDeaf,
+		QuietHours:     srv.QuietHours,
+	}
+}
+
+func ptrToString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func stringToPtr(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// Save validates and writes only the servers that differ from the stored
+// rows, deletes removed ones, and leaves unchanged rows untouched.
+func (s *Database) Save(cfg *config.Configuration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var setting Setting
+		if err := tx.First(&setting).Error; err != nil && err != gorm.ErrRecordNotFound {
+			return err
+		}
+		var rows []Server
+		if err := tx.Find(&rows).Error; err != nil {
+			return err
+		}
+		prev := &config.Configuration{Status: config.Status(setting.Status), TOSAcknowledged: setting.TOSAcknowledged}
+		for i := range rows {
+			prev.Servers = append(prev.Servers, rows[i].entry())
+		}
+
+		if err := cfg.ValidateChanged(prev); err != nil {
+			return err
+		}
+
+		status, _ := config.NormalizeStatus(cfg.Status)
+		if setting.ID == 0 || string(status) != setting.Status || cfg.TOSAcknowledged != setting.TOSAcknowledged {
+			if err := tx.Save(&Setting{
+				ID:              1,
+				Status:          string(status),
+				TOSAcknowledged: cfg.TOSAcknowledged,
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		return syncServers(tx, prev.Servers, cfg.Servers)
+	})
+}
+
+// syncServers writes the servers in next that differ from prev and deletes
+// those next no longer has.
+func syncServers(tx *gorm.DB, prev, next []config.ServerEntry) error {
+	changed, removed := config.ChangedServers(prev, next)
+	if len(removed) > 0 {
+		if err := tx.Delete(&Server{}, "id IN ?", removed).Error; err != nil {
+			return err
+		}
+	}
+	for _, srv := range changed {
+		server := serverRow(srv)
+		if err := tx.Save(&server).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Database) Close() error {
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
+type LogEntry struct {
+	Level     string    `json:"level"`
+	Message   string    `json:"message"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// MaxLogEntries is the default number of log rows kept.
+const MaxLogEntries = 1000
+
+var ErrInvalidMaxLogEntries = errors.New("max log entries must be positive")
+
+// SetMaxLogEntries changes how many log rows are kept and returned.
+func (s *Database) SetMaxLogEntries(n int) error {
+	if n <= 0 {
+		return fmt.Errorf("%w: %d", ErrInvalidMaxLogEntries, n)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.maxLogEntries = n
+	return nil
+}
+
+const whereServerID = "server_id = ?"
+
+func (s *Database) AddLog(level, message string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.db.Create(&Log{
+		Level:   level,
+		Message: message,
+	}).Error; err != nil {
+		return err
+	}
+
+	s.db.Exec(`
+		DELETE FROM logs WHERE id NOT IN (
+			SELECT id FROM logs ORDER BY created_at DESC LIMIT ?
+		)
+	`, s.maxLogEntries)
+
+	return nil
+}
+
+func (s *Database) GetLogs(level string) ([]LogEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var logs []Log
+	query := s.db.Order("created_at DESC").Limit(s.maxLogEntries)
+
+	if level != "" {
+		query = query.Where("level = ?", level)
+	}
+
+	if err := query.Find(&logs).Error; err != nil {
+		return nil, err
+	}
+	slices.Reverse(logs)
+
+	result := make([]LogEntry, len(logs))
+	for i, log := range logs {
+		result[i] = LogEntry{
+			Level:     log.Level,
+			Message:   log.Message,
+			Timestamp: log.CreatedAt,
+		}
+	}
+
+	return result, nil
+}
+
+func (s *Database) ClearLogs() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.db.Where("1 = 1").Delete(&Log{}).Error
+}
+
+type AuditEntry struct {
+	Action     string
+	SessionID  string
+	RemoteAddr string
+	Diff       string
+	Timestamp  time.Time
+}
+
+// AddAudit stores an audit record. Unlike logs, audit records are never
+// trimmed.
+func (s *Database) AddAudit(entry AuditEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.db.Create(&AuditRecord{
+		Action:     entry.Action,
+		SessionID:  entry.SessionID,
+		RemoteAddr: entry.RemoteAddr,
+		Diff:       entry.Diff,
+		CreatedAt:  entry.Timestamp,
+	}).Error
+}
+
+// GetAudit returns at most limit of the newest audit records, oldest first,
+// keeping only those of action when it is non-empty.
+func (s *Database) GetAudit(action string, limit int) ([]AuditEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var records []AuditRecord
+	query := s.db.Order("created_at DESC, id DESC").Limit(limit)
+	if action != "" {
+		query = query.Where("action = ?", action)
+	}
+	if err := query.Find(&records).Error; err != nil {
+		return nil, err
+	}
+	slices.Reverse(records)
+
+	result := make([]AuditEntry, len(records))
+	for i, record := range records {
+		result[i] = AuditEntry{
+			Action:     record.Action,
+			SessionID:  record.SessionID,
+			RemoteAddr: record.RemoteAddr,
+			Diff:       record.Diff,
+			Timestamp:  record.CreatedAt,
+		}
+	}
+	return result, nil
+}
+
+func (s *Database) SaveSession(state config.SessionState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.db.Save(&Session{
+		ServerID:  state.ServerID,
+		SessionID: state.SessionID,
+		Sequence:  state.Sequence,
+		ResumeURL: state.ResumeURL,
+	}).Error
+}
+
+func (s *Database) LoadSession(serverID string) (*config.SessionState, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var session Session
+	if err := s.db.First(&session, whereServerID, serverID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &config.SessionState{
+		ServerID:  session.ServerID,
+		SessionID: session.SessionID,
+		Sequence:  session.Sequence,
+		ResumeURL: session.ResumeURL,
+		UpdatedAt: session.UpdatedAt,
+	}, nil
+}
+
+func (s *Database) DeleteSession(serverID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.db.Delete(&Session{}, whereServerID, serverID).Error
+}
+
+// ListSessionServerIDs returns the server IDs that have persisted sessions.
+func (s *Database) ListSessionServerIDs() ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var ids []string
+	if err := s.db.Model(&Session{}).Pluck("server_id", &ids).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (s *Database) UpdateSessionSequence(serverID string, sequence int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.db.Model(&Session{}).
+		Where(whereServerID, serverID).
+		Update("sequence", sequence).Error
+}
@@ -0,0 +1,128 @@
+package store
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync/atomic"
+
+	"gorm.io/gorm/schema"
+)
+
+// encryptedPrefix marks values written by Cipher so rows stored before
+// encryption was enabled can still be read as plaintext.
+const encryptedPrefix = "enc:v1:"
+
+var ErrEncryptionKeyRequired = errors.New("encrypted value found but ENCRYPTION_KEY is not set")
+
+// Cipher encrypts column values with AES-256-GCM.
+type Cipher struct {
+	aead cipher.AEAD
+}
+
+// NewCipher derives an AES-256 key from the SHA-256 of key.
+func NewCipher(key string) (*Cipher, error) {
+	if key == "" {
+		return nil, errors.New("encryption key must not be empty")
+	}
+	sum := sha256.Sum256([]byte(key))
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Cipher{aead: aead}, nil
+}
+
+// Encrypt returns the prefixed, base64-encoded nonce and ciphertext.
+func (c *Cipher) Encrypt(plaintext string) (string, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := c.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt reverses Encrypt. Values without the encryption prefix are
+// returned unchanged.
+func (c *Cipher) Decrypt(value string) (string, error) {
+	encoded, ok := strings.CutPrefix(value, encryptedPrefix)
+	if !ok {
+		return value, nil
+	}
+	if c == nil {
+		return "", ErrEncryptionKeyRequired
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("decode encrypted value: %w", err)
+	}
+	nonceSize := c.aead.NonceSize()
+	if len(sealed) < nonceSize {
+		return "", errors.New("encrypted value too short")
+	}
+	plaintext, err := c.aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], nil)
+	if err != nil {
+		return "", fmt.Errorf("decrypt value: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+// columnCipher is used by the "encrypted" GORM serializer. Nil stores
+// plaintext.
+var columnCipher atomic.Pointer[Cipher]
+
+// UseEncryption enables encryption at rest for columns tagged with
+// serializer:encrypted and for FileSessions. Call it before opening the
+// store.
+func UseEncryption(c *Cipher) {
+	columnCipher.Store(c)
+}
+
+func init() {
+	schema.RegisterSerializer("encrypted", EncryptedSerializer{})
+}
+
+// EncryptedSerializer transparently encrypts string columns with the cipher
+// set by UseEncryption.
+type EncryptedSerializer struct{}
+
+func (EncryptedSerializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue any) error {
+	var stored string
+	switch v := dbValue.(type) {
+	case nil:
+	case []byte:
+		stored = string(v)
+	case string:
+		stored = v
+	default:
+		return fmt.Errorf("unsupported encrypted column type %T", dbValue)
+	}
+
+	plaintext, err := columnCipher.Load().Decrypt(stored)
+	if err != nil {
+		return err
+	}
+	field.ReflectValueOf(ctx, dst).SetString(plaintext)
+	return nil
+}
+
+func (EncryptedSerializer) Value(_ context.Context, _ *schema.Field, _ reflect.Value, fieldValue any) (any, error) {
+	plaintext, _ := fieldValue.(string)
+	c := columnCipher.Load()
+	if c == nil || plaintext == "" {
+		return plaintext, nil
+	}
+	return c.Encrypt(plaintext)
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"sync"
+	"time"
 
 	"github.com/pyyupsk/discord-stayonline/internal/config"
 )
@@ -13,6 +14,10 @@ import (
 type File struct {
 	path string
 	mu   sync.RWMutex
+
+	// WatchDebounce is how long Watch waits for writes to stop before
+	// reloading. Zero uses DefaultWatchDebounce.
+	WatchDebounce time.Duration
 }
 
 func NewFile(path string) *File {
@@ -25,52 +30,73 @@ func (s *File) Load() (*config.Configuration, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
+	cfg, _, err := s.read()
+	return cfg, err
+}
+
+// read loads the file, reporting whether it held a configuration. A missing
+// or empty file yields the default configuration. s.mu must be held.
+func (s *File) read() (*config.Configuration, bool, error) {
 	data, err := os.ReadFile(s.path)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
-			return config.Default(), nil
+			return config.Default(), false, nil
 		}
-		return nil, err
+		return nil, false, err
 	}
 
 	if len(data) == 0 {
-		return config.Default(), nil
+		return config.Default(), false, nil
 	}
 
 	var cfg config.Configuration
 	if err := json.Unmarshal(data, &cfg); err != nil {
-		return nil, err
+		return nil, false, err
 	}
 
-	return &cfg, nil
+	return &cfg, true, nil
 }
 
+// Save validates servers that differ from the stored configuration and
+// writes the file, skipping the write when nothing changed. An unreadable
+// file is overwritten after validating every server.
 func (s *File) Save(cfg *config.Configuration) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if err := cfg.Validate(); err != nil {
+	prev, exists, _ := s.read()
+	if err := cfg.ValidateChanged(prev); err != nil {
 		return err
 	}
+	cfg.Status, _ = config.NormalizeStatus(cfg.Status)
+	if exists && cfg.Equal(prev) {
+		return nil
+	}
 
 	data, err := json.MarshalIndent(cfg, "", "  ")
 	if err != nil {
 		return err
 	}
 
-	dir := filepath.Dir(s.path)
+	return writeFileAtomic(s.path, data)
+}
+
+// writeFileAtomic writes data to a temporary file beside path and renames it
+// into place, so readers never see a partially written file.
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
 	if dir != "" && dir != "." {
 		if err := os.MkdirAll(dir, 0755); err != nil {
 			return err
 		}
 	}
 
-	tmpPath := s.path + ".tmp"
+	tmpPath := path + ".tmp"
 	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
 		return err
 	}
 
-	return os.Rename(tmpPath, s.path)
+	return os.Rename(tmpPath, path)
 }
 
 func (s *File) Path() string {
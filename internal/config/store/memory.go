@@ -0,0 +1,46 @@
+package store
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/pyyupsk/discord-stayonline/internal/config"
+)
+
+// Memory keeps the configuration in memory only; it is lost on restart.
+// It suits tests and throwaway deployments configured through the API.
+type Memory struct {
+	mu  sync.RWMutex
+	cfg *config.Configuration
+}
+
+func NewMemory() *Memory {
+	return &Memory{}
+}
+
+func (s *Memory) Load() (*config.Configuration, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.cfg == nil {
+		return config.Default(), nil
+	}
+	cfg := *s.cfg
+	cfg.Servers = slices.Clone(s.cfg.Servers)
+	return &cfg, nil
+}
+
+func (s *Memory) Save(cfg *config.Configuration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := cfg.ValidateChanged(s.cfg); err != nil {
+		return err
+	}
+	cfg.Status, _ = config.NormalizeStatus(cfg.Status)
+
+	saved := *cfg
+	saved.Servers = slices.Clone(cfg.Servers)
+	s.cfg = &saved
+	return nil
+}
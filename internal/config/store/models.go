@@ -1,6 +1,10 @@
 package store
 
-import "time"
+import (
+	"time"
+
+	"github.com/pyyupsk/discord-stayonline/internal/config"
+)
 
 type Setting struct {
 	ID              int       `gorm:"primaryKey;default:1"`
@@ -14,16 +18,23 @@ func (Setting) TableName() string {
 }
 
 type Server struct {
-	ID             string    `gorm:"type:varchar(32);primaryKey"`
-	GuildID        string    `gorm:"type:varchar(20);not null;index:idx_servers_guild_id"`
-	GuildName      *string   `gorm:"type:varchar(100)"`
-	GuildIcon      *string   `gorm:"type:varchar(64)"`
-	ChannelID      string    `gorm:"type:varchar(20);not null"`
-	ChannelName    *string   `gorm:"type:varchar(100)"`
-	ConnectOnStart bool      `gorm:"column:connect_on_start;not null;default:false"`
-	Priority       int       `gorm:"not null;default:1;index:idx_servers_priority"`
-	CreatedAt      time.Time `gorm:"autoCreateTime"`
-	UpdatedAt      time.Time `gorm:"autoUpdateTime"`
+	ID             string              `gorm:"type:varchar(32);primaryKey"`
+	Label          *string             `gorm:"type:varchar(100)"`
+	GuildID        string              `gorm:"type:varchar(20);not null;index:idx_servers_guild_id"`
+	GuildName      *string             `gorm:"type:varchar(100)"`
+	GuildIcon      *string             `gorm:"type:varchar(64)"`
+	ChannelID      string              `gorm:"type:varchar(20);not null"`
+	ChannelName    *string             `gorm:"type:varchar(100)"`
+	ConnectOnStart bool                `gorm:"column:connect_on_start;not null;default:false"`
+	Priority       int                 `gorm:"not null;default:1;index:idx_servers_priority"`
+	PresenceOnly   bool                `gorm:"column:presence_only;not null;default:false"`
+	Status         *string             `gorm:"type:varchar(10)"`
+	StatusOverride *string             `gorm:"type:varchar(10)"`
+	SelfMute       *bool               `gorm:"column:self_mute"`
+	SelfDeaf       *bool               `gorm:"column:self_deaf"`
+	QuietHours     []config.QuietHours `gorm:"column:quiet_hours;type:text;serializer:json"`
+	CreatedAt      time.Time           `gorm:"autoCreateTime"`
+	UpdatedAt      time.Time           `gorm:"autoUpdateTime"`
 }
 
 func (Server) TableName() string {
@@ -41,11 +52,26 @@ func (Log) TableName() string {
 	return "logs"
 }
 
+// AuditRecord is one audited configuration change. Diff holds the change as
+// JSON.
+type AuditRecord struct {
+	ID         uint      `gorm:"primaryKey;autoIncrement"`
+	Action     string    `gorm:"type:varchar(40);not null;index:idx_audit_log_action"`
+	SessionID  string    `gorm:"column:session_id;type:varchar(64)"`
+	RemoteAddr string    `gorm:"column:remote_addr;type:varchar(64)"`
+	Diff       string    `gorm:"type:text;not null"`
+	CreatedAt  time.Time `gorm:"index:idx_audit_log_created_at"`
+}
+
+func (AuditRecord) TableName() string {
+	return "audit_log"
+}
+
 type Session struct {
 	ServerID  string    `gorm:"type:varchar(32);primaryKey"`
-	SessionID string    `gorm:"column:session_id;type:varchar(64);not null"`
-	Sequence  int       `gorm:"not null;default:0"`
-	ResumeURL string    `gorm:"column:resume_url;type:varchar(255);not null"`
+	SessionID string    `gorm:"column:session_id;type:text;not null;serializer:encrypted"`
+	Sequence  int64     `gorm:"not null;default:0"`
+	ResumeURL string    `gorm:"column:resume_url;type:text;not null;serializer:encrypted"`
 	UpdatedAt time.Time `gorm:"autoUpdateTime"`
 }
 
@@ -0,0 +1,129 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pyyupsk/discord-stayonline/internal/config"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// sqlRecorder is a GORM logger that keeps the SQL of every statement.
+type sqlRecorder struct {
+	mu  sync.Mutex
+	sql []string
+}
+
+func (r *sqlRecorder) LogMode(logger.LogLevel) logger.Interface { return r }
+func (r *sqlRecorder) Info(context.Context, string, ...any)     {}
+func (r *sqlRecorder) Warn(context.Context, string, ...any)     {}
+func (r *sqlRecorder) Error(context.Context, string, ...any)    {}
+
+func (r *sqlRecorder) Trace(_ context.Context, _ time.Time, fc func() (string, int64), _ error) {
+	sql, _ := fc()
+	r.mu.Lock()
+	r.sql = append(r.sql, sql)
+	r.mu.Unlock()
+}
+
+func (r *sqlRecorder) contains(substr string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, sql := range r.sql {
+		if strings.Contains(sql, substr) {
+			return true
+		}
+	}
+	return false
+}
+
+func newDryRunPostgres(t *testing.T) (*Database, *sqlRecorder) {
+	t.Helper()
+	recorder := &sqlRecorder{}
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=127.0.0.1 port=1"}), &gorm.Config{
+		DryRun:                 true,
+		SkipDefaultTransaction: true,
+		DisableAutomaticPing:   true,
+		Logger:                 recorder,
+	})
+	if err != nil {
+		t.Fatalf("gorm.Open() error = %v", err)
+	}
+	return &Database{db: db, dialect: DialectPostgres, maxLogEntries: MaxLogEntries}, recorder
+}
+
+func TestMaxLogEntriesAppliesToTrimAndRead(t *testing.T) {
+	s, recorder := newDryRunPostgres(t)
+	if err := s.SetMaxLogEntries(25); err != nil {
+		t.Fatalf("SetMaxLogEntries() error = %v", err)
+	}
+
+	if err := s.AddLog("info", "hello"); err != nil {
+		t.Fatalf("AddLog() error = %v", err)
+	}
+	if !recorder.contains("ORDER BY created_at DESC LIMIT 25") {
+		t.Errorf("expected trim to keep 25 rows, got %v", recorder.sql)
+	}
+
+	if _, err := s.GetLogs(""); err != nil {
+		t.Fatalf("GetLogs() error = %v", err)
+	}
+	if !recorder.contains(`SELECT * FROM "logs" ORDER BY created_at DESC LIMIT 25`) {
+		t.Errorf("expected read to return at most 25 rows, got %v", recorder.sql)
+	}
+}
+
+func TestSetMaxLogEntriesRejectsNonPositive(t *testing.T) {
+	s, _ := newDryRunPostgres(t)
+	for _, n := range []int{0, -1} {
+		if err := s.SetMaxLogEntries(n); !errors.Is(err, ErrInvalidMaxLogEntries) {
+			t.Errorf("SetMaxLogEntries(%d) error = %v, want ErrInvalidMaxLogEntries", n, err)
+		}
+	}
+	if s.maxLogEntries != MaxLogEntries {
+		t.Errorf("expected default to be kept, got %d", s.maxLogEntries)
+	}
+}
+
+func TestUpdateSessionSequenceTouchesUpdatedAt(t *testing.T) {
+	s, recorder := newDryRunPostgres(t)
+
+	if err := s.UpdateSessionSequence("server-1", 42); err != nil {
+		t.Fatalf("UpdateSessionSequence() error = %v", err)
+	}
+	if !recorder.contains(`UPDATE "sessions" SET "sequence"=42,"updated_at"=`) {
+		t.Errorf("expected sequence update to refresh updated_at, got %v", recorder.sql)
+	}
+}
+
+func TestSyncServersWritesOnlyChangedRows(t *testing.T) {
+	s, recorder := newDryRunPostgres(t)
+	prev := []config.ServerEntry{
+		{ID: "srv-same", GuildID: "1", ChannelID: "2", Priority: 1},
+		{ID: "srv-edited", GuildID: "1", ChannelID: "2", Priority: 1},
+		{ID: "srv-removed", GuildID: "1", ChannelID: "2", Priority: 1},
+	}
+	next := []config.ServerEntry{
+		{ID: "srv-same", GuildID: "1", ChannelID: "2", Priority: 1},
+		{ID: "srv-edited", GuildID: "1", ChannelID: "2", Priority: 2},
+	}
+
+	if err := syncServers(s.db, prev, next); err != nil {
+		t.Fatalf("syncServers() error = %v", err)
+	}
+	if !recorder.contains("srv-edited") {
+		t.Errorf("expected the edited server to be written, got %v", recorder.sql)
+	}
+	if !recorder.contains(`DELETE FROM "servers" WHERE id IN ('srv-removed')`) {
+		t.Errorf("expected the removed server to be deleted, got %v", recorder.sql)
+	}
+	if recorder.contains("srv-same") {
+		t.Errorf("expected the unchanged server not to be written, got %v", recorder.sql)
+	}
+}
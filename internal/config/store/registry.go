@@ -0,0 +1,130 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/pyyupsk/discord-stayonline/internal/config"
+)
+
+// ErrUnknownScheme is returned by Open for a storage URL whose scheme has no
+// registered backend.
+var ErrUnknownScheme = errors.New("no storage backend registered for scheme")
+
+// Factory opens a configuration store from a storage URL.
+type Factory func(u *url.URL) (config.ConfigStore, error)
+
+var (
+	backendsMu sync.RWMutex
+	backends   = make(map[string]Factory)
+)
+
+func init() {
+	Register("postgres", openPostgres)
+	Register("postgresql", openPostgres)
+	Register("sqlite", openSQLite)
+	Register("file", openFile)
+	Register("memory", openMemory)
+}
+
+// Register makes a backend available to Open under scheme. It panics if
+// scheme is empty or already registered, so conflicting backends are caught
+// at startup.
+func Register(scheme string, factory Factory) {
+	scheme = strings.ToLower(scheme)
+	if scheme == "" || factory == nil {
+		panic("store: Register called with an empty scheme or nil factory")
+	}
+
+	backendsMu.Lock()
+	defer backendsMu.Unlock()
+	if _, dup := backends[scheme]; dup {
+		panic("store: Register called twice for scheme " + scheme)
+	}
+	backends[scheme] = factory
+}
+
+// Schemes returns the registered schemes in sorted order.
+func Schemes() []string {
+	backendsMu.RLock()
+	defer backendsMu.RUnlock()
+
+	schemes := make([]string, 0, len(backends))
+	for scheme := range backends {
+		schemes = append(schemes, scheme)
+	}
+	slices.Sort(schemes)
+	return schemes
+}
+
+// ParseURL parses a storage URL, requiring a scheme.
+func ParseURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return nil, fmt.Errorf("invalid storage URL: %w", err)
+	}
+	if u.Scheme == "" {
+		return nil, fmt.Errorf("invalid storage URL %q: missing scheme", raw)
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	return u, nil
+}
+
+// Open opens the configuration store selected by the storage URL's scheme.
+func Open(raw string) (config.ConfigStore, error) {
+	u, err := ParseURL(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	backendsMu.RLock()
+	factory, ok := backends[u.Scheme]
+	backendsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w %q (registered: %s)", ErrUnknownScheme, u.Scheme, strings.Join(Schemes(), ", "))
+	}
+	return factory(u)
+}
+
+func openPostgres(u *url.URL) (config.ConfigStore, error) {
+	return NewPostgres(u.String())
+}
+
+// openSQLite opens a SQLite store, accepting paths as openFile does.
+func openSQLite(u *url.URL) (config.ConfigStore, error) {
+	path, err := urlPath(u)
+	if err != nil {
+		return nil, err
+	}
+	return NewSQLite(path)
+}
+
+// openFile opens a file store. Both file:///abs/config.json and the
+// relative file://config.json or file:config.json are accepted.
+func openFile(u *url.URL) (config.ConfigStore, error) {
+	path, err := urlPath(u)
+	if err != nil {
+		return nil, err
+	}
+	return NewFile(path), nil
+}
+
+// urlPath returns the filesystem path of a path-based storage URL.
+func urlPath(u *url.URL) (string, error) {
+	path := u.Opaque
+	if path == "" {
+		path = u.Host + u.Path
+	}
+	if path == "" {
+		return "", fmt.Errorf("%s storage URL has no path", u.Scheme)
+	}
+	return path, nil
+}
+
+func openMemory(*url.URL) (config.ConfigStore, error) {
+	return NewMemory(), nil
+}
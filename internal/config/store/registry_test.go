@@ -0,0 +1,93 @@
+package store
+
+import (
+	"errors"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"github.com/pyyupsk/discord-stayonline/internal/config"
+)
+
+func TestParseURL(t *testing.T) {
+	u, err := ParseURL(" Memory:// ")
+	if err != nil {
+		t.Fatalf("ParseURL() error = %v", err)
+	}
+	if u.Scheme != "memory" {
+		t.Errorf("expected the scheme lowercased to memory, got %q", u.Scheme)
+	}
+	if _, err := ParseURL("config.json"); err == nil {
+		t.Error("expected an error for a URL without a scheme")
+	}
+}
+
+func TestOpenSelectsBackendByScheme(t *testing.T) {
+	dir := t.TempDir()
+	for raw, want := range map[string]string{
+		"file://" + filepath.Join(dir, "abs.json"): filepath.Join(dir, "abs.json"),
+		"file://relative/config.json":              "relative/config.json",
+		"file:config.json":                         "config.json",
+	} {
+		s, err := Open(raw)
+		if err != nil {
+			t.Fatalf("Open(%q) error = %v", raw, err)
+		}
+		file, ok := s.(*File)
+		if !ok || file.Path() != want {
+			t.Errorf("Open(%q): expected a file store at %q, got %#v", raw, want, s)
+		}
+	}
+
+	if s, err := Open("memory://"); err != nil {
+		t.Fatalf("Open(memory) error = %v", err)
+	} else if _, ok := s.(*Memory); !ok {
+		t.Errorf("expected a memory store, got %T", s)
+	}
+
+	if _, err := Open("bogus://x"); !errors.Is(err, ErrUnknownScheme) {
+		t.Errorf("expected ErrUnknownScheme, got %v", err)
+	}
+}
+
+func TestRegisterCustomBackend(t *testing.T) {
+	var opened *url.URL
+	Register("test-custom", func(u *url.URL) (config.ConfigStore, error) {
+		opened = u
+		return NewMemory(), nil
+	})
+
+	if _, err := Open("TEST-CUSTOM://host/db?opt=1"); err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if opened == nil || opened.Host != "host" || opened.Query().Get("opt") != "1" {
+		t.Errorf("expected the factory to get the parsed URL, got %v", opened)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected registering a scheme twice to panic")
+		}
+	}()
+	Register("test-custom", func(*url.URL) (config.ConfigStore, error) { return nil, nil })
+}
+
+func TestMemoryStoreRoundTrip(t *testing.T) {
+	s := NewMemory()
+	cfg := &config.Configuration{
+		Servers: []config.ServerEntry{{ID: "a", GuildID: "1", ChannelID: "2", Priority: 1}},
+		Status:  " idle ",
+	}
+	if err := s.Save(cfg); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	cfg.Servers[0].Label = "changed after save"
+
+	loaded, err := s.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if loaded.Status != config.StatusIdle || len(loaded.Servers) != 1 || loaded.Servers[0].Label != "" {
+		t.Errorf("expected the saved copy, got %+v", loaded)
+	}
+}
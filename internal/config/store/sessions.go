@@ -0,0 +1,192 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/pyyupsk/discord-stayonline/internal/config"
+)
+
+// FileSessions persists Gateway session state to a JSON file, giving
+// file-based deployments the same resume support as the database store. The
+// whole file is rewritten atomically on every change, so deleted sessions
+// never linger in it. With UseEncryption, session IDs and resume URLs are
+// encrypted in the file.
+type FileSessions struct {
+	path     string
+	mu       sync.Mutex
+	sessions map[string]config.SessionState
+}
+
+func NewFileSessions(path string) *FileSessions {
+	return &FileSessions{
+		path: path,
+	}
+}
+
+// load reads the file on first use. Callers must hold s.mu.
+func (s *FileSessions) load() error {
+	if s.sessions != nil {
+		return nil
+	}
+
+	data, err := os.ReadFile(s.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	var sessions []config.SessionState
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &sessions); err != nil {
+			return err
+		}
+	}
+
+	loaded := make(map[string]config.SessionState, len(sessions))
+	for _, state := range sessions {
+		state, err := openSession(state)
+		if err != nil {
+			return err
+		}
+		loaded[state.ServerID] = state
+	}
+	s.sessions = loaded
+	return nil
+}
+
+// flush writes the sessions in server ID order. Callers must hold s.mu.
+func (s *FileSessions) flush() error {
+	sessions := make([]config.SessionState, 0, len(s.sessions))
+	for _, id := range s.serverIDs() {
+		state, err := sealSession(s.sessions[id])
+		if err != nil {
+			return err
+		}
+		sessions = append(sessions, state)
+	}
+
+	data, err := json.MarshalIndent(sessions, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(s.path, data)
+}
+
+// sealSession encrypts the session ID and resume URL of state with the
+// cipher set by UseEncryption, leaving them as they are when it is unset.
+func sealSession(state config.SessionState) (config.SessionState, error) {
+	c := columnCipher.Load()
+	if c == nil {
+		return state, nil
+	}
+	for _, field := range []*string{&state.SessionID, &state.ResumeURL} {
+		if *field == "" {
+			continue
+		}
+		sealed, err := c.Encrypt(*field)
+		if err != nil {
+			return state, err
+		}
+		*field = sealed
+	}
+	return state, nil
+}
+
+// openSession reverses sealSession. Fields written before encryption was
+// enabled are read as plaintext.
+func openSession(state config.SessionState) (config.SessionState, error) {
+	c := columnCipher.Load()
+	for _, field := range []*string{&state.SessionID, &state.ResumeURL} {
+		plaintext, err := c.Decrypt(*field)
+		if err != nil {
+			return state, err
+		}
+		*field = plaintext
+	}
+	return state, nil
+}
+
+func (s *FileSessions) serverIDs() []string {
+	ids := make([]string, 0, len(s.sessions))
+	for id := range s.sessions {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+func (s *FileSessions) SaveSession(state config.SessionState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.load(); err != nil {
+		return err
+	}
+	state.UpdatedAt = time.Now().UTC()
+	s.sessions[state.ServerID] = state
+	return s.flush()
+}
+
+func (s *FileSessions) LoadSession(serverID string) (*config.SessionState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	state, ok := s.sessions[serverID]
+	if !ok {
+		return nil, nil
+	}
+	return &state, nil
+}
+
+func (s *FileSessions) DeleteSession(serverID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.load(); err != nil {
+		return err
+	}
+	if _, ok := s.sessions[serverID]; !ok {
+		return nil
+	}
+	delete(s.sessions, serverID)
+	return s.flush()
+}
+
+// ListSessionServerIDs returns the server IDs that have persisted sessions.
+func (s *FileSessions) ListSessionServerIDs() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s.serverIDs(), nil
+}
+
+func (s *FileSessions) UpdateSessionSequence(serverID string, sequence int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.load(); err != nil {
+		return err
+	}
+	state, ok := s.sessions[serverID]
+	if !ok {
+		return nil
+	}
+	state.Sequence = sequence
+	state.UpdatedAt = time.Now().UTC()
+	s.sessions[state.ServerID] = state
+	return s.flush()
+}
+
+func (s *FileSessions) Path() string {
+	return s.path
+}
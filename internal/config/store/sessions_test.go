@@ -0,0 +1,137 @@
+package store
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/pyyupsk/discord-stayonline/internal/config"
+)
+
+func TestFileSessionsRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "sessions.json")
+	s := NewFileSessions(path)
+
+	if state, err := s.LoadSession("srv-1"); state != nil || err != nil {
+		t.Fatalf("expected no session before any save, got %+v, %v", state, err)
+	}
+
+	for _, state := range []config.SessionState{
+		{ServerID: "srv-2", SessionID: "sess-2", Sequence: 7, ResumeURL: "wss://resume-2"},
+		{ServerID: "srv-1", SessionID: "sess-1", Sequence: 3, ResumeURL: "wss://resume-1"},
+	} {
+		if err := s.SaveSession(state); err != nil {
+			t.Fatalf("SaveSession() error = %v", err)
+		}
+	}
+	if err := s.UpdateSessionSequence("srv-1", 42); err != nil {
+		t.Fatalf("UpdateSessionSequence() error = %v", err)
+	}
+	if err := s.UpdateSessionSequence("missing", 1); err != nil {
+		t.Fatalf("UpdateSessionSequence() of a missing session error = %v", err)
+	}
+
+	// A fresh store reads what the first one wrote.
+	reopened := NewFileSessions(path)
+	state, err := reopened.LoadSession("srv-1")
+	if err != nil {
+		t.Fatalf("LoadSession() error = %v", err)
+	}
+	if state == nil || state.SessionID != "sess-1" || state.Sequence != 42 ||
+		state.ResumeURL != "wss://resume-1" || state.UpdatedAt.IsZero() {
+		t.Fatalf("unexpected session after reload: %+v", state)
+	}
+	ids, err := reopened.ListSessionServerIDs()
+	if err != nil || !slices.Equal(ids, []string{"srv-1", "srv-2"}) {
+		t.Fatalf("expected both sessions listed, got %v, %v", ids, err)
+	}
+	if state, _ := reopened.LoadSession("missing"); state != nil {
+		t.Errorf("expected no session to be created by a sequence update, got %+v", state)
+	}
+
+	if err := reopened.DeleteSession("srv-2"); err != nil {
+		t.Fatalf("DeleteSession() error = %v", err)
+	}
+	if err := reopened.DeleteSession("srv-2"); err != nil {
+		t.Fatalf("DeleteSession() of a missing session error = %v", err)
+	}
+	ids, _ = NewFileSessions(path).ListSessionServerIDs()
+	if !slices.Equal(ids, []string{"srv-1"}) {
+		t.Errorf("expected only srv-1 after delete, got %v", ids)
+	}
+
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("expected no leftover temp file, got %v", err)
+	}
+}
+
+func TestFileSessionsKeepLargeSequence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.json")
+	const sequence int64 = 1<<53 + 1
+
+	if err := NewFileSessions(path).SaveSession(config.SessionState{ServerID: "srv-1", SessionID: "sess-1", Sequence: 1}); err != nil {
+		t.Fatalf("SaveSession() error = %v", err)
+	}
+	if err := NewFileSessions(path).UpdateSessionSequence("srv-1", sequence); err != nil {
+		t.Fatalf("UpdateSessionSequence() error = %v", err)
+	}
+
+	state, err := NewFileSessions(path).LoadSession("srv-1")
+	if err != nil || state == nil {
+		t.Fatalf("LoadSession() = %+v, %v", state, err)
+	}
+	if state.Sequence != sequence {
+		t.Errorf("expected sequence %d after reload, got %d", sequence, state.Sequence)
+	}
+}
+
+func TestFileSessionsRejectsCorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewFileSessions(path).LoadSession("srv-1"); err == nil {
+		t.Error("expected an error reading a corrupt sessions file")
+	}
+}
+
+func TestFileSessionsEncryptedAtRest(t *testing.T) {
+	c, err := NewCipher("test-encryption-key")
+	if err != nil {
+		t.Fatalf("NewCipher() error = %v", err)
+	}
+	UseEncryption(c)
+	t.Cleanup(func() { UseEncryption(nil) })
+
+	path := filepath.Join(t.TempDir(), "sessions.json")
+	want := config.SessionState{ServerID: "srv-1", SessionID: "sess-secret", Sequence: 5, ResumeURL: "wss://resume-secret"}
+	if err := NewFileSessions(path).SaveSession(want); err != nil {
+		t.Fatalf("SaveSession() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{want.SessionID, want.ResumeURL} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("expected %q to be encrypted on disk, got %s", secret, data)
+		}
+	}
+	if strings.Count(string(data), encryptedPrefix) != 2 {
+		t.Errorf("expected both fields as ciphertext, got %s", data)
+	}
+
+	state, err := NewFileSessions(path).LoadSession("srv-1")
+	if err != nil || state == nil || state.SessionID != want.SessionID || state.ResumeURL != want.ResumeURL {
+		t.Fatalf("expected the decrypted session after reload, got %+v, %v", state, err)
+	}
+
+	UseEncryption(nil)
+	if _, err := NewFileSessions(path).LoadSession("srv-1"); !errors.Is(err, ErrEncryptionKeyRequired) {
+		t.Errorf("expected ErrEncryptionKeyRequired without a key, got %v", err)
+	}
+}
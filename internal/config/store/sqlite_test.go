@@ -0,0 +1,135 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pyyupsk/discord-stayonline/internal/config"
+)
+
+func newTestSQLite(t *testing.T) *Database {
+	t.Helper()
+	s, err := NewSQLite(filepath.Join(t.TempDir(), "stayonline.db"))
+	if err != nil {
+		t.Fatalf("NewSQLite() error = %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	return s
+}
+
+func TestSQLiteRoundTrip(t *testing.T) {
+	s := newTestSQLite(t)
+	if s.Dialect() != DialectSQLite {
+		t.Errorf("expected dialect %q, got %q", DialectSQLite, s.Dialect())
+	}
+
+	cfg := &config.Configuration{
+		Servers: []config.ServerEntry{
+			{ID: "a", GuildID: "123456789012345678", ChannelID: "234567890123456789", ConnectOnStart: true, Priority: 1},
+			{ID: "b", GuildID: "345678901234567890", ChannelID: "456789012345678901", Priority: 2,
+				Status: config.StatusDND, StatusOverride: config.StatusOnline,
+				QuietHours: []config.QuietHours{{Start: "23:00", End: "07:00", Status: config.StatusDND}}},
+		},
+		Status:          config.StatusIdle,
+		TOSAcknowledged: true,
+	}
+	if err := s.Save(cfg); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	cfg.Servers = cfg.Servers[1:]
+	if err := s.Save(cfg); err != nil {
+		t.Fatalf("Save() without a server error = %v", err)
+	}
+	loaded, err := s.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !loaded.Equal(cfg) {
+		t.Errorf("expected %+v, got %+v", cfg, loaded)
+	}
+
+	if err := s.SaveSession(config.SessionState{ServerID: "b", SessionID: "sess", Sequence: 7}); err != nil {
+		t.Fatalf("SaveSession() error = %v", err)
+	}
+	if err := s.UpdateSessionSequence("b", 9); err != nil {
+		t.Fatalf("UpdateSessionSequence() error = %v", err)
+	}
+	state, err := s.LoadSession("b")
+	if err != nil || state == nil || state.SessionID != "sess" || state.Sequence != 9 {
+		t.Errorf("expected session sess at sequence 9, got %+v (err %v)", state, err)
+	}
+
+	if err := s.SetMaxLogEntries(2); err != nil {
+		t.Fatalf("SetMaxLogEntries() error = %v", err)
+	}
+	for _, msg := range []string{"one", "two", "three"} {
+		if err := s.AddLog("info", msg); err != nil {
+			t.Fatalf("AddLog() error = %v", err)
+		}
+	}
+	logs, err := s.GetLogs("")
+	if err != nil {
+		t.Fatalf("GetLogs() error = %v", err)
+	}
+	if len(logs) != 2 || logs[1].Message != "three" {
+		t.Errorf("expected the two newest logs, got %+v", logs)
+	}
+}
+
+func TestSQLiteReopenKeepsData(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stayonline.db")
+	s, err := NewSQLite(path)
+	if err != nil {
+		t.Fatalf("NewSQLite() error = %v", err)
+	}
+	cfg := &config.Configuration{Servers: []config.ServerEntry{}, Status: config.StatusDND, TOSAcknowledged: true}
+	if err := s.Save(cfg); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	_ = s.Close()
+
+	reopened, err := Open("sqlite:" + path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer func() { _ = reopened.(*Database).Close() }()
+	loaded, err := reopened.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if loaded.Status != config.StatusDND || !loaded.TOSAcknowledged {
+		t.Errorf("expected the saved settings after reopening, got %+v", loaded)
+	}
+}
+
+func TestSQLiteAuditRecords(t *testing.T) {
+	s := newTestSQLite(t)
+
+	start := time.Now().Truncate(time.Second)
+	for i, action := range []string{"config.update", "status.set", "config.update", "config.update"} {
+		entry := AuditEntry{Action: action, SessionID: "sess", Diff: `{"modified":["a"]}`, Timestamp: start.Add(time.Duration(i) * time.Second)}
+		if err := s.AddAudit(entry); err != nil {
+			t.Fatalf("AddAudit() error = %v", err)
+		}
+	}
+
+	all, err := s.GetAudit("", 10)
+	if err != nil {
+		t.Fatalf("GetAudit() error = %v", err)
+	}
+	if len(all) != 4 || all[0].Action != "config.update" || all[1].Action != "status.set" {
+		t.Fatalf("expected every record oldest first, got %+v", all)
+	}
+	if all[0].SessionID != "sess" || all[0].Diff != `{"modified":["a"]}` || !all[0].Timestamp.Equal(start) {
+		t.Errorf("expected the stored fields back, got %+v", all[0])
+	}
+
+	newest, err := s.GetAudit("config.update", 2)
+	if err != nil {
+		t.Fatalf("GetAudit() error = %v", err)
+	}
+	if len(newest) != 2 || !newest[0].Timestamp.Equal(start.Add(2*time.Second)) || !newest[1].Timestamp.Equal(start.Add(3*time.Second)) {
+		t.Errorf("expected the two newest config.update records, got %+v", newest)
+	}
+}
@@ -0,0 +1,87 @@
+package store
+
+import (
+	"context"
+	"log/slog"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/pyyupsk/discord-stayonline/internal/config"
+)
+
+// DefaultWatchDebounce is how long Watch waits after the last write to the
+// config file before reloading it, so an editor's save, often several
+// writes and a rename, causes one reload.
+const DefaultWatchDebounce = 500 * time.Millisecond
+
+// Watch sends the configuration each time the file changes, until ctx is
+// done, when the channel is closed. Writes are debounced by WatchDebounce,
+// and a file that does not parse or is unchanged since the last send is
+// skipped.
+//
+// The directory is watched rather than the file, so replacing the file by
+// rename, as Save and most editors do, keeps being noticed.
+func (s *File) Watch(ctx context.Context) (<-chan *config.Configuration, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	if err := watcher.Add(filepath.Dir(s.path)); err != nil {
+		_ = watcher.Close()
+		return nil, err
+	}
+
+	last, _ := s.Load()
+	debounce := s.WatchDebounce
+	if debounce <= 0 {
+		debounce = DefaultWatchDebounce
+	}
+
+	updates := make(chan *config.Configuration)
+	go func() {
+		defer close(updates)
+		defer func() { _ = watcher.Close() }()
+
+		name := filepath.Clean(s.path)
+		timer := time.NewTimer(debounce)
+		timer.Stop()
+		defer timer.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) == name {
+					timer.Reset(debounce)
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				slog.Warn("Config file watch error", "path", s.path, "error", err)
+			case <-timer.C:
+				cfg, err := s.Load()
+				if err != nil {
+					slog.Warn("Ignoring unreadable config file change", "path", s.path, "error", err)
+					continue
+				}
+				if last != nil && cfg.Equal(last) {
+					continue
+				}
+				select {
+				case updates <- cfg:
+					last = cfg
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return updates, nil
+}
@@ -0,0 +1,90 @@
+// Package deadletter keeps a bounded record of messages that could not be delivered.
+package deadletter
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	SourceWebSocket = "websocket"
+	SourceWebhook   = "webhook"
+)
+
+type Entry struct {
+	Source    string    `json:"source"`
+	Reason    string    `json:"reason"`
+	Payload   string    `json:"payload"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Buffer is a fixed-size ring of dropped messages. A nil *Buffer is valid and
+// discards everything, so callers don't need to check whether it is enabled.
+type Buffer struct {
+	entries []Entry
+	next    int
+	full    bool
+	mu      sync.Mutex
+}
+
+// New returns a buffer holding at most size entries, or nil when size is not positive.
+func New(size int) *Buffer {
+	if size <= 0 {
+		return nil
+	}
+	return &Buffer{
+		entries: make([]Entry, size),
+	}
+}
+
+func (b *Buffer) Add(source, reason string, payload []byte) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.entries[b.next] = Entry{
+		Source:    source,
+		Reason:    reason,
+		Payload:   string(payload),
+		Timestamp: time.Now(),
+	}
+	b.next = (b.next + 1) % len(b.entries)
+	if b.next == 0 {
+		b.full = true
+	}
+}
+
+// Entries returns the recorded messages, oldest first.
+func (b *Buffer) Entries() []Entry {
+	if b == nil {
+		return []Entry{}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.full {
+		return append([]Entry{}, b.entries[:b.next]...)
+	}
+
+	result := make([]Entry, 0, len(b.entries))
+	result = append(result, b.entries[b.next:]...)
+	return append(result, b.entries[:b.next]...)
+}
+
+func (b *Buffer) Len() int {
+	if b == nil {
+		return 0
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.full {
+		return len(b.entries)
+	}
+	return b.next
+}
@@ -0,0 +1,39 @@
+package deadletter
+
+import "testing"
+
+func TestNewDisabled(t *testing.T) {
+	if New(0) != nil {
+		t.Error("expected nil buffer for size 0")
+	}
+
+	var b *Buffer
+	b.Add(SourceWebSocket, "dropped", []byte("x"))
+	if b.Len() != 0 || len(b.Entries()) != 0 {
+		t.Error("expected nil buffer to discard entries")
+	}
+}
+
+func TestBufferBounded(t *testing.T) {
+	b := New(3)
+	for _, payload := range []string{"a", "b", "c", "d", "e"} {
+		b.Add(SourceWebhook, "status 500", []byte(payload))
+	}
+
+	entries := b.Entries()
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(entries))
+	}
+
+	for i, want := range []string{"c", "d", "e"} {
+		if entries[i].Payload != want {
+			t.Errorf("entry %d: expected payload %q, got %q", i, want, entries[i].Payload)
+		}
+		if entries[i].Source != SourceWebhook || entries[i].Reason != "status 500" {
+			t.Errorf("entry %d: unexpected source/reason %q/%q", i, entries[i].Source, entries[i].Reason)
+		}
+		if entries[i].Timestamp.IsZero() {
+			t.Errorf("entry %d: expected timestamp to be set", i)
+		}
+	}
+}
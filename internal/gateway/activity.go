@@ -0,0 +1,133 @@
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ActivityType is a Discord activity type. It is sent as an integer but
+// accepts the lowercase name (e.g. "listening") when decoding JSON.
+type ActivityType int
+
+const (
+	ActivityPlaying   ActivityType = 0
+	ActivityStreaming ActivityType = 1
+	ActivityListening ActivityType = 2
+	ActivityWatching  ActivityType = 3
+	ActivityCustom    ActivityType = 4
+	ActivityCompeting ActivityType = 5
+)
+
+// customStatusName is the activity name Discord expects for a custom status.
+const customStatusName = "Custom Status"
+
+var (
+	ErrUnknownActivityType = errors.New("unknown activity type")
+	ErrInvalidStreamURL    = errors.New("stream URL must be a Twitch or YouTube URL")
+)
+
+// streamHosts are the hosts Discord shows the streaming indicator for.
+var streamHosts = map[string]bool{
+	"twitch.tv":       true,
+	"www.twitch.tv":   true,
+	"youtube.com":     true,
+	"www.youtube.com": true,
+	"m.youtube.com":   true,
+	"youtu.be":        true,
+}
+
+var activityTypeNames = map[string]ActivityType{
+	"playing":   ActivityPlaying,
+	"streaming": ActivityStreaming,
+	"listening": ActivityListening,
+	"watching":  ActivityWatching,
+	"custom":    ActivityCustom,
+	"competing": ActivityCompeting,
+}
+
+// ParseActivityType maps an activity type name, case-insensitively, to its
+// integer value.
+func ParseActivityType(name string) (ActivityType, error) {
+	t, ok := activityTypeNames[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return 0, fmt.Errorf("%w: %q", ErrUnknownActivityType, name)
+	}
+	return t, nil
+}
+
+// NewActivity builds an activity of type t showing text. For a custom
+// status, text is the status itself; otherwise it is the activity name, as
+// in "Watching <text>".
+func NewActivity(t ActivityType, text string) Activity {
+	if t == ActivityCustom {
+		return Activity{Name: customStatusName, Type: ActivityCustom, State: text}
+	}
+	return Activity{Name: text, Type: t}
+}
+
+// NewStreamingActivity builds a streaming activity named name that links to
+// streamURL, which must pass ValidateStreamURL.
+func NewStreamingActivity(name, streamURL string) (Activity, error) {
+	if err := ValidateStreamURL(streamURL); err != nil {
+		return Activity{}, err
+	}
+	return Activity{Name: name, Type: ActivityStreaming, URL: streamURL}, nil
+}
+
+// ValidateStreamURL checks that raw is an http(s) Twitch or YouTube URL with
+// a path, as Discord ignores the streaming indicator for anything else.
+func ValidateStreamURL(raw string) error {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || !streamHosts[strings.ToLower(u.Hostname())] || strings.Trim(u.Path, "/") == "" {
+		return fmt.Errorf("%w: %q", ErrInvalidStreamURL, raw)
+	}
+	return nil
+}
+
+// activities returns the presence activity list for a, empty when nil.
+func activities(a *Activity) []Activity {
+	if a == nil {
+		return []Activity{}
+	}
+	return []Activity{*a}
+}
+
+// Valid reports whether t is a known activity type.
+func (t ActivityType) Valid() bool {
+	return t >= ActivityPlaying && t <= ActivityCompeting
+}
+
+func (t ActivityType) String() string {
+	for name, value := range activityTypeNames {
+		if value == t {
+			return name
+		}
+	}
+	return fmt.Sprintf("ActivityType(%d)", int(t))
+}
+
+// UnmarshalJSON accepts either a type name or its integer value.
+func (t *ActivityType) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		parsed, err := ParseActivityType(name)
+		if err != nil {
+			return err
+		}
+		*t = parsed
+		return nil
+	}
+
+	var n int
+	if err := json.Unmarshal(data, &n); err != nil {
+		return fmt.Errorf("%w: %s", ErrUnknownActivityType, data)
+	}
+	if !ActivityType(n).Valid() {
+		return fmt.Errorf("%w: %d", ErrUnknownActivityType, n)
+	}
+	*t = ActivityType(n)
+	return nil
+}
@@ -0,0 +1,124 @@
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParseActivityType(t *testing.T) {
+	tests := map[string]ActivityType{
+		"playing":   0,
+		"streaming": 1,
+		"listening": 2,
+		"watching":  3,
+		"custom":    4,
+		"competing": 5,
+		"Listening": 2,
+	}
+	for name, want := range tests {
+		got, err := ParseActivityType(name)
+		if err != nil {
+			t.Errorf("ParseActivityType(%q) error = %v", name, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("ParseActivityType(%q) = %d, want %d", name, got, want)
+		}
+	}
+
+	if _, err := ParseActivityType("dancing"); !errors.Is(err, ErrUnknownActivityType) {
+		t.Errorf("expected ErrUnknownActivityType, got %v", err)
+	}
+}
+
+func TestActivityTypeJSON(t *testing.T) {
+	var activity Activity
+	if err := json.Unmarshal([]byte(`{"name":"music","type":"listening"}`), &activity); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if activity.Type != ActivityListening {
+		t.Errorf("Type = %d, want %d", activity.Type, ActivityListening)
+	}
+
+	data, err := json.Marshal(activity)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if string(data) != `{"name":"music","type":2}` {
+		t.Errorf("Marshal() = %s, want numeric type", data)
+	}
+
+	if err := json.Unmarshal([]byte(`{"type":3}`), &activity); err != nil || activity.Type != ActivityWatching {
+		t.Errorf("expected integer type to decode, got %d, %v", activity.Type, err)
+	}
+	for _, raw := range []string{`{"type":"dancing"}`, `{"type":9}`, `{"type":true}`} {
+		if err := json.Unmarshal([]byte(raw), &activity); !errors.Is(err, ErrUnknownActivityType) {
+			t.Errorf("Unmarshal(%s) error = %v, want ErrUnknownActivityType", raw, err)
+		}
+	}
+}
+
+func TestNewActivity(t *testing.T) {
+	data, err := json.Marshal(NewActivity(ActivityCustom, "Watching the servers"))
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if string(data) != `{"name":"Custom Status","type":4,"state":"Watching the servers"}` {
+		t.Errorf("unexpected custom status payload %s", data)
+	}
+
+	if got := NewActivity(ActivityWatching, "the servers"); got != (Activity{Name: "the servers", Type: ActivityWatching}) {
+		t.Errorf("expected the text as the activity name, got %+v", got)
+	}
+}
+
+func TestStreamingActivity(t *testing.T) {
+	activity, err := NewStreamingActivity("Speedrun", "https://www.twitch.tv/someone")
+	if err != nil {
+		t.Fatalf("NewStreamingActivity() error = %v", err)
+	}
+	data, err := json.Marshal(activity)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if string(data) != `{"name":"Speedrun","type":1,"url":"https://www.twitch.tv/someone"}` {
+		t.Errorf("unexpected streaming payload %s", data)
+	}
+
+	// Other activities carry no url.
+	if data, _ := json.Marshal(NewActivity(ActivityPlaying, "chess")); string(data) != `{"name":"chess","type":0}` {
+		t.Errorf("unexpected playing payload %s", data)
+	}
+}
+
+func TestValidateStreamURL(t *testing.T) {
+	valid := []string{
+		"https://twitch.tv/someone",
+		"https://www.twitch.tv/someone",
+		"http://www.youtube.com/watch?v=abc123",
+		"https://youtu.be/abc123",
+	}
+	for _, raw := range valid {
+		if err := ValidateStreamURL(raw); err != nil {
+			t.Errorf("ValidateStreamURL(%q) error = %v", raw, err)
+		}
+	}
+
+	invalid := []string{
+		"",
+		"twitch.tv/someone",
+		"ftp://twitch.tv/someone",
+		"https://twitch.tv/",
+		"https://vimeo.com/123",
+		"https://twitch.tv.example.com/someone",
+	}
+	for _, raw := range invalid {
+		if err := ValidateStreamURL(raw); !errors.Is(err, ErrInvalidStreamURL) {
+			t.Errorf("ValidateStreamURL(%q) error = %v, want ErrInvalidStreamURL", raw, err)
+		}
+	}
+	if _, err := NewStreamingActivity("Speedrun", "https://vimeo.com/123"); !errors.Is(err, ErrInvalidStreamURL) {
+		t.Errorf("expected NewStreamingActivity to reject a non-stream URL, got %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// DiscordAPIBase is the REST API root used for the /gateway/bot lookup.
+const DiscordAPIBase = "https://discord.com/api/v10"
+
+// gatewayQuery is appended to bare Gateway URLs returned by Discord.
+const gatewayQuery = "/?v=10&encoding=json"
+
+// SessionStartLimit is the IDENTIFY budget reported by /gateway/bot.
+type SessionStartLimit struct {
+	Total          int `json:"total"`
+	Remaining      int `json:"remaining"`
+	ResetAfter     int `json:"reset_after"`
+	MaxConcurrency int `json:"max_concurrency"`
+}
+
+// BotGateway is the response of GET /gateway/bot.
+type BotGateway struct {
+	URL               string            `json:"url"`
+	Shards            int               `json:"shards"`
+	SessionStartLimit SessionStartLimit `json:"session_start_limit"`
+}
+
+// ConnectURL returns the Gateway URL with the version and encoding query
+// this client expects.
+func (b *BotGateway) ConnectURL() string {
+	return withGatewayQuery(b.URL)
+}
+
+// withGatewayQuery appends the version and encoding query to url unless it
+// already has a query string.
+func withGatewayQuery(url string) string {
+	if strings.Contains(url, "?") {
+		return url
+	}
+	return strings.TrimSuffix(url, "/") + gatewayQuery
+}
+
+// FetchBotGateway asks Discord for the recommended Gateway URL and session
+// start limits for a bot token. apiBase defaults to DiscordAPIBase.
+func FetchBotGateway(ctx context.Context, httpClient *http.Client, apiBase, token string) (*BotGateway, error) {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	if apiBase == "" {
+		apiBase = DiscordAPIBase
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiBase+"/gateway/bot", nil)
+	if err != nil {
+		return nil, fmt.Errorf("create gateway bot request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bot "+strings.TrimPrefix(token, "Bot "))
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch gateway bot: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch gateway bot: unexpected status %d", resp.StatusCode)
+	}
+
+	var gw BotGateway
+	if err := json.NewDecoder(resp.Body).Decode(&gw); err != nil {
+		return nil, fmt.Errorf("decode gateway bot: %w", err)
+	}
+	if gw.URL == "" {
+		return nil, fmt.Errorf("fetch gateway bot: response has no url")
+	}
+	return &gw, nil
+}
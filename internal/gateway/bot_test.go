@@ -0,0 +1,108 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFetchBotGatewayURLIsUsedToConnect(t *testing.T) {
+	mock := newMockGatewayServer(t)
+	defer mock.Close()
+
+	var gotAuth string
+	rest := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/gateway/bot" {
+			http.NotFound(w, r)
+			return
+		}
+		gotAuth = r.Header.Get("Authorization")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"url":    mock.URL(),
+			"shards": 1,
+			"session_start_limit": map[string]int{
+				"total":           1000,
+				"remaining":       998,
+				"reset_after":     3600000,
+				"max_concurrency": 1,
+			},
+		})
+	}))
+	defer rest.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	gw, err := FetchBotGateway(ctx, rest.Client(), rest.URL, testTokenClient)
+	if err != nil {
+		t.Fatalf("FetchBotGateway() error = %v", err)
+	}
+	if gotAuth != "Bot "+testTokenClient {
+		t.Errorf("expected bot authorization header, got %q", gotAuth)
+	}
+	if gw.SessionStartLimit.Remaining != 998 || gw.SessionStartLimit.MaxConcurrency != 1 {
+		t.Errorf("unexpected session start limit: %+v", gw.SessionStartLimit)
+	}
+
+	client := NewClient(testTokenClient, nil)
+	ready := make(chan struct{})
+	client.OnReady = func(string) { close(ready) }
+	client.SetGatewayURL(gw.ConnectURL())
+
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf(errFailedToConnectFmt, err)
+	}
+	defer func() { _ = client.Close() }()
+
+	select {
+	case <-ready:
+	case <-ctx.Done():
+		t.Fatal("timeout waiting for READY via the /gateway/bot URL")
+	}
+}
+
+func TestFetchBotGatewayRejectsErrorStatus(t *testing.T) {
+	rest := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer rest.Close()
+
+	if _, err := FetchBotGateway(context.Background(), rest.Client(), rest.URL, testTokenClient); err == nil {
+		t.Error("expected error for non-200 response")
+	}
+}
+
+func TestConfiguredGatewayURLGetsQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		path  string
+		query string
+	}{
+		{name: "bare", path: "", query: "v=10&encoding=json"},
+		{name: "trailing slash", path: "/", query: "v=10&encoding=json"},
+		{name: "own query", path: "/?v=10&encoding=json&compress=none", query: "v=10&encoding=json&compress=none"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotPath, gotQuery string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotPath, gotQuery = r.URL.Path, r.URL.RawQuery
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}))
+			defer server.Close()
+
+			client := NewClient(testTokenClient, nil)
+			client.SetGatewayURL("ws" + strings.TrimPrefix(server.URL, "http") + tt.path)
+			if err := client.Connect(context.Background()); err == nil {
+				t.Fatal("expected the dial to fail")
+			}
+			if gotPath != "/" || gotQuery != tt.query {
+				t.Errorf("dialed path %q query %q, want \"/\" and %q", gotPath, gotQuery, tt.query)
+			}
+		})
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"math/rand"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -16,6 +18,13 @@ import (
 const (
 	GatewayURL     = "wss://gateway.discord.gg/?v=10&encoding=json"
 	GatewayVersion = 10
+
+	// DefaultReadTimeout bounds reads before HELLO sets the heartbeat interval.
+	DefaultReadTimeout = 60 * time.Second
+
+	// DefaultMaxMissedHeartbeatAcks is how many consecutive heartbeats may go
+	// unacknowledged before the connection is treated as dead.
+	DefaultMaxMissedHeartbeatAcks = 2
 )
 
 var (
@@ -24,6 +33,14 @@ var (
 	browserList   = []string{"Discord Client", "Chrome", "Firefox", "Safari", "Edge", "Opera", "Brave"}
 )
 
+// presenceStatuses are the status values Discord accepts in IDENTIFY and presence updates.
+var presenceStatuses = map[string]bool{
+	"online":    true,
+	"idle":      true,
+	"dnd":       true,
+	"invisible": true,
+}
+
 func getClientProperties(index int) (os, browser, device string) {
 	os = osList[index%len(osList)]
 	browser = browserList[(index/len(osList))%len(browserList)]
@@ -47,6 +64,33 @@ var (
 	ErrInvalidSession = errors.New("session is invalid")
 )
 
+// CloseError reports a fatal Gateway close code. It matches ErrFatalClose
+// with errors.Is.
+type CloseError struct {
+	Code int
+}
+
+func (e *CloseError) Error() string {
+	if hint := CloseCodeHint(e.Code); hint != "" {
+		return fmt.Sprintf("%s: code %d (%s: %s)", ErrFatalClose, e.Code, DescribeCloseCode(e.Code), hint)
+	}
+	return fmt.Sprintf("%s: code %d", ErrFatalClose, e.Code)
+}
+
+func (e *CloseError) Unwrap() error {
+	return ErrFatalClose
+}
+
+// ClientStats breaks down how long each phase of the most recent connect took.
+// TimeToHello is measured from the completed dial and TimeToReady from HELLO,
+// so a slow handshake and a slow Discord response show up separately.
+type ClientStats struct {
+	Dial        time.Duration `json:"dial"`
+	TimeToHello time.Duration `json:"time_to_hello"`
+	TimeToReady time.Duration `json:"time_to_ready"`
+	Total       time.Duration `json:"total"`
+}
+
 type Client struct {
 	token       string
 	status      string
@@ -57,26 +101,82 @@ type Client struct {
 	mu    sync.RWMutex
 
 	sessionID        string
-	sequence         int
+	userID           string
+	sequence         int64
 	resumeURL        string
 	resumeSessionID  string
-	resumeSequence   int
+	resumeSequence   int64
 	resumeGatewayURL string
+	gatewayURL       string
 
 	heartbeatInterval time.Duration
+	readTimeout       time.Duration
+	keepAlive         time.Duration
+
+	// newDialer builds the TCP dialer for each connection; nil uses
+	// newNetDialer. Tests replace it to observe dialing.
+	newDialer func(keepAlive time.Duration) contextDialer
+
 	heartbeatTicker   *time.Ticker
 	lastHeartbeatAck  time.Time
-	heartbeatStop     chan struct{}
+	lastHeartbeatSent time.Time
+	heartbeatLatency  time.Duration
+
+	// invalidSessionDelay is the wait before resuming after a resumable
+	// INVALID_SESSION; zero picks a random 1-5 seconds.
+	invalidSessionDelay time.Duration
+
+	// unackedHeartbeats counts heartbeats sent since the last ACK; the
+	// connection is closed once it reaches maxMissedAcks.
+	unackedHeartbeats int
+	maxMissedAcks     int
+
+	// immediateFirstHeartbeat skips the jitter before the first heartbeat.
+	immediateFirstHeartbeat bool
+
+	// intents is the IDENTIFY intents bitfield; zero leaves it out.
+	intents int
+
+	// shard is sent in IDENTIFY when set.
+	shard *Shard
+
+	// activity is shown with the presence in IDENTIFY and presence updates;
+	// nil shows none.
+	activity *Activity
 
-	readStop     chan struct{}
-	readDone     chan struct{}
-	disconnected chan struct{}
+	// compressZlibStream asks for zlib-stream transport compression on new
+	// connections.
+	compressZlibStream bool
+
+	// lifecycle coordinates the goroutines of the current connection; nil
+	// before the first Connect.
+	lifecycle *connLifecycle
+
+	// All outbound frames go through writeQueue so a single goroutine owns
+	// conn.Write for the lifetime of a connection.
+	writeQueue chan outboundFrame
+	writeStop  chan struct{}
+
+	frameLog *FrameLog
+
+	connectStartedAt time.Time
+	dialedAt         time.Time
+	helloAt          time.Time
+	readyAt          time.Time
 
 	OnReady       func(sessionID string)
 	OnDisconnect  func(code int, reason string)
 	OnError       func(err error)
 	OnStateChange func(state int)
 
+	// OnHeartbeatAck is called with the round-trip time of each acknowledged
+	// heartbeat.
+	OnHeartbeatAck func(rtt time.Duration)
+
+	// OnVoiceState is called when Discord reports this account's own voice
+	// state. channelID is empty when it is not in a voice channel.
+	OnVoiceState func(guildID, channelID string)
+
 	logger *slog.Logger
 }
 
@@ -100,7 +200,92 @@ func (c *Client) SetStatus(status string) {
 	c.status = status
 }
 
-func (c *Client) SetResumeData(sessionID string, sequence int, resumeURL string) {
+// SetGatewayURL overrides the URL used for fresh connections, e.g. with the
+// one returned by /gateway/bot or a proxy. The version and encoding query is
+// appended when url has none. An empty url restores the default.
+func (c *Client) SetGatewayURL(url string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gatewayURL = url
+}
+
+// SetFrameLog records a summary of every inbound frame into log.
+func (c *Client) SetFrameLog(log *FrameLog) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.frameLog = log
+}
+
+// SetReadTimeout overrides how long a read may wait for the next frame. Zero
+// derives it from the heartbeat interval (see currentReadTimeout).
+func (c *Client) SetReadTimeout(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.readTimeout = d
+}
+
+// SetMaxMissedHeartbeatAcks sets how many consecutive heartbeats may go
+// unacknowledged before the connection is closed. Zero uses
+// DefaultMaxMissedHeartbeatAcks.
+func (c *Client) SetMaxMissedHeartbeatAcks(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.maxMissedAcks = n
+}
+
+// SetImmediateFirstHeartbeat sends the first heartbeat as soon as HELLO
+// arrives instead of after a random fraction of the interval. Discord
+// recommends the jitter, so it stays the default; skipping it surfaces a dead
+// connection sooner and makes the heartbeat loop deterministic in tests.
+func (c *Client) SetImmediateFirstHeartbeat(enabled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.immediateFirstHeartbeat = enabled
+}
+
+// SetIntents sets the Gateway intents sent in IDENTIFY, e.g.
+// IntentGuilds|IntentGuildVoiceStates. Bot tokens must send intents; zero
+// omits the field, as user accounts do.
+func (c *Client) SetIntents(intents int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.intents = intents
+}
+
+// SetShard pins the connection to a shard, sent in IDENTIFY. Nil omits it,
+// which Discord treats as the only shard.
+func (c *Client) SetShard(shard *Shard) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.shard = shard
+}
+
+// SetActivity sets the activity sent with the presence in IDENTIFY and in
+// SendPresenceUpdate. Nil clears it.
+func (c *Client) SetActivity(activity *Activity) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.activity = activity
+}
+
+// SetCompressZlibStream enables zlib-stream transport compression for
+// connections made after the call.
+func (c *Client) SetCompressZlibStream(enabled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.compressZlibStream = enabled
+}
+
+// SetInvalidSessionDelay sets how long to wait before resuming after a
+// resumable INVALID_SESSION. Zero waits a random 1-5 seconds, as Discord
+// recommends.
+func (c *Client) SetInvalidSessionDelay(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.invalidSessionDelay = d
+}
+
+func (c *Client) SetResumeData(sessionID string, sequence int64, resumeURL string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	c.resumeSessionID = sessionID
@@ -108,7 +293,7 @@ func (c *Client) SetResumeData(sessionID string, sequence int, resumeURL string)
 	c.resumeGatewayURL = resumeURL
 }
 
-func (c *Client) GetSessionData() (sessionID string, sequence int, resumeURL string) {
+func (c *Client) GetSessionData() (sessionID string, sequence int64, resumeURL string) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 	return c.sessionID, c.sequence, c.resumeURL
@@ -130,42 +315,75 @@ func (c *Client) Connect(ctx context.Context) error {
 	}
 	c.state = StateConnecting
 	resumeURL := c.resumeGatewayURL
+	gatewayURL := c.gatewayURL
+	compress := c.compressZlibStream
+	connectStartedAt := time.Now()
+	c.connectStartedAt = connectStartedAt
+	c.dialedAt = time.Time{}
+	c.helloAt = time.Time{}
+	c.readyAt = time.Time{}
 	c.mu.Unlock()
 
 	c.notifyStateChange(StateConnecting)
 
-	gatewayURL := GatewayURL
+	if gatewayURL == "" {
+		gatewayURL = GatewayURL
+	} else {
+		gatewayURL = withGatewayQuery(gatewayURL)
+	}
+	if resumeURL != "" {
+		gatewayURL = resumeURL + gatewayQuery
+	}
+	var inflate *zlibStream
+	if compress {
+		gatewayURL += zlibStreamQuery
+		inflate = &zlibStream{}
+	}
 	if resumeURL != "" {
-		gatewayURL = resumeURL + "/?v=10&encoding=json"
 		c.logger.Info("Resuming Discord Gateway session", "url", gatewayURL)
 	} else {
 		c.logger.Info("Connecting to Discord Gateway", "url", gatewayURL)
 	}
 
-	conn, _, err := websocket.Dial(ctx, gatewayURL, &websocket.DialOptions{
+	conn, resp, err := websocket.Dial(ctx, gatewayURL, &websocket.DialOptions{
+		HTTPClient:      c.httpClient(),
 		CompressionMode: websocket.CompressionDisabled,
 	})
 	if err != nil {
 		c.setState(StateDisconnected)
-		return fmt.Errorf("dial gateway: %w", err)
+		return newDialError(resp, err)
 	}
 
 	conn.SetReadLimit(1024 * 1024)
 
+	dialedAt := time.Now()
+	c.logger.Debug("Dialed Discord Gateway", "duration", dialedAt.Sub(connectStartedAt))
+
+	c.attachConn(conn)
+
 	c.mu.Lock()
-	c.conn = conn
-	c.heartbeatStop = make(chan struct{})
-	c.readStop = make(chan struct{})
-	c.readDone = make(chan struct{})
-	c.disconnected = make(chan struct{})
+	c.dialedAt = dialedAt
+	lifecycle := newConnLifecycle()
+	c.lifecycle = lifecycle
 	c.mu.Unlock()
 
-	go c.readLoop(ctx)
+	go c.readLoop(ctx, lifecycle, inflate)
 
 	return nil
 }
 
 func (c *Client) Close() error {
+	return c.shutdown(websocket.StatusGoingAway, "client closing")
+}
+
+// CloseForResume closes the connection without invalidating the Discord
+// session, so it can be resumed by a later process. Discord invalidates
+// sessions closed with 1000 or 1001.
+func (c *Client) CloseForResume() error {
+	return c.shutdown(websocket.StatusServiceRestart, "client restarting")
+}
+
+func (c *Client) shutdown(code websocket.StatusCode, reason string) error {
 	c.mu.Lock()
 
 	if c.state == StateClosed || c.state == StateDisconnected {
@@ -175,37 +393,26 @@ func (c *Client) Close() error {
 
 	c.state = StateClosed
 
-	if c.heartbeatStop != nil {
-		close(c.heartbeatStop)
-		c.heartbeatStop = nil
-	}
-
-	if c.readStop != nil {
-		close(c.readStop)
-		c.readStop = nil
-	}
+	lifecycle := c.lifecycle
+	lifecycle.stop()
+	c.stopWriterLocked()
 
 	conn := c.conn
 	c.conn = nil
-	readDone := c.readDone
 
 	c.mu.Unlock()
 
 	if conn != nil {
-		_ = conn.Close(websocket.StatusGoingAway, "client closing")
+		_ = conn.Close(code, reason)
 	}
 
-	if readDone != nil {
+	if lifecycle != nil {
 		select {
-		case <-readDone:
+		case <-lifecycle.readDone:
 		case <-time.After(5 * time.Second):
 		}
 	}
 
-	c.mu.Lock()
-	c.disconnected = nil
-	c.mu.Unlock()
-
 	c.notifyStateChange(StateClosed)
 	return nil
 }
@@ -214,21 +421,23 @@ func (c *Client) SendIdentify(ctx context.Context) error {
 	c.mu.RLock()
 	status := c.status
 	c.mu.RUnlock()
-	if status == "" {
-		status = "online"
-	}
 	return c.SendIdentifyWithStatus(ctx, status)
 }
 
 func (c *Client) SendIdentifyWithStatus(ctx context.Context, status string) error {
 	c.mu.RLock()
 	conn := c.conn
+	intents := c.intents
+	shard := c.shard
+	activity := c.activity
 	c.mu.RUnlock()
 
 	if conn == nil {
 		return ErrNotConnected
 	}
 
+	status = c.normalizeStatus(status)
+
 	identify := struct {
 		Op   int          `json:"op"`
 		Data IdentifyData `json:"d"`
@@ -247,9 +456,11 @@ func (c *Client) SendIdentifyWithStatus(ctx context.Context, status string) erro
 			Presence: &PresenceData{
 				Status:     status,
 				Since:      new(int64),
-				Activities: []Activity{},
+				Activities: activities(activity),
 				AFK:        false,
 			},
+			Intents: intents,
+			Shard:   shard,
 		},
 	}
 
@@ -259,7 +470,7 @@ func (c *Client) SendIdentifyWithStatus(ctx context.Context, status string) erro
 	}
 
 	c.logger.Debug("Sending IDENTIFY", "status", status)
-	return conn.Write(ctx, websocket.MessageText, data)
+	return c.write(ctx, data)
 }
 
 func (c *Client) sendResume(ctx context.Context) error {
@@ -291,7 +502,7 @@ func (c *Client) sendResume(ctx context.Context) error {
 	}
 
 	c.logger.Info("Sending RESUME", "session_id", sessionID, "sequence", seq)
-	return conn.Write(ctx, websocket.MessageText, data)
+	return c.write(ctx, data)
 }
 
 func (c *Client) SendHeartbeat(ctx context.Context) error {
@@ -305,8 +516,8 @@ func (c *Client) SendHeartbeat(ctx context.Context) error {
 	}
 
 	heartbeat := struct {
-		Op   int  `json:"op"`
-		Data *int `json:"d"`
+		Op   int    `json:"op"`
+		Data *int64 `json:"d"`
 	}{
 		Op: OpHeartbeat,
 	}
@@ -320,11 +531,39 @@ func (c *Client) SendHeartbeat(ctx context.Context) error {
 		return fmt.Errorf("marshal heartbeat: %w", err)
 	}
 
+	// Count the beat before writing it, so a fast ACK cannot be reset
+	// ahead of the increment.
+	c.mu.Lock()
+	c.unackedHeartbeats++
+	c.mu.Unlock()
+
 	c.logger.Debug("Sending heartbeat", "sequence", seq)
-	return conn.Write(ctx, websocket.MessageText, data)
+	if err := c.write(ctx, data); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.lastHeartbeatSent = time.Now()
+	c.mu.Unlock()
+	return nil
 }
 
+// SendPresenceUpdate sends status with the activity set by SetActivity.
 func (c *Client) SendPresenceUpdate(ctx context.Context, status string) error {
+	c.mu.RLock()
+	activity := c.activity
+	c.mu.RUnlock()
+	return c.sendPresenceUpdate(ctx, status, activity)
+}
+
+// SendPresenceUpdateWithActivity sends status with activity instead of the
+// one set by SetActivity, e.g. NewActivity(ActivityCustom, "Watching the
+// servers").
+func (c *Client) SendPresenceUpdateWithActivity(ctx context.Context, status string, activity Activity) error {
+	return c.sendPresenceUpdate(ctx, status, &activity)
+}
+
+func (c *Client) sendPresenceUpdate(ctx context.Context, status string, activity *Activity) error {
 	c.mu.RLock()
 	conn := c.conn
 	c.mu.RUnlock()
@@ -333,6 +572,8 @@ func (c *Client) SendPresenceUpdate(ctx context.Context, status string) error {
 		return ErrNotConnected
 	}
 
+	status = c.normalizeStatus(status)
+
 	presence := struct {
 		Op   int          `json:"op"`
 		Data PresenceData `json:"d"`
@@ -340,7 +581,7 @@ func (c *Client) SendPresenceUpdate(ctx context.Context, status string) error {
 		Op: OpPresenceUpdate,
 		Data: PresenceData{
 			Since:      nil,
-			Activities: []Activity{},
+			Activities: activities(activity),
 			Status:     status,
 			AFK:        false,
 		},
@@ -352,7 +593,7 @@ func (c *Client) SendPresenceUpdate(ctx context.Context, status string) error {
 	}
 
 	c.logger.Debug("Sending presence update", "status", status)
-	return conn.Write(ctx, websocket.MessageText, data)
+	return c.write(ctx, data)
 }
 
 func (c *Client) SendVoiceStateUpdate(ctx context.Context, guildID, channelID string, selfMute, selfDeaf bool) error {
@@ -386,30 +627,40 @@ func (c *Client) SendVoiceStateUpdate(ctx context.Context, guildID, channelID st
 	}
 
 	c.logger.Debug("Sending voice state update", "guild_id", guildID, "channel_id", channelID)
-	return conn.Write(ctx, websocket.MessageText, data)
+	return c.write(ctx, data)
+}
+
+// normalizeStatus trims status and falls back to "online" for empty or
+// unknown values, so a misconfigured status never reaches Discord.
+func (c *Client) normalizeStatus(status string) string {
+	trimmed := strings.TrimSpace(status)
+	if trimmed == "" {
+		return "online"
+	}
+	if !presenceStatuses[trimmed] {
+		c.logger.Warn("Invalid presence status, defaulting to online", "status", status)
+		return "online"
+	}
+	return trimmed
 }
 
-func (c *Client) readLoop(ctx context.Context) {
+// readLoop reads frames until the connection fails or lifecycle is
+// stopped, then finishes lifecycle. Frames are inflated through inflate
+// when the connection uses zlib-stream compression.
+func (c *Client) readLoop(ctx context.Context, lifecycle *connLifecycle, inflate *zlibStream) {
 	defer func() {
 		c.mu.Lock()
-		if c.readDone != nil {
-			close(c.readDone)
-			c.readDone = nil
-		}
-		if c.heartbeatStop != nil {
-			close(c.heartbeatStop)
-			c.heartbeatStop = nil
-		}
-		if c.disconnected != nil {
-			close(c.disconnected)
-			c.disconnected = nil
+		// A newer connection owns the writer once Connect has moved on.
+		if c.lifecycle == lifecycle {
+			c.stopWriterLocked()
 		}
 		c.mu.Unlock()
+		lifecycle.finish()
 	}()
 
 	for {
 		select {
-		case <-c.readStop:
+		case <-lifecycle.readStop:
 			return
 		case <-ctx.Done():
 			return
@@ -424,7 +675,7 @@ func (c *Client) readLoop(ctx context.Context) {
 			return
 		}
 
-		readCtx, cancel := context.WithTimeout(ctx, 60*time.Second)
+		readCtx, cancel := context.WithTimeout(ctx, c.currentReadTimeout())
 		_, data, err := conn.Read(readCtx)
 		cancel()
 
@@ -433,18 +684,51 @@ func (c *Client) readLoop(ctx context.Context) {
 			return
 		}
 
+		if inflate != nil {
+			message, complete, err := inflate.decompress(data)
+			if err != nil {
+				// The stream cannot recover from a bad frame; the read
+				// error after closing reports the disconnect.
+				c.logger.Error("Error decompressing message", "error", err)
+				_ = conn.Close(websocket.StatusInvalidFramePayloadData, "invalid zlib-stream")
+				continue
+			}
+			if !complete {
+				continue
+			}
+			data = message
+		}
+
 		if err := c.handleMessage(ctx, data); err != nil {
 			c.logger.Error("Error handling message", "error", err)
 		}
 	}
 }
 
+// currentReadTimeout returns how long the next read may block. A connection
+// without dispatch events is still healthy as long as heartbeat ACKs arrive,
+// so once HELLO has set the interval the timeout is kept longer than the
+// missed-ACK window instead of a fixed value that idle sessions would hit.
+func (c *Client) currentReadTimeout() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.readTimeout > 0 {
+		return c.readTimeout
+	}
+	return max(DefaultReadTimeout, c.heartbeatInterval*3)
+}
+
 func (c *Client) handleMessage(ctx context.Context, data []byte) error {
 	var msg GatewayMessage
 	if err := json.Unmarshal(data, &msg); err != nil {
 		return fmt.Errorf("unmarshal message: %w", err)
 	}
 
+	c.mu.RLock()
+	frameLog := c.frameLog
+	c.mu.RUnlock()
+	frameLog.record(&msg, len(data))
+
 	if msg.Sequence != nil {
 		c.mu.Lock()
 		c.sequence = *msg.Sequence
@@ -460,9 +744,7 @@ func (c *Client) handleMessage(ctx context.Context, data []byte) error {
 
 	case OpHeartbeat:
 		c.logger.Debug("Received heartbeat request from Gateway")
-		if err := c.SendHeartbeat(ctx); err != nil {
-			c.logger.Error("Failed to send requested heartbeat", "error", err)
-		}
+		c.handleHeartbeatRequest(ctx)
 
 	case OpHeartbeatAck:
 		c.handleHeartbeatAck()
@@ -473,7 +755,7 @@ func (c *Client) handleMessage(ctx context.Context, data []byte) error {
 
 	case OpInvalidSession:
 		c.logger.Warn("Received invalid session from Gateway")
-		c.handleInvalidSession(msg.Data)
+		c.handleInvalidSession(ctx, msg.Data)
 
 	default:
 		c.logger.Debug("Received unknown opcode", "op", msg.Op)
@@ -491,6 +773,7 @@ func (c *Client) handleHello(ctx context.Context, data json.RawMessage) error {
 	c.mu.Lock()
 	c.heartbeatInterval = time.Duration(hello.HeartbeatInterval) * time.Millisecond
 	resumeSessionID := c.resumeSessionID
+	c.helloAt = time.Now()
 	c.mu.Unlock()
 
 	c.logger.Info("Received HELLO", "heartbeat_interval_ms", hello.HeartbeatInterval)
@@ -516,10 +799,12 @@ func (c *Client) handleDispatch(_ context.Context, eventType string, data json.R
 		c.mu.Lock()
 		c.sessionID = ready.SessionID
 		c.resumeURL = ready.ResumeURL
+		c.userID = ready.User.ID
 		c.state = StateConnected
+		c.readyAt = time.Now()
 		c.mu.Unlock()
 
-		c.logger.Info("Connected to Discord Gateway", "session_id", ready.SessionID)
+		c.logger.Info("Connected to Discord Gateway", "session_id", ready.SessionID, "timing", c.ClientStats())
 		c.notifyStateChange(StateConnected)
 
 		if c.OnReady != nil {
@@ -529,27 +814,100 @@ func (c *Client) handleDispatch(_ context.Context, eventType string, data json.R
 	case "RESUMED":
 		c.mu.Lock()
 		c.sessionID = c.resumeSessionID
-		c.sequence = c.resumeSequence
+		c.sequence = max(c.sequence, c.resumeSequence)
+		c.resumeURL = c.resumeGatewayURL
 		c.state = StateConnected
+		c.readyAt = time.Now()
 		sessionID := c.sessionID
 		c.mu.Unlock()
 
-		c.logger.Info("Session resumed successfully", "session_id", sessionID)
+		c.logger.Info("Session resumed successfully", "session_id", sessionID, "timing", c.ClientStats())
 		c.notifyStateChange(StateConnected)
 
 		if c.OnReady != nil {
 			c.OnReady(sessionID)
 		}
+
+	case "VOICE_STATE_UPDATE":
+		c.handleVoiceState(data)
 	}
 
 	return nil
 }
 
+// handleVoiceState passes this account's voice state to OnVoiceState. Other
+// members' updates are ignored; ours match by user ID, or by session ID when
+// the user ID is unknown (e.g. after a RESUME).
+func (c *Client) handleVoiceState(data json.RawMessage) {
+	if c.OnVoiceState == nil {
+		return
+	}
+	var state VoiceState
+	if err := json.Unmarshal(data, &state); err != nil {
+		c.logger.Debug("Failed to decode voice state update", "error", err)
+		return
+	}
+
+	c.mu.RLock()
+	own := state.SessionID != "" && state.SessionID == c.sessionID
+	if c.userID != "" {
+		own = state.UserID == c.userID
+	}
+	c.mu.RUnlock()
+	if !own {
+		return
+	}
+
+	channelID := ""
+	if state.ChannelID != nil {
+		channelID = *state.ChannelID
+	}
+	c.OnVoiceState(state.GuildID, channelID)
+}
+
+// handleHeartbeatRequest answers an op 1 from the Gateway. A failed write is
+// treated like a dead connection, and the periodic ticker is restarted so the
+// next scheduled heartbeat doesn't follow the requested one too closely.
+func (c *Client) handleHeartbeatRequest(ctx context.Context) {
+	if err := c.SendHeartbeat(ctx); err != nil {
+		c.logger.Error("Failed to send requested heartbeat, closing connection", "error", err)
+		c.closeConn(websocket.StatusProtocolError, "requested heartbeat failed")
+		return
+	}
+
+	c.mu.Lock()
+	if c.heartbeatTicker != nil && c.heartbeatInterval > 0 {
+		c.heartbeatTicker.Reset(c.heartbeatInterval)
+	}
+	c.mu.Unlock()
+}
+
+// closeConn closes the underlying connection so the read loop exits and the
+// disconnect is reported through the usual callbacks.
+func (c *Client) closeConn(code websocket.StatusCode, reason string) {
+	c.mu.RLock()
+	conn := c.conn
+	c.mu.RUnlock()
+	if conn != nil {
+		_ = conn.Close(code, reason)
+	}
+}
+
 func (c *Client) handleHeartbeatAck() {
 	c.mu.Lock()
 	c.lastHeartbeatAck = time.Now()
+	c.unackedHeartbeats = 0
+	var rtt time.Duration
+	if !c.lastHeartbeatSent.IsZero() {
+		rtt = c.lastHeartbeatAck.Sub(c.lastHeartbeatSent)
+		c.heartbeatLatency = rtt
+	}
 	c.mu.Unlock()
-	c.logger.Debug("Received heartbeat ACK")
+	c.logger.Debug("Received heartbeat ACK", "rtt", rtt)
+
+	if rtt > 0 && c.OnHeartbeatAck != nil {
+		c.OnHeartbeatAck(rtt)
+	}
 }
 
 func (c *Client) handleReconnect() {
@@ -558,7 +916,7 @@ func (c *Client) handleReconnect() {
 	}
 }
 
-func (c *Client) handleInvalidSession(data json.RawMessage) {
+func (c *Client) handleInvalidSession(ctx context.Context, data json.RawMessage) {
 	var resumable bool
 	_ = json.Unmarshal(data, &resumable)
 
@@ -583,12 +941,61 @@ func (c *Client) handleInvalidSession(data json.RawMessage) {
 		return
 	}
 
-	if c.OnError != nil {
-		c.OnError(ErrInvalidSession)
+	// Discord keeps resumable sessions: wait 1-5 seconds, then RESUME on the
+	// same connection.
+	c.mu.Lock()
+	if c.sessionID != "" {
+		c.resumeSessionID = c.sessionID
+		c.resumeSequence = c.sequence
+		if c.resumeURL != "" {
+			c.resumeGatewayURL = c.resumeURL
+		}
+	}
+	canResume := c.resumeSessionID != ""
+	delay := c.invalidSessionDelay
+	var readStop chan struct{}
+	if c.lifecycle != nil {
+		readStop = c.lifecycle.readStop
+	}
+	c.mu.Unlock()
+
+	if delay <= 0 {
+		delay = time.Second + time.Duration(rand.Int63n(int64(4*time.Second)))
 	}
+	c.logger.Info("Session invalidated but resumable, resuming after delay", "delay", delay)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			return
+		case <-readStop:
+			return
+		case <-time.After(delay):
+		}
+
+		var err error
+		if canResume {
+			err = c.sendResume(ctx)
+		} else {
+			err = c.SendIdentify(ctx)
+		}
+		if err != nil {
+			c.logger.Error("Failed to resume after invalid session, closing connection", "error", err)
+			c.closeConn(websocket.StatusProtocolError, "resume after invalid session failed")
+		}
+	}()
 }
 
 func (c *Client) handleReadError(err error) {
+	if errors.Is(err, context.DeadlineExceeded) {
+		c.logger.Warn("No Gateway frames received within read timeout", "timeout", c.currentReadTimeout())
+		if c.OnDisconnect != nil {
+			c.OnDisconnect(0, "read timeout")
+		}
+		c.setState(StateDisconnected)
+		return
+	}
+
 	c.logger.Error("Read error", "error", err)
 
 	closeStatus := websocket.CloseStatus(err)
@@ -597,7 +1004,7 @@ func (c *Client) handleReadError(err error) {
 
 		if IsFatalCloseCode(int(closeStatus)) {
 			if c.OnError != nil {
-				c.OnError(fmt.Errorf("%w: code %d", ErrFatalClose, closeStatus))
+				c.OnError(&CloseError{Code: int(closeStatus)})
 			}
 		} else {
 			if c.OnDisconnect != nil {
@@ -614,24 +1021,35 @@ func (c *Client) handleReadError(err error) {
 }
 
 func (c *Client) startHeartbeat(ctx context.Context) {
-	c.mu.RLock()
+	c.mu.Lock()
 	interval := c.heartbeatInterval
-	stopChan := c.heartbeatStop
-	c.mu.RUnlock()
+	var stopChan chan struct{}
+	if c.lifecycle != nil {
+		stopChan = c.lifecycle.heartbeatStop
+	}
+	maxMissed := c.maxMissedAcks
+	immediate := c.immediateFirstHeartbeat
+	c.unackedHeartbeats = 0
+	c.mu.Unlock()
 
 	if interval == 0 {
 		return
 	}
+	if maxMissed <= 0 {
+		maxMissed = DefaultMaxMissedHeartbeatAcks
+	}
 
-	jitterDuration := randomJitter(interval * 2)
-	c.logger.Debug("Waiting before first heartbeat", "jitter", jitterDuration)
+	if !immediate {
+		jitterDuration := randomJitter(interval * 2)
+		c.logger.Debug("Waiting before first heartbeat", "jitter", jitterDuration)
 
-	select {
-	case <-stopChan:
-		return
-	case <-ctx.Done():
-		return
-	case <-time.After(jitterDuration):
+		select {
+		case <-stopChan:
+			return
+		case <-ctx.Done():
+			return
+		case <-time.After(jitterDuration):
+		}
 	}
 
 	if err := c.SendHeartbeat(ctx); err != nil {
@@ -639,18 +1057,12 @@ func (c *Client) startHeartbeat(ctx context.Context) {
 		return
 	}
 
+	ticker := time.NewTicker(interval)
 	c.mu.Lock()
-	c.lastHeartbeatAck = time.Now()
-	c.heartbeatTicker = time.NewTicker(interval)
+	c.heartbeatTicker = ticker
 	c.mu.Unlock()
 
-	defer func() {
-		c.mu.Lock()
-		if c.heartbeatTicker != nil {
-			c.heartbeatTicker.Stop()
-		}
-		c.mu.Unlock()
-	}()
+	defer ticker.Stop()
 
 	for {
 		select {
@@ -658,19 +1070,16 @@ func (c *Client) startHeartbeat(ctx context.Context) {
 			return
 		case <-ctx.Done():
 			return
-		case <-c.heartbeatTicker.C:
+		case <-ticker.C:
+			// Counting beats rather than comparing against the last ACK time
+			// is not fooled by GC pauses, clock jumps, or a delayed ticker.
 			c.mu.RLock()
-			lastAck := c.lastHeartbeatAck
+			unacked := c.unackedHeartbeats
 			c.mu.RUnlock()
 
-			if time.Since(lastAck) > interval*2 {
-				c.logger.Warn("Missed heartbeat ACK, connection may be dead")
-				c.mu.RLock()
-				conn := c.conn
-				c.mu.RUnlock()
-				if conn != nil {
-					_ = conn.Close(websocket.StatusProtocolError, "missed heartbeat ACK")
-				}
+			if unacked >= maxMissed {
+				c.logger.Warn("Missed heartbeat ACKs, connection may be dead", "unacked", unacked)
+				c.closeConn(websocket.StatusProtocolError, "missed heartbeat ACK")
 				return
 			}
 
@@ -706,14 +1115,65 @@ func (c *Client) SessionID() string {
 	return c.sessionID
 }
 
-func (c *Client) Sequence() int {
+// LastHeartbeatAck returns when the last heartbeat ACK was received, or the
+// zero time if none has been on this connection yet.
+func (c *Client) LastHeartbeatAck() time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.lastHeartbeatAck
+}
+
+// HeartbeatLatency returns the round-trip time between the last heartbeat
+// sent and its ACK, or zero if no heartbeat has been acknowledged yet.
+func (c *Client) HeartbeatLatency() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.heartbeatLatency
+}
+
+func (c *Client) Sequence() int64 {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 	return c.sequence
 }
 
+// ClientStats returns the timing breakdown of the most recent connect. Phases
+// that have not completed yet are reported as zero.
+func (c *Client) ClientStats() ClientStats {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var stats ClientStats
+	if !c.connectStartedAt.IsZero() && !c.dialedAt.IsZero() {
+		stats.Dial = c.dialedAt.Sub(c.connectStartedAt)
+	}
+	if !c.dialedAt.IsZero() && !c.helloAt.IsZero() {
+		stats.TimeToHello = c.helloAt.Sub(c.dialedAt)
+	}
+	if !c.helloAt.IsZero() && !c.readyAt.IsZero() {
+		stats.TimeToReady = c.readyAt.Sub(c.helloAt)
+	}
+	if !c.connectStartedAt.IsZero() && !c.readyAt.IsZero() {
+		stats.Total = c.readyAt.Sub(c.connectStartedAt)
+	}
+	return stats
+}
+
+// ConnectedAt returns when the current connection reached READY or RESUMED,
+// or the zero time if it has not.
+func (c *Client) ConnectedAt() time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.readyAt
+}
+
+// Disconnected returns a channel closed once the current connection's read
+// loop has exited, or nil before the first Connect.
 func (c *Client) Disconnected() <-chan struct{} {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
-	return c.disconnected
+	if c.lifecycle == nil {
+		return nil
+	}
+	return c.lifecycle.disconnected
 }
@@ -32,6 +32,16 @@ type mockGatewayServer struct {
 	sendInvalidOnIdent bool
 	closeOnConnect     bool
 	closeCode          websocket.StatusCode
+	helloDelay         time.Duration
+	readyDelay         time.Duration
+
+	identifyPayload json.RawMessage
+	resumePayload   json.RawMessage
+	presencePayload json.RawMessage
+
+	// ackLimit stops acknowledging heartbeats after this many; zero
+	// acknowledges all of them.
+	ackLimit int
 }
 
 func newMockGatewayServer(t *testing.T) *mockGatewayServer {
@@ -60,6 +70,8 @@ func newMockGatewayServer(t *testing.T) *mockGatewayServer {
 			return
 		}
 
+		time.Sleep(mock.helloDelay)
+
 		// Send HELLO
 		hello := map[string]any{
 			"op": OpHello,
@@ -112,10 +124,15 @@ func (m *mockGatewayServer) handleMessage(ctx context.Context, data []byte) {
 	conn := m.conn
 	sendReadyOnIdent := m.sendReadyOnIdent
 	sendInvalidOnIdent := m.sendInvalidOnIdent
+	readyDelay := m.readyDelay
 	m.mu.Unlock()
 
 	switch msg.Op {
 	case OpIdentify:
+		m.mu.Lock()
+		m.identifyPayload = msg.Data
+		m.mu.Unlock()
+
 		if sendInvalidOnIdent {
 			invalid := map[string]any{
 				"op": OpInvalidSession,
@@ -124,6 +141,7 @@ func (m *mockGatewayServer) handleMessage(ctx context.Context, data []byte) {
 			data, _ := json.Marshal(invalid)
 			_ = conn.Write(ctx, websocket.MessageText, data)
 		} else if sendReadyOnIdent {
+			time.Sleep(readyDelay)
 			ready := map[string]any{
 				"op": OpDispatch,
 				"t":  "READY",
@@ -141,7 +159,11 @@ func (m *mockGatewayServer) handleMessage(ctx context.Context, data []byte) {
 	case OpHeartbeat:
 		m.mu.Lock()
 		m.heartbeatCount++
+		withheld := m.ackLimit > 0 && m.heartbeatCount > m.ackLimit
 		m.mu.Unlock()
+		if withheld {
+			return
+		}
 
 		ack := map[string]any{
 			"op": OpHeartbeatAck,
@@ -149,8 +171,23 @@ func (m *mockGatewayServer) handleMessage(ctx context.Context, data []byte) {
 		ackData, _ := json.Marshal(ack)
 		_ = conn.Write(ctx, websocket.MessageText, ackData)
 
+	case OpResume:
+		m.mu.Lock()
+		m.resumePayload = msg.Data
+		m.mu.Unlock()
+
+		resumed := map[string]any{
+			"op": OpDispatch,
+			"t":  "RESUMED",
+			"s":  2,
+		}
+		data, _ := json.Marshal(resumed)
+		_ = conn.Write(ctx, websocket.MessageText, data)
+
 	case OpPresenceUpdate:
-		// No response needed for presence update
+		m.mu.Lock()
+		m.presencePayload = msg.Data
+		m.mu.Unlock()
 
 	case OpVoiceStateUpdate:
 		// No response needed for voice state update
@@ -373,7 +410,7 @@ func TestHandleInvalidSession(t *testing.T) {
 	}
 
 	// Non-resumable session
-	client.handleInvalidSession(json.RawMessage(`false`))
+	client.handleInvalidSession(context.Background(), json.RawMessage(`false`))
 
 	if !errorCalled {
 		t.Error("expected OnError callback to be called")
@@ -393,12 +430,15 @@ func TestHandleInvalidSessionResumable(t *testing.T) {
 	client.sessionID = testOldSession
 	client.sequence = 100
 
-	client.OnError = func(_ error) {
-		// No-op: callback required to prevent nil panic but error handling not tested here
-	}
+	errorCalled := false
+	client.OnError = func(_ error) { errorCalled = true }
 
 	// Resumable session
-	client.handleInvalidSession(json.RawMessage(`true`))
+	client.handleInvalidSession(context.Background(), json.RawMessage(`true`))
+
+	if errorCalled {
+		t.Error("expected a resumable invalid session to be resumed, not reported as an error")
+	}
 
 	if client.SessionID() != testOldSession {
 		t.Errorf("expected sessionID to be preserved, got '%s'", client.SessionID())
@@ -424,8 +464,8 @@ func TestHandleHello(t *testing.T) {
 	defer func() { _ = conn.Close(websocket.StatusNormalClosure, "") }()
 
 	client := NewClient(testTokenClient, nil)
-	client.conn = conn
-	client.heartbeatStop = make(chan struct{})
+	client.attachConn(conn)
+	client.lifecycle = newConnLifecycle()
 
 	// Read HELLO from mock server
 	_, data, err := conn.Read(ctx)
@@ -453,7 +493,7 @@ func TestHandleHello(t *testing.T) {
 	}
 
 	// Stop heartbeat
-	close(client.heartbeatStop)
+	client.lifecycle.stop()
 }
 
 func TestSequenceUpdates(t *testing.T) {
@@ -468,6 +508,47 @@ func TestSequenceUpdates(t *testing.T) {
 	}
 }
 
+// largeSequence does not fit in 32 bits and is not exactly representable
+// as a float64.
+const largeSequence int64 = 1<<53 + 1
+
+func TestLargeSequenceSurvivesResume(t *testing.T) {
+	client := NewClient(testTokenClient, nil)
+	_ = client.handleMessage(context.Background(), []byte(`{"op": 0, "s": 9007199254740993, "t": "UNKNOWN_EVENT", "d": {}}`))
+	if client.Sequence() != largeSequence {
+		t.Fatalf("expected sequence %d, got %d", largeSequence, client.Sequence())
+	}
+
+	mock := newMockGatewayServer(t)
+	defer mock.Close()
+
+	resumed := NewClient(testTokenClient, nil)
+	resumed.SetGatewayURL(mock.URL())
+	resumed.SetResumeData(testOldSession, largeSequence, "")
+	ready := make(chan struct{}, 1)
+	resumed.OnReady = func(string) { ready <- struct{}{} }
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := resumed.Connect(ctx); err != nil {
+		t.Fatalf(errFailedToConnectFmt, err)
+	}
+	defer func() { _ = resumed.Close() }()
+
+	select {
+	case <-ready:
+	case <-ctx.Done():
+		t.Fatal("timeout waiting for RESUMED")
+	}
+
+	mock.mu.Lock()
+	payload := mock.resumePayload
+	mock.mu.Unlock()
+	if !strings.Contains(string(payload), `"seq":9007199254740993`) {
+		t.Errorf("expected the exact sequence in RESUME, got %s", payload)
+	}
+}
+
 func TestConnectToMockServer(t *testing.T) {
 	mock := newMockGatewayServer(t)
 	defer mock.Close()
@@ -475,7 +556,7 @@ func TestConnectToMockServer(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	// Connect directly (we test the mock server, as real Connect uses hardcoded URL)
+	// Connect directly to test the mock server itself
 	conn, _, err := websocket.Dial(ctx, mock.URL(), nil)
 	if err != nil {
 		t.Fatalf(errFailedToConnectFmt, err)
@@ -511,20 +592,15 @@ func TestCloseWithActiveConnection(t *testing.T) {
 	}
 
 	client := NewClient(testTokenClient, nil)
-	client.conn = conn
+	client.attachConn(conn)
 	client.state = StateConnected
-	client.heartbeatStop = make(chan struct{})
-	client.readStop = make(chan struct{})
-	client.readDone = make(chan struct{})
+	lifecycle := newConnLifecycle()
+	client.lifecycle = lifecycle
 
-	// Capture channels before starting goroutine to avoid race
-	readStop := client.readStop
-	readDone := client.readDone
-
-	// Start a goroutine to close readDone when readStop is closed
+	// Stand in for the read loop, finishing once Close stops it
 	go func() {
-		<-readStop
-		close(readDone)
+		<-lifecycle.readStop
+		lifecycle.finish()
 	}()
 
 	stateChanged := make(chan struct{})
@@ -568,7 +644,7 @@ func TestSendIdentifyWithConnection(t *testing.T) {
 	_, _, _ = conn.Read(ctx)
 
 	client := NewClient(testTokenClient, nil)
-	client.conn = conn
+	client.attachConn(conn)
 
 	err = client.SendIdentify(ctx)
 	if err != nil {
@@ -609,7 +685,7 @@ func TestSendHeartbeatWithConnection(t *testing.T) {
 	_, _, _ = conn.Read(ctx)
 
 	client := NewClient(testTokenClient, nil)
-	client.conn = conn
+	client.attachConn(conn)
 	client.sequence = 42
 
 	err = client.SendHeartbeat(ctx)
@@ -650,7 +726,7 @@ func TestSendPresenceUpdateWithConnection(t *testing.T) {
 	_, _, _ = conn.Read(ctx)
 
 	client := NewClient(testTokenClient, nil)
-	client.conn = conn
+	client.attachConn(conn)
 
 	err = client.SendPresenceUpdate(ctx, "online")
 	if err != nil {
@@ -675,7 +751,7 @@ func TestSendVoiceStateUpdateWithConnection(t *testing.T) {
 	_, _, _ = conn.Read(ctx)
 
 	client := NewClient(testTokenClient, nil)
-	client.conn = conn
+	client.attachConn(conn)
 
 	// With channel ID
 	err = client.SendVoiceStateUpdate(ctx, "guild123", "channel123", true, false)
@@ -793,3 +869,544 @@ func TestHandleHelloInvalidJSON(t *testing.T) {
 		t.Error("expected error for invalid HELLO JSON")
 	}
 }
+
+func TestClientStatsMeasuresConnectPhases(t *testing.T) {
+	mock := newMockGatewayServer(t)
+	mock.helloDelay = 100 * time.Millisecond
+	mock.readyDelay = 150 * time.Millisecond
+	defer mock.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client := NewClient(testTokenClient, nil)
+	ready := make(chan struct{})
+	client.OnReady = func(string) { close(ready) }
+
+	client.connectStartedAt = time.Now()
+	conn, _, err := websocket.Dial(ctx, mock.URL(), nil)
+	if err != nil {
+		t.Fatalf(errFailedToConnectFmt, err)
+	}
+	client.dialedAt = time.Now()
+	client.attachConn(conn)
+	lifecycle := newConnLifecycle()
+	client.lifecycle = lifecycle
+	client.state = StateConnecting
+
+	go client.readLoop(ctx, lifecycle, nil)
+
+	select {
+	case <-ready:
+	case <-ctx.Done():
+		t.Fatal("timeout waiting for READY")
+	}
+
+	stats := client.ClientStats()
+	assertPhase(t, "TimeToHello", stats.TimeToHello, mock.helloDelay)
+	assertPhase(t, "TimeToReady", stats.TimeToReady, mock.readyDelay)
+	if stats.Total < stats.Dial+stats.TimeToHello+stats.TimeToReady {
+		t.Errorf("expected Total %v to cover all phases %+v", stats.Total, stats)
+	}
+
+	_ = client.Close()
+}
+
+func TestClientStatsBeforeConnect(t *testing.T) {
+	client := NewClient(testTokenClient, nil)
+	if stats := client.ClientStats(); stats != (ClientStats{}) {
+		t.Errorf("expected zero stats before connect, got %+v", stats)
+	}
+}
+
+func assertPhase(t *testing.T, name string, got, want time.Duration) {
+	t.Helper()
+	if got < want || got > want+250*time.Millisecond {
+		t.Errorf("expected %s around %v, got %v", name, want, got)
+	}
+}
+
+func TestNormalizeStatus(t *testing.T) {
+	client := NewClient(testTokenClient, nil)
+
+	tests := map[string]string{
+		"":          "online",
+		"  ":        "online",
+		" idle ":    "idle",
+		"invisible": "invisible",
+		"bogus":     "online",
+	}
+	for input, want := range tests {
+		if got := client.normalizeStatus(input); got != want {
+			t.Errorf("normalizeStatus(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestHeartbeatRequestResetsTicker(t *testing.T) {
+	mock := newMockGatewayServer(t)
+	defer mock.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn, _, err := websocket.Dial(ctx, mock.URL(), nil)
+	if err != nil {
+		t.Fatalf(errFailedToConnectFmt, err)
+	}
+	defer func() { _ = conn.Close(websocket.StatusNormalClosure, "") }()
+
+	interval := 300 * time.Millisecond
+	client := NewClient(testTokenClient, nil)
+	client.attachConn(conn)
+	client.heartbeatInterval = interval
+	client.heartbeatTicker = time.NewTicker(interval)
+	defer client.heartbeatTicker.Stop()
+
+	time.Sleep(200 * time.Millisecond)
+	if err := client.handleMessage(ctx, []byte(`{"op": 1}`)); err != nil {
+		t.Fatalf("handleMessage returned error: %v", err)
+	}
+	requestedAt := time.Now()
+
+	select {
+	case tick := <-client.heartbeatTicker.C:
+		if since := tick.Sub(requestedAt); since < interval-50*time.Millisecond {
+			t.Errorf("expected next tick a full interval after the requested heartbeat, got %v", since)
+		}
+	case <-time.After(2 * interval):
+		t.Fatal("ticker did not fire after reset")
+	}
+}
+
+func TestHeartbeatRequestFailureClosesConnection(t *testing.T) {
+	mock := newMockGatewayServer(t)
+	defer mock.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn, _, err := websocket.Dial(ctx, mock.URL(), nil)
+	if err != nil {
+		t.Fatalf(errFailedToConnectFmt, err)
+	}
+
+	client := NewClient(testTokenClient, nil)
+	client.attachConn(conn)
+
+	failedCtx, failCancel := context.WithCancel(ctx)
+	failCancel()
+	client.handleHeartbeatRequest(failedCtx)
+
+	readCtx, readCancel := context.WithTimeout(ctx, time.Second)
+	defer readCancel()
+	for {
+		if _, _, err := conn.Read(readCtx); err != nil {
+			if readCtx.Err() != nil {
+				t.Error("expected connection to be closed after a failed requested heartbeat")
+			}
+			return
+		}
+	}
+}
+
+func TestCurrentReadTimeout(t *testing.T) {
+	client := NewClient(testTokenClient, nil)
+	if got := client.currentReadTimeout(); got != DefaultReadTimeout {
+		t.Errorf("expected %v before HELLO, got %v", DefaultReadTimeout, got)
+	}
+
+	client.heartbeatInterval = 45 * time.Second
+	if got := client.currentReadTimeout(); got != 135*time.Second {
+		t.Errorf("expected timeout derived from heartbeat interval, got %v", got)
+	}
+
+	client.SetReadTimeout(5 * time.Second)
+	if got := client.currentReadTimeout(); got != 5*time.Second {
+		t.Errorf("expected override, got %v", got)
+	}
+}
+
+func TestIdleConnectionIsNotDisconnected(t *testing.T) {
+	mock := newMockGatewayServer(t)
+	defer mock.Close()
+
+	client := NewClient(testTokenClient, nil)
+	client.SetGatewayURL(mock.URL())
+	// Heartbeats run every 100ms; no dispatch events follow READY.
+	client.SetReadTimeout(300 * time.Millisecond)
+	disconnects := make(chan string, 1)
+	client.OnDisconnect = func(_ int, reason string) { disconnects <- reason }
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf(errFailedToConnectFmt, err)
+	}
+	defer func() { _ = client.Close() }()
+
+	select {
+	case reason := <-disconnects:
+		t.Fatalf("idle connection was disconnected: %s", reason)
+	case <-time.After(1500 * time.Millisecond):
+	}
+	if client.State() != StateConnected {
+		t.Errorf("expected connection to stay up, state = %d", client.State())
+	}
+}
+
+func TestSilentConnectionReportsReadTimeout(t *testing.T) {
+	mock := newMockGatewayServer(t)
+	mock.heartbeatInterval = 60000
+	defer mock.Close()
+
+	client := NewClient(testTokenClient, nil)
+	client.SetGatewayURL(mock.URL())
+	client.SetReadTimeout(200 * time.Millisecond)
+	disconnects := make(chan string, 1)
+	client.OnDisconnect = func(_ int, reason string) { disconnects <- reason }
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf(errFailedToConnectFmt, err)
+	}
+	defer func() { _ = client.Close() }()
+
+	select {
+	case reason := <-disconnects:
+		if reason != "read timeout" {
+			t.Errorf("expected read timeout, got %q", reason)
+		}
+	case <-ctx.Done():
+		t.Fatal("expected a silent connection to hit the read timeout")
+	}
+}
+
+func TestImmediateFirstHeartbeat(t *testing.T) {
+	mock := newMockGatewayServer(t)
+	// With jitter the first heartbeat could wait up to two minutes.
+	mock.heartbeatInterval = 60000
+	defer mock.Close()
+
+	client := NewClient(testTokenClient, nil)
+	client.SetGatewayURL(mock.URL())
+	client.SetImmediateFirstHeartbeat(true)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf(errFailedToConnectFmt, err)
+	}
+	defer func() { _ = client.Close() }()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		mock.mu.Lock()
+		count := mock.heartbeatCount
+		mock.mu.Unlock()
+		if count == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the first heartbeat right after HELLO, got %d heartbeats", count)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestMissedHeartbeatAcksCloseConnection(t *testing.T) {
+	mock := newMockGatewayServer(t)
+	mock.ackLimit = 3
+	defer mock.Close()
+
+	client := NewClient(testTokenClient, nil)
+	client.SetGatewayURL(mock.URL())
+	client.SetMaxMissedHeartbeatAcks(2)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf(errFailedToConnectFmt, err)
+	}
+	defer func() { _ = client.Close() }()
+
+	select {
+	case <-client.Disconnected():
+	case <-ctx.Done():
+		t.Fatal("expected the connection to close after unacknowledged heartbeats")
+	}
+
+	// Give the mock time to process anything still in flight.
+	time.Sleep(50 * time.Millisecond)
+	mock.mu.Lock()
+	sent := mock.heartbeatCount
+	mock.mu.Unlock()
+	if sent != 5 {
+		t.Errorf("expected 3 acknowledged and 2 unacknowledged heartbeats before closing, got %d", sent)
+	}
+}
+
+func TestHeartbeatLatency(t *testing.T) {
+	mock := newMockGatewayServer(t)
+	defer mock.Close()
+
+	client := NewClient(testTokenClient, nil)
+	client.SetGatewayURL(mock.URL())
+	acks := make(chan time.Duration, 10)
+	client.OnHeartbeatAck = func(rtt time.Duration) { acks <- rtt }
+
+	if latency := client.HeartbeatLatency(); latency != 0 {
+		t.Errorf("expected zero latency before any ACK, got %v", latency)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf(errFailedToConnectFmt, err)
+	}
+	defer func() { _ = client.Close() }()
+
+	select {
+	case <-acks:
+		if latency := client.HeartbeatLatency(); latency <= 0 {
+			t.Errorf("expected a positive latency after an ACK, got %v", latency)
+		}
+	case <-ctx.Done():
+		t.Fatal("timeout waiting for a heartbeat ACK")
+	}
+}
+
+func TestResumableInvalidSessionResumesAfterDelay(t *testing.T) {
+	mock := newMockGatewayServer(t)
+	defer mock.Close()
+
+	delay := 200 * time.Millisecond
+	client := NewClient(testTokenClient, nil)
+	client.SetGatewayURL(mock.URL())
+	client.SetInvalidSessionDelay(delay)
+	ready := make(chan string, 2)
+	client.OnReady = func(sessionID string) { ready <- sessionID }
+	client.OnError = func(err error) { t.Errorf("unexpected OnError: %v", err) }
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf(errFailedToConnectFmt, err)
+	}
+	defer func() { _ = client.Close() }()
+
+	select {
+	case <-ready:
+	case <-ctx.Done():
+		t.Fatal("timeout waiting for READY")
+	}
+
+	mock.mu.Lock()
+	conn := mock.conn
+	mock.mu.Unlock()
+	invalidatedAt := time.Now()
+	if err := conn.Write(ctx, websocket.MessageText, []byte(`{"op": 9, "d": true}`)); err != nil {
+		t.Fatalf("failed to send invalid session: %v", err)
+	}
+
+	select {
+	case sessionID := <-ready:
+		if sessionID != "test-session-123" {
+			t.Errorf("expected the session to resume, got %q", sessionID)
+		}
+	case <-ctx.Done():
+		t.Fatal("timeout waiting for RESUMED")
+	}
+	if elapsed := time.Since(invalidatedAt); elapsed < delay {
+		t.Errorf("expected RESUME after %v, sent after %v", delay, elapsed)
+	}
+
+	mock.mu.Lock()
+	payload := mock.resumePayload
+	mock.mu.Unlock()
+	var resume ResumeData
+	if err := json.Unmarshal(payload, &resume); err != nil {
+		t.Fatalf("expected a RESUME payload: %v", err)
+	}
+	if resume.SessionID != "test-session-123" || resume.Sequence != 1 {
+		t.Errorf("unexpected RESUME payload: %+v", resume)
+	}
+	if client.State() != StateConnected {
+		t.Errorf("expected the client to stay connected, got state %d", client.State())
+	}
+}
+
+func TestIdentifyIntents(t *testing.T) {
+	tests := []struct {
+		name    string
+		intents int
+	}{
+		{name: "unset", intents: 0},
+		{name: "bot", intents: IntentGuilds | IntentGuildVoiceStates | IntentGuildPresences},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := newMockGatewayServer(t)
+			defer mock.Close()
+
+			client := NewClient(testTokenClient, nil)
+			client.SetGatewayURL(mock.URL())
+			client.SetIntents(tt.intents)
+			ready := make(chan struct{})
+			client.OnReady = func(string) { close(ready) }
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := client.Connect(ctx); err != nil {
+				t.Fatalf(errFailedToConnectFmt, err)
+			}
+			defer func() { _ = client.Close() }()
+
+			select {
+			case <-ready:
+			case <-ctx.Done():
+				t.Fatal("timeout waiting for READY")
+			}
+
+			mock.mu.Lock()
+			payload := mock.identifyPayload
+			mock.mu.Unlock()
+			var fields map[string]json.RawMessage
+			if err := json.Unmarshal(payload, &fields); err != nil {
+				t.Fatalf("expected an IDENTIFY payload: %v", err)
+			}
+			raw, ok := fields["intents"]
+			if tt.intents == 0 {
+				if ok {
+					t.Errorf("expected no intents field, got %s", raw)
+				}
+				return
+			}
+			if string(raw) != "385" {
+				t.Errorf("expected intents 385, got %s", raw)
+			}
+		})
+	}
+}
+
+func TestCustomStatusInIdentifyAndPresence(t *testing.T) {
+	mock := newMockGatewayServer(t)
+	defer mock.Close()
+
+	custom := NewActivity(ActivityCustom, "Watching the servers")
+	client := NewClient(testTokenClient, nil)
+	client.SetGatewayURL(mock.URL())
+	client.SetActivity(&custom)
+	ready := make(chan struct{})
+	client.OnReady = func(string) { close(ready) }
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf(errFailedToConnectFmt, err)
+	}
+	defer func() { _ = client.Close() }()
+
+	select {
+	case <-ready:
+	case <-ctx.Done():
+		t.Fatal("timeout waiting for READY")
+	}
+
+	mock.mu.Lock()
+	payload := mock.identifyPayload
+	mock.mu.Unlock()
+	var identify IdentifyData
+	if err := json.Unmarshal(payload, &identify); err != nil {
+		t.Fatalf("expected an IDENTIFY payload: %v", err)
+	}
+	if identify.Presence == nil || len(identify.Presence.Activities) != 1 || identify.Presence.Activities[0] != custom {
+		t.Fatalf("expected the custom status in IDENTIFY, got %s", payload)
+	}
+
+	waitForPresence := func() PresenceData {
+		t.Helper()
+		for {
+			mock.mu.Lock()
+			payload := mock.presencePayload
+			mock.presencePayload = nil
+			mock.mu.Unlock()
+			if payload != nil {
+				var presence PresenceData
+				if err := json.Unmarshal(payload, &presence); err != nil {
+					t.Fatalf("invalid presence payload %s: %v", payload, err)
+				}
+				return presence
+			}
+			select {
+			case <-ctx.Done():
+				t.Fatal("timeout waiting for a presence update")
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+	}
+
+	// Plain presence updates keep the configured activity.
+	if err := client.SendPresenceUpdate(ctx, "idle"); err != nil {
+		t.Fatalf("SendPresenceUpdate() error = %v", err)
+	}
+	if presence := waitForPresence(); presence.Status != "idle" || len(presence.Activities) != 1 || presence.Activities[0].State != "Watching the servers" {
+		t.Errorf("expected idle with the custom status, got %+v", presence)
+	}
+
+	playing := Activity{Name: "chess", Type: ActivityPlaying, Details: "Ranked"}
+	if err := client.SendPresenceUpdateWithActivity(ctx, "dnd", playing); err != nil {
+		t.Fatalf("SendPresenceUpdateWithActivity() error = %v", err)
+	}
+	if presence := waitForPresence(); presence.Status != "dnd" || len(presence.Activities) != 1 || presence.Activities[0] != playing {
+		t.Errorf("expected dnd playing chess, got %+v", presence)
+	}
+}
+
+func TestIdentifyShard(t *testing.T) {
+	tests := []struct {
+		name  string
+		shard *Shard
+		want  string
+	}{
+		{name: "unset", shard: nil, want: ""},
+		{name: "single", shard: &Shard{ID: 0, Total: 1}, want: "[0,1]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := newMockGatewayServer(t)
+			defer mock.Close()
+
+			client := NewClient(testTokenClient, nil)
+			client.SetGatewayURL(mock.URL())
+			client.SetShard(tt.shard)
+			ready := make(chan struct{})
+			client.OnReady = func(string) { close(ready) }
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := client.Connect(ctx); err != nil {
+				t.Fatalf(errFailedToConnectFmt, err)
+			}
+			defer func() { _ = client.Close() }()
+
+			select {
+			case <-ready:
+			case <-ctx.Done():
+				t.Fatal("timeout waiting for READY")
+			}
+
+			mock.mu.Lock()
+			payload := mock.identifyPayload
+			mock.mu.Unlock()
+			var fields map[string]json.RawMessage
+			if err := json.Unmarshal(payload, &fields); err != nil {
+				t.Fatalf("expected an IDENTIFY payload: %v", err)
+			}
+			if got := string(fields["shard"]); got != tt.want {
+				t.Errorf("IDENTIFY shard = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package gateway
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+)
+
+// dialTimeout bounds establishing the TCP connection to the Gateway.
+const dialTimeout = 30 * time.Second
+
+// contextDialer opens the TCP connection underneath a Gateway WebSocket.
+type contextDialer interface {
+	DialContext(ctx context.Context, network, address string) (net.Conn, error)
+}
+
+// newNetDialer returns a net.Dialer that sends TCP keep-alive probes every
+// keepAlive. Zero uses the Go default (15s) and a negative value disables
+// keep-alive.
+func newNetDialer(keepAlive time.Duration) contextDialer {
+	return &net.Dialer{
+		Timeout:   dialTimeout,
+		KeepAlive: keepAlive,
+	}
+}
+
+// SetKeepAlive sets the TCP keep-alive interval for new connections so the
+// OS notices a peer silently dropped by a NAT or firewall. Zero uses the Go
+// default and a negative value disables keep-alive.
+func (c *Client) SetKeepAlive(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.keepAlive = d
+}
+
+// httpClient builds the client used for the WebSocket handshake, dialing
+// through a keep-alive configured dialer.
+func (c *Client) httpClient() *http.Client {
+	c.mu.RLock()
+	keepAlive := c.keepAlive
+	newDialer := c.newDialer
+	c.mu.RUnlock()
+
+	if newDialer == nil {
+		newDialer = newNetDialer
+	}
+
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy:               http.ProxyFromEnvironment,
+			DialContext:         newDialer(keepAlive).DialContext,
+			TLSHandshakeTimeout: 10 * time.Second,
+		},
+	}
+}
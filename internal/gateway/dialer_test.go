@@ -0,0 +1,72 @@
+package gateway
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingDialer records the keep-alive it was built with and every address
+// it dials.
+type recordingDialer struct {
+	mu        sync.Mutex
+	keepAlive []time.Duration
+	dialed    []string
+}
+
+func (r *recordingDialer) build(keepAlive time.Duration) contextDialer {
+	r.mu.Lock()
+	r.keepAlive = append(r.keepAlive, keepAlive)
+	r.mu.Unlock()
+	return dialFunc(func(ctx context.Context, network, address string) (net.Conn, error) {
+		r.mu.Lock()
+		r.dialed = append(r.dialed, address)
+		r.mu.Unlock()
+		return newNetDialer(keepAlive).DialContext(ctx, network, address)
+	})
+}
+
+type dialFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+func (f dialFunc) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	return f(ctx, network, address)
+}
+
+func TestConnectUsesKeepAliveDialer(t *testing.T) {
+	mock := newMockGatewayServer(t)
+	defer mock.Close()
+
+	recorder := &recordingDialer{}
+	client := NewClient(testTokenClient, nil)
+	client.SetGatewayURL(mock.URL())
+	client.SetKeepAlive(7 * time.Second)
+	client.newDialer = recorder.build
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf(errFailedToConnectFmt, err)
+	}
+	defer func() { _ = client.Close() }()
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	if len(recorder.keepAlive) != 1 || recorder.keepAlive[0] != 7*time.Second {
+		t.Errorf("expected dialer built with 7s keep-alive, got %v", recorder.keepAlive)
+	}
+	if len(recorder.dialed) != 1 {
+		t.Errorf("expected the Gateway connection to go through the dialer, dialed %v", recorder.dialed)
+	}
+}
+
+func TestNewNetDialerKeepAlive(t *testing.T) {
+	d, ok := newNetDialer(-1).(*net.Dialer)
+	if !ok {
+		t.Fatalf("expected *net.Dialer, got %T", newNetDialer(-1))
+	}
+	if d.KeepAlive != -1 || d.Timeout != dialTimeout {
+		t.Errorf("unexpected dialer configuration: keep-alive %v, timeout %v", d.KeepAlive, d.Timeout)
+	}
+}
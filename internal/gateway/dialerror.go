@@ -0,0 +1,96 @@
+package gateway
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// DialFailure classifies why dialing the Gateway failed.
+type DialFailure string
+
+const (
+	// DialFailureDNS is a failed lookup of the Gateway host.
+	DialFailureDNS DialFailure = "dns"
+	// DialFailureTLS is a failed TLS handshake or certificate check.
+	DialFailureTLS DialFailure = "tls"
+	// DialFailureRateLimited is a 429 answer to the WebSocket handshake.
+	DialFailureRateLimited DialFailure = "rate_limited"
+	// DialFailureRejected is any other 4xx answer to the handshake, such as
+	// a 403 from an edge proxy blocking the address.
+	DialFailureRejected DialFailure = "rejected"
+	// DialFailureServerError is a 5xx answer to the handshake.
+	DialFailureServerError DialFailure = "server_error"
+	// DialFailureNetwork is any other failure: refused or reset
+	// connections, timeouts, and unexpected handshake answers.
+	DialFailureNetwork DialFailure = "network"
+)
+
+// DialError reports a failed Gateway dial with its classification.
+type DialError struct {
+	Kind DialFailure
+	// StatusCode is the HTTP status of a rejected handshake, zero when the
+	// server never answered.
+	StatusCode int
+	// RetryAfter is the handshake's Retry-After header, zero when absent.
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (e *DialError) Error() string {
+	if e.StatusCode != 0 {
+		return fmt.Sprintf("dial gateway (%s, HTTP %d): %v", e.Kind, e.StatusCode, e.Err)
+	}
+	return fmt.Sprintf("dial gateway (%s): %v", e.Kind, e.Err)
+}
+
+func (e *DialError) Unwrap() error {
+	return e.Err
+}
+
+// newDialError classifies a failed dial from its error and, when the server
+// answered the handshake, its response.
+func newDialError(resp *http.Response, err error) *DialError {
+	e := &DialError{Kind: DialFailureNetwork, Err: err}
+	if resp != nil && resp.StatusCode != http.StatusSwitchingProtocols {
+		e.StatusCode = resp.StatusCode
+		switch {
+		case resp.StatusCode == http.StatusTooManyRequests:
+			e.Kind = DialFailureRateLimited
+			e.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
+		case resp.StatusCode >= 500:
+			e.Kind = DialFailureServerError
+		case resp.StatusCode >= 400:
+			e.Kind = DialFailureRejected
+		}
+		return e
+	}
+
+	var dnsErr *net.DNSError
+	var certErr *tls.CertificateVerificationError
+	var recordErr tls.RecordHeaderError
+	var authorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	switch {
+	case errors.As(err, &dnsErr):
+		e.Kind = DialFailureDNS
+	case errors.As(err, &certErr), errors.As(err, &recordErr),
+		errors.As(err, &authorityErr), errors.As(err, &hostnameErr):
+		e.Kind = DialFailureTLS
+	}
+	return e
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds.
+func parseRetryAfter(value string) time.Duration {
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
@@ -0,0 +1,87 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// dialStatus connects a client to a server that answers the handshake with
+// the given status and headers, and returns the dial error.
+func dialStatus(t *testing.T, status int, header http.Header) *DialError {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		for k, v := range header {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	client := NewClient("token", nil)
+	client.SetGatewayURL("ws" + strings.TrimPrefix(server.URL, "http"))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := client.Connect(ctx)
+	var dialErr *DialError
+	if !errors.As(err, &dialErr) {
+		t.Fatalf("expected a *DialError, got %v", err)
+	}
+	return dialErr
+}
+
+func TestDialErrorRateLimited(t *testing.T) {
+	dialErr := dialStatus(t, http.StatusTooManyRequests, http.Header{"Retry-After": {"30"}})
+	if dialErr.Kind != DialFailureRateLimited {
+		t.Errorf("expected kind %q, got %q", DialFailureRateLimited, dialErr.Kind)
+	}
+	if dialErr.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("expected status 429, got %d", dialErr.StatusCode)
+	}
+	if dialErr.RetryAfter != 30*time.Second {
+		t.Errorf("expected Retry-After of 30s, got %v", dialErr.RetryAfter)
+	}
+}
+
+func TestDialErrorRejected(t *testing.T) {
+	dialErr := dialStatus(t, http.StatusForbidden, nil)
+	if dialErr.Kind != DialFailureRejected {
+		t.Errorf("expected kind %q, got %q", DialFailureRejected, dialErr.Kind)
+	}
+	if dialErr.StatusCode != http.StatusForbidden {
+		t.Errorf("expected status 403, got %d", dialErr.StatusCode)
+	}
+	if dialErr.RetryAfter != 0 {
+		t.Errorf("expected no Retry-After, got %v", dialErr.RetryAfter)
+	}
+}
+
+func TestDialErrorServerError(t *testing.T) {
+	if dialErr := dialStatus(t, http.StatusBadGateway, nil); dialErr.Kind != DialFailureServerError {
+		t.Errorf("expected kind %q, got %q", DialFailureServerError, dialErr.Kind)
+	}
+}
+
+func TestDialErrorRefused(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+	server.Close()
+
+	client := NewClient("token", nil)
+	client.SetGatewayURL(url)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var dialErr *DialError
+	if err := client.Connect(ctx); !errors.As(err, &dialErr) {
+		t.Fatalf("expected a *DialError, got %v", err)
+	}
+	if dialErr.Kind != DialFailureNetwork || dialErr.StatusCode != 0 {
+		t.Errorf("expected a network failure without status, got %q (HTTP %d)", dialErr.Kind, dialErr.StatusCode)
+	}
+}
@@ -0,0 +1,80 @@
+package gateway
+
+import (
+	"sync"
+	"time"
+)
+
+// FrameSummary describes an inbound frame without its payload, so it is safe
+// to expose for debugging: tokens and event data are never captured.
+type FrameSummary struct {
+	Op        int       `json:"op"`
+	Type      string    `json:"type,omitempty"`
+	Sequence  *int64    `json:"sequence,omitempty"`
+	Size      int       `json:"size"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// FrameLog is a fixed-size ring of recent frame summaries. It is shared across
+// reconnects so the frames leading up to a disconnect are kept. A nil
+// *FrameLog records nothing.
+type FrameLog struct {
+	frames []FrameSummary
+	next   int
+	full   bool
+	mu     sync.Mutex
+}
+
+// NewFrameLog returns a log holding at most size frames, or nil when size is not positive.
+func NewFrameLog(size int) *FrameLog {
+	if size <= 0 {
+		return nil
+	}
+	return &FrameLog{
+		frames: make([]FrameSummary, size),
+	}
+}
+
+func (l *FrameLog) record(msg *GatewayMessage, size int) {
+	if l == nil {
+		return
+	}
+
+	summary := FrameSummary{
+		Op:        msg.Op,
+		Type:      msg.Type,
+		Size:      size,
+		Timestamp: time.Now(),
+	}
+	if msg.Sequence != nil {
+		seq := *msg.Sequence
+		summary.Sequence = &seq
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.frames[l.next] = summary
+	l.next = (l.next + 1) % len(l.frames)
+	if l.next == 0 {
+		l.full = true
+	}
+}
+
+// Frames returns the recorded summaries, oldest first.
+func (l *FrameLog) Frames() []FrameSummary {
+	if l == nil {
+		return []FrameSummary{}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.full {
+		return append([]FrameSummary{}, l.frames[:l.next]...)
+	}
+
+	result := make([]FrameSummary, 0, len(l.frames))
+	result = append(result, l.frames[l.next:]...)
+	return append(result, l.frames[:l.next]...)
+}
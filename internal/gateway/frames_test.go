@@ -0,0 +1,69 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestFrameLogRecordsSummaries(t *testing.T) {
+	client := NewClient(testTokenClient, nil)
+	log := NewFrameLog(10)
+	client.SetFrameLog(log)
+
+	ready := `{"op": 0, "s": 7, "t": "READY", "d": {"v": 10, "session_id": "secret-session", "resume_gateway_url": "wss://example"}}`
+	_ = client.handleMessage(context.Background(), []byte(ready))
+	_ = client.handleMessage(context.Background(), []byte(`{"op": 11}`))
+
+	frames := log.Frames()
+	if len(frames) != 2 {
+		t.Fatalf("expected 2 frames, got %d", len(frames))
+	}
+
+	if frames[0].Op != OpDispatch || frames[0].Type != "READY" || frames[0].Size != len(ready) {
+		t.Errorf("unexpected READY summary: %+v", frames[0])
+	}
+	if frames[0].Sequence == nil || *frames[0].Sequence != 7 {
+		t.Errorf("expected sequence 7, got %v", frames[0].Sequence)
+	}
+	if frames[1].Op != OpHeartbeatAck || frames[1].Sequence != nil {
+		t.Errorf("unexpected ACK summary: %+v", frames[1])
+	}
+
+	data, _ := json.Marshal(frames)
+	for _, secret := range []string{"secret-session", "wss://example", testTokenClient} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("frame summaries leaked %q: %s", secret, data)
+		}
+	}
+}
+
+func TestFrameLogBounded(t *testing.T) {
+	log := NewFrameLog(2)
+	for seq := int64(1); seq <= 5; seq++ {
+		log.record(&GatewayMessage{Op: OpDispatch, Sequence: &seq}, 1)
+	}
+
+	frames := log.Frames()
+	if len(frames) != 2 {
+		t.Fatalf("expected 2 frames, got %d", len(frames))
+	}
+	if *frames[0].Sequence != 4 || *frames[1].Sequence != 5 {
+		t.Errorf("expected most recent frames 4 and 5, got %d and %d", *frames[0].Sequence, *frames[1].Sequence)
+	}
+}
+
+func TestFrameLogDisabled(t *testing.T) {
+	if NewFrameLog(0) != nil {
+		t.Error("expected nil frame log for size 0")
+	}
+
+	client := NewClient(testTokenClient, nil)
+	_ = client.handleMessage(context.Background(), []byte(`{"op": 11}`))
+
+	var log *FrameLog
+	if len(log.Frames()) != 0 {
+		t.Error("expected nil frame log to be empty")
+	}
+}
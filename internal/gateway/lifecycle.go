@@ -0,0 +1,54 @@
+package gateway
+
+import "sync"
+
+// connLifecycle holds the channels that coordinate the goroutines of one
+// connection. Each channel is closed exactly once: stop is called by
+// shutdown and by the read loop on exit, finish only by the read loop.
+// Goroutines keep the lifecycle of the connection they were started for, so
+// a later Connect never has its channels closed by an earlier read loop.
+type connLifecycle struct {
+	// heartbeatStop and readStop ask the heartbeat and read loops to exit.
+	heartbeatStop chan struct{}
+	readStop      chan struct{}
+	// readDone and disconnected are closed once the read loop has exited.
+	readDone     chan struct{}
+	disconnected chan struct{}
+
+	stopOnce   sync.Once
+	finishOnce sync.Once
+}
+
+func newConnLifecycle() *connLifecycle {
+	return &connLifecycle{
+		heartbeatStop: make(chan struct{}),
+		readStop:      make(chan struct{}),
+		readDone:      make(chan struct{}),
+		disconnected:  make(chan struct{}),
+	}
+}
+
+// stop signals the heartbeat and read loops to exit. It is safe to call
+// more than once and on a nil lifecycle.
+func (l *connLifecycle) stop() {
+	if l == nil {
+		return
+	}
+	l.stopOnce.Do(func() {
+		close(l.heartbeatStop)
+		close(l.readStop)
+	})
+}
+
+// finish records that the read loop has exited, releasing Close and
+// anything waiting on Disconnected.
+func (l *connLifecycle) finish() {
+	if l == nil {
+		return
+	}
+	l.stop()
+	l.finishOnce.Do(func() {
+		close(l.readDone)
+		close(l.disconnected)
+	})
+}
@@ -0,0 +1,81 @@
+package gateway
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestConnLifecycleClosesChannelsOnce(t *testing.T) {
+	l := newConnLifecycle()
+	l.stop()
+	l.stop()
+	select {
+	case <-l.disconnected:
+		t.Fatal("expected disconnected to stay open until the read loop finishes")
+	default:
+	}
+
+	l.finish()
+	l.finish()
+	for name, ch := range map[string]chan struct{}{
+		"heartbeatStop": l.heartbeatStop,
+		"readStop":      l.readStop,
+		"readDone":      l.readDone,
+		"disconnected":  l.disconnected,
+	} {
+		select {
+		case <-ch:
+		default:
+			t.Errorf("expected %s to be closed", name)
+		}
+	}
+
+	var nilLifecycle *connLifecycle
+	nilLifecycle.stop()
+	nilLifecycle.finish()
+}
+
+// TestConnectCloseStress reconnects the same client repeatedly while other
+// goroutines close it and wait on Disconnected. Run with -race.
+func TestConnectCloseStress(t *testing.T) {
+	mock := newMockGatewayServer(t)
+	defer mock.Close()
+
+	client := NewClient(testTokenClient, nil)
+	client.SetGatewayURL(mock.URL())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	for i := range 25 {
+		if err := client.Connect(ctx); err != nil {
+			t.Fatalf("Connect() #%d error = %v", i, err)
+		}
+		disconnected := client.Disconnected()
+
+		var wg sync.WaitGroup
+		for range 3 {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				_ = client.Close()
+			}()
+			go func() {
+				defer wg.Done()
+				select {
+				case <-client.Disconnected():
+				case <-ctx.Done():
+				}
+			}()
+		}
+		wg.Wait()
+
+		select {
+		case <-disconnected:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("connection #%d: Disconnected was never closed", i)
+		}
+	}
+}
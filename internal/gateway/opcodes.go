@@ -1,6 +1,12 @@
 package gateway
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
 
 const (
 	OpDispatch         = 0
@@ -16,6 +22,13 @@ const (
 	OpHeartbeatAck     = 11
 )
 
+// Gateway intents, combined with | into the IDENTIFY intents bitfield.
+const (
+	IntentGuilds           = 1 << 0
+	IntentGuildVoiceStates = 1 << 7
+	IntentGuildPresences   = 1 << 8
+)
+
 const (
 	CloseUnknownError         = 4000
 	CloseUnknownOpcode        = 4001
@@ -47,10 +60,112 @@ func IsFatalCloseCode(code int) bool {
 	}
 }
 
+// IsMaintenanceCloseCode reports whether a close code means Discord is
+// moving the connection to another host, as during a rolling deploy: 1001
+// (going away) or 1012 (service restart). The session is still valid and
+// should be resumed promptly. Rate limiting (4008) is not one of these.
+func IsMaintenanceCloseCode(code int) bool {
+	return code == 1001 || code == 1012
+}
+
+// DescribeCloseCode returns a short human-readable meaning for a Gateway
+// close code, or "" for codes it does not know.
+func DescribeCloseCode(code int) string {
+	switch code {
+	case CloseUnknownError:
+		return "unknown error"
+	case CloseUnknownOpcode:
+		return "unknown opcode"
+	case CloseDecodeError:
+		return "decode error"
+	case CloseNotAuthenticated:
+		return "not authenticated"
+	case CloseAuthenticationFailed:
+		return "token invalid"
+	case CloseAlreadyAuthenticated:
+		return "already authenticated"
+	case CloseInvalidSeq:
+		return "invalid sequence"
+	case CloseRateLimited:
+		return "rate limited"
+	case CloseSessionTimedOut:
+		return "session timed out"
+	case CloseInvalidShard:
+		return "invalid shard"
+	case CloseShardingRequired:
+		return "sharding required"
+	case CloseInvalidAPIVersion:
+		return "invalid API version"
+	case CloseInvalidIntents:
+		return "invalid intents"
+	case CloseDisallowedIntents:
+		return "disallowed intents"
+	default:
+		return ""
+	}
+}
+
+// CloseCodeHint returns what to change before reconnecting after a fatal
+// close code, or "" when there is no specific fix.
+func CloseCodeHint(code int) string {
+	switch code {
+	case CloseInvalidShard:
+		return "the configured shard must have an ID below its total"
+	case CloseShardingRequired:
+		return "the account is in too many guilds for one connection; configure a shard"
+	default:
+		return ""
+	}
+}
+
+// Shard identifies one connection of a sharded session: shard ID of Total.
+// It is sent in IDENTIFY as [id, total].
+type Shard struct {
+	ID    int
+	Total int
+}
+
+var ErrInvalidShardConfig = errors.New("invalid shard")
+
+// ParseShard parses "id,total" (e.g. "0,1"), requiring 0 <= id < total.
+func ParseShard(raw string) (Shard, error) {
+	idText, totalText, ok := strings.Cut(raw, ",")
+	if !ok {
+		return Shard{}, fmt.Errorf("%w: %q, want id,total", ErrInvalidShardConfig, raw)
+	}
+	id, idErr := strconv.Atoi(strings.TrimSpace(idText))
+	total, totalErr := strconv.Atoi(strings.TrimSpace(totalText))
+	shard := Shard{ID: id, Total: total}
+	if idErr != nil || totalErr != nil || !shard.Valid() {
+		return Shard{}, fmt.Errorf("%w: %q, want id,total with 0 <= id < total", ErrInvalidShardConfig, raw)
+	}
+	return shard, nil
+}
+
+// Valid reports whether s has an ID below a positive total.
+func (s Shard) Valid() bool {
+	return s.Total > 0 && s.ID >= 0 && s.ID < s.Total
+}
+
+// MarshalJSON encodes the shard as the [id, total] array IDENTIFY expects.
+func (s Shard) MarshalJSON() ([]byte, error) {
+	return json.Marshal([2]int{s.ID, s.Total})
+}
+
+// UnmarshalJSON decodes an [id, total] array.
+func (s *Shard) UnmarshalJSON(data []byte) error {
+	var pair [2]int
+	if err := json.Unmarshal(data, &pair); err != nil {
+		return err
+	}
+	*s = Shard{ID: pair[0], Total: pair[1]}
+	return nil
+}
+
 type GatewayMessage struct {
 	Op       int             `json:"op"`
 	Data     json.RawMessage `json:"d"`
-	Sequence *int            `json:"s,omitempty"`
+	Sequence *int64          `json:"s,omitempty"`
 	Type     string          `json:"t,omitempty"`
 }
 
@@ -59,9 +174,23 @@ type HelloData struct {
 }
 
 type ReadyData struct {
-	Version   int    `json:"v"`
-	SessionID string `json:"session_id"`
-	ResumeURL string `json:"resume_gateway_url"`
+	Version   int       `json:"v"`
+	SessionID string    `json:"session_id"`
+	ResumeURL string    `json:"resume_gateway_url"`
+	User      ReadyUser `json:"user"`
+}
+
+type ReadyUser struct {
+	ID string `json:"id"`
+}
+
+// VoiceState is the payload of a VOICE_STATE_UPDATE dispatch. ChannelID is
+// nil when the user left voice.
+type VoiceState struct {
+	GuildID   string  `json:"guild_id"`
+	ChannelID *string `json:"channel_id"`
+	UserID    string  `json:"user_id"`
+	SessionID string  `json:"session_id"`
 }
 
 type IdentifyData struct {
@@ -70,6 +199,8 @@ type IdentifyData struct {
 	Presence       *PresenceData      `json:"presence,omitempty"`
 	Compress       bool               `json:"compress,omitempty"`
 	LargeThreshold int                `json:"large_threshold,omitempty"`
+	Intents        int                `json:"intents,omitempty"`
+	Shard          *Shard             `json:"shard,omitempty"`
 }
 
 type IdentifyProperties struct {
@@ -86,8 +217,16 @@ type PresenceData struct {
 }
 
 type Activity struct {
-	Name string `json:"name"`
-	Type int    `json:"type"`
+	Name string       `json:"name"`
+	Type ActivityType `json:"type"`
+
+	// State is the text of a custom status, or the second line of other
+	// activities. Details is the first line shown under the name.
+	State   string `json:"state,omitempty"`
+	Details string `json:"details,omitempty"`
+
+	// URL is the stream of a streaming activity, see ValidateStreamURL.
+	URL string `json:"url,omitempty"`
 }
 
 type VoiceStateData struct {
@@ -100,5 +239,5 @@ type VoiceStateData struct {
 type ResumeData struct {
 	Token     string `json:"token"`
 	SessionID string `json:"session_id"`
-	Sequence  int    `json:"seq"`
+	Sequence  int64  `json:"seq"`
 }
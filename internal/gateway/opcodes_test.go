@@ -0,0 +1,41 @@
+package gateway
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseShard(t *testing.T) {
+	valid := map[string]Shard{
+		"0,1":   {ID: 0, Total: 1},
+		"3, 16": {ID: 3, Total: 16},
+	}
+	for raw, want := range valid {
+		got, err := ParseShard(raw)
+		if err != nil || got != want {
+			t.Errorf("ParseShard(%q) = %+v, %v; want %+v", raw, got, err, want)
+		}
+	}
+
+	for _, raw := range []string{"", "0", "1,1", "-1,2", "0,0", "a,b"} {
+		if _, err := ParseShard(raw); !errors.Is(err, ErrInvalidShardConfig) {
+			t.Errorf("ParseShard(%q) error = %v, want ErrInvalidShardConfig", raw, err)
+		}
+	}
+}
+
+func TestShardCloseErrorsAreActionable(t *testing.T) {
+	for _, code := range []int{CloseInvalidShard, CloseShardingRequired} {
+		err := error(&CloseError{Code: code})
+		if !errors.Is(err, ErrFatalClose) {
+			t.Errorf("close %d: expected a fatal close error", code)
+		}
+		if hint := CloseCodeHint(code); hint == "" || !strings.Contains(err.Error(), hint) {
+			t.Errorf("close %d: expected the error to carry a hint, got %q", code, err)
+		}
+	}
+	if err := (&CloseError{Code: CloseAuthenticationFailed}).Error(); err != "fatal close code received: code 4004" {
+		t.Errorf("unexpected message for close 4004: %q", err)
+	}
+}
@@ -0,0 +1,80 @@
+package gateway
+
+import (
+	"context"
+
+	"github.com/coder/websocket"
+)
+
+// outboundFrame is a text frame waiting for the writer goroutine.
+type outboundFrame struct {
+	ctx    context.Context
+	data   []byte
+	result chan error
+}
+
+// attachConn installs conn as the active connection and starts the goroutine
+// that serializes writes to it.
+func (c *Client) attachConn(conn *websocket.Conn) {
+	queue := make(chan outboundFrame, 16)
+	stop := make(chan struct{})
+
+	c.mu.Lock()
+	c.stopWriterLocked()
+	c.conn = conn
+	c.writeQueue = queue
+	c.writeStop = stop
+	c.mu.Unlock()
+
+	go writeLoop(conn, queue, stop)
+}
+
+// stopWriterLocked stops the writer goroutine. c.mu must be held.
+func (c *Client) stopWriterLocked() {
+	if c.writeStop != nil {
+		close(c.writeStop)
+		c.writeStop = nil
+		c.writeQueue = nil
+	}
+}
+
+func writeLoop(conn *websocket.Conn, queue <-chan outboundFrame, stop <-chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		case frame := <-queue:
+			frame.result <- conn.Write(frame.ctx, websocket.MessageText, frame.data)
+		}
+	}
+}
+
+// write queues data for the writer goroutine and waits for the result.
+func (c *Client) write(ctx context.Context, data []byte) error {
+	c.mu.RLock()
+	queue := c.writeQueue
+	stop := c.writeStop
+	c.mu.RUnlock()
+
+	if queue == nil {
+		return ErrNotConnected
+	}
+
+	frame := outboundFrame{ctx: ctx, data: data, result: make(chan error, 1)}
+	select {
+	case queue <- frame:
+	case <-stop:
+		return ErrNotConnected
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case err := <-frame.result:
+		return err
+	case <-stop:
+		return ErrNotConnected
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,81 @@
+package gateway
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestConcurrentWritesAreSerialized(t *testing.T) {
+	mock := newMockGatewayServer(t)
+	defer mock.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client := NewClient(testTokenClient, nil)
+	ready := make(chan struct{})
+	client.OnReady = func(string) { close(ready) }
+	client.SetGatewayURL(mock.URL())
+
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf(errFailedToConnectFmt, err)
+	}
+	defer func() { _ = client.Close() }()
+
+	select {
+	case <-ready:
+	case <-ctx.Done():
+		t.Fatal("timeout waiting for READY")
+	}
+
+	const perKind = 50
+	var wg sync.WaitGroup
+	errs := make(chan error, 3*perKind)
+	for range perKind {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			errs <- client.SendHeartbeat(ctx)
+		}()
+		go func() {
+			defer wg.Done()
+			errs <- client.SendPresenceUpdate(ctx, "idle")
+		}()
+		go func() {
+			defer wg.Done()
+			errs <- client.SendVoiceStateUpdate(ctx, "guild123", "channel123", true, true)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("concurrent write failed: %v", err)
+		}
+	}
+
+	// Every heartbeat must arrive as an intact, parseable frame.
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		mock.mu.Lock()
+		count := mock.heartbeatCount
+		mock.mu.Unlock()
+		if count >= perKind {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected at least %d heartbeats at the server, got %d", perKind, count)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWriteWithoutConnection(t *testing.T) {
+	client := NewClient(testTokenClient, nil)
+	if err := client.write(context.Background(), []byte(`{}`)); err != ErrNotConnected {
+		t.Errorf(errExpectedNotConn, err)
+	}
+}
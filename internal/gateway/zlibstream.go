@@ -0,0 +1,90 @@
+package gateway
+
+import (
+	"bytes"
+	"compress/flate"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// zlibStreamQuery asks the Gateway to compress the whole connection as one
+// zlib stream.
+const zlibStreamQuery = "&compress=zlib-stream"
+
+// zlibSuffix is the Z_SYNC_FLUSH marker that ends every complete message.
+var zlibSuffix = []byte{0x00, 0x00, 0xff, 0xff}
+
+// zlibWindowSize is the largest distance a deflate back-reference can reach.
+const zlibWindowSize = 32 * 1024
+
+// ErrInvalidZlibHeader is returned when a zlib-stream connection does not
+// start with a zlib header.
+var ErrInvalidZlibHeader = errors.New("invalid zlib-stream header")
+
+// zlibStream inflates the messages of one zlib-stream connection. The
+// stream is continuous: a message can refer back to bytes of earlier ones,
+// so the inflater is carried from message to message, primed with the last
+// 32 KiB of output. A new connection starts a new stream and needs a new
+// zlibStream.
+type zlibStream struct {
+	// pending holds the frames of a message that has not been flushed yet.
+	pending []byte
+	// window is the tail of the output so far.
+	window []byte
+	// started is set once the zlib header has been read.
+	started bool
+
+	src      bytes.Reader
+	inflater io.ReadCloser
+}
+
+// decompress adds a frame to the stream. It returns the inflated message
+// and true once the frame completes one, or false while the message is
+// still split across frames.
+func (z *zlibStream) decompress(data []byte) ([]byte, bool, error) {
+	z.pending = append(z.pending, data...)
+	if !bytes.HasSuffix(z.pending, zlibSuffix) {
+		return nil, false, nil
+	}
+	in := z.pending
+	z.pending = nil
+
+	if !z.started {
+		if !validZlibHeader(in) {
+			return nil, false, ErrInvalidZlibHeader
+		}
+		in = in[2:]
+		z.started = true
+	}
+
+	z.src.Reset(in)
+	if z.inflater == nil {
+		z.inflater = flate.NewReaderDict(&z.src, z.window)
+	} else if err := z.inflater.(flate.Resetter).Reset(&z.src, z.window); err != nil {
+		return nil, false, fmt.Errorf("reset zlib-stream inflater: %w", err)
+	}
+
+	// The flush marker ends the data without ending the deflate stream, so
+	// running out of input right after it is expected.
+	out, err := io.ReadAll(z.inflater)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, false, fmt.Errorf("inflate zlib-stream: %w", err)
+	}
+
+	z.window = append(z.window, out...)
+	if len(z.window) > zlibWindowSize {
+		z.window = append(z.window[:0], z.window[len(z.window)-zlibWindowSize:]...)
+	}
+	return out, true, nil
+}
+
+// validZlibHeader reports whether data starts with a zlib header for a
+// deflate stream without a preset dictionary.
+func validZlibHeader(data []byte) bool {
+	if len(data) < 2 {
+		return false
+	}
+	cmf, flg := data[0], data[1]
+	return cmf&0x0f == 8 && flg&0x20 == 0 && (uint16(cmf)<<8|uint16(flg))%31 == 0
+}
@@ -0,0 +1,167 @@
+package gateway
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+)
+
+// zlibFrames compresses messages as one zlib stream, flushing after each,
+// the way the Gateway sends them.
+func zlibFrames(t *testing.T, messages ...string) [][]byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zlib.NewWriter(&buf)
+	frames := make([][]byte, 0, len(messages))
+	for _, msg := range messages {
+		if _, err := w.Write([]byte(msg)); err != nil {
+			t.Fatalf("compress: %v", err)
+		}
+		if err := w.Flush(); err != nil {
+			t.Fatalf("flush: %v", err)
+		}
+		frames = append(frames, bytes.Clone(buf.Bytes()))
+		buf.Reset()
+	}
+	return frames
+}
+
+func TestZlibStreamCarriesStateAcrossMessages(t *testing.T) {
+	// Messages repeat each other so later ones are mostly back-references,
+	// and together they exceed the 32 KiB window.
+	var messages []string
+	for i := range 8 {
+		messages = append(messages, fmt.Sprintf(`{"op":0,"s":%d,"d":{"members":"%s"}}`, i, strings.Repeat(fmt.Sprintf("member-%d,", i%3), 800)))
+	}
+	frames := zlibFrames(t, messages...)
+
+	var z zlibStream
+	for i, frame := range frames {
+		// Split every other message across two frames.
+		var parts [][]byte
+		if i%2 == 1 {
+			parts = [][]byte{frame[:len(frame)/2], frame[len(frame)/2:]}
+		} else {
+			parts = [][]byte{frame}
+		}
+
+		var got []byte
+		for j, part := range parts {
+			out, complete, err := z.decompress(part)
+			if err != nil {
+				t.Fatalf("message %d: decompress() error = %v", i, err)
+			}
+			if complete != (j == len(parts)-1) {
+				t.Fatalf("message %d part %d: complete = %v", i, j, complete)
+			}
+			got = out
+		}
+		if string(got) != messages[i] {
+			t.Fatalf("message %d: got %d bytes, want %q...", i, len(got), messages[i][:40])
+		}
+	}
+	if len(z.window) > zlibWindowSize {
+		t.Errorf("expected window capped at %d bytes, got %d", zlibWindowSize, len(z.window))
+	}
+}
+
+func TestZlibStreamRejectsMissingHeader(t *testing.T) {
+	var z zlibStream
+	_, _, err := z.decompress(append([]byte(`{"op":10}`), zlibSuffix...))
+	if !errors.Is(err, ErrInvalidZlibHeader) {
+		t.Errorf("expected ErrInvalidZlibHeader, got %v", err)
+	}
+}
+
+// TestConnectZlibStream feeds compressed HELLO and READY frames through a
+// mock Gateway, and checks a second connection starts a fresh stream.
+func TestConnectZlibStream(t *testing.T) {
+	queries := make(chan string, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries <- r.URL.RawQuery
+		conn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.CloseNow() }()
+
+		var buf bytes.Buffer
+		zw := zlib.NewWriter(&buf)
+		send := func(msg any, split bool) error {
+			data, _ := json.Marshal(msg)
+			_, _ = zw.Write(data)
+			_ = zw.Flush()
+			frame := bytes.Clone(buf.Bytes())
+			buf.Reset()
+			parts := [][]byte{frame}
+			if split {
+				parts = [][]byte{frame[:len(frame)/2], frame[len(frame)/2:]}
+			}
+			for _, part := range parts {
+				if err := conn.Write(r.Context(), websocket.MessageBinary, part); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		if err := send(map[string]any{"op": OpHello, "d": map[string]any{"heartbeat_interval": 45000}}, false); err != nil {
+			return
+		}
+		for {
+			_, data, err := conn.Read(r.Context())
+			if err != nil {
+				return
+			}
+			var msg struct {
+				Op int `json:"op"`
+			}
+			_ = json.Unmarshal(data, &msg)
+			if msg.Op != OpIdentify {
+				continue
+			}
+			ready := map[string]any{"op": OpDispatch, "t": "READY", "s": 1, "d": map[string]any{"session_id": testSessionID}}
+			if err := send(ready, true); err != nil {
+				return
+			}
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(testTokenClient, nil)
+	client.SetGatewayURL("ws" + strings.TrimPrefix(server.URL, "http"))
+	client.SetCompressZlibStream(true)
+	ready := make(chan string, 2)
+	client.OnReady = func(sessionID string) { ready <- sessionID }
+
+	for attempt := range 2 {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		if err := client.Connect(ctx); err != nil {
+			cancel()
+			t.Fatalf(errFailedToConnectFmt, err)
+		}
+		if query := <-queries; !strings.Contains(query, "compress=zlib-stream") {
+			t.Errorf("connect %d: expected compress=zlib-stream in query, got %q", attempt, query)
+		}
+		select {
+		case sessionID := <-ready:
+			if sessionID != testSessionID {
+				t.Errorf("connect %d: expected session %q, got %q", attempt, testSessionID, sessionID)
+			}
+		case <-ctx.Done():
+			t.Fatalf("connect %d: timeout waiting for compressed READY", attempt)
+		}
+		_ = client.Close()
+		cancel()
+	}
+}
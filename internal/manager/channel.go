@@ -0,0 +1,60 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/pyyupsk/discord-stayonline/internal/gateway"
+)
+
+// ErrPresenceOnly is returned when moving a presence-only server, which has
+// no voice channel to move.
+var ErrPresenceOnly = errors.New("server is presence-only and has no voice channel")
+
+// MoveChannel moves a joined server's voice connection to another channel in
+// its guild. The override is not saved to the config: it is kept for
+// reconnects until RestoreChannel clears it, and is lost on Rejoin, Exit, or
+// a restart.
+func (m *SessionManager) MoveChannel(serverID, channelID string) error {
+	return m.setChannelOverride(serverID, channelID)
+}
+
+// RestoreChannel clears a MoveChannel override and moves the voice
+// connection back to the configured channel.
+func (m *SessionManager) RestoreChannel(serverID string) error {
+	return m.setChannelOverride(serverID, "")
+}
+
+func (m *SessionManager) setChannelOverride(serverID, channelID string) error {
+	m.mu.Lock()
+	session, exists := m.sessions[serverID]
+	if !exists {
+		m.mu.Unlock()
+		return ErrNotConnected
+	}
+	if session.serverEntry.PresenceOnly {
+		m.mu.Unlock()
+		return ErrPresenceOnly
+	}
+	session.state.SetChannelOverride(channelID)
+	client := session.currentClient()
+	m.mu.Unlock()
+
+	if client == nil || client.State() != gateway.StateConnected {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(session.ctx, 5*time.Second)
+	defer cancel()
+	entry := session.serverEntry
+	return client.SendVoiceStateUpdate(ctx, entry.GuildID, targetChannel(session), entry.SelfMuted(), entry.SelfDeafened())
+}
+
+// targetChannel is the voice channel the session should be in: the override
+// when one is set, otherwise the configured channel.
+func targetChannel(session *Session) string {
+	if override := session.state.channelOverride(); override != "" {
+		return override
+	}
+	return session.serverEntry.ChannelID
+}
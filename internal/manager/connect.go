@@ -0,0 +1,76 @@
+package manager
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pyyupsk/discord-stayonline/internal/gateway"
+)
+
+// DefaultQuickConnectRetryDelay is the wait before each quick retry of a
+// failed connect.
+const DefaultQuickConnectRetryDelay = 500 * time.Millisecond
+
+// DefaultRateLimitedDialDelay is the shortest wait after the Gateway answers
+// the WebSocket handshake with 429, unless Retry-After asks for longer.
+const DefaultRateLimitedDialDelay = time.Minute
+
+// quickRetryAllowed reports whether a failed dial may be retried at once.
+// Discord or a proxy in front of it refusing the handshake will refuse an
+// immediate retry too; DNS and network failures are often transient.
+func quickRetryAllowed(dialErr *gateway.DialError) bool {
+	if dialErr == nil {
+		return true
+	}
+	return dialErr.Kind != gateway.DialFailureRateLimited && dialErr.Kind != gateway.DialFailureRejected
+}
+
+// dialBackoff stretches the backoff delay after a rate-limited handshake to
+// honor Retry-After, and at least DefaultRateLimitedDialDelay.
+func dialBackoff(delay time.Duration, dialErr *gateway.DialError) time.Duration {
+	if dialErr == nil || dialErr.Kind != gateway.DialFailureRateLimited {
+		return delay
+	}
+	return max(delay, dialErr.RetryAfter, DefaultRateLimitedDialDelay)
+}
+
+// connectLimiter bounds how many sessions may be connecting at once, from
+// dialing the Gateway until READY or RESUMED. Sessions beyond the limit wait
+// for a slot, so a mass reconnect after an outage is spread out instead of
+// hitting Discord all at once.
+type connectLimiter struct {
+	once  sync.Once
+	slots chan struct{}
+}
+
+// acquireConnectSlot waits for a connect slot and returns the function that
+// frees it, which is safe to call more than once. It returns nil if the
+// session stops while waiting. Without MaxConcurrentConnects it never waits.
+func (m *SessionManager) acquireConnectSlot(session *Session) func() {
+	m.connects.once.Do(func() {
+		if m.MaxConcurrentConnects > 0 {
+			m.connects.slots = make(chan struct{}, m.MaxConcurrentConnects)
+		}
+	})
+	if m.connects.slots == nil {
+		return func() {}
+	}
+
+	select {
+	case m.connects.slots <- struct{}{}:
+	default:
+		session.logger.Debug("Waiting for a connect slot", "max_concurrent_connects", cap(m.connects.slots))
+		select {
+		case m.connects.slots <- struct{}{}:
+		case <-session.ctx.Done():
+			return nil
+		case <-session.stopReconnect:
+			return nil
+		}
+	}
+
+	var release sync.Once
+	return func() {
+		release.Do(func() { <-m.connects.slots })
+	}
+}
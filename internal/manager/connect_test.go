@@ -0,0 +1,29 @@
+package manager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pyyupsk/discord-stayonline/internal/gateway"
+)
+
+func TestDialBackoffHonorsRateLimit(t *testing.T) {
+	limited := &gateway.DialError{Kind: gateway.DialFailureRateLimited, RetryAfter: 5 * time.Minute}
+	if got := dialBackoff(time.Second, limited); got != 5*time.Minute {
+		t.Errorf("expected Retry-After of 5m to win, got %v", got)
+	}
+	limited.RetryAfter = 0
+	if got := dialBackoff(time.Second, limited); got != DefaultRateLimitedDialDelay {
+		t.Errorf("expected at least %v after a 429, got %v", DefaultRateLimitedDialDelay, got)
+	}
+	if got := dialBackoff(time.Second, &gateway.DialError{Kind: gateway.DialFailureDNS}); got != time.Second {
+		t.Errorf("expected backoff unchanged for a DNS failure, got %v", got)
+	}
+
+	if quickRetryAllowed(&gateway.DialError{Kind: gateway.DialFailureRejected}) {
+		t.Error("expected no quick retry after a rejected handshake")
+	}
+	if !quickRetryAllowed(nil) {
+		t.Error("expected quick retries for non-dial errors")
+	}
+}
@@ -0,0 +1,243 @@
+package manager
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/pyyupsk/discord-stayonline/internal/config"
+	"github.com/pyyupsk/discord-stayonline/internal/gateway"
+)
+
+// Explanation reasons, from most to least actionable.
+const (
+	ReasonNoToken            = "no_token"
+	ReasonTOSNotAcknowledged = "tos_not_acknowledged"
+	ReasonFatalClose         = "fatal_close"
+	ReasonConnectionLimit    = "connection_limit"
+	ReasonGuildLimit         = "guild_connection_limit"
+	ReasonBackoff            = "backoff"
+	ReasonError              = "error"
+	ReasonConnecting         = "connecting"
+	ReasonConnected          = "connected"
+	ReasonNotJoined          = "not_joined"
+)
+
+// Explanation summarizes why a server is or is not connected.
+type Explanation struct {
+	ServerID       string           `json:"server_id"`
+	Status         ConnectionStatus `json:"status"`
+	Reason         string           `json:"reason"`
+	Message        string           `json:"message"`
+	LastError      string           `json:"last_error,omitempty"`
+	LastCloseCode  int              `json:"last_close_code,omitempty"`
+	BackoffAttempt int              `json:"backoff_attempt,omitempty"`
+	NextRetryAt    *time.Time       `json:"next_retry_at,omitempty"`
+	SharedWith     string           `json:"shared_with,omitempty"`
+
+	// ChannelOverride is the voice channel set by MoveChannel, if any.
+	ChannelOverride string `json:"channel_override,omitempty"`
+
+	// DialFailure classifies the last failed Gateway dial while the
+	// session has not connected since.
+	DialFailure gateway.DialFailure `json:"dial_failure,omitempty"`
+
+	// ConnectedSince and UptimeSecs report when the current connection
+	// became ready and how long it has been up, for connected sessions.
+	ConnectedSince *time.Time `json:"connected_since,omitempty"`
+	UptimeSecs     int64      `json:"uptime_secs,omitempty"`
+
+	// LastHeartbeatAck and HeartbeatAgeSeconds report when the connection
+	// last had a heartbeat acknowledged and how long ago that was. An age
+	// well above the heartbeat interval warns of a lagging connection before
+	// it is closed for missed ACKs. HeartbeatLatencyMS is that heartbeat's
+	// round-trip time.
+	LastHeartbeatAck    *time.Time `json:"last_heartbeat_ack,omitempty"`
+	HeartbeatAgeSeconds float64    `json:"heartbeat_age_seconds,omitempty"`
+	HeartbeatLatencyMS  float64    `json:"heartbeat_latency_ms,omitempty"`
+}
+
+// Explain combines the session state, TOS and token state, and connection
+// limits into a single explanation of the server's connection state.
+func (m *SessionManager) Explain(serverID string) (Explanation, error) {
+	cfg, err := m.loadConfig()
+	if err != nil {
+		return Explanation{}, err
+	}
+	if !slices.ContainsFunc(cfg.Servers, func(s config.ServerEntry) bool { return s.ID == serverID }) {
+		return Explanation{}, ErrServerNotFound
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	e := Explanation{ServerID: serverID, Status: StatusDisconnected}
+	session, exists := m.sessions[serverID]
+	if !exists {
+		m.explainIdleLocked(&e, cfg)
+		return e, nil
+	}
+
+	owner := session
+	if session.sharedWith != nil {
+		owner = session.sharedWith
+		e.SharedWith = owner.serverEntry.ID
+	}
+	state := owner.state.Snapshot()
+	e.Status = state.ConnectionStatus
+	e.LastError = state.LastError
+	e.LastCloseCode = state.LastCloseCode
+	e.BackoffAttempt = state.BackoffAttempt
+	e.ChannelOverride = session.state.channelOverride()
+	e.DialFailure = state.LastDialFailure
+
+	switch {
+	case owner.reconnectStopped():
+		e.Reason = ReasonFatalClose
+		e.Message = "Not connected: " + describeClose(state.LastCloseCode, state.LastError)
+	case state.ConnectionStatus.Online():
+		e.Reason = ReasonConnected
+		e.Message = "Connected since " + state.LastConnectTime.Format(time.RFC3339)
+		if state.ConnectionStatus == StatusInVoice {
+			e.Message += ", in voice channel"
+		}
+		if since, uptime := state.uptime(time.Now()); !since.IsZero() {
+			e.ConnectedSince = &since
+			e.UptimeSecs = int64(uptime / time.Second)
+		}
+		if client := owner.currentClient(); client != nil {
+			if ack := client.LastHeartbeatAck(); !ack.IsZero() {
+				e.LastHeartbeatAck = &ack
+				e.HeartbeatAgeSeconds = time.Since(ack).Seconds()
+				e.HeartbeatLatencyMS = milliseconds(client.HeartbeatLatency())
+			}
+		}
+	case state.ConnectionStatus == StatusConnecting:
+		e.Reason = ReasonConnecting
+		e.Message = "Connecting to the Gateway"
+		if state.BackoffAttempt > 0 {
+			e.Message += fmt.Sprintf(" (attempt %d)", state.BackoffAttempt+1)
+		}
+	case state.ConnectionStatus == StatusBackoff:
+		e.Reason = ReasonBackoff
+		retryIn := max(time.Until(state.NextRetryAt), 0).Round(time.Second)
+		if !state.NextRetryAt.IsZero() {
+			next := state.NextRetryAt
+			e.NextRetryAt = &next
+		}
+		e.Message = fmt.Sprintf("Backing off: attempt %d, retrying in %s", state.BackoffAttempt, retryIn)
+		if state.LastError != "" {
+			e.Message += " (last error: " + state.LastError + ")"
+		}
+	case state.ConnectionStatus == StatusError:
+		e.Reason = ReasonError
+		e.Message = "Error: " + state.LastError + ", reconnecting"
+	default:
+		m.explainIdleLocked(&e, cfg)
+	}
+
+	if e.SharedWith != "" {
+		e.Message = fmt.Sprintf("Sharing the presence connection of %s. %s", owner.serverEntry.DisplayName(), e.Message)
+	}
+	return e, nil
+}
+
+// HeartbeatAges returns, for each connected session with its own
+// connection, the time since its last heartbeat ACK.
+func (m *SessionManager) HeartbeatAges() map[string]time.Duration {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	ages := make(map[string]time.Duration)
+	for id, s := range m.sessions {
+		client := s.currentClient()
+		if s.sharedWith != nil || client == nil || !s.state.status().Online() {
+			continue
+		}
+		if ack := client.LastHeartbeatAck(); !ack.IsZero() {
+			ages[id] = time.Since(ack)
+		}
+	}
+	return ages
+}
+
+// HeartbeatLatencies returns, for each connected session with its own
+// connection, the round-trip time of its last acknowledged heartbeat.
+func (m *SessionManager) HeartbeatLatencies() map[string]time.Duration {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	latencies := make(map[string]time.Duration)
+	for id, s := range m.sessions {
+		client := s.currentClient()
+		if s.sharedWith != nil || client == nil || !s.state.status().Online() {
+			continue
+		}
+		if latency := client.HeartbeatLatency(); latency > 0 {
+			latencies[id] = latency
+		}
+	}
+	return latencies
+}
+
+// milliseconds converts d to fractional milliseconds for JSON output.
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// explainIdleLocked explains a server without a running session: whatever
+// would stop a join, else that it has simply not been joined. m.mu must be
+// held.
+func (m *SessionManager) explainIdleLocked(e *Explanation, cfg *config.Configuration) {
+	idx := slices.IndexFunc(cfg.Servers, func(s config.ServerEntry) bool { return s.ID == e.ServerID })
+	switch {
+	case m.token == "":
+		e.Reason = ReasonNoToken
+		e.Message = "Blocked: no Discord token configured"
+	case !cfg.TOSAcknowledged:
+		e.Reason = ReasonTOSNotAcknowledged
+		e.Message = "Blocked: Terms of Service not acknowledged"
+	case m.activeCountLocked() >= m.maxConnections():
+		e.Reason = ReasonConnectionLimit
+		e.Message = fmt.Sprintf("Blocked: maximum connections reached (%d/%d)", m.activeCountLocked(), m.maxConnections())
+		if m.PreemptLowerPriority {
+			e.Message += "; joining may preempt a lower-priority server"
+		}
+	case idx >= 0 && m.guildFullLocked(cfg.Servers[idx].GuildID):
+		guildID := cfg.Servers[idx].GuildID
+		e.Reason = ReasonGuildLimit
+		e.Message = fmt.Sprintf("Blocked: maximum connections for guild %s reached (%d/%d)", guildID, m.guildActiveCountLocked(guildID), m.guildLimit(guildID))
+	default:
+		e.Reason = ReasonNotJoined
+		e.Message = "Not connected: not joined"
+	}
+}
+
+// describeClose explains a fatal disconnect, e.g. "token invalid (close
+// 4004)".
+func describeClose(code int, lastError string) string {
+	if code == 0 {
+		return lastError
+	}
+	if desc := gateway.DescribeCloseCode(code); desc != "" {
+		if hint := gateway.CloseCodeHint(code); hint != "" {
+			return fmt.Sprintf("%s (close %d): %s", desc, code, hint)
+		}
+		return fmt.Sprintf("%s (close %d)", desc, code)
+	}
+	return fmt.Sprintf("Gateway closed the connection (close %d)", code)
+}
+
+// reconnectStopped reports whether the session gave up reconnecting, which
+// happens after a fatal close code.
+func (s *Session) reconnectStopped() bool {
+	if s.stopReconnect == nil {
+		return false
+	}
+	select {
+	case <-s.stopReconnect:
+		return true
+	default:
+		return false
+	}
+}
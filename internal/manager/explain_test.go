@@ -0,0 +1,239 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pyyupsk/discord-stayonline/internal/config"
+)
+
+type memoryConfigStore struct {
+	cfg *config.Configuration
+}
+
+func (s *memoryConfigStore) Load() (*config.Configuration, error) {
+	cfg := *s.cfg
+	cfg.Servers = append([]config.ServerEntry(nil), s.cfg.Servers...)
+	return &cfg, nil
+}
+
+func (s *memoryConfigStore) Save(cfg *config.Configuration) error {
+	s.cfg = cfg
+	return nil
+}
+
+func newExplainManager(t *testing.T, tosAcknowledged bool) *SessionManager {
+	t.Helper()
+	store := &memoryConfigStore{cfg: &config.Configuration{
+		Servers: []config.ServerEntry{
+			{ID: "a", GuildID: "111", ChannelID: "222"},
+			{ID: "b", GuildID: "333", ChannelID: "444"},
+		},
+		Status:          config.StatusOnline,
+		TOSAcknowledged: tosAcknowledged,
+	}}
+	m := NewSessionManager("token", store, nil, nil, nil)
+	t.Cleanup(m.Stop)
+	return m
+}
+
+// seedSession registers a session without running it.
+func seedSession(m *SessionManager, serverID string, mutate func(*SessionState)) *Session {
+	ctx, cancel := context.WithCancel(m.ctx)
+	session := &Session{
+		serverEntry:   config.ServerEntry{ID: serverID},
+		state:         NewSessionState(serverID),
+		ctx:           ctx,
+		cancel:        cancel,
+		stopReconnect: make(chan struct{}),
+	}
+	mutate(session.state)
+	m.mu.Lock()
+	m.sessions[serverID] = session
+	m.trackSession(session)
+	m.mu.Unlock()
+	return session
+}
+
+func TestExplain(t *testing.T) {
+	tests := []struct {
+		name    string
+		tos     bool
+		setup   func(*SessionManager)
+		reason  string
+		message string
+	}{
+		{
+			name:    "not joined",
+			tos:     true,
+			setup:   func(*SessionManager) {},
+			reason:  ReasonNotJoined,
+			message: "Not connected: not joined",
+		},
+		{
+			name:    "tos not acknowledged",
+			tos:     false,
+			setup:   func(*SessionManager) {},
+			reason:  ReasonTOSNotAcknowledged,
+			message: "Blocked: Terms of Service not acknowledged",
+		},
+		{
+			name: "no token",
+			tos:  true,
+			setup: func(m *SessionManager) {
+				m.token = ""
+			},
+			reason:  ReasonNoToken,
+			message: "Blocked: no Discord token configured",
+		},
+		{
+			name: "connection limit",
+			tos:  true,
+			setup: func(m *SessionManager) {
+				m.MaxConnections = 1
+				seedSession(m, "b", func(s *SessionState) { s.MarkConnected("sid") })
+			},
+			reason:  ReasonConnectionLimit,
+			message: "Blocked: maximum connections reached (1/1)",
+		},
+		{
+			name: "connected",
+			tos:  true,
+			setup: func(m *SessionManager) {
+				seedSession(m, "a", func(s *SessionState) { s.MarkConnected("sid") })
+			},
+			reason:  ReasonConnected,
+			message: "Connected since ",
+		},
+		{
+			name: "connecting",
+			tos:  true,
+			setup: func(m *SessionManager) {
+				seedSession(m, "a", func(s *SessionState) { s.MarkConnecting() })
+			},
+			reason:  ReasonConnecting,
+			message: "Connecting to the Gateway",
+		},
+		{
+			name: "backing off",
+			tos:  true,
+			setup: func(m *SessionManager) {
+				seedSession(m, "a", func(s *SessionState) {
+					s.MarkError("connection closed")
+					s.MarkBackoff()
+					s.MarkBackoff()
+					s.MarkBackoff()
+					s.ScheduleRetry(8*time.Second + 400*time.Millisecond)
+				})
+			},
+			reason:  ReasonBackoff,
+			message: "Backing off: attempt 3, retrying in 8s (last error: connection closed)",
+		},
+		{
+			name: "error",
+			tos:  true,
+			setup: func(m *SessionManager) {
+				seedSession(m, "a", func(s *SessionState) { s.MarkError("read timeout") })
+			},
+			reason:  ReasonError,
+			message: "Error: read timeout, reconnecting",
+		},
+		{
+			name: "fatal close",
+			tos:  true,
+			setup: func(m *SessionManager) {
+				session := seedSession(m, "a", func(s *SessionState) {
+					s.MarkError("fatal close code received: code 4004")
+					s.MarkClosed(4004)
+				})
+				close(session.stopReconnect)
+			},
+			reason:  ReasonFatalClose,
+			message: "Not connected: token invalid (close 4004)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newExplainManager(t, tt.tos)
+			tt.setup(m)
+
+			e, err := m.Explain("a")
+			if err != nil {
+				t.Fatalf("Explain() error = %v", err)
+			}
+			if e.Reason != tt.reason {
+				t.Errorf("Reason = %q, want %q", e.Reason, tt.reason)
+			}
+			if !strings.HasPrefix(e.Message, tt.message) {
+				t.Errorf("Message = %q, want prefix %q", e.Message, tt.message)
+			}
+		})
+	}
+}
+
+func TestExplainSharedFollower(t *testing.T) {
+	m := newExplainManager(t, true)
+	leader := seedSession(m, "b", func(s *SessionState) { s.MarkConnected("sid") })
+	seedSession(m, "a", func(*SessionState) {}).sharedWith = leader
+
+	e, err := m.Explain("a")
+	if err != nil {
+		t.Fatalf("Explain() error = %v", err)
+	}
+	if e.SharedWith != "b" || e.Reason != ReasonConnected {
+		t.Errorf("expected connected via b, got %+v", e)
+	}
+}
+
+func TestExplainUnknownServer(t *testing.T) {
+	m := newExplainManager(t, true)
+	if _, err := m.Explain("missing"); !errors.Is(err, ErrServerNotFound) {
+		t.Errorf("expected ErrServerNotFound, got %v", err)
+	}
+}
+
+func TestSessionUptime(t *testing.T) {
+	m := newExplainManager(t, true)
+	connectedAt := time.Now().Add(-(3*time.Hour + 12*time.Minute))
+	seedSession(m, "a", func(s *SessionState) {
+		s.MarkConnected("session-a")
+		s.LastConnectTime = connectedAt
+	})
+	seedSession(m, "b", func(s *SessionState) {
+		s.LastConnectTime = connectedAt
+		s.MarkBackoff()
+	})
+
+	const want = int64((3*time.Hour + 12*time.Minute) / time.Second)
+	detail, _ := m.GetSessionDetail("a")
+	if !detail.ConnectedSince.Equal(connectedAt) {
+		t.Errorf("expected connected_since %v, got %v", connectedAt, detail.ConnectedSince)
+	}
+	if detail.UptimeSecs < want || detail.UptimeSecs > want+1 {
+		t.Errorf("expected uptime of about %d seconds, got %d", want, detail.UptimeSecs)
+	}
+	e, err := m.Explain("a")
+	if err != nil {
+		t.Fatalf("Explain() error = %v", err)
+	}
+	if e.ConnectedSince == nil || !e.ConnectedSince.Equal(connectedAt) || e.UptimeSecs < want {
+		t.Errorf("expected explanation uptime since %v, got %v (%ds)", connectedAt, e.ConnectedSince, e.UptimeSecs)
+	}
+
+	detail, _ = m.GetSessionDetail("b")
+	data, err := json.Marshal(detail)
+	if err != nil {
+		t.Fatalf("marshal detail: %v", err)
+	}
+	if strings.Contains(string(data), "connected_since") || strings.Contains(string(data), "uptime_secs") {
+		t.Errorf("expected no uptime for a disconnected session, got %s", data)
+	}
+	if e, _ := m.Explain("b"); e.ConnectedSince != nil || e.UptimeSecs != 0 {
+		t.Errorf("expected no explanation uptime for a disconnected session, got %v (%ds)", e.ConnectedSince, e.UptimeSecs)
+	}
+}
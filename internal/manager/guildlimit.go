@@ -0,0 +1,62 @@
+package manager
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidGuildLimit is returned by ParseGuildLimits for a malformed pair.
+var ErrInvalidGuildLimit = errors.New("invalid guild connection limit, expected guild_id:limit")
+
+// ParseGuildLimits parses comma-separated guild_id:limit pairs, e.g.
+// "123:1,456:3", into per-guild connection caps. Errors identify the pair by
+// position.
+func ParseGuildLimits(raw string) (map[string]int, error) {
+	limits := make(map[string]int)
+	for i, pair := range strings.Split(raw, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		guildID, value, ok := strings.Cut(pair, ":")
+		guildID = strings.TrimSpace(guildID)
+		limit, err := strconv.Atoi(strings.TrimSpace(value))
+		if !ok || guildID == "" || err != nil || limit < 0 {
+			return nil, fmt.Errorf("%w (pair %d)", ErrInvalidGuildLimit, i+1)
+		}
+		limits[guildID] = limit
+	}
+	return limits, nil
+}
+
+// guildLimit returns the connection cap for guildID: its entry in
+// GuildConnectionLimits, else MaxConnectionsPerGuild. Zero is unlimited.
+func (m *SessionManager) guildLimit(guildID string) int {
+	if limit, ok := m.GuildConnectionLimits[guildID]; ok {
+		return limit
+	}
+	return m.MaxConnectionsPerGuild
+}
+
+// guildActiveCountLocked counts the connected and connecting sessions in
+// guildID, including servers riding a shared connection. m.mu must be held.
+func (m *SessionManager) guildActiveCountLocked(guildID string) int {
+	count := 0
+	for _, s := range m.sessions {
+		if s.serverEntry.GuildID != guildID {
+			continue
+		}
+		if s.state.active() {
+			count++
+		}
+	}
+	return count
+}
+
+// guildFullLocked reports whether guildID has reached its connection cap.
+// m.mu must be held.
+func (m *SessionManager) guildFullLocked(guildID string) bool {
+	limit := m.guildLimit(guildID)
+	return limit > 0 && m.guildActiveCountLocked(guildID) >= limit
+}
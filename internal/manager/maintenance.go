@@ -0,0 +1,31 @@
+package manager
+
+import (
+	"time"
+
+	"github.com/pyyupsk/discord-stayonline/internal/gateway"
+)
+
+// resumeData is a session's Gateway state kept in memory across a
+// maintenance reconnect.
+type resumeData struct {
+	sessionID string
+	sequence  int64
+	resumeURL string
+}
+
+// reconnectDelay returns how long to wait before reconnecting after client's
+// connection was lost. A maintenance close gets the short, fixed
+// MaintenanceReconnectDelay and the client's session is kept so the next
+// connection resumes it; anything else uses the normal backoff.
+func (m *SessionManager) reconnectDelay(session *Session, client *gateway.Client) time.Duration {
+	if m.MaintenanceReconnectDelay <= 0 || !gateway.IsMaintenanceCloseCode(session.lastCloseCode) {
+		return gateway.CalculateBackoff(session.state.attempt())
+	}
+
+	if sid, seq, resumeURL := client.GetSessionData(); sid != "" && resumeURL != "" {
+		session.resume = &resumeData{sessionID: sid, sequence: seq, resumeURL: resumeURL}
+	}
+	session.logger.Info("Gateway closed for maintenance, reconnecting promptly", "code", session.lastCloseCode)
+	return m.MaintenanceReconnectDelay
+}
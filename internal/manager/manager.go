@@ -3,28 +3,54 @@ package manager
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
+	"maps"
+	"slices"
 	"sync"
 	"time"
 
 	"github.com/pyyupsk/discord-stayonline/internal/config"
 	"github.com/pyyupsk/discord-stayonline/internal/gateway"
+	"github.com/pyyupsk/discord-stayonline/internal/metrics"
+	"github.com/pyyupsk/discord-stayonline/internal/telemetry"
 	"github.com/pyyupsk/discord-stayonline/internal/webhook"
 )
 
 var (
 	ErrServerNotFound     = errors.New("server not found")
-	ErrTooManyConnections = errors.New("maximum 35 connections allowed")
+	ErrTooManyConnections = errors.New("maximum connections reached")
 	ErrTOSNotAcknowledged = errors.New("TOS not acknowledged")
 	ErrAlreadyConnected   = errors.New("already connected")
 	ErrNotConnected       = errors.New("not connected")
+	ErrServiceStopping    = errors.New("service is stopping")
+
+	ErrGuildConnectionLimit = errors.New("maximum connections for this guild reached")
 )
 
 type SessionStore interface {
 	SaveSession(state config.SessionState) error
 	LoadSession(serverID string) (*config.SessionState, error)
 	DeleteSession(serverID string) error
-	UpdateSessionSequence(serverID string, sequence int) error
+	UpdateSessionSequence(serverID string, sequence int64) error
+	ListSessionServerIDs() ([]string, error)
+}
+
+// NotifyEvents selects which session events send webhook notifications.
+type NotifyEvents struct {
+	// Down is a fatal disconnect that stops reconnection.
+	Down bool
+	// Up is a session becoming ready again after a disconnect.
+	Up bool
+	// Reconnecting is a lost connection waiting to retry.
+	Reconnecting bool
+	// Connected is a session's first successful connection.
+	Connected bool
+}
+
+// DefaultNotifyEvents enables the down, up, and reconnecting notifications.
+func DefaultNotifyEvents() NotifyEvents {
+	return NotifyEvents{Down: true, Up: true, Reconnecting: true}
 }
 
 type SessionManager struct {
@@ -32,21 +58,232 @@ type SessionManager struct {
 	store        config.ConfigStore
 	sessionStore SessionStore
 	logger       *slog.Logger
+	baseLogger   *slog.Logger
 	webhook      *webhook.Notifier
 
 	sessions map[string]*Session
 	mu       sync.RWMutex
+	statuses statusCache
+
+	// announced holds the servers that have connected since they were last
+	// stopped, so the connected webhook is sent once rather than on every
+	// READY after a rejoin or an invalidated session. mu guards it.
+	announced map[string]bool
+
+	// reconciled holds the servers seen by the last reconcile, so later
+	// ones only act when servers were removed. reconcileMu guards it.
+	reconciled  []config.ServerEntry
+	reconcileMu sync.Mutex
 
 	OnStatusChange func(serverID string, status ConnectionStatus, message string)
 
+	// FrameLogSize is the number of inbound gateway frame summaries kept per
+	// session for debugging. Zero disables frame logging.
+	FrameLogSize int
+
+	// Metrics receives connect, uptime, and heartbeat observations. Nil
+	// disables metrics.
+	Metrics *metrics.Metrics
+
+	// Telemetry, when set, exports connect traces and mirrors Metrics over
+	// OpenTelemetry. Nil disables it.
+	Telemetry *telemetry.Telemetry
+
+	// GatewayURL overrides the Gateway URL for fresh connections. Empty uses
+	// the default.
+	GatewayURL string
+
+	// ReadTimeout overrides how long a Gateway read may wait for the next
+	// frame before the connection is treated as dead. Zero derives it from
+	// the heartbeat interval.
+	ReadTimeout time.Duration
+
+	// KeepAlive is the TCP keep-alive interval for Gateway connections. Zero
+	// uses the Go default and a negative value disables keep-alive.
+	KeepAlive time.Duration
+
+	// MaxMissedHeartbeatAcks is how many consecutive heartbeats may go
+	// unacknowledged before a connection is treated as dead. Zero uses
+	// gateway.DefaultMaxMissedHeartbeatAcks.
+	MaxMissedHeartbeatAcks int
+
+	// ImmediateFirstHeartbeat sends each connection's first heartbeat
+	// without the jitter Discord recommends.
+	ImmediateFirstHeartbeat bool
+
+	// Shard, when set, pins every connection to that shard in IDENTIFY.
+	Shard *gateway.Shard
+
+	// Activity, when set, is shown with every session's presence, e.g. a
+	// custom status.
+	Activity *gateway.Activity
+
+	// Intents is the Gateway intents bitfield sent in IDENTIFY. Zero omits
+	// it, which user tokens expect; bot tokens must set it.
+	Intents int
+
+	// CompressZlibStream asks the Gateway to compress each connection as a
+	// zlib stream, which shrinks large READY payloads.
+	CompressZlibStream bool
+
+	// CleanupOrphanedSessions makes Start reconcile sessions against the
+	// configured servers. Callers may also run Reconcile after config changes.
+	CleanupOrphanedSessions bool
+
+	// MaxConnections caps concurrently active sessions. Zero or values above
+	// config.MaxServers use config.MaxServers.
+	MaxConnections int
+
+	// MaxConnectionsPerGuild caps the active sessions sharing one guild,
+	// separately from MaxConnections; a Join beyond it fails with
+	// ErrGuildConnectionLimit. GuildConnectionLimits overrides it for
+	// individual guilds. Zero is unlimited.
+	MaxConnectionsPerGuild int
+	GuildConnectionLimits  map[string]int
+
+	// PreemptLowerPriority lets a Join at capacity disconnect the active
+	// session with the lowest priority (highest Priority number) when it is
+	// strictly lower than the joining server's, instead of failing with
+	// ErrTooManyConnections.
+	PreemptLowerPriority bool
+
+	// ResumeFailureGrace defers the reconnecting webhook for a disconnect
+	// caused by an invalid session. If the session is READY again within the
+	// window, neither the reconnecting nor the restored notification is sent.
+	// Zero disables the suppression.
+	ResumeFailureGrace time.Duration
+
+	// StartupSummary makes Start send a startup webhook and, once the
+	// auto-connected sessions have settled, a single status summary.
+	StartupSummary bool
+
+	// StartupSummarySettle is how long sessions must go without a status
+	// change before the summary is sent. Zero uses
+	// DefaultStartupSummarySettle.
+	StartupSummarySettle time.Duration
+
+	// SharedPresenceConnection runs all presence-only servers on a single
+	// Gateway connection instead of one each. Presence is per connection, so
+	// they all show the same status.
+	SharedPresenceConnection bool
+
+	// SessionResumeTTL is how old persisted session data may be and still
+	// be resumed, which is what lets a restart skip IDENTIFY. Older data is
+	// discarded and the session identifies fresh. Zero resumes regardless of
+	// age.
+	SessionResumeTTL time.Duration
+
+	// SequenceFlushInterval is how often a connected session's latest
+	// sequence is saved to the session store, so a restart after a crash
+	// can still resume. The sequence is always saved on disconnect and
+	// shutdown. Zero disables the periodic flush; see
+	// DefaultSequenceFlushInterval.
+	SequenceFlushInterval time.Duration
+
+	// ConfirmVoiceState reports StatusInVoice once Discord confirms the
+	// account joined the configured voice channel, instead of treating the
+	// fire-and-forget voice state update as success.
+	ConfirmVoiceState bool
+
+	// VoiceJoinDelay is how long a session waits after READY before joining
+	// its voice channel, so Discord has settled the new session and does not
+	// ignore the join. Zero joins immediately.
+	VoiceJoinDelay time.Duration
+
+	// NotifyEvents selects which session events are sent to the webhook.
+	// NewSessionManager sets DefaultNotifyEvents.
+	NotifyEvents NotifyEvents
+
+	// NotifyWorkers is the number of goroutines sending webhook
+	// notifications and NotifyQueueSize how many may wait for them; further
+	// notifications are dropped. Zero uses DefaultNotifyWorkers and
+	// DefaultNotifyQueueSize.
+	NotifyWorkers   int
+	NotifyQueueSize int
+
+	// StoreRetryAttempts is how many times a failed session store write is
+	// retried in the background, starting StoreRetryDelay after the failure
+	// and doubling. Zero uses DefaultStoreRetryAttempts and
+	// DefaultStoreRetryDelay.
+	StoreRetryAttempts int
+	StoreRetryDelay    time.Duration
+
+	// QuickConnectRetries is how many times a failed connect is retried
+	// after QuickConnectRetryDelay before entering the exponential backoff,
+	// to ride out transient DNS or TCP failures. The count resets once a
+	// connect succeeds. Zero disables quick retries, and a zero delay uses
+	// DefaultQuickConnectRetryDelay.
+	QuickConnectRetries    int
+	QuickConnectRetryDelay time.Duration
+
+	// MaintenanceReconnectDelay is how long a session waits to reconnect
+	// after Discord closes its connection for maintenance (1001 or 1012),
+	// instead of the normal backoff. The session is resumed on the new
+	// connection. Zero treats these closes like any other disconnect.
+	MaintenanceReconnectDelay time.Duration
+
+	// MaxReconnectAttempts stops reconnecting a session once its backoff
+	// attempt exceeds it, marking it errored and sending the down webhook,
+	// so a token that keeps failing does not retry forever. Zero is
+	// unlimited.
+	MaxReconnectAttempts int
+
+	// MaxConcurrentConnects caps how many sessions may be connecting at
+	// once, from dialing the Gateway until READY or RESUMED; the rest wait
+	// their turn. It does not change per-session backoff. Zero is unlimited.
+	MaxConcurrentConnects int
+
+	// afterFunc runs f after d; nil uses time.AfterFunc. Tests replace it to
+	// control delays.
+	afterFunc func(d time.Duration, f func())
+
+	// now returns the current time for quiet hours and the daily reconnect
+	// count; nil uses time.Now.
+	now func() time.Time
+
+	summary       *startupSummary
+	connects      connectLimiter
+	notifications notifyPool
+	storage       storeState
+	reconnects    dailyCounter
+
 	ctx    context.Context
 	cancel context.CancelFunc
 }
 
 type Session struct {
+	// serverEntry is the entry the session was joined with. It is read
+	// without a lock, so it is never changed once the session is
+	// registered; per-session changes such as overrides live in state.
 	serverEntry config.ServerEntry
 	state       *SessionState
-	client      *gateway.Client
+	frameLog    *gateway.FrameLog
+	logger      *slog.Logger
+
+	// client is the current connection's Gateway client, replaced by the
+	// session goroutine on each connect; clientMu guards it.
+	client   *gateway.Client
+	clientMu sync.Mutex
+
+	// resumeFailedAt is when the Gateway last invalidated this session, and
+	// pendingReconnectNotice holds the deferred webhook for the disconnect
+	// that followed it.
+	resumeFailedAt         time.Time
+	pendingReconnectNotice *time.Timer
+
+	// lastCloseCode is the close code of the current connection's
+	// disconnect, zero if it had none, and resume is the in-memory session
+	// to resume on the next connection after a maintenance close.
+	lastCloseCode int
+	resume        *resumeData
+
+	// quickRetries counts the quick retries used since the last successful
+	// connect.
+	quickRetries int
+
+	// sharedWith is the session whose connection this presence-only server
+	// rides on in shared mode; nil for sessions with their own connection.
+	sharedWith *Session
 
 	ctx    context.Context
 	cancel context.CancelFunc
@@ -59,33 +296,55 @@ func NewSessionManager(token string, store config.ConfigStore, sessionStore Sess
 		logger = slog.Default()
 	}
 	ctx, cancel := context.WithCancel(context.Background())
+	webhookNotifier.SetContext(ctx)
 	return &SessionManager{
 		token:        token,
 		store:        store,
 		sessionStore: sessionStore,
 		webhook:      webhookNotifier,
 		logger:       logger.With("component", "manager"),
+		baseLogger:   logger,
 		sessions:     make(map[string]*Session),
+		NotifyEvents: DefaultNotifyEvents(),
 		ctx:          ctx,
 		cancel:       cancel,
 	}
 }
 
 func (m *SessionManager) Start() error {
-	cfg, err := m.store.Load()
+	cfg, err := m.loadConfig()
 	if err != nil {
 		return err
 	}
 
+	if m.CleanupOrphanedSessions {
+		m.reconcile(cfg)
+	}
+
 	if !cfg.TOSAcknowledged {
 		m.logger.Warn("TOS not acknowledged - skipping auto-connect")
 		return nil
 	}
 
 	var toConnect []config.ServerEntry
+	shared := 0
 	for _, server := range cfg.Servers {
 		if server.ConnectOnStart {
 			toConnect = append(toConnect, server)
+			if m.sharesPresence(server) {
+				shared++
+			}
+		}
+	}
+	if shared > 1 {
+		m.logger.Info("Presence-only servers will share one Gateway connection and show the same status", "servers", shared)
+	}
+
+	if m.StartupSummary && m.webhook != nil {
+		connecting := len(toConnect)
+		m.notify(func() { m.webhook.NotifyStartup(connecting) })
+		if len(toConnect) > 0 {
+			m.summary = newStartupSummary(m, toConnect)
 		}
 	}
 
@@ -110,10 +369,11 @@ func (m *SessionManager) Stop() {
 
 	for id, session := range m.sessions {
 		m.logger.Info("Stopping session", "server_id", id)
-		session.cancel()
-		if session.client != nil {
-			_ = session.client.Close()
+		if client := session.currentClient(); client != nil {
+			m.persistSequence(session, client)
+			_ = client.CloseForResume()
 		}
+		session.cancel()
 		if session.stopReconnect != nil {
 			select {
 			case <-session.stopReconnect:
@@ -125,7 +385,10 @@ func (m *SessionManager) Stop() {
 }
 
 func (m *SessionManager) Join(serverID string) error {
-	cfg, err := m.store.Load()
+	if m.ctx.Err() != nil {
+		return ErrServiceStopping
+	}
+	cfg, err := m.loadConfig()
 	if err != nil {
 		return err
 	}
@@ -143,45 +406,143 @@ func (m *SessionManager) Join(serverID string) error {
 	if serverEntry == nil {
 		return ErrServerNotFound
 	}
+	if !config.GuildAllowed(serverEntry.GuildID) {
+		return config.ErrGuildNotAllowed
+	}
 
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	if session, exists := m.sessions[serverID]; exists {
-		if session.state.ConnectionStatus == StatusConnected ||
-			session.state.ConnectionStatus == StatusConnecting {
-			return ErrAlreadyConnected
+	for {
+		if err := m.checkJoinLocked(serverID, serverEntry.GuildID); err != nil {
+			return err
 		}
-	}
 
-	activeCount := 0
-	for _, s := range m.sessions {
-		if s.state.ConnectionStatus == StatusConnected ||
-			s.state.ConnectionStatus == StatusConnecting {
-			activeCount++
+		if m.sharesPresence(*serverEntry) {
+			if leader := m.sharedLeaderLocked(serverID); leader != nil {
+				m.attachFollowerLocked(*serverEntry, leader)
+				return nil
+			}
 		}
-	}
-	if activeCount >= config.MaxServerEntries {
-		return ErrTooManyConnections
+
+		if m.activeCountLocked() < m.maxConnections() {
+			break
+		}
+		victim := m.preemptionCandidateLocked(serverEntry.Priority)
+		if victim == nil {
+			return ErrTooManyConnections
+		}
+
+		victimID := victim.serverEntry.ID
+		m.mu.Unlock()
+		m.logger.Warn("Preempting lower-priority session",
+			"server_id", victimID,
+			"priority", victim.serverEntry.Priority,
+			"preempted_by", serverID,
+			"preempted_by_priority", serverEntry.Priority,
+		)
+		m.stopSession(victimID, "Preempted by higher-priority server")
+		m.mu.Lock()
+		// Other Joins and Stop may have run while the lock was released,
+		// so every check runs again before the session is added.
 	}
 
+	// The session counts as connecting from the moment it is added, so a
+	// Join racing with runSession's first connect sees it as joined.
+	state := NewSessionState(serverID)
+	state.setStatusOverride(serverEntry.StatusOverride)
+	state.MarkConnecting()
 	ctx, cancel := context.WithCancel(m.ctx)
 	session := &Session{
 		serverEntry:   *serverEntry,
-		state:         NewSessionState(serverID),
+		state:         state,
+		frameLog:      gateway.NewFrameLog(m.FrameLogSize),
+		logger:        m.sessionLogger(*serverEntry),
 		ctx:           ctx,
 		cancel:        cancel,
 		stopReconnect: make(chan struct{}),
 	}
 
 	m.sessions[serverID] = session
+	m.trackSession(session)
 
 	go m.runSession(session)
+	if len(serverEntry.QuietHours) > 0 && !m.sharesPresence(*serverEntry) {
+		go m.scheduleQuietHours(session)
+	}
+
+	return nil
+}
 
+// checkJoinLocked returns why serverID cannot start a session in guildID
+// now, or nil if it can. m.mu must be held.
+func (m *SessionManager) checkJoinLocked(serverID, guildID string) error {
+	// Stop cancels the context before taking the lock, so checking again
+	// here keeps a Join racing with Stop from adding a doomed session.
+	if m.ctx.Err() != nil {
+		return ErrServiceStopping
+	}
+	if session, exists := m.sessions[serverID]; exists && session.state.active() {
+		return ErrAlreadyConnected
+	}
+	if m.guildFullLocked(guildID) {
+		return ErrGuildConnectionLimit
+	}
 	return nil
 }
 
+func (m *SessionManager) maxConnections() int {
+	if m.MaxConnections <= 0 || m.MaxConnections > config.MaxServers() {
+		return config.MaxServers()
+	}
+	return m.MaxConnections
+}
+
+// activeCountLocked counts connected and connecting sessions. m.mu must be held.
+func (m *SessionManager) activeCountLocked() int {
+	count := 0
+	for _, s := range m.sessions {
+		if s.sharedWith != nil {
+			continue
+		}
+		if s.state.active() {
+			count++
+		}
+	}
+	return count
+}
+
+// preemptionCandidateLocked returns the active session with the lowest
+// priority below priority, or nil if preemption is disabled or none
+// qualifies. m.mu must be held.
+func (m *SessionManager) preemptionCandidateLocked(priority int) *Session {
+	if !m.PreemptLowerPriority {
+		return nil
+	}
+	var victim *Session
+	for _, s := range m.sessions {
+		if s.sharedWith != nil {
+			continue
+		}
+		if !s.state.active() {
+			continue
+		}
+		if s.serverEntry.Priority <= priority {
+			continue
+		}
+		if victim == nil ||
+			s.serverEntry.Priority > victim.serverEntry.Priority ||
+			(s.serverEntry.Priority == victim.serverEntry.Priority && s.serverEntry.ID > victim.serverEntry.ID) {
+			victim = s
+		}
+	}
+	return victim
+}
+
 func (m *SessionManager) Rejoin(serverID string) error {
+	if m.ctx.Err() != nil {
+		return ErrServiceStopping
+	}
 	m.mu.Lock()
 	session, exists := m.sessions[serverID]
 	m.mu.Unlock()
@@ -199,20 +560,24 @@ func (m *SessionManager) Rejoin(serverID string) error {
 		}
 	}
 
-	if session.client != nil {
-		_ = session.client.Close()
+	if client := session.currentClient(); client != nil {
+		_ = client.Close()
 	}
 	session.cancel()
 
 	m.mu.Lock()
 	delete(m.sessions, serverID)
+	m.statuses.remove(serverID)
+	followers := m.detachFollowersLocked(session)
 	m.mu.Unlock()
 
 	m.deleteSessionData(serverID)
 
 	time.Sleep(100 * time.Millisecond)
 
-	return m.Join(serverID)
+	err := m.Join(serverID)
+	m.rejoinFollowers(followers)
+	return err
 }
 
 func (m *SessionManager) deleteSessionData(serverID string) {
@@ -225,17 +590,116 @@ func (m *SessionManager) deleteSessionData(serverID string) {
 }
 
 func (m *SessionManager) Exit(serverID string) error {
+	if !m.stopSession(serverID, "User requested exit") {
+		return ErrNotConnected
+	}
+	return nil
+}
+
+// RemoveServer deletes a server from the configuration, stopping its
+// session and deleting its persisted session data, and returns the
+// remaining servers.
+func (m *SessionManager) RemoveServer(serverID string) ([]config.ServerEntry, error) {
+	cfg, err := config.Update(m.store, func(cfg *config.Configuration) error {
+		idx := slices.IndexFunc(cfg.Servers, func(s config.ServerEntry) bool { return s.ID == serverID })
+		if idx < 0 {
+			return ErrServerNotFound
+		}
+		cfg.Servers = slices.Delete(cfg.Servers, idx, idx+1)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !m.stopSession(serverID, "Server removed from configuration") {
+		m.deleteSessionData(serverID)
+	}
+	return cfg.Servers, nil
+}
+
+// Reconcile tears down in-memory sessions and deletes persisted session data
+// for servers that are no longer configured. After the first call, it does
+// nothing unless servers were removed since the previous one.
+func (m *SessionManager) Reconcile() error {
+	cfg, err := config.LoadConfig(m.store)
+	if err != nil {
+		return err
+	}
+	m.reconcile(cfg)
+	return nil
+}
+
+func (m *SessionManager) reconcile(cfg *config.Configuration) {
+	m.reconcileMu.Lock()
+	prev := m.reconciled
+	m.reconciled = slices.Clone(cfg.Servers)
+	m.reconcileMu.Unlock()
+	if prev != nil {
+		if _, removed := config.ChangedServers(prev, cfg.Servers); len(removed) == 0 {
+			return
+		}
+	}
+
+	configured := make(map[string]bool, len(cfg.Servers))
+	for _, server := range cfg.Servers {
+		configured[server.ID] = true
+	}
+
+	m.mu.RLock()
+	var removed []string
+	for id := range m.sessions {
+		if !configured[id] {
+			removed = append(removed, id)
+		}
+	}
+	m.mu.RUnlock()
+
+	for _, id := range removed {
+		m.logger.Info("Stopping session for removed server", "server_id", id)
+		m.stopSession(id, "Server removed from configuration")
+	}
+
+	if m.sessionStore == nil {
+		return
+	}
+	ids, err := m.sessionStore.ListSessionServerIDs()
+	if err != nil {
+		m.logger.Error("Failed to list persisted sessions", "error", err)
+		return
+	}
+	for _, id := range ids {
+		if !configured[id] {
+			m.logger.Info("Deleting orphaned session data", "server_id", id)
+			m.deleteSessionData(id)
+		}
+	}
+}
+
+// stopSession tears down a running session and its persisted data. It
+// reports whether a session existed.
+func (m *SessionManager) stopSession(serverID, reason string) bool {
 	m.mu.Lock()
 	session, exists := m.sessions[serverID]
 	if !exists {
 		m.mu.Unlock()
-		return ErrNotConnected
+		return false
+	}
+
+	if session.sharedWith != nil {
+		delete(m.sessions, serverID)
+		m.statuses.remove(serverID)
+		m.mu.Unlock()
+		session.cancel()
+		m.notifyStatusChange(serverID, StatusDisconnected, reason)
+		session.logger.Info("Left shared presence-only connection")
+		return true
 	}
 
 	session.state.MarkDisconnected()
 	m.mu.Unlock()
 
-	m.notifyStatusChange(serverID, StatusDisconnected, "User requested exit")
+	m.notifyStatusChange(serverID, StatusDisconnected, reason)
 
 	if session.stopReconnect != nil {
 		select {
@@ -245,46 +709,169 @@ func (m *SessionManager) Exit(serverID string) error {
 		}
 	}
 
-	if session.client != nil {
-		_ = session.client.Close()
+	if client := session.currentClient(); client != nil {
+		_ = client.Close()
 	}
 	session.cancel()
 
 	m.mu.Lock()
 	delete(m.sessions, serverID)
+	delete(m.announced, serverID)
+	m.statuses.remove(serverID)
+	followers := m.detachFollowersLocked(session)
 	m.mu.Unlock()
 
 	m.deleteSessionData(serverID)
 
-	m.logger.Info("Session exited", "server_id", serverID)
-	return nil
+	session.logger.Info("Session exited")
+	m.rejoinFollowers(followers)
+	return true
 }
 
+// GetStatus reports serverID's status from the status snapshot without
+// taking the session map lock.
 func (m *SessionManager) GetStatus(serverID string) (ConnectionStatus, error) {
+	status, exists := m.statuses.load()[serverID]
+	if !exists {
+		return StatusDisconnected, nil
+	}
+	return status, nil
+}
+
+// GetAllStatuses returns a copy of the status snapshot, taken without the
+// session map lock.
+func (m *SessionManager) GetAllStatuses() map[string]ConnectionStatus {
+	statuses := maps.Clone(m.statuses.load())
+	if statuses == nil {
+		statuses = make(map[string]ConnectionStatus)
+	}
+	return statuses
+}
+
+// ApplyStatus sets the presence status on every live session that follows
+// the global status, sending a presence update to those currently connected.
+// Sessions that are still connecting pick the status up in their IDENTIFY.
+func (m *SessionManager) ApplyStatus(status string) {
+	m.mu.RLock()
+	sessions := make([]*Session, 0, len(m.sessions))
+	for _, session := range m.sessions {
+		if session.state.statusOverride() == "" && session.serverEntry.Status == "" && !m.inQuietHours(session) {
+			sessions = append(sessions, session)
+		}
+	}
+	m.mu.RUnlock()
+
+	for _, session := range sessions {
+		m.sendPresence(session, status)
+	}
+}
+
+// SetStatusOverride stores a presence status for one server that takes
+// precedence over the global status, including across reconnects, and sends
+// it to the live session. An empty status clears the override and reverts the
+// session to the server's own status, or the global status if it has none.
+// It returns the override that was replaced.
+func (m *SessionManager) SetStatusOverride(serverID string, status config.Status) (config.Status, error) {
+	var previous, effective config.Status
+	_, err := config.Update(m.store, func(cfg *config.Configuration) error {
+		idx := slices.IndexFunc(cfg.Servers, func(s config.ServerEntry) bool { return s.ID == serverID })
+		if idx < 0 {
+			return ErrServerNotFound
+		}
+		if status != "" && m.sharesPresence(cfg.Servers[idx]) {
+			return ErrSharedConnection
+		}
+		previous = cfg.Servers[idx].StatusOverride
+		cfg.Servers[idx].StatusOverride = status
+
+		effective = status
+		if effective == "" {
+			effective = cfg.Servers[idx].Status
+		}
+		if effective == "" {
+			effective = cfg.Status
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	effective, _ = config.NormalizeStatus(effective)
+
+	m.mu.Lock()
+	session, exists := m.sessions[serverID]
+	quiet := false
+	if exists {
+		session.state.setStatusOverride(status)
+		quiet = m.inQuietHours(session)
+	}
+	m.mu.Unlock()
+
+	if exists && !quiet {
+		m.sendPresence(session, string(effective))
+	}
+	return previous, nil
+}
+
+// sendPresence sets the status used for future IDENTIFYs and, when the
+// session is connected, sends it as a presence update.
+func (m *SessionManager) sendPresence(session *Session, status string) {
+	client := session.currentClient()
+	if client == nil {
+		return
+	}
+	client.SetStatus(status)
+	if client.State() != gateway.StateConnected {
+		return
+	}
+	ctx, cancel := context.WithTimeout(session.ctx, 5*time.Second)
+	defer cancel()
+	if err := client.SendPresenceUpdate(ctx, status); err != nil {
+		session.logger.Warn("Failed to send presence update", "status", status, "error", err)
+	}
+}
+
+// GetSessionDetail returns a snapshot of a session's state, taken under the
+// state's lock, with its current uptime. A presence-only server sharing
+// another's connection reports that connection's state under its own ID.
+func (m *SessionManager) GetSessionDetail(serverID string) (*SessionDetail, bool) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
 	session, exists := m.sessions[serverID]
 	if !exists {
-		return StatusDisconnected, nil
+		return nil, false
 	}
-	return session.state.ConnectionStatus, nil
+	detail := &SessionDetail{SessionState: session.state.Snapshot()}
+	detail.ServerEntryID = serverID
+	since, uptime := detail.uptime(time.Now())
+	detail.ConnectedSince = since
+	detail.UptimeSecs = int64(uptime / time.Second)
+	detail.MaxReconnectAttempts = m.MaxReconnectAttempts
+	return detail, true
 }
 
-func (m *SessionManager) GetAllStatuses() map[string]ConnectionStatus {
+// GetFrames returns the recent inbound frame summaries for a session.
+func (m *SessionManager) GetFrames(serverID string) ([]gateway.FrameSummary, bool) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	statuses := make(map[string]ConnectionStatus)
-	for id, session := range m.sessions {
-		statuses[id] = session.state.ConnectionStatus
+	session, exists := m.sessions[serverID]
+	if !exists {
+		return nil, false
 	}
-	return statuses
+	return session.frameLog.Frames(), true
+}
+
+// sessionLogger scopes log output to one server entry, keyed by both its ID
+// and a human-friendly label.
+func (m *SessionManager) sessionLogger(entry config.ServerEntry) *slog.Logger {
+	return m.logger.With("server_id", entry.ID, "server", entry.DisplayName())
 }
 
 func (m *SessionManager) runSession(session *Session) {
 	serverID := session.serverEntry.ID
-	m.logger.Info("Starting session", "server_id", serverID)
+	session.logger.Info("Starting session")
 
 	for {
 		if m.shouldStopSession(session) {
@@ -294,21 +881,36 @@ func (m *SessionManager) runSession(session *Session) {
 		session.state.MarkConnecting()
 		m.notifyStatusChange(serverID, StatusConnecting, "Connecting...")
 
-		status := m.loadGlobalStatus()
+		status := m.loadSessionStatus(serverID)
 		if status == "" {
 			return
 		}
 
+		release := m.acquireConnectSlot(session)
+		if release == nil {
+			return
+		}
+
+		session.lastCloseCode = 0
 		client := m.createAndConfigureClient(session, status)
+		onReady := client.OnReady
+		client.OnReady = func(sessionID string) {
+			release()
+			onReady(sessionID)
+		}
 
+		connectStartedAt := time.Now()
 		if err := client.Connect(session.ctx); err != nil {
+			release()
+			m.Telemetry.RecordConnectError(session.serverEntry.ID, connectStartedAt, session.state.attempt(), err)
 			if m.handleConnectionError(session, err) {
 				continue
 			}
 			return
 		}
+		session.quickRetries = 0
 
-		if m.waitForDisconnection(session, client) {
+		if m.waitForDisconnection(session, client, release) {
 			return
 		}
 	}
@@ -325,71 +927,162 @@ func (m *SessionManager) shouldStopSession(session *Session) bool {
 	}
 }
 
-func (m *SessionManager) loadGlobalStatus() string {
-	cfg, err := m.store.Load()
+// loadSessionStatus returns the status of the server's active quiet hours,
+// else its status override if one is stored, else its own status, else the
+// global status.
+func (m *SessionManager) loadSessionStatus(serverID string) string {
+	cfg, err := m.loadConfig()
 	if err != nil {
 		m.logger.Error("Failed to load config", "error", err)
 		return ""
 	}
-	status := string(cfg.Status)
-	if status == "" {
-		status = "online"
+	for _, entry := range cfg.Servers {
+		if entry.ID != serverID || m.sharesPresence(entry) {
+			continue
+		}
+		if status, quiet := entry.QuietStatus(m.clock()); quiet {
+			return string(status)
+		}
+		if entry.StatusOverride != "" {
+			if status, ok := config.NormalizeStatus(entry.StatusOverride); ok {
+				return string(status)
+			}
+			m.logger.Warn("Invalid status override, ignoring it", "server_id", serverID, "status", entry.StatusOverride)
+		}
+		if entry.Status != "" {
+			if status, ok := config.NormalizeStatus(entry.Status); ok {
+				return string(status)
+			}
+			m.logger.Warn("Invalid server status, using global status", "server_id", serverID, "status", entry.Status)
+		}
 	}
-	return status
+	status, ok := config.NormalizeStatus(cfg.Status)
+	if !ok {
+		m.logger.Warn("Invalid configured status, defaulting to online", "status", cfg.Status)
+	}
+	return string(status)
 }
 
 func (m *SessionManager) createAndConfigureClient(session *Session, status string) *gateway.Client {
-	serverID := session.serverEntry.ID
-	client := gateway.NewClient(m.token, m.logger)
+	client := gateway.NewClient(m.token, m.baseLogger.With("server_id", session.serverEntry.ID, "server", session.serverEntry.DisplayName()))
 	client.SetStatus(status)
-	session.client = client
-
-	m.tryResumeSession(client, serverID)
+	client.SetGatewayURL(m.GatewayURL)
+	client.SetReadTimeout(m.ReadTimeout)
+	client.SetKeepAlive(m.KeepAlive)
+	client.SetMaxMissedHeartbeatAcks(m.MaxMissedHeartbeatAcks)
+	client.SetImmediateFirstHeartbeat(m.ImmediateFirstHeartbeat)
+	client.SetIntents(m.Intents)
+	client.SetCompressZlibStream(m.CompressZlibStream)
+	client.SetActivity(m.Activity)
+	client.SetShard(m.Shard)
+	client.SetFrameLog(session.frameLog)
+	session.setClient(client)
+
+	m.tryResumeSession(session, client)
 	m.setupClientCallbacks(session, client)
 
 	return client
 }
 
-func (m *SessionManager) tryResumeSession(client *gateway.Client, serverID string) {
+func (m *SessionManager) tryResumeSession(session *Session, client *gateway.Client) {
+	if resume := session.resume; resume != nil {
+		session.resume = nil
+		client.SetResumeData(resume.sessionID, resume.sequence, resume.resumeURL)
+		session.logger.Info("Attempting session resume", "session_id", resume.sessionID)
+		return
+	}
 	if m.sessionStore == nil {
 		return
 	}
-	savedSession, err := m.sessionStore.LoadSession(serverID)
+	savedSession, err := m.sessionStore.LoadSession(session.serverEntry.ID)
 	if err != nil || savedSession == nil {
 		return
 	}
+	if age := time.Since(savedSession.UpdatedAt); m.SessionResumeTTL > 0 && !savedSession.UpdatedAt.IsZero() && age > m.SessionResumeTTL {
+		session.logger.Info("Persisted session too old to resume, identifying", "age", age.Round(time.Second))
+		m.deleteSessionData(session.serverEntry.ID)
+		return
+	}
 	client.SetResumeData(savedSession.SessionID, savedSession.Sequence, savedSession.ResumeURL)
-	m.logger.Info("Attempting session resume", "server_id", serverID, "session_id", savedSession.SessionID)
+	session.logger.Info("Attempting session resume", "session_id", savedSession.SessionID)
+}
+
+// announce marks serverID as connected and reports whether it was not
+// already, that is, whether this is its first READY since it was started.
+func (m *SessionManager) announce(serverID string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.announced[serverID] {
+		return false
+	}
+	if m.announced == nil {
+		m.announced = make(map[string]bool)
+	}
+	m.announced[serverID] = true
+	return true
 }
 
 func (m *SessionManager) setupClientCallbacks(session *Session, client *gateway.Client) {
 	serverID := session.serverEntry.ID
 
 	client.OnReady = func(sessionID string) {
-		wasReconnecting := session.state.BackoffAttempt > 0
+		attempt := session.state.attempt()
+		wasReconnecting := attempt > 0
 
 		session.state.MarkConnected(sessionID)
+		stats := client.ClientStats()
+		m.Metrics.ObserveConnect(stats.Total)
+		m.Telemetry.RecordConnect(serverID, client.ConnectedAt(), stats, attempt)
 		m.notifyStatusChange(serverID, StatusConnected, "Connected")
 		m.saveSessionState(serverID, client)
-		m.joinVoiceChannel(session, client)
+		m.scheduleVoiceJoin(session, client)
+		first := m.announce(serverID)
+
+		if m.webhook == nil {
+			return
+		}
+		switch {
+		case wasReconnecting:
+			if !m.suppressRecoveryNotice(session) && m.NotifyEvents.Up {
+				server := webhookServer(session.serverEntry)
+				m.notify(func() { m.webhook.NotifyUp(server) })
+			}
+		case first && m.NotifyEvents.Connected:
+			server := webhookServer(session.serverEntry)
+			m.notify(func() { m.webhook.NotifyConnected(server) })
+		}
+	}
+
+	if m.Metrics != nil || m.Telemetry != nil {
+		client.OnHeartbeatAck = func(rtt time.Duration) {
+			m.Metrics.ObserveHeartbeatRTT(rtt)
+			m.Telemetry.ObserveHeartbeatRTT(rtt)
+		}
+	}
 
-		if wasReconnecting && m.webhook != nil {
-			go m.webhook.NotifyUp(
-				serverID,
-				session.serverEntry.GuildID,
-				session.serverEntry.ChannelID,
-			)
+	if m.ConfirmVoiceState {
+		client.OnVoiceState = func(guildID, channelID string) {
+			m.handleVoiceState(session, guildID, channelID)
 		}
 	}
 
-	client.OnDisconnect = func(_ int, reason string) {
+	client.OnDisconnect = func(code int, reason string) {
+		session.lastCloseCode = code
 		session.state.MarkError(reason)
+		session.state.MarkClosed(code)
 		m.notifyStatusChange(serverID, StatusError, reason)
 	}
 
 	client.OnError = func(err error) {
 		session.state.MarkError(err.Error())
+		var closeErr *gateway.CloseError
+		if errors.As(err, &closeErr) {
+			session.state.MarkClosed(closeErr.Code)
+		}
 		m.notifyStatusChange(serverID, StatusError, err.Error())
+		if errors.Is(err, gateway.ErrInvalidSession) {
+			session.resumeFailedAt = time.Now()
+		}
 		m.handleInvalidSession(serverID, err)
 		m.handleFatalError(session, serverID, err)
 	}
@@ -409,18 +1102,76 @@ func (m *SessionManager) saveSessionState(serverID string, client *gateway.Clien
 		Sequence:  seq,
 		ResumeURL: resumeURL,
 	}
-	if err := m.sessionStore.SaveSession(state); err != nil {
-		m.logger.Error("Failed to save session state", "server_id", serverID, "error", err)
+	m.writeSession(serverID, "save", func() error {
+		return m.sessionStore.SaveSession(state)
+	})
+}
+
+// persistSequence saves the client's latest sequence to the stored session,
+// if there is one, so a resume after a disconnect or restart replays as
+// little as possible.
+func (m *SessionManager) persistSequence(session *Session, client *gateway.Client) {
+	if m.sessionStore == nil {
+		return
+	}
+	seq := client.Sequence()
+	if seq <= 0 {
+		return
 	}
+	serverID := session.serverEntry.ID
+	m.writeSession(serverID, "update_sequence", func() error {
+		return m.sessionStore.UpdateSessionSequence(serverID, seq)
+	})
+}
+
+// scheduleVoiceJoin joins the voice channel once VoiceJoinDelay has passed
+// after READY, unless the session has stopped by then.
+func (m *SessionManager) scheduleVoiceJoin(session *Session, client *gateway.Client) {
+	if m.VoiceJoinDelay <= 0 {
+		m.joinVoiceChannel(session, client)
+		return
+	}
+	join := func() {
+		if session.ctx.Err() != nil {
+			return
+		}
+		m.joinVoiceChannel(session, client)
+	}
+	if m.afterFunc != nil {
+		m.afterFunc(m.VoiceJoinDelay, join)
+		return
+	}
+	time.AfterFunc(m.VoiceJoinDelay, join)
 }
 
 func (m *SessionManager) joinVoiceChannel(session *Session, client *gateway.Client) {
-	if session.serverEntry.ChannelID == "" {
+	channelID := targetChannel(session)
+	if session.serverEntry.PresenceOnly || channelID == "" {
 		return
 	}
 	ctx, cancel := context.WithTimeout(session.ctx, 5*time.Second)
 	defer cancel()
-	_ = client.SendVoiceStateUpdate(ctx, session.serverEntry.GuildID, session.serverEntry.ChannelID, true, true)
+	entry := session.serverEntry
+	_ = client.SendVoiceStateUpdate(ctx, entry.GuildID, channelID, entry.SelfMuted(), entry.SelfDeafened())
+}
+
+// handleVoiceState moves a connected session to StatusInVoice when Discord
+// reports the account in the target channel, and back when it leaves.
+func (m *SessionManager) handleVoiceState(session *Session, guildID, channelID string) {
+	entry := session.serverEntry
+	target := targetChannel(session)
+	if target == "" || guildID != entry.GuildID {
+		return
+	}
+	state := session.state
+	switch status := state.status(); {
+	case channelID == target && status == StatusConnected:
+		state.MarkInVoice()
+		m.notifyStatusChange(entry.ID, StatusInVoice, "Voice channel joined")
+	case channelID != target && status == StatusInVoice:
+		state.MarkOutOfVoice()
+		m.notifyStatusChange(entry.ID, StatusConnected, "Not in voice channel")
+	}
 }
 
 func (m *SessionManager) handleInvalidSession(serverID string, err error) {
@@ -429,9 +1180,9 @@ func (m *SessionManager) handleInvalidSession(serverID string, err error) {
 	}
 	m.logger.Info("Clearing invalid session data", "server_id", serverID)
 	if m.sessionStore != nil {
-		if delErr := m.sessionStore.DeleteSession(serverID); delErr != nil {
-			m.logger.Error("Failed to delete session", "server_id", serverID, "error", delErr)
-		}
+		m.writeSession(serverID, "delete", func() error {
+			return m.sessionStore.DeleteSession(serverID)
+		})
 	}
 }
 
@@ -439,15 +1190,11 @@ func (m *SessionManager) handleFatalError(session *Session, serverID string, err
 	if !errors.Is(err, gateway.ErrFatalClose) {
 		return
 	}
-	m.logger.Error("Fatal Gateway error - stopping reconnection", "server_id", serverID, "error", err)
+	session.logger.Error("Fatal Gateway error - stopping reconnection", "error", err)
 
-	if m.webhook != nil {
-		go m.webhook.NotifyDown(
-			serverID,
-			session.serverEntry.GuildID,
-			session.serverEntry.ChannelID,
-			err.Error(),
-		)
+	if m.webhook != nil && m.NotifyEvents.Down {
+		server := webhookServer(session.serverEntry)
+		m.notify(func() { m.webhook.NotifyDown(server, err.Error()) })
 	}
 
 	select {
@@ -457,16 +1204,59 @@ func (m *SessionManager) handleFatalError(session *Session, serverID string, err
 	}
 }
 
+// reconnectAttemptsExhausted stops the session's reconnect loop once its
+// backoff attempt exceeds MaxReconnectAttempts, reporting whether it did.
+func (m *SessionManager) reconnectAttemptsExhausted(session *Session) bool {
+	if m.MaxReconnectAttempts <= 0 || session.state.attempt() <= m.MaxReconnectAttempts {
+		return false
+	}
+	reason := fmt.Sprintf("Gave up after %d reconnect attempts", m.MaxReconnectAttempts)
+	session.logger.Error("Reconnect attempts exhausted - stopping reconnection", "max", m.MaxReconnectAttempts)
+	session.state.MarkError(reason)
+	m.notifyStatusChange(session.serverEntry.ID, StatusError, reason)
+
+	if m.webhook != nil && m.NotifyEvents.Down {
+		server := webhookServer(session.serverEntry)
+		m.notify(func() { m.webhook.NotifyDown(server, reason) })
+	}
+
+	select {
+	case <-session.stopReconnect:
+	default:
+		close(session.stopReconnect)
+	}
+	return true
+}
+
 func (m *SessionManager) handleConnectionError(session *Session, err error) bool {
 	serverID := session.serverEntry.ID
-	session.state.MarkError(err.Error())
+	var dialErr *gateway.DialError
+	errors.As(err, &dialErr)
+	var kind gateway.DialFailure
+	if dialErr != nil {
+		kind = dialErr.Kind
+	}
+	session.state.MarkDialFailure(err.Error(), kind)
 	m.notifyStatusChange(serverID, StatusError, err.Error())
 
-	session.state.MarkBackoff()
-	m.notifyStatusChange(serverID, StatusBackoff, "Waiting to reconnect...")
-
-	delay := gateway.CalculateBackoff(session.state.BackoffAttempt)
-	m.logger.Info("Waiting before reconnect", "server_id", serverID, "delay", delay)
+	var delay time.Duration
+	if session.quickRetries < m.QuickConnectRetries && quickRetryAllowed(dialErr) {
+		session.quickRetries++
+		delay = m.QuickConnectRetryDelay
+		if delay <= 0 {
+			delay = DefaultQuickConnectRetryDelay
+		}
+		session.logger.Info("Connect failed, retrying quickly", "attempt", session.quickRetries, "max", m.QuickConnectRetries, "delay", delay)
+	} else {
+		session.state.MarkBackoff()
+		if m.reconnectAttemptsExhausted(session) {
+			return false
+		}
+		m.notifyStatusChange(serverID, StatusBackoff, "Waiting to reconnect...")
+		delay = dialBackoff(gateway.CalculateBackoff(session.state.attempt()), dialErr)
+		session.logger.Info("Waiting before reconnect", "delay", delay)
+	}
+	session.state.ScheduleRetry(delay)
 
 	select {
 	case <-session.ctx.Done():
@@ -478,27 +1268,51 @@ func (m *SessionManager) handleConnectionError(session *Session, err error) bool
 	}
 }
 
-func (m *SessionManager) waitForDisconnection(session *Session, client *gateway.Client) bool {
+// waitForDisconnection blocks until the connection is lost or the session
+// stops, then waits out the reconnect backoff. It calls release, freeing the
+// connect slot, before any backoff.
+func (m *SessionManager) waitForDisconnection(session *Session, client *gateway.Client, release func()) bool {
+	if m.sessionStore != nil && m.SequenceFlushInterval > 0 {
+		stopFlush := make(chan struct{})
+		defer close(stopFlush)
+		go m.flushSequences(session, client, stopFlush)
+	}
+
 	disconnected := client.Disconnected()
 	select {
 	case <-session.ctx.Done():
 		_ = client.Close()
+		release()
 		return true
 	case <-session.stopReconnect:
 		_ = client.Close()
+		release()
 		return true
 	case <-disconnected:
+		release()
 		serverID := session.serverEntry.ID
-		m.logger.Info("Connection lost, will reconnect", "server_id", serverID)
+		session.logger.Info("Connection lost, will reconnect")
+		m.persistSequence(session, client)
 		_ = client.Close()
+		if connectedAt := client.ConnectedAt(); !connectedAt.IsZero() {
+			m.Metrics.ObserveSessionUptime(time.Since(connectedAt))
+			m.Telemetry.ObserveSessionUptime(time.Since(connectedAt))
+		}
+
+		m.Metrics.IncReconnects()
+		m.reconnects.inc(m.clock())
 
 		session.state.MarkBackoff()
+		if m.reconnectAttemptsExhausted(session) {
+			return true
+		}
 		m.notifyStatusChange(serverID, StatusBackoff, "Reconnecting...")
-		delay := gateway.CalculateBackoff(session.state.BackoffAttempt)
-		m.logger.Info("Waiting before reconnect", "server_id", serverID, "delay", delay)
+		delay := m.reconnectDelay(session, client)
+		session.state.ScheduleRetry(delay)
+		session.logger.Info("Waiting before reconnect", "delay", delay)
 
-		if m.webhook != nil {
-			go m.webhook.NotifyReconnecting(serverID, session.state.BackoffAttempt, delay)
+		if m.webhook != nil && m.NotifyEvents.Reconnecting {
+			m.notifyReconnecting(session, delay)
 		}
 
 		select {
@@ -513,7 +1327,49 @@ func (m *SessionManager) waitForDisconnection(session *Session, client *gateway.
 }
 
 func (m *SessionManager) notifyStatusChange(serverID string, status ConnectionStatus, message string) {
+	m.summary.touch()
 	if m.OnStatusChange != nil {
 		m.OnStatusChange(serverID, status, message)
+		for _, id := range m.followerIDs(serverID) {
+			m.OnStatusChange(id, status, message)
+		}
+	}
+}
+
+// notifyReconnecting sends the reconnecting webhook, deferring it by
+// ResumeFailureGrace when the disconnect followed an invalid session so a
+// quick re-identify stays silent.
+func (m *SessionManager) notifyReconnecting(session *Session, delay time.Duration) {
+	server := webhookServer(session.serverEntry)
+	attempt := session.state.attempt()
+
+	resumeFailed := !session.resumeFailedAt.IsZero() && time.Since(session.resumeFailedAt) < m.ResumeFailureGrace
+	session.resumeFailedAt = time.Time{}
+	if m.ResumeFailureGrace <= 0 || !resumeFailed {
+		m.notify(func() { m.webhook.NotifyReconnecting(server, attempt, delay) })
+		return
+	}
+
+	session.pendingReconnectNotice = time.AfterFunc(m.ResumeFailureGrace, func() {
+		if session.ctx.Err() != nil {
+			return
+		}
+		m.notify(func() { m.webhook.NotifyReconnecting(server, attempt, delay) })
+	})
+}
+
+// suppressRecoveryNotice cancels a deferred reconnecting webhook. It reports
+// true when the notice had not been sent yet, meaning the matching restored
+// notification should be skipped as well.
+func (m *SessionManager) suppressRecoveryNotice(session *Session) bool {
+	timer := session.pendingReconnectNotice
+	if timer == nil {
+		return false
+	}
+	session.pendingReconnectNotice = nil
+	if !timer.Stop() {
+		return false
 	}
+	session.logger.Info("Recovered from invalid session within grace period, skipping notifications")
+	return true
 }
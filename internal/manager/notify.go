@@ -0,0 +1,87 @@
+package manager
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/pyyupsk/discord-stayonline/internal/config"
+	"github.com/pyyupsk/discord-stayonline/internal/webhook"
+)
+
+const (
+	// DefaultNotifyWorkers is the number of goroutines sending webhook
+	// notifications.
+	DefaultNotifyWorkers = 2
+
+	// DefaultNotifyQueueSize is how many notifications may wait for a
+	// worker before new ones are dropped.
+	DefaultNotifyQueueSize = 64
+)
+
+// notifyPool runs webhook notifications on a fixed set of workers so a slow
+// endpoint backs up a bounded queue instead of piling up goroutines.
+type notifyPool struct {
+	once    sync.Once
+	jobs    chan func()
+	dropped atomic.Uint64
+}
+
+// notify queues a webhook notification. It is dropped with a warning if the
+// queue is full. Workers start on first use and stop with the manager.
+func (m *SessionManager) notify(job func()) {
+	if m.webhook == nil {
+		return
+	}
+	m.notifications.once.Do(m.startNotifyWorkers)
+
+	select {
+	case m.notifications.jobs <- job:
+		m.Metrics.SetWebhookQueueDepth(len(m.notifications.jobs))
+		m.Telemetry.SetWebhookQueueDepth(len(m.notifications.jobs))
+	default:
+		m.notifications.dropped.Add(1)
+		m.Metrics.IncWebhookDropped()
+		m.Telemetry.IncWebhookDropped()
+		m.logger.Warn("Webhook notification queue full, dropping notification", "queue_size", cap(m.notifications.jobs))
+	}
+}
+
+func (m *SessionManager) startNotifyWorkers() {
+	workers := m.NotifyWorkers
+	if workers <= 0 {
+		workers = DefaultNotifyWorkers
+	}
+	size := m.NotifyQueueSize
+	if size <= 0 {
+		size = DefaultNotifyQueueSize
+	}
+
+	jobs := make(chan func(), size)
+	m.notifications.jobs = jobs
+	for range workers {
+		go func() {
+			for {
+				select {
+				case <-m.ctx.Done():
+					return
+				case job := <-jobs:
+					m.Metrics.SetWebhookQueueDepth(len(jobs))
+					m.Telemetry.SetWebhookQueueDepth(len(jobs))
+					job()
+				}
+			}
+		}()
+	}
+}
+
+// webhookServer describes entry for webhook notifications.
+func webhookServer(entry config.ServerEntry) webhook.Server {
+	return webhook.Server{
+		ID:          entry.ID,
+		Label:       entry.Label,
+		GuildID:     entry.GuildID,
+		GuildName:   entry.GuildName,
+		ChannelID:   entry.ChannelID,
+		ChannelName: entry.ChannelName,
+	}
+}
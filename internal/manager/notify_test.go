@@ -0,0 +1,105 @@
+package manager
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/pyyupsk/discord-stayonline/internal/config"
+	"github.com/pyyupsk/discord-stayonline/internal/metrics"
+	"github.com/pyyupsk/discord-stayonline/internal/webhook"
+)
+
+func TestNotificationsBoundedUnderFlood(t *testing.T) {
+	release := make(chan struct{})
+	var inFlight, maxInFlight, received atomic.Int32
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		for {
+			peak := maxInFlight.Load()
+			if n <= peak || maxInFlight.CompareAndSwap(peak, n) {
+				break
+			}
+		}
+		<-release
+		received.Add(1)
+		inFlight.Add(-1)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer hook.Close()
+
+	store := &memoryConfigStore{cfg: config.Default()}
+	m := NewSessionManager("token", store, nil, webhook.NewNotifier(hook.URL, nil), nil)
+	m.NotifyWorkers = 2
+	m.NotifyQueueSize = 4
+	m.Metrics = metrics.New()
+	defer m.Stop()
+
+	before := runtime.NumGoroutine()
+	for range 100 {
+		m.notify(func() { m.webhook.NotifyUp(webhook.Server{ID: "server-1", GuildID: "guild-1", ChannelID: "channel-1"}) })
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for inFlight.Load() < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("timeout waiting for workers to start sending")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if grown := runtime.NumGoroutine() - before; grown > 20 {
+		t.Errorf("expected a bounded number of goroutines, grew by %d", grown)
+	}
+	dropped := counterValue(t, m.Metrics, "discord_stayonline_webhook_notifications_dropped_total")
+	if dropped < 94 {
+		t.Errorf("expected the flood beyond the queue to be dropped, dropped %v", dropped)
+	}
+	if depth := gaugeValue(t, m.Metrics, "discord_stayonline_webhook_queue_depth"); depth < 2 || depth > 4 {
+		t.Errorf("expected a queue depth between 2 and 4, got %v", depth)
+	}
+
+	close(release)
+	deadline = time.Now().Add(5 * time.Second)
+	for received.Load() < int32(100-dropped) {
+		if time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := received.Load(); got != int32(100-dropped) {
+		t.Errorf("expected %v notifications sent, got %d", 100-dropped, got)
+	}
+	if peak := maxInFlight.Load(); peak != 2 {
+		t.Errorf("expected at most 2 concurrent sends, got %d", peak)
+	}
+}
+
+func counterValue(t *testing.T, m *metrics.Metrics, name string) float64 {
+	t.Helper()
+	return findMetric(t, m, name).GetCounter().GetValue()
+}
+
+func gaugeValue(t *testing.T, m *metrics.Metrics, name string) float64 {
+	t.Helper()
+	return findMetric(t, m, name).GetGauge().GetValue()
+}
+
+func findMetric(t *testing.T, m *metrics.Metrics, name string) *dto.Metric {
+	t.Helper()
+	families, err := m.Registry().Gather()
+	if err != nil {
+		t.Fatalf("Gather() error = %v", err)
+	}
+	for _, f := range families {
+		if f.GetName() == name {
+			return f.GetMetric()[0]
+		}
+	}
+	t.Fatalf("metric %s not found", name)
+	return nil
+}
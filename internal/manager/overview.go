@@ -0,0 +1,106 @@
+package manager
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pyyupsk/discord-stayonline/internal/webhook"
+)
+
+// Overview aggregates the state of every session for a dashboard summary.
+type Overview struct {
+	Configured int `json:"configured"`
+	Connected  int `json:"connected"`
+	Connecting int `json:"connecting"`
+	Errored    int `json:"errored"`
+	Backoff    int `json:"backoff"`
+
+	// AvgHeartbeatLatencyMS averages the last heartbeat round-trip time of
+	// the connected sessions with their own connection; zero when none has
+	// been acknowledged yet.
+	AvgHeartbeatLatencyMS float64 `json:"avg_heartbeat_latency_ms"`
+
+	// ReconnectsToday counts dropped connections scheduled to reconnect
+	// since local midnight.
+	ReconnectsToday int `json:"reconnects_today"`
+
+	Webhooks WebhookStats `json:"webhooks"`
+}
+
+// WebhookStats counts webhook notifications: those the notifier delivered
+// or failed to, and those dropped because the queue was full.
+type WebhookStats struct {
+	webhook.Stats
+	Dropped uint64 `json:"dropped"`
+}
+
+// Overview returns the aggregate state of all sessions. Statuses come from
+// the status snapshot; the configured count fails only if no configuration
+// has ever loaded.
+func (m *SessionManager) Overview() (Overview, error) {
+	cfg, err := m.loadConfig()
+	if err != nil {
+		return Overview{}, err
+	}
+
+	o := Overview{
+		Configured:      len(cfg.Servers),
+		ReconnectsToday: m.reconnects.count(m.clock()),
+		Webhooks: WebhookStats{
+			Stats:   m.webhook.Stats(),
+			Dropped: m.notifications.dropped.Load(),
+		},
+	}
+	for _, status := range m.statuses.load() {
+		switch {
+		case status.Online():
+			o.Connected++
+		case status == StatusConnecting:
+			o.Connecting++
+		case status == StatusError:
+			o.Errored++
+		case status == StatusBackoff:
+			o.Backoff++
+		}
+	}
+
+	if latencies := m.HeartbeatLatencies(); len(latencies) > 0 {
+		var total time.Duration
+		for _, latency := range latencies {
+			total += latency
+		}
+		o.AvgHeartbeatLatencyMS = milliseconds(total / time.Duration(len(latencies)))
+	}
+	return o, nil
+}
+
+// dailyCounter counts events since local midnight, starting over on the
+// first event or read of a new day.
+type dailyCounter struct {
+	mu  sync.Mutex
+	day time.Time
+	n   int
+}
+
+func (c *dailyCounter) inc(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.roll(now)
+	c.n++
+}
+
+func (c *dailyCounter) count(now time.Time) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.roll(now)
+	return c.n
+}
+
+// roll resets the count when now falls on a later day. c.mu must be held.
+func (c *dailyCounter) roll(now time.Time) {
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if !day.Equal(c.day) {
+		c.day = day
+		c.n = 0
+	}
+}
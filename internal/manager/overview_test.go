@@ -0,0 +1,60 @@
+package manager
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pyyupsk/discord-stayonline/internal/webhook"
+)
+
+func TestOverviewAggregatesSessions(t *testing.T) {
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer hook.Close()
+
+	m := newExplainManager(t, true)
+	m.webhook = webhook.NewNotifier(hook.URL, nil)
+	now := time.Date(2026, 3, 1, 23, 0, 0, 0, time.Local)
+	m.now = func() time.Time { return now }
+
+	seedSession(m, "a", func(s *SessionState) { s.MarkConnected("sid") })
+	seedSession(m, "b", func(s *SessionState) {
+		s.MarkConnected("sid")
+		s.MarkInVoice()
+	})
+	seedSession(m, "c", func(s *SessionState) { s.MarkError("read timeout") })
+	seedSession(m, "d", func(s *SessionState) { s.MarkBackoff() })
+	seedSession(m, "e", func(s *SessionState) { s.MarkConnecting() })
+	seedSession(m, "f", func(*SessionState) {})
+
+	m.reconnects.inc(now.Add(-24 * time.Hour))
+	m.reconnects.inc(now)
+	m.reconnects.inc(now)
+	m.webhook.NotifyUp(webhook.Server{ID: "a"})
+	m.notifications.dropped.Add(1)
+
+	o, err := m.Overview()
+	if err != nil {
+		t.Fatalf("Overview() error = %v", err)
+	}
+	want := Overview{
+		Configured:      2,
+		Connected:       2,
+		Connecting:      1,
+		Errored:         1,
+		Backoff:         1,
+		ReconnectsToday: 2,
+		Webhooks:        WebhookStats{Stats: webhook.Stats{Sent: 1}, Dropped: 1},
+	}
+	if o != want {
+		t.Errorf("Overview() = %+v, want %+v", o, want)
+	}
+
+	now = now.Add(2 * time.Hour)
+	if o, _ := m.Overview(); o.ReconnectsToday != 0 {
+		t.Errorf("expected the reconnect count to start over at midnight, got %d", o.ReconnectsToday)
+	}
+}
@@ -0,0 +1,64 @@
+package manager
+
+import "time"
+
+// clock returns the current time, from now when tests set it.
+func (m *SessionManager) clock() time.Time {
+	if m.now != nil {
+		return m.now()
+	}
+	return time.Now()
+}
+
+// inQuietHours reports whether one of the session's quiet hours windows is
+// active, in which case it decides the status over overrides and the global
+// status. m.mu must be held.
+func (m *SessionManager) inQuietHours(session *Session) bool {
+	_, quiet := session.serverEntry.QuietStatus(m.clock())
+	return quiet
+}
+
+// scheduleQuietHours applies the session's presence at the next start or end
+// of its quiet hours, then schedules the boundary after that, until the
+// session stops.
+func (m *SessionManager) scheduleQuietHours(session *Session) {
+	now := m.clock()
+	m.mu.RLock()
+	next := session.serverEntry.NextQuietBoundary(now)
+	m.mu.RUnlock()
+	if next.IsZero() {
+		return
+	}
+
+	fire := func() {
+		if session.ctx.Err() != nil {
+			return
+		}
+		m.applyQuietHours(session)
+		m.scheduleQuietHours(session)
+	}
+	if m.afterFunc != nil {
+		m.afterFunc(next.Sub(now), fire)
+		return
+	}
+	go func() {
+		timer := time.NewTimer(next.Sub(now))
+		defer timer.Stop()
+		select {
+		case <-session.ctx.Done():
+		case <-timer.C:
+			fire()
+		}
+	}()
+}
+
+// applyQuietHours sends the status the session should show now that a quiet
+// hours window has started or ended.
+func (m *SessionManager) applyQuietHours(session *Session) {
+	status := m.loadSessionStatus(session.serverEntry.ID)
+	if status == "" {
+		return
+	}
+	session.logger.Info("Quiet hours boundary reached", "status", status)
+	m.sendPresence(session, status)
+}
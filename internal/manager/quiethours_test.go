@@ -0,0 +1,205 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+
+	"github.com/pyyupsk/discord-stayonline/internal/config"
+	"github.com/pyyupsk/discord-stayonline/internal/gateway"
+)
+
+// presenceGateway accepts one Gateway connection, answers its IDENTIFY with
+// READY, and reports the status of each presence update it receives.
+func presenceGateway(t *testing.T) (url string, presence <-chan string) {
+	t.Helper()
+	updates := make(chan string, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.CloseNow() }()
+		hello, _ := json.Marshal(map[string]any{"op": gateway.OpHello, "d": map[string]any{"heartbeat_interval": 45000}})
+		if conn.Write(r.Context(), websocket.MessageText, hello) != nil {
+			return
+		}
+		for {
+			_, data, err := conn.Read(r.Context())
+			if err != nil {
+				return
+			}
+			var msg struct {
+				Op   int                  `json:"op"`
+				Data gateway.PresenceData `json:"d"`
+			}
+			if json.Unmarshal(data, &msg) != nil {
+				continue
+			}
+			switch msg.Op {
+			case gateway.OpIdentify:
+				ready, _ := json.Marshal(map[string]any{"op": gateway.OpDispatch, "t": "READY", "s": 1, "d": map[string]any{"session_id": "quiet"}})
+				_ = conn.Write(r.Context(), websocket.MessageText, ready)
+			case gateway.OpPresenceUpdate:
+				updates <- msg.Data.Status
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+	return "ws" + strings.TrimPrefix(server.URL, "http"), updates
+}
+
+func TestQuietHoursFollowSchedule(t *testing.T) {
+	url, presence := presenceGateway(t)
+
+	m := newExplainManager(t, true)
+	entry := &m.store.(*memoryConfigStore).cfg.Servers[0]
+	entry.StatusOverride = config.StatusIdle
+	entry.QuietHours = []config.QuietHours{{Start: "22:00", End: "07:00", Status: config.StatusDND}}
+
+	now := time.Date(2026, 3, 1, 21, 30, 0, 0, time.Local)
+	m.now = func() time.Time { return now }
+	var scheduled time.Duration
+	var fire func()
+	m.afterFunc = func(d time.Duration, f func()) {
+		scheduled = d
+		fire = f
+	}
+
+	session := seedSession(m, "a", func(*SessionState) {})
+	session.serverEntry = *entry
+	session.logger = m.logger
+
+	client := gateway.NewClient("token", nil)
+	client.SetGatewayURL(url)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer func() { _ = client.Close() }()
+	for client.State() != gateway.StateConnected {
+		if ctx.Err() != nil {
+			t.Fatal("timeout waiting for READY")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	session.client = client
+
+	expectPresence := func(want string) {
+		t.Helper()
+		select {
+		case status := <-presence:
+			if status != want {
+				t.Errorf("expected presence %q, got %q", want, status)
+			}
+		case <-ctx.Done():
+			t.Fatalf("timeout waiting for presence %q", want)
+		}
+	}
+
+	if got := m.loadSessionStatus("a"); got != string(config.StatusIdle) {
+		t.Errorf("expected the override before quiet hours, got %q", got)
+	}
+	m.scheduleQuietHours(session)
+	if scheduled != 30*time.Minute {
+		t.Fatalf("expected the start scheduled in 30m, got %v", scheduled)
+	}
+
+	now = now.Add(scheduled)
+	fire()
+	expectPresence(string(config.StatusDND))
+	if scheduled != 9*time.Hour {
+		t.Fatalf("expected the end scheduled in 9h, got %v", scheduled)
+	}
+
+	// A global status change does not break into quiet hours.
+	m.ApplyStatus(string(config.StatusOnline))
+	select {
+	case status := <-presence:
+		t.Errorf("expected no presence update during quiet hours, got %q", status)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	now = now.Add(scheduled)
+	fire()
+	expectPresence(string(config.StatusIdle))
+	if scheduled != 15*time.Hour {
+		t.Errorf("expected the next start scheduled in 15h, got %v", scheduled)
+	}
+}
+
+func TestQuietHoursStopWithSession(t *testing.T) {
+	m := newExplainManager(t, true)
+	m.now = func() time.Time { return time.Date(2026, 3, 1, 21, 59, 0, 0, time.Local) }
+	var fire func()
+	m.afterFunc = func(_ time.Duration, f func()) { fire = f }
+
+	session := seedSession(m, "a", func(*SessionState) {})
+	session.serverEntry.QuietHours = []config.QuietHours{{Start: "22:00", End: "23:00", Status: config.StatusDND}}
+	m.scheduleQuietHours(session)
+
+	session.cancel()
+	m.afterFunc = func(time.Duration, func()) { t.Error("expected no reschedule after the session stopped") }
+	fire()
+}
+
+func TestSessionStatusPrecedence(t *testing.T) {
+	m := newExplainManager(t, true)
+	m.now = func() time.Time { return time.Date(2026, 3, 1, 23, 0, 0, 0, time.Local) }
+	entry := &m.store.(*memoryConfigStore).cfg.Servers[0]
+
+	if got := m.loadSessionStatus("a"); got != string(config.StatusOnline) {
+		t.Errorf("expected the global status, got %q", got)
+	}
+	entry.Status = config.StatusIdle
+	if got := m.loadSessionStatus("a"); got != string(config.StatusIdle) {
+		t.Errorf("expected the server's status over the global one, got %q", got)
+	}
+	entry.StatusOverride = config.StatusDND
+	if got := m.loadSessionStatus("a"); got != string(config.StatusDND) {
+		t.Errorf("expected the override over the server's status, got %q", got)
+	}
+	entry.QuietHours = []config.QuietHours{{Start: "22:00", End: "07:00", Status: config.StatusOnline}}
+	if got := m.loadSessionStatus("a"); got != string(config.StatusOnline) {
+		t.Errorf("expected quiet hours over the override, got %q", got)
+	}
+}
+
+func TestStatusOverrideLeavesSessionEntryAlone(t *testing.T) {
+	m := newExplainManager(t, true)
+	session := seedSession(m, "a", func(*SessionState) {})
+	session.serverEntry = m.store.(*memoryConfigStore).cfg.Servers[0]
+
+	// The session's goroutines copy its entry without a lock.
+	var stop atomic.Bool
+	var readers sync.WaitGroup
+	readers.Go(func() {
+		for !stop.Load() {
+			_ = webhookServer(session.serverEntry)
+		}
+	})
+
+	for _, status := range []config.Status{config.StatusDND, "", config.StatusIdle} {
+		if _, err := m.SetStatusOverride("a", status); err != nil {
+			t.Fatalf("SetStatusOverride(%q) error = %v", status, err)
+		}
+		if got := session.state.statusOverride(); got != status {
+			t.Errorf("expected the session's override %q, got %q", status, got)
+		}
+	}
+	stop.Store(true)
+	readers.Wait()
+
+	if session.serverEntry.StatusOverride != "" {
+		t.Errorf("expected the session's entry to be unchanged, got override %q", session.serverEntry.StatusOverride)
+	}
+}
@@ -0,0 +1,37 @@
+package manager
+
+import (
+	"time"
+
+	"github.com/pyyupsk/discord-stayonline/internal/gateway"
+)
+
+// DefaultSequenceFlushInterval is the suggested SequenceFlushInterval.
+const DefaultSequenceFlushInterval = 15 * time.Second
+
+// flushSequences saves client's sequence to the session store every
+// SequenceFlushInterval while it keeps changing, so a crash loses at most
+// one interval of progress before the next RESUME. It returns when stop is
+// closed, the session is cancelled, or reconnection is stopped.
+func (m *SessionManager) flushSequences(session *Session, client *gateway.Client, stop <-chan struct{}) {
+	ticker := time.NewTicker(m.SequenceFlushInterval)
+	defer ticker.Stop()
+
+	flushed := client.Sequence()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-session.ctx.Done():
+			return
+		case <-session.stopReconnect:
+			return
+		case <-ticker.C:
+		}
+
+		if seq := client.Sequence(); seq != flushed {
+			m.persistSequence(session, client)
+			flushed = seq
+		}
+	}
+}
@@ -1,6 +1,12 @@
 package manager
 
-import "time"
+import (
+	"sync"
+	"time"
+
+	"github.com/pyyupsk/discord-stayonline/internal/config"
+	"github.com/pyyupsk/discord-stayonline/internal/gateway"
+)
 
 type ConnectionStatus string
 
@@ -10,16 +16,92 @@ const (
 	StatusDisconnected ConnectionStatus = "disconnected"
 	StatusError        ConnectionStatus = "error"
 	StatusBackoff      ConnectionStatus = "backoff"
+
+	// StatusInVoice is StatusConnected with Discord having confirmed the
+	// account is in the configured voice channel. It is only used when
+	// voice state confirmation is enabled.
+	StatusInVoice ConnectionStatus = "in_voice"
 )
 
+// Online reports whether the status is a live Gateway connection, whether
+// or not voice has been confirmed.
+func (s ConnectionStatus) Online() bool {
+	return s == StatusConnected || s == StatusInVoice
+}
+
+// SessionState is a session's connection state. It is written by the
+// session's own goroutines and read by API requests, so its methods take mu;
+// read the fields of a Snapshot rather than of a live state.
 type SessionState struct {
-	ServerEntryID    string
-	ConnectionStatus ConnectionStatus
-	LastError        string
-	BackoffAttempt   int
-	LastConnectTime  time.Time
-	SessionID        string
-	Sequence         int
+	ServerEntryID    string           `json:"server_id"`
+	ConnectionStatus ConnectionStatus `json:"status"`
+	LastError        string           `json:"last_error,omitempty"`
+	LastCloseCode    int              `json:"last_close_code,omitempty"`
+	BackoffAttempt   int              `json:"backoff_attempt"`
+	NextRetryAt      time.Time        `json:"next_retry_at,omitzero"`
+	LastConnectTime  time.Time        `json:"last_connect_time,omitzero"`
+	SessionID        string           `json:"session_id,omitempty"`
+	Sequence         int64            `json:"sequence"`
+
+	// ChannelOverride is a voice channel joined instead of the configured
+	// one until it is cleared. It is kept in memory only.
+	ChannelOverride string `json:"channel_override,omitempty"`
+
+	// LastDialFailure classifies the last failed Gateway dial, cleared
+	// once a connect succeeds.
+	LastDialFailure gateway.DialFailure `json:"last_dial_failure,omitempty"`
+
+	// override is the server's status override, taken from its entry at
+	// Join and replaced by SetStatusOverride. The session's entry itself is
+	// never changed once the session runs.
+	override config.Status
+
+	// onStatusChange, when set, is called with the new status on every
+	// transition, with mu held.
+	onStatusChange func(ConnectionStatus)
+
+	mu sync.Mutex
+}
+
+// currentClient returns the session's Gateway client, nil before the first
+// connect.
+func (s *Session) currentClient() *gateway.Client {
+	s.clientMu.Lock()
+	defer s.clientMu.Unlock()
+	return s.client
+}
+
+// setClient replaces the session's Gateway client.
+func (s *Session) setClient(client *gateway.Client) {
+	s.clientMu.Lock()
+	defer s.clientMu.Unlock()
+	s.client = client
+}
+
+// SessionDetail is a copy of a session's state with its uptime computed at
+// the time of the copy.
+type SessionDetail struct {
+	SessionState
+
+	// ConnectedSince and UptimeSecs report when the current connection
+	// became ready and how long ago that was. Both are zero unless the
+	// session is connected.
+	ConnectedSince time.Time `json:"connected_since,omitzero"`
+	UptimeSecs     int64     `json:"uptime_secs,omitzero"`
+
+	// MaxReconnectAttempts is the manager's cap on BackoffAttempt, zero when
+	// reconnects are unlimited.
+	MaxReconnectAttempts int `json:"max_reconnect_attempts,omitzero"`
+}
+
+// uptime returns when the current connection became ready and how long it
+// has been up at now, or zero values when the session is not connected. It
+// reads s without locking, so call it on a Snapshot.
+func (s *SessionState) uptime(now time.Time) (time.Time, time.Duration) {
+	if !s.ConnectionStatus.Online() || s.LastConnectTime.IsZero() {
+		return time.Time{}, 0
+	}
+	return s.LastConnectTime, max(now.Sub(s.LastConnectTime), 0)
 }
 
 func NewSessionState(serverEntryID string) *SessionState {
@@ -30,42 +112,184 @@ func NewSessionState(serverEntryID string) *SessionState {
 	}
 }
 
+// Snapshot returns a copy of the state taken under its lock.
+func (s *SessionState) Snapshot() SessionState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return SessionState{
+		ServerEntryID:    s.ServerEntryID,
+		ConnectionStatus: s.ConnectionStatus,
+		LastError:        s.LastError,
+		LastCloseCode:    s.LastCloseCode,
+		BackoffAttempt:   s.BackoffAttempt,
+		NextRetryAt:      s.NextRetryAt,
+		LastConnectTime:  s.LastConnectTime,
+		SessionID:        s.SessionID,
+		Sequence:         s.Sequence,
+		ChannelOverride:  s.ChannelOverride,
+		LastDialFailure:  s.LastDialFailure,
+	}
+}
+
+// status returns the current connection status.
+func (s *SessionState) status() ConnectionStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ConnectionStatus
+}
+
+// active reports whether the session is connected or connecting, and so
+// counts against connection limits.
+func (s *SessionState) active() bool {
+	status := s.status()
+	return status.Online() || status == StatusConnecting
+}
+
+// attempt returns the current backoff attempt.
+func (s *SessionState) attempt() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.BackoffAttempt
+}
+
+// channelOverride returns the voice channel joined instead of the
+// configured one, if any.
+func (s *SessionState) channelOverride() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ChannelOverride
+}
+
+// SetChannelOverride records the voice channel joined instead of the
+// configured one; empty clears it.
+func (s *SessionState) SetChannelOverride(channelID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ChannelOverride = channelID
+}
+
+// statusOverride returns the server's status override, if any.
+func (s *SessionState) statusOverride() config.Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.override
+}
+
+// setStatusOverride replaces the server's status override; empty clears it.
+func (s *SessionState) setStatusOverride(status config.Status) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.override = status
+}
+
+// setStatus changes the connection status and reports it to onStatusChange.
+// s.mu must be held.
+func (s *SessionState) setStatus(status ConnectionStatus) {
+	s.ConnectionStatus = status
+	if s.onStatusChange != nil {
+		s.onStatusChange(status)
+	}
+}
+
 func (s *SessionState) Reset() {
-	s.ConnectionStatus = StatusDisconnected
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.setStatus(StatusDisconnected)
 	s.LastError = ""
+	s.LastCloseCode = 0
 	s.BackoffAttempt = 0
+	s.NextRetryAt = time.Time{}
 	s.SessionID = ""
 	s.Sequence = 0
 }
 
 func (s *SessionState) MarkConnecting() {
-	s.ConnectionStatus = StatusConnecting
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.setStatus(StatusConnecting)
 }
 
 func (s *SessionState) MarkConnected(sessionID string) {
-	s.ConnectionStatus = StatusConnected
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.setStatus(StatusConnected)
 	s.LastConnectTime = time.Now()
 	s.SessionID = sessionID
 	s.BackoffAttempt = 0
 	s.LastError = ""
+	s.LastCloseCode = 0
+	s.LastDialFailure = ""
+	s.NextRetryAt = time.Time{}
+}
+
+func (s *SessionState) MarkInVoice() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.setStatus(StatusInVoice)
+}
+
+// MarkOutOfVoice drops a confirmed voice status back to connected.
+func (s *SessionState) MarkOutOfVoice() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ConnectionStatus == StatusInVoice {
+		s.setStatus(StatusConnected)
+	}
 }
 
 func (s *SessionState) MarkError(err string) {
-	s.ConnectionStatus = StatusError
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.setStatus(StatusError)
 	s.LastError = err
 }
 
 func (s *SessionState) MarkBackoff() {
-	s.ConnectionStatus = StatusBackoff
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.setStatus(StatusBackoff)
 	s.BackoffAttempt++
 }
 
+// MarkClosed records the close code of the last Gateway disconnect. Zero
+// (no close frame) leaves the previous code in place.
+func (s *SessionState) MarkClosed(code int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if code != 0 {
+		s.LastCloseCode = code
+	}
+}
+
+// ScheduleRetry records when the next reconnect attempt will start.
+func (s *SessionState) ScheduleRetry(delay time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.NextRetryAt = time.Now().Add(delay)
+}
+
 func (s *SessionState) MarkDisconnected() {
-	s.ConnectionStatus = StatusDisconnected
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.setStatus(StatusDisconnected)
 	s.LastError = ""
 }
 
-func (s *SessionState) UpdateSequence(seq int) {
+// MarkDialFailure records the error of a failed connect and, for a failed
+// dial, its classification.
+func (s *SessionState) MarkDialFailure(err string, kind gateway.DialFailure) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.setStatus(StatusError)
+	s.LastError = err
+	if kind != "" {
+		s.LastDialFailure = kind
+	}
+}
+
+func (s *SessionState) UpdateSequence(seq int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	if seq > 0 {
 		s.Sequence = seq
 	}
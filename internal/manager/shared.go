@@ -0,0 +1,94 @@
+package manager
+
+import (
+	"context"
+	"errors"
+
+	"github.com/pyyupsk/discord-stayonline/internal/config"
+)
+
+// ErrSharedConnection is returned for per-server presence changes on a
+// presence-only server whose connection is shared with others.
+var ErrSharedConnection = errors.New("presence is shared by all presence-only servers; change the global status instead")
+
+// sharesPresence reports whether entry runs on the shared presence-only
+// connection.
+func (m *SessionManager) sharesPresence(entry config.ServerEntry) bool {
+	return m.SharedPresenceConnection && entry.PresenceOnly
+}
+
+// sharedLeaderLocked returns the running presence-only session that owns the
+// shared Gateway connection, or nil if there is none. m.mu must be held.
+func (m *SessionManager) sharedLeaderLocked(serverID string) *Session {
+	for id, s := range m.sessions {
+		if id == serverID || s.sharedWith != nil || !s.serverEntry.PresenceOnly {
+			continue
+		}
+		if s.ctx.Err() == nil {
+			return s
+		}
+	}
+	return nil
+}
+
+// attachFollowerLocked registers entry as riding on leader's connection. The
+// follower has no client of its own and mirrors the leader's state. m.mu must
+// be held.
+func (m *SessionManager) attachFollowerLocked(entry config.ServerEntry, leader *Session) {
+	ctx, cancel := context.WithCancel(leader.ctx)
+	session := &Session{
+		serverEntry:   entry,
+		state:         leader.state,
+		frameLog:      leader.frameLog,
+		logger:        m.sessionLogger(entry),
+		sharedWith:    leader,
+		ctx:           ctx,
+		cancel:        cancel,
+		stopReconnect: make(chan struct{}),
+	}
+	m.sessions[entry.ID] = session
+	m.trackSession(session)
+	session.logger.Info("Sharing presence-only Gateway connection", "leader", leader.serverEntry.ID)
+}
+
+// followerIDs returns the servers sharing leaderID's connection.
+func (m *SessionManager) followerIDs(leaderID string) []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var ids []string
+	for id, s := range m.sessions {
+		if s.sharedWith != nil && s.sharedWith.serverEntry.ID == leaderID {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// detachFollowersLocked removes the sessions sharing leader's connection and
+// returns their server IDs so they can be joined again. m.mu must be held.
+func (m *SessionManager) detachFollowersLocked(leader *Session) []string {
+	var ids []string
+	for id, s := range m.sessions {
+		if s.sharedWith == leader {
+			s.cancel()
+			delete(m.sessions, id)
+			m.statuses.remove(id)
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// rejoinFollowers joins servers that lost their shared connection. The
+// first one opens a new connection and the rest attach to it.
+func (m *SessionManager) rejoinFollowers(ids []string) {
+	if m.ctx.Err() != nil {
+		return
+	}
+	for _, id := range ids {
+		if err := m.Join(id); err != nil {
+			m.logger.Error("Failed to move server to a new shared connection", "server_id", id, "error", err)
+		}
+	}
+}
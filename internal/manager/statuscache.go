@@ -0,0 +1,123 @@
+package manager
+
+import (
+	"maps"
+	"sync"
+	"sync/atomic"
+)
+
+// statusCache is a copy-on-write snapshot of every session's status, so the
+// status read endpoints never contend with connection goroutines for the
+// session map lock. Writers are serialized by mu and publish a new map on
+// each transition; readers load the current map without locking.
+type statusCache struct {
+	mu sync.Mutex
+	// states maps each server ID to the state it mirrors. Servers sharing a
+	// connection map to the same state.
+	states   map[string]*SessionState
+	snapshot atomic.Pointer[map[string]ConnectionStatus]
+}
+
+// load returns the current snapshot. It must not be modified.
+func (c *statusCache) load() map[string]ConnectionStatus {
+	if p := c.snapshot.Load(); p != nil {
+		return *p
+	}
+	return nil
+}
+
+// update publishes a copy of the snapshot with mutate applied. c.mu must be
+// held.
+func (c *statusCache) update(mutate func(map[string]ConnectionStatus)) {
+	next := maps.Clone(c.load())
+	if next == nil {
+		next = make(map[string]ConnectionStatus)
+	}
+	mutate(next)
+	c.snapshot.Store(&next)
+}
+
+// track adds serverID to the snapshot, following state from now on.
+//
+// state reports transitions to set while holding its own lock, so state's
+// status is read before taking c.mu, never under it. A transition between
+// the read and the registration is not reported to set, so the status is
+// read again afterwards and published until it stops changing.
+func (c *statusCache) track(serverID string, state *SessionState) {
+	status := state.status()
+	for {
+		c.mu.Lock()
+		if c.states == nil {
+			c.states = make(map[string]*SessionState)
+		}
+		c.states[serverID] = state
+		c.update(func(next map[string]ConnectionStatus) {
+			next[serverID] = status
+		})
+		c.mu.Unlock()
+
+		current := state.status()
+		if current == status {
+			return
+		}
+		status = current
+	}
+}
+
+// set records status for every server following state. It is called with
+// state's lock held, so it must not call back into state. Transitions of a
+// state no longer tracked, such as a session winding down after a rejoin
+// replaced it, are ignored.
+func (c *statusCache) set(state *SessionState, status ConnectionStatus) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var ids []string
+	for id, s := range c.states {
+		if s == state {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return
+	}
+	c.update(func(next map[string]ConnectionStatus) {
+		for _, id := range ids {
+			next[id] = status
+		}
+	})
+}
+
+// remove drops serverID from the snapshot.
+func (c *statusCache) remove(serverID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.states, serverID)
+	c.update(func(next map[string]ConnectionStatus) {
+		delete(next, serverID)
+	})
+}
+
+// trackSession adds a session that was just registered in m.sessions to the
+// status snapshot and keeps it updated on every transition of its state.
+// Followers share their leader's state, which already reports transitions.
+func (m *SessionManager) trackSession(session *Session) {
+	state := session.state
+	if session.sharedWith == nil {
+		state.onStatusChange = func(status ConnectionStatus) {
+			m.statuses.set(state, status)
+		}
+	}
+	m.statuses.track(session.serverEntry.ID, state)
+}
+
+// StatusCounts returns how many sessions are in each connection status, read
+// from the status snapshot.
+func (m *SessionManager) StatusCounts() map[string]int {
+	counts := make(map[string]int)
+	for _, status := range m.statuses.load() {
+		counts[string(status)]++
+	}
+	return counts
+}
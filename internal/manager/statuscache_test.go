@@ -0,0 +1,220 @@
+package manager
+
+import (
+	"fmt"
+	"maps"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pyyupsk/discord-stayonline/internal/config"
+)
+
+// lockedStatuses is the status read path before the snapshot: iterating the
+// session map under its lock.
+func lockedStatuses(m *SessionManager) map[string]ConnectionStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	statuses := make(map[string]ConnectionStatus)
+	for id, session := range m.sessions {
+		statuses[id] = session.state.ConnectionStatus
+	}
+	return statuses
+}
+
+func TestStatusSnapshotFollowsTransitions(t *testing.T) {
+	m := newExplainManager(t, true)
+	leader := seedSession(m, "leader", func(s *SessionState) {})
+
+	if status, _ := m.GetStatus("leader"); status != StatusDisconnected {
+		t.Fatalf("expected new session to be disconnected, got %s", status)
+	}
+
+	leader.state.MarkConnecting()
+	leader.state.MarkConnected("session")
+	if status, _ := m.GetStatus("leader"); status != StatusConnected {
+		t.Fatalf("expected connected, got %s", status)
+	}
+
+	m.mu.Lock()
+	m.attachFollowerLocked(config.ServerEntry{ID: "follower"}, leader)
+	m.mu.Unlock()
+	if status, _ := m.GetStatus("follower"); status != StatusConnected {
+		t.Errorf("expected follower to start with the leader's status, got %s", status)
+	}
+
+	leader.state.MarkBackoff()
+	if got, want := m.GetAllStatuses(), lockedStatuses(m); !maps.Equal(got, want) {
+		t.Errorf("snapshot %v does not match sessions %v", got, want)
+	}
+
+	// Copies handed to callers do not change the snapshot.
+	m.GetAllStatuses()["leader"] = StatusError
+	if status, _ := m.GetStatus("leader"); status != StatusBackoff {
+		t.Errorf("expected caller mutation to leave the snapshot alone, got %s", status)
+	}
+
+	m.mu.Lock()
+	delete(m.sessions, "leader")
+	m.statuses.remove("leader")
+	m.detachFollowersLocked(leader)
+	m.mu.Unlock()
+	if statuses := m.GetAllStatuses(); len(statuses) != 0 {
+		t.Errorf("expected removed sessions to leave the snapshot, got %v", statuses)
+	}
+
+	// A removed session winding down must not reappear.
+	leader.state.MarkDisconnected()
+	if statuses := m.GetAllStatuses(); len(statuses) != 0 {
+		t.Errorf("expected transitions of a removed session to be ignored, got %v", statuses)
+	}
+}
+
+func TestStatusSnapshotConsistentUnderChurn(t *testing.T) {
+	m := newExplainManager(t, true)
+	var sessions []*Session
+	for i := range 8 {
+		sessions = append(sessions, seedSession(m, fmt.Sprintf("server-%d", i), func(s *SessionState) {}))
+	}
+
+	var stop atomic.Bool
+	var readers sync.WaitGroup
+	for range 4 {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for !stop.Load() {
+				statuses := m.GetAllStatuses()
+				if len(statuses) != len(sessions) {
+					t.Errorf("expected %d statuses, got %v", len(sessions), statuses)
+					return
+				}
+			}
+		}()
+	}
+
+	var writers sync.WaitGroup
+	for _, session := range sessions {
+		writers.Add(1)
+		go func() {
+			defer writers.Done()
+			for range 500 {
+				session.state.MarkConnecting()
+				session.state.MarkConnected("session")
+				session.state.MarkError("boom")
+				session.state.MarkBackoff()
+			}
+			session.state.MarkConnected("session")
+		}()
+	}
+	writers.Wait()
+	stop.Store(true)
+	readers.Wait()
+
+	for _, session := range sessions[:4] {
+		session.state.MarkInVoice()
+	}
+	if got, want := m.GetAllStatuses(), lockedStatuses(m); !maps.Equal(got, want) {
+		t.Errorf("snapshot %v does not match sessions %v", got, want)
+	}
+}
+
+func TestFollowerJoinDuringLeaderTransitions(t *testing.T) {
+	m := newExplainManager(t, true)
+	m.SharedPresenceConnection = true
+	cfg := m.store.(*memoryConfigStore).cfg
+	for i := range cfg.Servers {
+		cfg.Servers[i].PresenceOnly = true
+		cfg.Servers[i].Priority = 1
+	}
+	leader := seedSession(m, "a", func(*SessionState) {})
+	leader.serverEntry = cfg.Servers[0]
+	leader.state.MarkConnected("session")
+
+	var stop atomic.Bool
+	var transitions sync.WaitGroup
+	transitions.Go(func() {
+		for !stop.Load() {
+			leader.state.MarkConnecting()
+			leader.state.MarkConnected("session")
+		}
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 500 {
+			if err := m.Join("b"); err != nil {
+				t.Errorf("Join(b) error = %v", err)
+				return
+			}
+			m.stopSession("b", "test")
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		// The deadlocked Join holds m.mu, so the manager's cleanup would
+		// hang too; panic to fail with every goroutine's stack instead.
+		panic("follower joins deadlocked with leader transitions")
+	}
+	stop.Store(true)
+	transitions.Wait()
+
+	if err := m.Join("b"); err != nil {
+		t.Fatalf("Join(b) error = %v", err)
+	}
+	leader.state.MarkBackoff()
+	if status, _ := m.GetStatus("b"); status != StatusBackoff {
+		t.Errorf("expected the follower to mirror the leader, got %s", status)
+	}
+}
+
+// BenchmarkStatusReadsUnderChurn compares reading every status from the
+// snapshot with iterating the session map under its lock, while other
+// goroutines take the write lock and change statuses.
+func BenchmarkStatusReadsUnderChurn(b *testing.B) {
+	reads := map[string]func(*SessionManager) map[string]ConnectionStatus{
+		"snapshot": (*SessionManager).GetAllStatuses,
+		"locked":   lockedStatuses,
+	}
+	for _, name := range []string{"snapshot", "locked"} {
+		b.Run(name, func(b *testing.B) {
+			m := NewSessionManager("token", &memoryConfigStore{cfg: &config.Configuration{}}, nil, nil, nil)
+			defer m.Stop()
+			var sessions []*Session
+			for i := range 35 {
+				sessions = append(sessions, seedSession(m, fmt.Sprintf("server-%d", i), func(s *SessionState) {}))
+			}
+
+			var stop atomic.Bool
+			var churn sync.WaitGroup
+			for i := range 4 {
+				churn.Add(1)
+				go func() {
+					defer churn.Done()
+					for n := i; !stop.Load(); n++ {
+						state := sessions[n%len(sessions)].state
+						m.mu.Lock()
+						state.MarkConnecting()
+						m.mu.Unlock()
+						state.MarkConnected("session")
+					}
+				}()
+			}
+
+			read := reads[name]
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					_ = read(m)
+				}
+			})
+			b.StopTimer()
+			stop.Store(true)
+			churn.Wait()
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package manager
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pyyupsk/discord-stayonline/internal/config"
+)
+
+const (
+	// DefaultStoreRetryAttempts is how many times a failed session store
+	// write is retried before it is given up.
+	DefaultStoreRetryAttempts = 5
+
+	// DefaultStoreRetryDelay is the wait before the first retry; it doubles
+	// for each following attempt.
+	DefaultStoreRetryDelay = time.Second
+
+	// storeRetryMaxDelay caps the doubling retry delay.
+	storeRetryMaxDelay = 30 * time.Second
+)
+
+// StoreHealth reports whether the manager's last store operation succeeded.
+type StoreHealth struct {
+	Up        bool      `json:"up"`
+	LastError string    `json:"last_error,omitempty"`
+	DownSince time.Time `json:"down_since,omitzero"`
+}
+
+// storeState tracks store health, the last configuration loaded
+// successfully, and which session writes are still pending a retry.
+type storeState struct {
+	mu        sync.Mutex
+	lastCfg   *config.Configuration
+	lastErr   string
+	downSince time.Time
+	writes    map[string]uint64
+}
+
+// StoreHealth returns the health of the configuration and session stores as
+// seen by the manager's own reads and writes.
+func (m *SessionManager) StoreHealth() StoreHealth {
+	m.storage.mu.Lock()
+	defer m.storage.mu.Unlock()
+	return StoreHealth{
+		Up:        m.storage.downSince.IsZero(),
+		LastError: m.storage.lastErr,
+		DownSince: m.storage.downSince,
+	}
+}
+
+func (m *SessionManager) markStoreUp() {
+	m.storage.mu.Lock()
+	defer m.storage.mu.Unlock()
+	if !m.storage.downSince.IsZero() {
+		m.logger.Info("Store recovered", "down_for", time.Since(m.storage.downSince).Round(time.Second))
+	}
+	m.storage.lastErr = ""
+	m.storage.downSince = time.Time{}
+}
+
+func (m *SessionManager) markStoreDown(err error) {
+	m.storage.mu.Lock()
+	defer m.storage.mu.Unlock()
+	if m.storage.downSince.IsZero() {
+		m.storage.downSince = time.Now()
+	}
+	m.storage.lastErr = err.Error()
+}
+
+// loadConfig loads the configuration, falling back to the last one loaded
+// successfully when the store fails so an outage does not tear down
+// sessions that are otherwise healthy. It only fails if nothing has been
+// loaded yet.
+func (m *SessionManager) loadConfig() (*config.Configuration, error) {
+	cfg, err := config.LoadConfig(m.store)
+	if err == nil {
+		m.markStoreUp()
+		m.storage.mu.Lock()
+		m.storage.lastCfg = cloneConfig(cfg)
+		m.storage.mu.Unlock()
+		return cfg, nil
+	}
+
+	m.markStoreDown(err)
+	m.storage.mu.Lock()
+	cached := m.storage.lastCfg
+	m.storage.mu.Unlock()
+	if cached == nil {
+		return nil, err
+	}
+	m.logger.Warn("Failed to load config, using last loaded copy", "error", err)
+	return cloneConfig(cached), nil
+}
+
+func cloneConfig(cfg *config.Configuration) *config.Configuration {
+	c := *cfg
+	c.Servers = append([]config.ServerEntry(nil), cfg.Servers...)
+	return &c
+}
+
+// writeSession runs a session store write for serverID. If it fails, it is
+// retried in the background with a doubling delay until it succeeds, a
+// newer write for the same server replaces it, the attempts run out, or the
+// manager stops.
+func (m *SessionManager) writeSession(serverID, op string, write func() error) {
+	m.storage.mu.Lock()
+	if m.storage.writes == nil {
+		m.storage.writes = make(map[string]uint64)
+	}
+	m.storage.writes[serverID]++
+	gen := m.storage.writes[serverID]
+	m.storage.mu.Unlock()
+
+	err := write()
+	if err == nil {
+		m.markStoreUp()
+		return
+	}
+	m.markStoreDown(err)
+	m.logger.Warn("Session store write failed, retrying", "server_id", serverID, "op", op, "error", err)
+
+	attempts := m.StoreRetryAttempts
+	if attempts <= 0 {
+		attempts = DefaultStoreRetryAttempts
+	}
+	delay := m.StoreRetryDelay
+	if delay <= 0 {
+		delay = DefaultStoreRetryDelay
+	}
+
+	go func() {
+		for range attempts {
+			select {
+			case <-m.ctx.Done():
+				return
+			case <-time.After(delay):
+			}
+			delay = min(delay*2, storeRetryMaxDelay)
+
+			m.storage.mu.Lock()
+			superseded := m.storage.writes[serverID] != gen
+			m.storage.mu.Unlock()
+			if superseded {
+				return
+			}
+
+			if err = write(); err == nil {
+				m.markStoreUp()
+				return
+			}
+			m.markStoreDown(err)
+		}
+		m.logger.Error("Giving up on session store write", "server_id", serverID, "op", op, "error", err)
+	}()
+}
@@ -0,0 +1,94 @@
+package manager
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pyyupsk/discord-stayonline/internal/config"
+	"github.com/pyyupsk/discord-stayonline/internal/webhook"
+)
+
+const (
+	// DefaultStartupSummarySettle is how long startup sessions must go
+	// without a status change before the summary is sent.
+	DefaultStartupSummarySettle = 10 * time.Second
+
+	// startupSummaryMaxWait sends the summary even if some sessions are still
+	// connecting, so a server stuck in retries cannot hold it back forever.
+	startupSummaryMaxWait = 2 * time.Minute
+)
+
+// startupSummary sends one consolidated status webhook once the sessions
+// started by Start have stopped changing state.
+type startupSummary struct {
+	manager   *SessionManager
+	servers   []config.ServerEntry
+	settle    time.Duration
+	startedAt time.Time
+
+	mu    sync.Mutex
+	timer *time.Timer
+	done  bool
+}
+
+func newStartupSummary(m *SessionManager, servers []config.ServerEntry) *startupSummary {
+	settle := m.StartupSummarySettle
+	if settle <= 0 {
+		settle = DefaultStartupSummarySettle
+	}
+	s := &startupSummary{
+		manager:   m,
+		servers:   servers,
+		settle:    settle,
+		startedAt: time.Now(),
+	}
+	s.timer = time.AfterFunc(settle, s.fire)
+	return s
+}
+
+// touch restarts the settle window after a status change.
+func (s *startupSummary) touch() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.done {
+		s.timer.Reset(s.settle)
+	}
+}
+
+func (s *startupSummary) fire() {
+	if s.manager.ctx.Err() != nil {
+		return
+	}
+
+	summary := make([]webhook.ServerSummary, 0, len(s.servers))
+	connecting := false
+	for _, entry := range s.servers {
+		status, _ := s.manager.GetStatus(entry.ID)
+		if status == StatusConnecting {
+			connecting = true
+		}
+		summary = append(summary, webhook.ServerSummary{
+			Name:   entry.DisplayName(),
+			Status: string(status),
+			Up:     status.Online(),
+		})
+	}
+
+	s.mu.Lock()
+	if s.done {
+		s.mu.Unlock()
+		return
+	}
+	if connecting && time.Since(s.startedAt) < startupSummaryMaxWait {
+		s.timer.Reset(s.settle)
+		s.mu.Unlock()
+		return
+	}
+	s.done = true
+	s.mu.Unlock()
+
+	s.manager.notify(func() { s.manager.webhook.NotifyStatusSummary(summary) })
+}
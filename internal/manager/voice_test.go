@@ -0,0 +1,90 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+
+	"github.com/pyyupsk/discord-stayonline/internal/config"
+	"github.com/pyyupsk/discord-stayonline/internal/gateway"
+)
+
+// voiceGateway accepts one Gateway connection and reports the channel of
+// each voice state update it receives.
+func voiceGateway(t *testing.T) (url string, voice <-chan string) {
+	t.Helper()
+	updates := make(chan string, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.CloseNow() }()
+		for {
+			_, data, err := conn.Read(r.Context())
+			if err != nil {
+				return
+			}
+			var msg struct {
+				Op   int                    `json:"op"`
+				Data gateway.VoiceStateData `json:"d"`
+			}
+			if json.Unmarshal(data, &msg) == nil && msg.Op == gateway.OpVoiceStateUpdate && msg.Data.ChannelID != nil {
+				updates <- *msg.Data.ChannelID
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+	return "ws" + strings.TrimPrefix(server.URL, "http"), updates
+}
+
+func TestVoiceJoinWaitsForDelay(t *testing.T) {
+	url, voice := voiceGateway(t)
+
+	m := newExplainManager(t, true)
+	m.VoiceJoinDelay = 3 * time.Second
+	var scheduled time.Duration
+	var fire func()
+	m.afterFunc = func(d time.Duration, f func()) {
+		scheduled = d
+		fire = f
+	}
+
+	session := seedSession(m, "a", func(*SessionState) {})
+	session.serverEntry = config.ServerEntry{ID: "a", GuildID: "111", ChannelID: "222"}
+
+	client := gateway.NewClient("token", nil)
+	client.SetGatewayURL(url)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer func() { _ = client.Close() }()
+
+	m.scheduleVoiceJoin(session, client)
+	if scheduled != m.VoiceJoinDelay {
+		t.Fatalf("expected voice join scheduled after %v, got %v", m.VoiceJoinDelay, scheduled)
+	}
+	select {
+	case channel := <-voice:
+		t.Fatalf("expected no voice join before the delay, got channel %q", channel)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	fire()
+	select {
+	case channel := <-voice:
+		if channel != "222" {
+			t.Errorf("expected voice join to channel 222, got %q", channel)
+		}
+	case <-ctx.Done():
+		t.Fatal("timeout waiting for the delayed voice join")
+	}
+}
@@ -0,0 +1,62 @@
+package manager
+
+import (
+	"errors"
+
+	"github.com/pyyupsk/discord-stayonline/internal/config"
+)
+
+// WatchConfig applies each configuration received from updates, such as
+// from store.File.Watch, until the channel is closed or the manager stops:
+// sessions of removed servers are stopped, and servers added with
+// connect_on_start are joined. Changes to servers that stay configured take
+// effect on their next Join or Rejoin.
+func (m *SessionManager) WatchConfig(updates <-chan *config.Configuration) {
+	prev, err := m.loadConfig()
+	if err != nil {
+		m.logger.Warn("Failed to load config before watching for changes", "error", err)
+		prev = config.Default()
+	}
+
+	go func() {
+		for {
+			select {
+			case <-m.ctx.Done():
+				return
+			case cfg, ok := <-updates:
+				if !ok {
+					return
+				}
+				m.applyConfig(prev, cfg)
+				prev = cfg
+			}
+		}
+	}()
+}
+
+func (m *SessionManager) applyConfig(prev, next *config.Configuration) {
+	changed, removed := config.ChangedServers(prev.Servers, next.Servers)
+	m.logger.Info("Configuration changed", "changed", len(changed), "removed", len(removed))
+
+	m.storage.mu.Lock()
+	m.storage.lastCfg = cloneConfig(next)
+	m.storage.mu.Unlock()
+
+	m.reconcile(next)
+
+	if !next.TOSAcknowledged {
+		return
+	}
+	known := make(map[string]bool, len(prev.Servers))
+	for _, server := range prev.Servers {
+		known[server.ID] = true
+	}
+	for _, server := range changed {
+		if known[server.ID] || !server.ConnectOnStart {
+			continue
+		}
+		if err := m.Join(server.ID); err != nil && !errors.Is(err, ErrAlreadyConnected) {
+			m.logger.Error("Failed to connect added server", "server_id", server.ID, "error", err)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+// Package metrics exposes Prometheus metrics for Gateway sessions.
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "discord_stayonline"
+
+// Metrics holds the collectors exported on /metrics. A nil *Metrics is valid
+// and discards all observations.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	connectDuration prometheus.Histogram
+	sessionUptime   prometheus.Histogram
+	heartbeatRTT    prometheus.Histogram
+
+	reconnects prometheus.Counter
+
+	webhookQueueDepth prometheus.Gauge
+	webhookDropped    prometheus.Counter
+	webhookFailures   prometheus.Counter
+}
+
+// New creates a Metrics instance backed by its own registry.
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		connectDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "gateway_connect_duration_seconds",
+			Help:      "Time from dialing the Gateway to READY or RESUMED.",
+			Buckets:   []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+		}),
+		sessionUptime: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "gateway_session_uptime_seconds",
+			Help:      "How long a Gateway connection stayed up before it dropped.",
+			Buckets:   []float64{60, 300, 900, 1800, 3600, 6 * 3600, 12 * 3600, 24 * 3600, 72 * 3600, 168 * 3600},
+		}),
+		heartbeatRTT: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "gateway_heartbeat_rtt_seconds",
+			Help:      "Round-trip time between a heartbeat and its ACK.",
+			Buckets:   prometheus.ExponentialBuckets(0.01, 2, 10),
+		}),
+		reconnects: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "gateway_reconnects_total",
+			Help:      "Gateway connections that dropped and were scheduled to reconnect.",
+		}),
+		webhookQueueDepth: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "webhook_queue_depth",
+			Help:      "Webhook notifications waiting for a worker.",
+		}),
+		webhookDropped: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "webhook_notifications_dropped_total",
+			Help:      "Webhook notifications dropped because the queue was full.",
+		}),
+		webhookFailures: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "webhook_send_failures_total",
+			Help:      "Webhook requests that failed or returned an error status.",
+		}),
+	}
+	m.registry.MustRegister(m.connectDuration, m.sessionUptime, m.heartbeatRTT, m.reconnects,
+		m.webhookQueueDepth, m.webhookDropped, m.webhookFailures)
+	return m
+}
+
+// heartbeatAgeDesc describes the per-session heartbeat age gauge, computed
+// at scrape time.
+var heartbeatAgeDesc = prometheus.NewDesc(
+	prometheus.BuildFQName(namespace, "", "gateway_heartbeat_age_seconds"),
+	"Time since the session's last heartbeat ACK.",
+	[]string{"server_id"}, nil,
+)
+
+// heartbeatAges collects heartbeatAgeDesc from a source of ages by server ID.
+type heartbeatAges func() map[string]time.Duration
+
+func (f heartbeatAges) Describe(ch chan<- *prometheus.Desc) {
+	ch <- heartbeatAgeDesc
+}
+
+func (f heartbeatAges) Collect(ch chan<- prometheus.Metric) {
+	for serverID, age := range f() {
+		ch <- prometheus.MustNewConstMetric(heartbeatAgeDesc, prometheus.GaugeValue, age.Seconds(), serverID)
+	}
+}
+
+// SetHeartbeatAges exports the ages returned by source, read on each scrape,
+// as the gateway_heartbeat_age_seconds gauge. Call it at most once.
+func (m *Metrics) SetHeartbeatAges(source func() map[string]time.Duration) {
+	if m == nil || source == nil {
+		return
+	}
+	m.registry.MustRegister(heartbeatAges(source))
+}
+
+// Session gauges, computed at scrape time from the manager's status counts.
+var (
+	sessionsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "sessions"),
+		"Sessions by connection status.",
+		[]string{"status"}, nil,
+	)
+	activeSessionsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "sessions_active"),
+		"Sessions managed, in any status.",
+		nil, nil,
+	)
+)
+
+// sessionStatuses collects sessionsDesc and activeSessionsDesc from a source
+// of session counts by status.
+type sessionStatuses func() map[string]int
+
+func (f sessionStatuses) Describe(ch chan<- *prometheus.Desc) {
+	ch <- sessionsDesc
+	ch <- activeSessionsDesc
+}
+
+func (f sessionStatuses) Collect(ch chan<- prometheus.Metric) {
+	total := 0
+	for status, n := range f() {
+		total += n
+		ch <- prometheus.MustNewConstMetric(sessionsDesc, prometheus.GaugeValue, float64(n), status)
+	}
+	ch <- prometheus.MustNewConstMetric(activeSessionsDesc, prometheus.GaugeValue, float64(total))
+}
+
+// SetSessionStatuses exports the session counts by status returned by
+// source, read on each scrape, as the sessions and sessions_active gauges.
+// Call it at most once.
+func (m *Metrics) SetSessionStatuses(source func() map[string]int) {
+	if m == nil || source == nil {
+		return
+	}
+	m.registry.MustRegister(sessionStatuses(source))
+}
+
+// SetWebSocketClients exports the count returned by source, read on each
+// scrape, as the websocket_clients gauge. Call it at most once.
+func (m *Metrics) SetWebSocketClients(source func() int) {
+	if m == nil || source == nil {
+		return
+	}
+	m.registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "websocket_clients",
+		Help:      "Dashboard WebSocket clients connected.",
+	}, func() float64 { return float64(source()) }))
+}
+
+// Registry returns the registry the collectors are registered with.
+func (m *Metrics) Registry() *prometheus.Registry {
+	if m == nil {
+		return nil
+	}
+	return m.registry
+}
+
+// Handler serves the metrics in the Prometheus text format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// ObserveConnect records how long a connect took to reach READY/RESUMED.
+func (m *Metrics) ObserveConnect(d time.Duration) {
+	if m == nil || d <= 0 {
+		return
+	}
+	m.connectDuration.Observe(d.Seconds())
+}
+
+// ObserveSessionUptime records how long a connection stayed up.
+func (m *Metrics) ObserveSessionUptime(d time.Duration) {
+	if m == nil || d <= 0 {
+		return
+	}
+	m.sessionUptime.Observe(d.Seconds())
+}
+
+// ObserveHeartbeatRTT records a heartbeat round-trip time.
+func (m *Metrics) ObserveHeartbeatRTT(d time.Duration) {
+	if m == nil || d <= 0 {
+		return
+	}
+	m.heartbeatRTT.Observe(d.Seconds())
+}
+
+// IncReconnects counts a dropped connection scheduled to reconnect.
+func (m *Metrics) IncReconnects() {
+	if m == nil {
+		return
+	}
+	m.reconnects.Inc()
+}
+
+// SetWebhookQueueDepth records how many webhook notifications are queued.
+func (m *Metrics) SetWebhookQueueDepth(n int) {
+	if m == nil {
+		return
+	}
+	m.webhookQueueDepth.Set(float64(n))
+}
+
+// IncWebhookDropped counts a webhook notification dropped at a full queue.
+func (m *Metrics) IncWebhookDropped() {
+	if m == nil {
+		return
+	}
+	m.webhookDropped.Inc()
+}
+
+// IncWebhookFailures counts a webhook request that failed to deliver.
+func (m *Metrics) IncWebhookFailures() {
+	if m == nil {
+		return
+	}
+	m.webhookFailures.Inc()
+}
@@ -0,0 +1,132 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+func histogram(t *testing.T, m *Metrics, name string) *dto.Histogram {
+	t.Helper()
+	families, err := m.Registry().Gather()
+	if err != nil {
+		t.Fatalf("Gather() error = %v", err)
+	}
+	for _, f := range families {
+		if f.GetName() == name {
+			return f.GetMetric()[0].GetHistogram()
+		}
+	}
+	t.Fatalf("metric %s not found", name)
+	return nil
+}
+
+// cumulativeCount returns the cumulative count of the bucket with the given
+// upper bound.
+func cumulativeCount(t *testing.T, h *dto.Histogram, upperBound float64) uint64 {
+	t.Helper()
+	for _, b := range h.GetBucket() {
+		if b.GetUpperBound() == upperBound {
+			return b.GetCumulativeCount()
+		}
+	}
+	t.Fatalf("bucket le=%v not found", upperBound)
+	return 0
+}
+
+func TestObservationsLandInExpectedBuckets(t *testing.T) {
+	m := New()
+
+	m.ObserveConnect(300 * time.Millisecond)
+	m.ObserveConnect(3 * time.Second)
+	m.ObserveSessionUptime(2 * time.Hour)
+	m.ObserveHeartbeatRTT(30 * time.Millisecond)
+
+	connect := histogram(t, m, "discord_stayonline_gateway_connect_duration_seconds")
+	if connect.GetSampleCount() != 2 {
+		t.Errorf("expected 2 connect samples, got %d", connect.GetSampleCount())
+	}
+	if got := cumulativeCount(t, connect, 0.25); got != 0 {
+		t.Errorf("expected 0 connects <= 0.25s, got %d", got)
+	}
+	if got := cumulativeCount(t, connect, 0.5); got != 1 {
+		t.Errorf("expected 1 connect <= 0.5s, got %d", got)
+	}
+	if got := cumulativeCount(t, connect, 5); got != 2 {
+		t.Errorf("expected 2 connects <= 5s, got %d", got)
+	}
+
+	uptime := histogram(t, m, "discord_stayonline_gateway_session_uptime_seconds")
+	if got := cumulativeCount(t, uptime, 3600); got != 0 {
+		t.Errorf("expected 0 sessions <= 1h, got %d", got)
+	}
+	if got := cumulativeCount(t, uptime, 6*3600); got != 1 {
+		t.Errorf("expected 1 session <= 6h, got %d", got)
+	}
+
+	rtt := histogram(t, m, "discord_stayonline_gateway_heartbeat_rtt_seconds")
+	if got := cumulativeCount(t, rtt, 0.02); got != 0 {
+		t.Errorf("expected 0 heartbeats <= 20ms, got %d", got)
+	}
+	if got := cumulativeCount(t, rtt, 0.04); got != 1 {
+		t.Errorf("expected 1 heartbeat <= 40ms, got %d", got)
+	}
+}
+
+func TestNilMetricsDiscardsObservations(t *testing.T) {
+	var m *Metrics
+	m.ObserveConnect(time.Second)
+	m.ObserveSessionUptime(time.Second)
+	m.ObserveHeartbeatRTT(time.Second)
+	m.SetWebhookQueueDepth(1)
+	m.IncWebhookDropped()
+	m.IncWebhookFailures()
+	m.IncReconnects()
+	m.SetHeartbeatAges(func() map[string]time.Duration { return nil })
+	m.SetSessionStatuses(func() map[string]int { return nil })
+	m.SetWebSocketClients(func() int { return 0 })
+}
+
+func TestHandlerServesHistograms(t *testing.T) {
+	m := New()
+	m.ObserveConnect(time.Second)
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if !strings.Contains(rec.Body.String(), "discord_stayonline_gateway_connect_duration_seconds_bucket") {
+		t.Errorf("expected connect histogram in output, got:\n%s", rec.Body.String())
+	}
+}
+
+func TestSessionGaugesAndCounters(t *testing.T) {
+	m := New()
+	m.SetSessionStatuses(func() map[string]int {
+		return map[string]int{"connected": 2, "backoff": 1}
+	})
+	m.SetWebSocketClients(func() int { return 3 })
+	m.IncReconnects()
+	m.IncWebhookFailures()
+	m.IncWebhookFailures()
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rec.Body.String()
+
+	for _, line := range []string{
+		`discord_stayonline_sessions{status="connected"} 2`,
+		`discord_stayonline_sessions{status="backoff"} 1`,
+		"discord_stayonline_sessions_active 3",
+		"discord_stayonline_websocket_clients 3",
+		"discord_stayonline_gateway_reconnects_total 1",
+		"discord_stayonline_webhook_send_failures_total 2",
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("expected %q in output, got:\n%s", line, body)
+		}
+	}
+}
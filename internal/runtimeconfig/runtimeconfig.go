@@ -0,0 +1,141 @@
+// Package runtimeconfig reads settings from the environment and records the
+// effective value of each, and whether it came from the environment or a
+// default, so operators can see what the service is actually running with.
+package runtimeconfig
+
+import (
+	"log/slog"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Source says where a setting's value came from.
+type Source string
+
+const (
+	SourceEnv     Source = "env"
+	SourceDefault Source = "default"
+)
+
+// Setting is the effective value of one environment variable.
+type Setting struct {
+	Name   string `json:"name"`
+	Value  any    `json:"value"`
+	Source Source `json:"source"`
+}
+
+// secrets are never recorded, whatever they are read through.
+var secrets = []string{
+	"API_KEY",
+	"DATABASE_URL",
+	"DISCORD_TOKEN",
+	"DISCORD_WEBHOOK_URL",
+	"ENCRYPTION_KEY",
+	"STORAGE_URL",
+	"WEBHOOK_HEADERS",
+	"WEBHOOK_SIGNING_SECRET",
+}
+
+// IsSecret reports whether the named setting holds a credential and must not
+// be exposed.
+func IsSecret(name string) bool {
+	if slices.Contains(secrets, name) {
+		return true
+	}
+	for _, marker := range []string{"TOKEN", "SECRET", "PASSWORD"} {
+		if strings.Contains(name, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// Registry holds the settings read through it. A nil *Registry still reads
+// the environment but records nothing.
+type Registry struct {
+	mu       sync.RWMutex
+	settings map[string]Setting
+}
+
+func New() *Registry {
+	return &Registry{
+		settings: make(map[string]Setting),
+	}
+}
+
+func (r *Registry) record(name string, value any, source Source) {
+	if r == nil || IsSecret(name) {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.settings[name] = Setting{Name: name, Value: value, Source: source}
+}
+
+// String returns the environment variable key, or defaultValue when it is
+// unset or empty.
+func (r *Registry) String(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		r.record(key, value, SourceEnv)
+		return value
+	}
+	r.record(key, defaultValue, SourceDefault)
+	return defaultValue
+}
+
+// Int returns the environment variable key as an integer, or defaultValue
+// when it is unset or invalid.
+func (r *Registry) Int(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		r.record(key, defaultValue, SourceDefault)
+		return defaultValue
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		slog.Warn("Invalid integer environment variable, using default", "key", key, "value", value, "default", defaultValue)
+		r.record(key, defaultValue, SourceDefault)
+		return defaultValue
+	}
+	r.record(key, n, SourceEnv)
+	return n
+}
+
+// Bool returns the environment variable key as a boolean, or defaultValue
+// when it is unset or invalid.
+func (r *Registry) Bool(key string, defaultValue bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		r.record(key, defaultValue, SourceDefault)
+		return defaultValue
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		slog.Warn("Invalid boolean environment variable, using default", "key", key, "value", value, "default", defaultValue)
+		r.record(key, defaultValue, SourceDefault)
+		return defaultValue
+	}
+	r.record(key, b, SourceEnv)
+	return b
+}
+
+// Settings returns the recorded settings sorted by name.
+func (r *Registry) Settings() []Setting {
+	if r == nil {
+		return []Setting{}
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	settings := make([]Setting, 0, len(r.settings))
+	for _, s := range r.settings {
+		settings = append(settings, s)
+	}
+	slices.SortFunc(settings, func(a, b Setting) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return settings
+}
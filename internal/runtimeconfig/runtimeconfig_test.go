@@ -0,0 +1,67 @@
+package runtimeconfig
+
+import (
+	"testing"
+)
+
+func TestRegistryRecordsValuesAndSources(t *testing.T) {
+	t.Setenv("PORT", "9090")
+	t.Setenv("MAX_CONNECTIONS", "5")
+	t.Setenv("PUBLIC_STATUS", "true")
+	t.Setenv("WEBHOOK_WORKERS", "lots")
+	t.Setenv("DISCORD_TOKEN", "secret-token")
+	t.Setenv("WEBHOOK_SIGNING_SECRET", "signing-secret")
+
+	r := New()
+	if got := r.String("PORT", "8080"); got != "9090" {
+		t.Errorf("String() = %q, want 9090", got)
+	}
+	if got := r.String("CONFIG_PATH", "config.json"); got != "config.json" {
+		t.Errorf("String() = %q, want the default", got)
+	}
+	if got := r.Int("MAX_CONNECTIONS", 35); got != 5 {
+		t.Errorf("Int() = %d, want 5", got)
+	}
+	if got := r.Int("WEBHOOK_WORKERS", 2); got != 2 {
+		t.Errorf("Int() = %d, want the default for an invalid value", got)
+	}
+	if got := r.Bool("PUBLIC_STATUS", false); !got {
+		t.Error("Bool() = false, want true")
+	}
+	if got := r.Bool("NOTIFY_DOWN", true); !got {
+		t.Error("Bool() = false, want the default")
+	}
+	if got := r.String("DISCORD_TOKEN", ""); got != "secret-token" {
+		t.Errorf("String() = %q, secrets must still be read", got)
+	}
+	r.String("WEBHOOK_SIGNING_SECRET", "")
+
+	want := []Setting{
+		{Name: "CONFIG_PATH", Value: "config.json", Source: SourceDefault},
+		{Name: "MAX_CONNECTIONS", Value: 5, Source: SourceEnv},
+		{Name: "NOTIFY_DOWN", Value: true, Source: SourceDefault},
+		{Name: "PORT", Value: "9090", Source: SourceEnv},
+		{Name: "PUBLIC_STATUS", Value: true, Source: SourceEnv},
+		{Name: "WEBHOOK_WORKERS", Value: 2, Source: SourceDefault},
+	}
+	got := r.Settings()
+	if len(got) != len(want) {
+		t.Fatalf("Settings() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Settings()[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestNilRegistryReadsWithoutRecording(t *testing.T) {
+	t.Setenv("PORT", "9090")
+	var r *Registry
+	if got := r.String("PORT", "8080"); got != "9090" {
+		t.Errorf("String() = %q, want 9090", got)
+	}
+	if settings := r.Settings(); len(settings) != 0 {
+		t.Errorf("expected no settings, got %+v", settings)
+	}
+}
@@ -0,0 +1,236 @@
+// Package telemetry exports Gateway connection traces and metrics over
+// OpenTelemetry, mirroring the Prometheus metrics in package metrics.
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/pyyupsk/discord-stayonline/internal/gateway"
+)
+
+// ServiceName is reported when OTEL_SERVICE_NAME is not set.
+const ServiceName = "discord-stayonline"
+
+const instrumentationName = "github.com/pyyupsk/discord-stayonline"
+
+// Telemetry records Gateway traces and metrics. A nil *Telemetry is valid and
+// discards everything, so disabled telemetry costs nothing.
+type Telemetry struct {
+	tracer trace.Tracer
+	meter  metric.Meter
+
+	connectDuration   metric.Float64Histogram
+	sessionUptime     metric.Float64Histogram
+	heartbeatRTT      metric.Float64Histogram
+	webhookQueueDepth metric.Int64Gauge
+	webhookDropped    metric.Int64Counter
+
+	shutdown []func(context.Context) error
+}
+
+// New exports to an OTLP/HTTP collector configured by the standard
+// OTEL_EXPORTER_OTLP_* and OTEL_SERVICE_NAME environment variables.
+func New(ctx context.Context) (*Telemetry, error) {
+	res, err := resource.Merge(
+		resource.NewSchemaless(attribute.String("service.name", ServiceName)),
+		resource.Environment(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("telemetry resource: %w", err)
+	}
+
+	traceExporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("create OTLP trace exporter: %w", err)
+	}
+	metricExporter, err := otlpmetrichttp.New(ctx)
+	if err != nil {
+		_ = traceExporter.Shutdown(ctx)
+		return nil, fmt.Errorf("create OTLP metric exporter: %w", err)
+	}
+
+	tp := sdktrace.NewTracerProvider(sdktrace.WithBatcher(traceExporter), sdktrace.WithResource(res))
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(sdkmetric.NewPeriodicReader(metricExporter)), sdkmetric.WithResource(res))
+
+	t, err := NewWithProviders(tp, mp)
+	if err != nil {
+		_ = tp.Shutdown(ctx)
+		_ = mp.Shutdown(ctx)
+		return nil, err
+	}
+	t.shutdown = []func(context.Context) error{tp.Shutdown, mp.Shutdown}
+	return t, nil
+}
+
+// NewWithProviders records to the given providers, which stay owned by the
+// caller.
+func NewWithProviders(tp trace.TracerProvider, mp metric.MeterProvider) (*Telemetry, error) {
+	t := &Telemetry{
+		tracer: tp.Tracer(instrumentationName),
+		meter:  mp.Meter(instrumentationName),
+	}
+
+	var errs []error
+	record := func(err error) {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	var err error
+	t.connectDuration, err = t.meter.Float64Histogram("gateway.connect.duration",
+		metric.WithUnit("s"),
+		metric.WithDescription("Time from dialing the Gateway to READY or RESUMED."),
+		metric.WithExplicitBucketBoundaries(0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60))
+	record(err)
+	t.sessionUptime, err = t.meter.Float64Histogram("gateway.session.uptime",
+		metric.WithUnit("s"),
+		metric.WithDescription("How long a Gateway connection stayed up before it dropped."),
+		metric.WithExplicitBucketBoundaries(60, 300, 900, 1800, 3600, 6*3600, 12*3600, 24*3600, 72*3600, 168*3600))
+	record(err)
+	t.heartbeatRTT, err = t.meter.Float64Histogram("gateway.heartbeat.rtt",
+		metric.WithUnit("s"),
+		metric.WithDescription("Round-trip time between a heartbeat and its ACK."),
+		metric.WithExplicitBucketBoundaries(0.01, 0.02, 0.04, 0.08, 0.16, 0.32, 0.64, 1.28, 2.56, 5.12))
+	record(err)
+	t.webhookQueueDepth, err = t.meter.Int64Gauge("webhook.queue.depth",
+		metric.WithDescription("Webhook notifications waiting for a worker."))
+	record(err)
+	t.webhookDropped, err = t.meter.Int64Counter("webhook.notifications.dropped",
+		metric.WithDescription("Webhook notifications dropped because the queue was full."))
+	record(err)
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("create telemetry instruments: %w", errors.Join(errs...))
+	}
+	return t, nil
+}
+
+// Shutdown flushes and stops the exporters created by New.
+func (t *Telemetry) Shutdown(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+	var errs []error
+	for _, shutdown := range t.shutdown {
+		errs = append(errs, shutdown(ctx))
+	}
+	return errors.Join(errs...)
+}
+
+// RecordConnect traces a connect that reached READY or RESUMED at readyAt,
+// split into its dial, HELLO and IDENTIFY/RESUME phases from the client's
+// timing breakdown, and records its duration. attempt is the reconnect
+// attempt it ended, zero for a first connect.
+func (t *Telemetry) RecordConnect(serverID string, readyAt time.Time, stats gateway.ClientStats, attempt int) {
+	if t == nil || readyAt.IsZero() || stats.Total <= 0 {
+		return
+	}
+	t.connectDuration.Record(context.Background(), stats.Total.Seconds())
+
+	start := readyAt.Add(-stats.Total)
+	ctx, span := t.tracer.Start(context.Background(), "gateway.connect",
+		trace.WithTimestamp(start),
+		trace.WithAttributes(
+			attribute.String("server_id", serverID),
+			attribute.Int("reconnect_attempt", attempt),
+		))
+
+	dialed := start.Add(stats.Dial)
+	hello := dialed.Add(stats.TimeToHello)
+	t.phase(ctx, "gateway.dial", start, dialed)
+	t.phase(ctx, "gateway.hello", dialed, hello)
+	t.phase(ctx, "gateway.identify", hello, readyAt)
+
+	span.AddEvent("ready", trace.WithTimestamp(readyAt))
+	span.End(trace.WithTimestamp(readyAt))
+}
+
+// phase records a completed child span of a connect.
+func (t *Telemetry) phase(ctx context.Context, name string, start, end time.Time) {
+	if !end.After(start) {
+		return
+	}
+	_, span := t.tracer.Start(ctx, name, trace.WithTimestamp(start))
+	span.End(trace.WithTimestamp(end))
+}
+
+// RecordConnectError traces a connect started at start that failed.
+func (t *Telemetry) RecordConnectError(serverID string, start time.Time, attempt int, err error) {
+	if t == nil {
+		return
+	}
+	_, span := t.tracer.Start(context.Background(), "gateway.connect",
+		trace.WithTimestamp(start),
+		trace.WithAttributes(
+			attribute.String("server_id", serverID),
+			attribute.Int("reconnect_attempt", attempt),
+		))
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+	span.End()
+}
+
+// ObserveSessionUptime records how long a connection stayed up.
+func (t *Telemetry) ObserveSessionUptime(d time.Duration) {
+	if t == nil || d <= 0 {
+		return
+	}
+	t.sessionUptime.Record(context.Background(), d.Seconds())
+}
+
+// ObserveHeartbeatRTT records the round-trip time of a heartbeat ACK.
+func (t *Telemetry) ObserveHeartbeatRTT(d time.Duration) {
+	if t == nil || d <= 0 {
+		return
+	}
+	t.heartbeatRTT.Record(context.Background(), d.Seconds())
+}
+
+// SetWebhookQueueDepth records how many webhook notifications are queued.
+func (t *Telemetry) SetWebhookQueueDepth(n int) {
+	if t == nil {
+		return
+	}
+	t.webhookQueueDepth.Record(context.Background(), int64(n))
+}
+
+// IncWebhookDropped counts a webhook notification dropped on a full queue.
+func (t *Telemetry) IncWebhookDropped() {
+	if t == nil {
+		return
+	}
+	t.webhookDropped.Add(context.Background(), 1)
+}
+
+// SetHeartbeatAges exports the ages returned by source, read on each
+// collection, as the gateway.heartbeat.age gauge. Call it at most once.
+func (t *Telemetry) SetHeartbeatAges(source func() map[string]time.Duration) error {
+	if t == nil || source == nil {
+		return nil
+	}
+	_, err := t.meter.Float64ObservableGauge("gateway.heartbeat.age",
+		metric.WithUnit("s"),
+		metric.WithDescription("Time since the session's last heartbeat ACK."),
+		metric.WithFloat64Callback(func(_ context.Context, o metric.Float64Observer) error {
+			for serverID, age := range source() {
+				o.Observe(age.Seconds(), metric.WithAttributes(attribute.String("server_id", serverID)))
+			}
+			return nil
+		}))
+	if err != nil {
+		return fmt.Errorf("create heartbeat age gauge: %w", err)
+	}
+	return nil
+}
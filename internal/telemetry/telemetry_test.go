@@ -0,0 +1,150 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/pyyupsk/discord-stayonline/internal/gateway"
+)
+
+func newTestTelemetry(t *testing.T) (*Telemetry, *tracetest.SpanRecorder, *sdkmetric.ManualReader) {
+	t.Helper()
+	spans := tracetest.NewSpanRecorder()
+	reader := sdkmetric.NewManualReader()
+	tel, err := NewWithProviders(
+		sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)),
+		sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)),
+	)
+	if err != nil {
+		t.Fatalf("NewWithProviders() error = %v", err)
+	}
+	return tel, spans, reader
+}
+
+func collect(t *testing.T, reader *sdkmetric.ManualReader) map[string]metricdata.Aggregation {
+	t.Helper()
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	data := make(map[string]metricdata.Aggregation)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			data[m.Name] = m.Data
+		}
+	}
+	return data
+}
+
+func TestRecordConnectTracesPhases(t *testing.T) {
+	tel, spans, reader := newTestTelemetry(t)
+
+	readyAt := time.Now()
+	stats := gateway.ClientStats{
+		Dial:        100 * time.Millisecond,
+		TimeToHello: 50 * time.Millisecond,
+		TimeToReady: 250 * time.Millisecond,
+		Total:       400 * time.Millisecond,
+	}
+	tel.RecordConnect("server-1", readyAt, stats, 2)
+
+	ended := spans.Ended()
+	byName := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range ended {
+		byName[span.Name()] = span
+	}
+	root, ok := byName["gateway.connect"]
+	if !ok || len(ended) != 4 {
+		t.Fatalf("expected a connect span with three phases, got %d spans", len(ended))
+	}
+	if !root.StartTime().Equal(readyAt.Add(-stats.Total)) || !root.EndTime().Equal(readyAt) {
+		t.Errorf("connect span runs %v to %v, want %v to %v", root.StartTime(), root.EndTime(), readyAt.Add(-stats.Total), readyAt)
+	}
+	for _, name := range []string{"gateway.dial", "gateway.hello", "gateway.identify"} {
+		phase, ok := byName[name]
+		if !ok {
+			t.Errorf("missing %s span", name)
+			continue
+		}
+		if phase.Parent().SpanID() != root.SpanContext().SpanID() {
+			t.Errorf("expected %s to be a child of the connect span", name)
+		}
+	}
+	if d := byName["gateway.identify"].EndTime().Sub(byName["gateway.identify"].StartTime()); d != stats.TimeToReady {
+		t.Errorf("identify span lasted %v, want %v", d, stats.TimeToReady)
+	}
+
+	hist, ok := collect(t, reader)["gateway.connect.duration"].(metricdata.Histogram[float64])
+	if !ok || len(hist.DataPoints) != 1 || hist.DataPoints[0].Count != 1 || hist.DataPoints[0].Sum != 0.4 {
+		t.Errorf("expected one 0.4s connect duration, got %+v", hist)
+	}
+}
+
+func TestRecordConnectErrorMarksSpanFailed(t *testing.T) {
+	tel, spans, _ := newTestTelemetry(t)
+
+	tel.RecordConnectError("server-1", time.Now().Add(-time.Second), 1, errors.New("dial refused"))
+
+	ended := spans.Ended()
+	if len(ended) != 1 || ended[0].Status().Code != codes.Error || ended[0].Status().Description != "dial refused" {
+		t.Fatalf("expected one failed connect span, got %+v", ended)
+	}
+}
+
+func TestMetricsMirrorPrometheus(t *testing.T) {
+	tel, _, reader := newTestTelemetry(t)
+
+	tel.ObserveSessionUptime(2 * time.Hour)
+	tel.ObserveHeartbeatRTT(30 * time.Millisecond)
+	tel.SetWebhookQueueDepth(3)
+	tel.IncWebhookDropped()
+	if err := tel.SetHeartbeatAges(func() map[string]time.Duration {
+		return map[string]time.Duration{"server-1": 1500 * time.Millisecond}
+	}); err != nil {
+		t.Fatalf("SetHeartbeatAges() error = %v", err)
+	}
+
+	data := collect(t, reader)
+	for _, name := range []string{"gateway.session.uptime", "gateway.heartbeat.rtt"} {
+		if hist, ok := data[name].(metricdata.Histogram[float64]); !ok || len(hist.DataPoints) != 1 {
+			t.Errorf("expected one %s observation, got %+v", name, data[name])
+		}
+	}
+	if depth, ok := data["webhook.queue.depth"].(metricdata.Gauge[int64]); !ok || depth.DataPoints[0].Value != 3 {
+		t.Errorf("expected queue depth 3, got %+v", data["webhook.queue.depth"])
+	}
+	if dropped, ok := data["webhook.notifications.dropped"].(metricdata.Sum[int64]); !ok || dropped.DataPoints[0].Value != 1 {
+		t.Errorf("expected 1 dropped notification, got %+v", data["webhook.notifications.dropped"])
+	}
+	age, ok := data["gateway.heartbeat.age"].(metricdata.Gauge[float64])
+	if !ok || len(age.DataPoints) != 1 || age.DataPoints[0].Value != 1.5 {
+		t.Fatalf("expected a 1.5s heartbeat age, got %+v", data["gateway.heartbeat.age"])
+	}
+	if id, _ := age.DataPoints[0].Attributes.Value("server_id"); id.AsString() != "server-1" {
+		t.Errorf("expected server_id attribute, got %v", age.DataPoints[0].Attributes)
+	}
+}
+
+func TestNilTelemetryDiscards(t *testing.T) {
+	var tel *Telemetry
+	tel.RecordConnect("server-1", time.Now(), gateway.ClientStats{Total: time.Second}, 0)
+	tel.RecordConnectError("server-1", time.Now(), 0, errors.New("boom"))
+	tel.ObserveSessionUptime(time.Second)
+	tel.ObserveHeartbeatRTT(time.Second)
+	tel.SetWebhookQueueDepth(1)
+	tel.IncWebhookDropped()
+	if err := tel.SetHeartbeatAges(func() map[string]time.Duration { return nil }); err != nil {
+		t.Errorf("SetHeartbeatAges() error = %v", err)
+	}
+	if err := tel.Shutdown(context.Background()); err != nil {
+		t.Errorf("Shutdown() error = %v", err)
+	}
+}
@@ -10,10 +10,47 @@ import (
 	"strings"
 )
 
+// ParseRoutes splits a comma-separated list of client routes, as used by
+// SPA_ROUTES. A route ending in "/*" matches any path below it.
+func ParseRoutes(raw string) []string {
+	var routes []string
+	for _, route := range strings.Split(raw, ",") {
+		route = strings.TrimSpace(route)
+		if route == "" {
+			continue
+		}
+		if !strings.HasPrefix(route, "/") {
+			route = "/" + route
+		}
+		routes = append(routes, route)
+	}
+	return routes
+}
+
+// matchRoute reports whether urlPath is one of routes.
+func matchRoute(routes []string, urlPath string) bool {
+	if urlPath != "/" {
+		urlPath = strings.TrimSuffix(urlPath, "/")
+	}
+	for _, route := range routes {
+		if prefix, ok := strings.CutSuffix(route, "/*"); ok {
+			if strings.HasPrefix(urlPath, prefix+"/") {
+				return true
+			}
+			continue
+		}
+		if urlPath == route {
+			return true
+		}
+	}
+	return false
+}
+
 // SPAHandler returns an HTTP handler for serving an SPA with fallback to index.html.
 // This ensures client-side routing works by serving index.html for any path that
-// doesn't match a static file.
-func SPAHandler(fsys fs.FS) http.Handler {
+// doesn't match a static file. When routes is non-empty, only those client
+// routes fall back to index.html and any other path is a 404.
+func SPAHandler(fsys fs.FS, routes []string) http.Handler {
 	fileServer := http.FileServer(http.FS(fsys))
 
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -51,6 +88,10 @@ func SPAHandler(fsys fs.FS) http.Handler {
 		}
 
 		// No file extension - this is an SPA route, serve index.html
+		if len(routes) > 0 && !matchRoute(routes, urlPath) {
+			http.NotFound(w, r)
+			return
+		}
 		r.URL.Path = "/"
 		fileServer.ServeHTTP(w, r)
 	})
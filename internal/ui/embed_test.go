@@ -0,0 +1,45 @@
+package ui
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+func TestSPAHandlerRoutes(t *testing.T) {
+	webFS := fstest.MapFS{
+		"index.html":    {Data: []byte("<html>app</html>")},
+		"assets/app.js": {Data: []byte("console.log(1)")},
+	}
+
+	tests := []struct {
+		name   string
+		routes []string
+		path   string
+		want   int
+	}{
+		{name: "permissive deep link", path: "/nonexistent-page", want: http.StatusOK},
+		{name: "root", routes: ParseRoutes("/login"), path: "/", want: http.StatusOK},
+		{name: "known route", routes: ParseRoutes("/login, activity"), path: "/activity", want: http.StatusOK},
+		{name: "known route with trailing slash", routes: ParseRoutes("/login"), path: "/login/", want: http.StatusOK},
+		{name: "wildcard route", routes: ParseRoutes("/servers/*"), path: "/servers/abc", want: http.StatusOK},
+		{name: "wildcard does not match its parent", routes: ParseRoutes("/servers/*"), path: "/servers", want: http.StatusNotFound},
+		{name: "unknown route", routes: ParseRoutes("/login,/servers/*"), path: "/nonexistent-page", want: http.StatusNotFound},
+		{name: "static asset", routes: ParseRoutes("/login"), path: "/assets/app.js", want: http.StatusOK},
+		{name: "api path", path: "/api/unknown", want: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			SPAHandler(webFS, tt.routes).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != tt.want {
+				t.Fatalf("GET %s = %d, want %d", tt.path, rec.Code, tt.want)
+			}
+			if tt.want == http.StatusOK && tt.path != "/assets/app.js" && rec.Body.String() != "<html>app</html>" {
+				t.Errorf("expected index.html, got %q", rec.Body.String())
+			}
+		})
+	}
+}
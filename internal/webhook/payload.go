@@ -0,0 +1,104 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Type selects the payload format a Notifier sends.
+type Type string
+
+const (
+	TypeDiscord Type = "discord"
+	TypeSlack   Type = "slack"
+)
+
+var ErrInvalidType = errors.New("invalid webhook type, expected discord or slack")
+
+// ParseType parses a WEBHOOK_TYPE value. It is case-insensitive and empty
+// means TypeDiscord.
+func ParseType(raw string) (Type, error) {
+	switch t := Type(strings.ToLower(strings.TrimSpace(raw))); t {
+	case "":
+		return TypeDiscord, nil
+	case TypeDiscord, TypeSlack:
+		return t, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrInvalidType, raw)
+	}
+}
+
+// payloadBuilder turns a notification embed into the request body for one
+// kind of webhook receiver.
+type payloadBuilder interface {
+	build(embed Embed) any
+}
+
+func newPayloadBuilder(t Type) payloadBuilder {
+	if t == TypeSlack {
+		return slackPayload{}
+	}
+	return discordPayload{}
+}
+
+// discordPayload sends the embed as is to a Discord webhook.
+type discordPayload struct{}
+
+func (discordPayload) build(embed Embed) any {
+	return WebhookPayload{
+		Username:  WebhookUsername,
+		AvatarURL: WebhookAvatarURL,
+		Embeds:    []Embed{embed},
+	}
+}
+
+// SlackPayload is the body of a Slack incoming webhook message.
+type SlackPayload struct {
+	Username    string            `json:"username,omitempty"`
+	IconURL     string            `json:"icon_url,omitempty"`
+	Attachments []SlackAttachment `json:"attachments"`
+}
+
+// SlackAttachment is a Slack legacy attachment, the Slack counterpart of an
+// embed with a colored bar.
+type SlackAttachment struct {
+	Color    string       `json:"color,omitempty"`
+	Fallback string       `json:"fallback,omitempty"`
+	Title    string       `json:"title,omitempty"`
+	Text     string       `json:"text,omitempty"`
+	Fields   []SlackField `json:"fields,omitempty"`
+	TS       int64        `json:"ts,omitempty"`
+}
+
+type SlackField struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+	Short bool   `json:"short,omitempty"`
+}
+
+// slackPayload maps the embed to a single Slack attachment.
+type slackPayload struct{}
+
+func (slackPayload) build(embed Embed) any {
+	attachment := SlackAttachment{
+		Fallback: embed.Title,
+		Title:    embed.Title,
+		Text:     embed.Description,
+	}
+	if embed.Color != 0 {
+		attachment.Color = fmt.Sprintf("#%06x", embed.Color)
+	}
+	for _, f := range embed.Fields {
+		attachment.Fields = append(attachment.Fields, SlackField{Title: f.Name, Value: f.Value, Short: f.Inline})
+	}
+	if ts, err := time.Parse(time.RFC3339, embed.Timestamp); err == nil {
+		attachment.TS = ts.Unix()
+	}
+	return SlackPayload{
+		Username:    WebhookUsername,
+		IconURL:     WebhookAvatarURL,
+		Attachments: []SlackAttachment{attachment},
+	}
+}
@@ -0,0 +1,115 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// captureNotifier returns a notifier of type t and a function returning the
+// last request body it sent, decoded as generic JSON.
+func captureNotifier(t *testing.T, typ Type) (*Notifier, func() map[string]any) {
+	t.Helper()
+	var body map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body = nil
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+	n := NewNotifier(server.URL, nil)
+	n.SetType(typ)
+	return n, func() map[string]any { return body }
+}
+
+func TestDiscordPayloadShape(t *testing.T) {
+	n, last := captureNotifier(t, TypeDiscord)
+
+	n.NotifyDown(Server{ID: "server-1", ChannelID: "222"}, "token invalid")
+	body := last()
+	if body["username"] != WebhookUsername || body["avatar_url"] != WebhookAvatarURL {
+		t.Errorf("expected the Discord identity, got %v", body)
+	}
+	embeds, _ := body["embeds"].([]any)
+	if len(embeds) != 1 {
+		t.Fatalf("expected one embed, got %v", body["embeds"])
+	}
+	embed := embeds[0].(map[string]any)
+	if embed["title"] != "🔴 Connection Lost" || embed["color"] != float64(ColorRed) {
+		t.Errorf("unexpected embed: %v", embed)
+	}
+	if _, ok := body["attachments"]; ok {
+		t.Errorf("expected no Slack attachments in a Discord payload, got %v", body)
+	}
+}
+
+func TestSlackPayloadShape(t *testing.T) {
+	n, last := captureNotifier(t, TypeSlack)
+	server := Server{ID: "server-1", GuildID: "111", ChannelID: "222"}
+
+	tests := []struct {
+		name   string
+		notify func()
+		title  string
+		color  string
+		field  string
+	}{
+		{"down", func() { n.NotifyDown(server, "token invalid") }, "🔴 Connection Lost", "#ed4245", "Reason"},
+		{"up", func() { n.NotifyUp(server) }, "🟢 Connection Restored", "#57f287", FieldChannel},
+		{"reconnecting", func() { n.NotifyReconnecting(server, 2, 5*time.Second) }, "🟡 Reconnecting", "#fee75c", "Retry In"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.notify()
+			body := last()
+			if body["username"] != WebhookUsername || body["icon_url"] != WebhookAvatarURL {
+				t.Errorf("expected the Slack identity, got %v", body)
+			}
+			if _, ok := body["embeds"]; ok {
+				t.Errorf("expected no Discord embeds in a Slack payload, got %v", body)
+			}
+			attachments, _ := body["attachments"].([]any)
+			if len(attachments) != 1 {
+				t.Fatalf("expected one attachment, got %v", body["attachments"])
+			}
+			a := attachments[0].(map[string]any)
+			if a["title"] != tt.title || a["fallback"] != tt.title || a["color"] != tt.color {
+				t.Errorf("unexpected attachment: %v", a)
+			}
+			if ts, _ := a["ts"].(float64); ts <= 0 {
+				t.Errorf("expected a unix timestamp, got %v", a["ts"])
+			}
+			fields, _ := a["fields"].([]any)
+			var titles []string
+			for _, f := range fields {
+				field := f.(map[string]any)
+				if _, ok := field["value"].(string); !ok {
+					t.Errorf("expected a string value, got %v", field)
+				}
+				titles = append(titles, field["title"].(string))
+			}
+			if len(titles) == 0 || titles[0] != FieldServerID || titles[len(titles)-1] != tt.field {
+				t.Errorf("expected fields from %q to %q, got %v", FieldServerID, tt.field, titles)
+			}
+		})
+	}
+}
+
+func TestParseType(t *testing.T) {
+	for raw, want := range map[string]Type{"": TypeDiscord, "discord": TypeDiscord, " Slack ": TypeSlack} {
+		if got, err := ParseType(raw); err != nil || got != want {
+			t.Errorf("ParseType(%q) = (%q, %v), want %q", raw, got, err, want)
+		}
+	}
+	if _, err := ParseType("teams"); !errors.Is(err, ErrInvalidType) {
+		t.Errorf("expected ErrInvalidType, got %v", err)
+	}
+}
+
+func TestSetTypeNilNotifier(t *testing.T) {
+	var n *Notifier
+	n.SetType(TypeSlack)
+}
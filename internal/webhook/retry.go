@@ -0,0 +1,66 @@
+package webhook
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// DefaultMaxRetries is how many times a webhook request that failed
+	// with a network error, a 5xx, or a 429 is retried.
+	DefaultMaxRetries = 3
+
+	// DefaultRetryDelay is the wait before the first retry; it doubles for
+	// each following attempt.
+	DefaultRetryDelay = time.Second
+
+	// maxRetryWait bounds one retry delay. A 429 asking for longer is not
+	// retried.
+	maxRetryWait = 30 * time.Second
+)
+
+// SetMaxRetries sets how many times a request that failed transiently is
+// retried. Zero disables retries; negative values restore
+// DefaultMaxRetries.
+func (n *Notifier) SetMaxRetries(retries int) {
+	if n == nil {
+		return
+	}
+	if retries < 0 {
+		retries = DefaultMaxRetries
+	}
+	n.maxRetries = retries
+}
+
+// retryDelay returns the backoff before retry number attempt, starting at
+// the notifier's base delay and doubling up to maxRetryWait.
+func (n *Notifier) retryDelay(attempt int) time.Duration {
+	delay := n.retryBase
+	for i := 1; i < attempt && delay < maxRetryWait; i++ {
+		delay *= 2
+	}
+	return min(delay, maxRetryWait)
+}
+
+// retryAfter reads the delay a 429 response asks for from Retry-After in
+// seconds, falling back to fallback.
+func retryAfter(header http.Header, fallback time.Duration) time.Duration {
+	if seconds, err := strconv.ParseFloat(header.Get("Retry-After"), 64); err == nil && seconds >= 0 {
+		return time.Duration(seconds * float64(time.Second))
+	}
+	return fallback
+}
+
+// wait sleeps for d, returning false if the notifier's context is done
+// first.
+func (n *Notifier) wait(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-n.ctx.Done():
+		return false
+	}
+}
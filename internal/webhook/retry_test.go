@@ -0,0 +1,98 @@
+package webhook
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pyyupsk/discord-stayonline/internal/deadletter"
+)
+
+// statusSequence answers each request with the next status in statuses,
+// then with 204, and counts requests.
+func statusSequence(t *testing.T, header http.Header, statuses ...int) (*Notifier, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		i := int(requests.Add(1)) - 1
+		if i >= len(statuses) {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		for key, values := range header {
+			w.Header()[key] = values
+		}
+		w.WriteHeader(statuses[i])
+	}))
+	t.Cleanup(server.Close)
+
+	n := NewNotifier(server.URL, nil)
+	n.retryBase = time.Millisecond
+	n.SetDeadLetters(deadletter.New(10))
+	return n, &requests
+}
+
+func TestSendRetriesTransientFailures(t *testing.T) {
+	n, requests := statusSequence(t, nil, http.StatusBadGateway, http.StatusServiceUnavailable)
+
+	n.NotifyUp(Server{ID: "server-1"})
+
+	if got := requests.Load(); got != 3 {
+		t.Errorf("expected two retries, got %d requests", got)
+	}
+	if stats := n.Stats(); stats.Sent != 1 || stats.Failed != 0 {
+		t.Errorf("expected the retry to deliver, got %+v", stats)
+	}
+}
+
+func TestSendGivesUpAfterMaxRetries(t *testing.T) {
+	n, requests := statusSequence(t, nil, 500, 500, 500)
+	n.SetMaxRetries(2)
+
+	n.NotifyUp(Server{ID: "server-1"})
+
+	if got := requests.Load(); got != 3 {
+		t.Errorf("expected the first request and 2 retries, got %d requests", got)
+	}
+	if entries := n.deadLetters.Entries(); len(entries) != 1 || entries[0].Reason != "status 500" {
+		t.Errorf("expected one dead letter after the retries, got %+v", entries)
+	}
+}
+
+func TestSendHonorsRetryAfter(t *testing.T) {
+	n, requests := statusSequence(t, http.Header{"Retry-After": {"0.2"}}, http.StatusTooManyRequests)
+
+	start := time.Now()
+	n.NotifyUp(Server{ID: "server-1"})
+
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("expected the retry to wait for Retry-After, took %v", elapsed)
+	}
+	if got := requests.Load(); got != 2 || n.Stats().Sent != 1 {
+		t.Errorf("expected one retry to deliver, got %d requests and %+v", got, n.Stats())
+	}
+}
+
+func TestSendDoesNotRetryClientErrors(t *testing.T) {
+	n, requests := statusSequence(t, nil, http.StatusBadRequest)
+
+	n.NotifyUp(Server{ID: "server-1"})
+
+	if got := requests.Load(); got != 1 {
+		t.Errorf("expected no retry of a 400, got %d requests", got)
+	}
+	if stats := n.Stats(); stats.Failed != 1 {
+		t.Errorf("expected the 400 counted as failed, got %+v", stats)
+	}
+}
+
+func TestRetryDelayDoubles(t *testing.T) {
+	n := NewNotifier("http://example.invalid", nil)
+	for attempt, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 10: maxRetryWait} {
+		if got := n.retryDelay(attempt); got != want {
+			t.Errorf("retryDelay(%d) = %v, want %v", attempt, got, want)
+		}
+	}
+}
@@ -3,17 +3,51 @@ package webhook
 import (
 	"bytes"
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/pyyupsk/discord-stayonline/internal/deadletter"
 )
 
 type Notifier struct {
-	webhookURL string
-	client     *http.Client
-	logger     *slog.Logger
+	// webhookURLs are the endpoints every notification is sent to.
+	webhookURLs []string
+	client      *http.Client
+	logger      *slog.Logger
+	timeout     time.Duration
+	ctx         context.Context
+
+	headers       http.Header
+	signingSecret []byte
+	payload       payloadBuilder
+
+	// maxRetries and retryBase control retries of transient failures.
+	maxRetries int
+	retryBase  time.Duration
+
+	deadLetters *deadletter.Buffer
+	onFailure   func()
+
+	sent   atomic.Uint64
+	failed atomic.Uint64
+}
+
+// Stats counts the webhook requests a Notifier has made.
+type Stats struct {
+	Sent   uint64 `json:"sent"`
+	Failed uint64 `json:"failed"`
 }
 
 type Embed struct {
@@ -48,44 +82,232 @@ const (
 	WebhookAvatarURL = "https://raw.githubusercontent.com/pyyupsk/discord-stayonline/main/web/public/android-chrome-512x512.png"
 )
 
-const FieldServerID = "Server ID"
+const (
+	FieldServerID = "Server ID"
+	FieldLabel    = "Label"
+	FieldGuild    = "Guild"
+	FieldChannel  = "Channel"
+)
+
+// Server identifies the server a notification is about. The label and
+// names are optional; when known they are shown alongside the IDs so alerts
+// can be read without looking up the configuration.
+type Server struct {
+	ID          string
+	Label       string
+	GuildID     string
+	GuildName   string
+	ChannelID   string
+	ChannelName string
+}
+
+// fields describes the server as embed fields.
+func (s Server) fields() []Field {
+	fields := []Field{{Name: FieldServerID, Value: s.ID, Inline: true}}
+	if s.Label != "" {
+		fields = append(fields, Field{Name: FieldLabel, Value: s.Label, Inline: true})
+	}
+	if guild := named(s.GuildName, s.GuildID); guild != "" {
+		fields = append(fields, Field{Name: FieldGuild, Value: guild, Inline: true})
+	}
+	if s.ChannelID != "" {
+		channel := fmt.Sprintf("<#%s>", s.ChannelID)
+		if s.ChannelName != "" {
+			channel = fmt.Sprintf("#%s (%s)", s.ChannelName, channel)
+		}
+		fields = append(fields, Field{Name: FieldChannel, Value: channel, Inline: true})
+	}
+	return fields
+}
+
+// target names the server in embed descriptions: the channel mention, or
+// for presence-only servers the guild.
+func (s Server) target() string {
+	if s.ChannelID != "" {
+		return fmt.Sprintf("<#%s>", s.ChannelID)
+	}
+	if s.GuildName != "" {
+		return s.GuildName
+	}
+	return s.ID
+}
+
+// named formats "name (id)", falling back to whichever is set.
+func named(name, id string) string {
+	switch {
+	case name != "" && id != "":
+		return fmt.Sprintf("%s (%s)", name, id)
+	case name != "":
+		return name
+	default:
+		return id
+	}
+}
+
+// DefaultTimeout bounds a single webhook request.
+const DefaultTimeout = 10 * time.Second
+
+// SignatureHeader carries the hex HMAC-SHA256 of the request body, prefixed
+// with "sha256=", when a signing secret is configured.
+const SignatureHeader = "X-Signature-256"
+
+var ErrInvalidHeader = errors.New("invalid webhook header, expected key:value")
+
+// ParseHeaders parses comma-separated "Key: Value" pairs, as used by
+// WEBHOOK_HEADERS. Values cannot contain commas. Errors identify the pair by
+// position only, since values are often credentials.
+func ParseHeaders(raw string) (http.Header, error) {
+	headers := http.Header{}
+	for i, pair := range strings.Split(raw, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(pair, ":")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("%w (pair %d)", ErrInvalidHeader, i+1)
+		}
+		headers.Add(key, strings.TrimSpace(value))
+	}
+	return headers, nil
+}
+
+// ParseURLs splits a comma-separated list of webhook URLs, as used by
+// DISCORD_WEBHOOK_URL and WEBHOOK_URLS, dropping blanks and duplicates.
+func ParseURLs(raw string) []string {
+	var urls []string
+	for _, url := range strings.Split(raw, ",") {
+		url = strings.TrimSpace(url)
+		if url != "" && !slices.Contains(urls, url) {
+			urls = append(urls, url)
+		}
+	}
+	return urls
+}
 
-func NewNotifier(webhookURL string, logger *slog.Logger) *Notifier {
-	if webhookURL == "" {
+// NewNotifier returns a notifier sending to each of the comma-separated
+// webhookURLs, or nil when there are none.
+func NewNotifier(webhookURLs string, logger *slog.Logger) *Notifier {
+	urls := ParseURLs(webhookURLs)
+	if len(urls) == 0 {
 		return nil
 	}
 	if logger == nil {
 		logger = slog.Default()
 	}
 	return &Notifier{
-		webhookURL: webhookURL,
-		client: &http.Client{
-			Timeout: 10 * time.Second,
-		},
-		logger: logger.With("component", "webhook"),
+		webhookURLs: urls,
+		client:      &http.Client{},
+		logger:      logger.With("component", "webhook"),
+		timeout:     DefaultTimeout,
+		ctx:         context.Background(),
+		payload:     discordPayload{},
+		maxRetries:  DefaultMaxRetries,
+		retryBase:   DefaultRetryDelay,
+	}
+}
+
+// SetType sets the payload format, Discord embeds by default.
+func (n *Notifier) SetType(t Type) {
+	if n == nil {
+		return
+	}
+	n.payload = newPayloadBuilder(t)
+}
+
+// SetTimeout bounds each webhook request. Non-positive values restore
+// DefaultTimeout.
+func (n *Notifier) SetTimeout(d time.Duration) {
+	if n == nil {
+		return
+	}
+	if d <= 0 {
+		d = DefaultTimeout
+	}
+	n.timeout = d
+}
+
+// SetHeaders adds headers to every webhook request, e.g. for receivers
+// behind an authenticating gateway.
+func (n *Notifier) SetHeaders(headers http.Header) {
+	if n == nil {
+		return
+	}
+	n.headers = headers.Clone()
+}
+
+// SetSigningSecret enables HMAC-SHA256 signing of request bodies in
+// SignatureHeader. An empty secret disables signing.
+func (n *Notifier) SetSigningSecret(secret string) {
+	if n == nil {
+		return
+	}
+	n.signingSecret = []byte(secret)
+}
+
+// SetContext makes in-flight and future sends abort once ctx is done, so
+// pending notifications do not hold up shutdown.
+func (n *Notifier) SetContext(ctx context.Context) {
+	if n == nil {
+		return
+	}
+	n.ctx = ctx
+}
+
+// SetDeadLetters records webhook payloads that fail to deliver into buf.
+func (n *Notifier) SetDeadLetters(buf *deadletter.Buffer) {
+	if n == nil {
+		return
 	}
+	n.deadLetters = buf
 }
 
-func (n *Notifier) NotifyDown(serverID, guildID, channelID, reason string) {
+// SetOnFailure registers f to be called each time a webhook request fails
+// or returns an error status, e.g. to count failures in metrics.
+func (n *Notifier) SetOnFailure(f func()) {
+	if n == nil {
+		return
+	}
+	n.onFailure = f
+}
+
+// Stats returns how many webhook deliveries succeeded and how many failed or
+// returned an error status, counting each URL separately. A nil Notifier
+// reports zero.
+func (n *Notifier) Stats() Stats {
+	if n == nil {
+		return Stats{}
+	}
+	return Stats{Sent: n.sent.Load(), Failed: n.failed.Load()}
+}
+
+// fail reports a failed delivery of data to the dead-letter buffer and the
+// failure callback.
+func (n *Notifier) fail(reason string, data []byte) {
+	n.failed.Add(1)
+	n.deadLetters.Add(deadletter.SourceWebhook, reason, data)
+	if n.onFailure != nil {
+		n.onFailure()
+	}
+}
+
+func (n *Notifier) NotifyDown(server Server, reason string) {
 	if n == nil {
 		return
 	}
 
 	embed := Embed{
 		Title:       "🔴 Connection Lost",
-		Description: fmt.Sprintf("Connection to <#%s> has been lost.", channelID),
+		Description: fmt.Sprintf("Connection to %s has been lost.", server.target()),
 		Color:       ColorRed,
 		Timestamp:   time.Now().UTC().Format(time.RFC3339),
-		Fields: []Field{
-			{Name: FieldServerID, Value: serverID, Inline: true},
-			{Name: "Reason", Value: reason, Inline: false},
-		},
+		Fields:      append(server.fields(), Field{Name: "Reason", Value: reason, Inline: false}),
 	}
 
 	n.send(embed)
 }
 
-func (n *Notifier) NotifyReconnecting(serverID string, attempt int, delay time.Duration) {
+func (n *Notifier) NotifyReconnecting(server Server, attempt int, delay time.Duration) {
 	if n == nil {
 		return
 	}
@@ -95,67 +317,228 @@ func (n *Notifier) NotifyReconnecting(serverID string, attempt int, delay time.D
 		Description: fmt.Sprintf("Attempting to reconnect (attempt #%d)", attempt),
 		Color:       ColorYellow,
 		Timestamp:   time.Now().UTC().Format(time.RFC3339),
-		Fields: []Field{
-			{Name: FieldServerID, Value: serverID, Inline: true},
-			{Name: "Retry In", Value: delay.Round(time.Second).String(), Inline: true},
-		},
+		Fields:      append(server.fields(), Field{Name: "Retry In", Value: delay.Round(time.Second).String(), Inline: true}),
 	}
 
 	n.send(embed)
 }
 
-func (n *Notifier) NotifyUp(serverID, guildID, channelID string) {
+func (n *Notifier) NotifyUp(server Server) {
 	if n == nil {
 		return
 	}
 
 	embed := Embed{
 		Title:       "🟢 Connection Restored",
-		Description: fmt.Sprintf("Connection to <#%s> has been successfully restored.", channelID),
+		Description: fmt.Sprintf("Connection to %s has been successfully restored.", server.target()),
 		Color:       ColorGreen,
 		Timestamp:   time.Now().UTC().Format(time.RFC3339),
+		Fields:      server.fields(),
+	}
+
+	n.send(embed)
+}
+
+// NotifyConnected reports a session's first successful connection, as
+// opposed to NotifyUp, which reports recovery after a disconnect.
+func (n *Notifier) NotifyConnected(server Server) {
+	if n == nil {
+		return
+	}
+
+	embed := Embed{
+		Title:       "✅ Connected",
+		Description: fmt.Sprintf("Connected to %s.", server.target()),
+		Color:       ColorGreen,
+		Timestamp:   time.Now().UTC().Format(time.RFC3339),
+		Fields:      server.fields(),
+	}
+
+	n.send(embed)
+}
+
+func (n *Notifier) NotifyDashboardConnected() {
+	if n == nil {
+		return
+	}
+
+	embed := Embed{
+		Title:       "🖥️ Dashboard Connected",
+		Description: "A dashboard client is now watching the service.",
+		Color:       ColorGreen,
+		Timestamp:   time.Now().UTC().Format(time.RFC3339),
+	}
+
+	n.send(embed)
+}
+
+func (n *Notifier) NotifyDashboardsDisconnected() {
+	if n == nil {
+		return
+	}
+
+	embed := Embed{
+		Title:       "🖥️ Dashboards Disconnected",
+		Description: "No dashboard clients are connected anymore.",
+		Color:       ColorYellow,
+		Timestamp:   time.Now().UTC().Format(time.RFC3339),
+	}
+
+	n.send(embed)
+}
+
+func (n *Notifier) NotifyStartup(connecting int) {
+	if n == nil {
+		return
+	}
+
+	embed := Embed{
+		Title:       "🚀 Service Started",
+		Description: fmt.Sprintf("Service started, %d server(s) connecting.", connecting),
+		Color:       ColorGreen,
+		Timestamp:   time.Now().UTC().Format(time.RFC3339),
+	}
+
+	n.send(embed)
+}
+
+// ServerSummary is one server's line in a status summary.
+type ServerSummary struct {
+	Name   string
+	Status string
+	Up     bool
+}
+
+func (n *Notifier) NotifyStatusSummary(servers []ServerSummary) {
+	if n == nil {
+		return
+	}
+
+	up := 0
+	var lines strings.Builder
+	for _, s := range servers {
+		icon := "🔴"
+		if s.Up {
+			up++
+			icon = "🟢"
+		}
+		fmt.Fprintf(&lines, "%s %s: %s\n", icon, s.Name, s.Status)
+	}
+
+	color := ColorGreen
+	if up < len(servers) {
+		color = ColorYellow
+	}
+
+	embed := Embed{
+		Title:       "📊 Status Summary",
+		Description: lines.String(),
+		Color:       color,
+		Timestamp:   time.Now().UTC().Format(time.RFC3339),
 		Fields: []Field{
-			{Name: FieldServerID, Value: serverID, Inline: true},
+			{Name: "Connected", Value: fmt.Sprintf("%d/%d", up, len(servers)), Inline: true},
 		},
 	}
 
 	n.send(embed)
 }
 
-func (n *Notifier) send(embed Embed) {
-	payload := WebhookPayload{
-		Username:  WebhookUsername,
-		AvatarURL: WebhookAvatarURL,
-		Embeds:    []Embed{embed},
-	}
+func (n *Notifier) sign(body []byte) string {
+	mac := hmac.New(sha256.New, n.signingSecret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
 
-	data, err := json.Marshal(payload)
+func (n *Notifier) send(embed Embed) {
+	data, err := json.Marshal(n.payload.build(embed))
 	if err != nil {
 		n.logger.Error("Failed to marshal webhook payload", "error", err)
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	if len(n.webhookURLs) == 1 {
+		n.deliver(n.webhookURLs[0], n.logger, data)
+		return
+	}
+
+	// Each URL is retried on its own, so a failing one does not hold up or
+	// prevent delivery to the others. URLs carry the webhook token, so they
+	// are logged by position.
+	var wg sync.WaitGroup
+	for i, url := range n.webhookURLs {
+		wg.Go(func() {
+			n.deliver(url, n.logger.With("endpoint", i+1), data)
+		})
+	}
+	wg.Wait()
+}
+
+// deliver posts data to url, retrying transient failures.
+func (n *Notifier) deliver(url string, logger *slog.Logger, data []byte) {
+	for attempt := 1; ; attempt++ {
+		status, header, err := n.post(url, data)
+		delay := n.retryDelay(attempt)
+		var reason string
+		switch {
+		case err != nil:
+			if errors.Is(err, context.Canceled) {
+				logger.Warn("Webhook send cancelled")
+				return
+			}
+			logger.Error("Failed to send webhook", "error", err)
+			reason = err.Error()
+		case status >= 400:
+			logger.Error("Webhook returned error", "status", status)
+			reason = fmt.Sprintf("status %d", status)
+			if status == http.StatusTooManyRequests {
+				delay = retryAfter(header, delay)
+			} else if status < 500 {
+				n.fail(reason, data)
+				return
+			}
+		default:
+			n.sent.Add(1)
+			logger.Debug("Webhook sent successfully")
+			return
+		}
+
+		if attempt > n.maxRetries || delay > maxRetryWait {
+			n.fail(reason, data)
+			return
+		}
+		logger.Warn("Retrying webhook", "attempt", attempt, "max_retries", n.maxRetries, "delay", delay, "reason", reason)
+		if !n.wait(delay) {
+			logger.Warn("Webhook send cancelled")
+			return
+		}
+	}
+}
+
+// post sends one webhook request to url with data as the body, returning the
+// response status and headers.
+func (n *Notifier) post(url string, data []byte) (int, http.Header, error) {
+	ctx, cancel := context.WithTimeout(n.ctx, n.timeout)
 	defer cancel()
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.webhookURL, bytes.NewReader(data))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
 	if err != nil {
-		n.logger.Error("Failed to create webhook request", "error", err)
-		return
+		return 0, nil, err
+	}
+	for key, values := range n.headers {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
 	}
 	req.Header.Set("Content-Type", "application/json")
+	if len(n.signingSecret) > 0 {
+		req.Header.Set(SignatureHeader, "sha256="+n.sign(data))
+	}
 
 	resp, err := n.client.Do(req)
 	if err != nil {
-		n.logger.Error("Failed to send webhook", "error", err)
-		return
+		return 0, nil, err
 	}
-	defer func() { _ = resp.Body.Close() }()
-
-	if resp.StatusCode >= 400 {
-		n.logger.Error("Webhook returned error", "status", resp.StatusCode)
-		return
-	}
-
-	n.logger.Debug("Webhook sent successfully")
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+	return resp.StatusCode, resp.Header, nil
 }
@@ -0,0 +1,298 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pyyupsk/discord-stayonline/internal/deadletter"
+)
+
+func TestFailedSendRecordedInDeadLetters(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	n := NewNotifier(server.URL, nil)
+	n.retryBase = time.Millisecond
+	n.SetDeadLetters(deadletter.New(10))
+
+	n.NotifyUp(Server{ID: "server-1", GuildID: "guild-1", ChannelID: "channel-1"})
+
+	entries := n.deadLetters.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 dead letter, got %d", len(entries))
+	}
+	if entries[0].Source != deadletter.SourceWebhook || entries[0].Reason != "status 500" {
+		t.Errorf("unexpected dead letter: %+v", entries[0])
+	}
+	if !strings.Contains(entries[0].Payload, "server-1") {
+		t.Errorf("expected payload to contain server ID, got %s", entries[0].Payload)
+	}
+}
+
+func TestOnFailureCalledForFailedSends(t *testing.T) {
+	status := http.StatusBadGateway
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	n := NewNotifier(server.URL, nil)
+	n.retryBase = time.Millisecond
+	failures := 0
+	n.SetOnFailure(func() { failures++ })
+
+	n.NotifyUp(Server{ID: "server-1"})
+	status = http.StatusNoContent
+	n.NotifyUp(Server{ID: "server-1"})
+
+	if failures != 1 {
+		t.Errorf("expected 1 failure for the retried 502 answers only, got %d", failures)
+	}
+	if stats := n.Stats(); stats.Sent != 1 || stats.Failed != 1 {
+		t.Errorf("expected 1 sent and 1 failed, got %+v", stats)
+	}
+}
+
+func TestSetDeadLettersNilNotifier(t *testing.T) {
+	var n *Notifier
+	n.SetDeadLetters(deadletter.New(1))
+}
+
+func TestCancelledContextAbortsInFlightSend(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	n := NewNotifier(server.URL, nil)
+	n.SetContext(ctx)
+	n.SetDeadLetters(deadletter.New(10))
+
+	done := make(chan struct{})
+	go func() {
+		n.NotifyUp(Server{ID: "server-1", GuildID: "guild-1", ChannelID: "channel-1"})
+		close(done)
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected cancellation to abort the in-flight send")
+	}
+	if entries := n.deadLetters.Entries(); len(entries) != 0 {
+		t.Errorf("expected cancelled send not to be dead-lettered, got %+v", entries)
+	}
+}
+
+func TestSendTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	n := NewNotifier(server.URL, nil)
+	n.SetTimeout(100 * time.Millisecond)
+	n.SetMaxRetries(0)
+	n.SetDeadLetters(deadletter.New(10))
+
+	start := time.Now()
+	n.NotifyUp(Server{ID: "server-1", GuildID: "guild-1", ChannelID: "channel-1"})
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("expected send to give up after the configured timeout, took %v", elapsed)
+	}
+	entries := n.deadLetters.Entries()
+	if len(entries) != 1 || !strings.Contains(entries[0].Reason, "deadline exceeded") {
+		t.Errorf("expected timed-out send to be dead-lettered, got %+v", entries)
+	}
+}
+
+func TestCustomHeadersAndSignature(t *testing.T) {
+	var got *http.Request
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	headers, err := ParseHeaders("Authorization: Bearer abc123, X-Env:prod")
+	if err != nil {
+		t.Fatalf("ParseHeaders() error = %v", err)
+	}
+
+	n := NewNotifier(server.URL, nil)
+	n.SetHeaders(headers)
+	n.SetSigningSecret("s3cret")
+	n.NotifyUp(Server{ID: "server-1", GuildID: "guild-1", ChannelID: "channel-1"})
+
+	if got == nil {
+		t.Fatal("expected a webhook request")
+	}
+	if got.Header.Get("Authorization") != "Bearer abc123" || got.Header.Get("X-Env") != "prod" {
+		t.Errorf("expected custom headers, got %v", got.Header)
+	}
+	if got.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("expected JSON content type, got %q", got.Header.Get("Content-Type"))
+	}
+
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write(body)
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	if sig := got.Header.Get(SignatureHeader); sig != want {
+		t.Errorf("expected signature %q, got %q", want, sig)
+	}
+}
+
+func TestNoExtraHeadersByDefault(t *testing.T) {
+	var got *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	NewNotifier(server.URL, nil).NotifyUp(Server{ID: "server-1", GuildID: "guild-1", ChannelID: "channel-1"})
+
+	if got == nil {
+		t.Fatal("expected a webhook request")
+	}
+	if got.Header.Get(SignatureHeader) != "" {
+		t.Error("expected no signature without a signing secret")
+	}
+}
+
+func TestParseHeadersRejectsMalformedPair(t *testing.T) {
+	_, err := ParseHeaders("X-Ok: 1, Bearer secret-token")
+	if !errors.Is(err, ErrInvalidHeader) {
+		t.Fatalf("expected ErrInvalidHeader, got %v", err)
+	}
+	if strings.Contains(err.Error(), "secret-token") {
+		t.Errorf("error leaks header value: %v", err)
+	}
+}
+
+func TestEmbedsIncludeServerNames(t *testing.T) {
+	var payload WebhookPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	n := NewNotifier(server.URL, nil)
+
+	fieldValues := func() map[string]string {
+		if len(payload.Embeds) != 1 {
+			t.Fatalf("expected one embed, got %+v", payload.Embeds)
+		}
+		values := make(map[string]string)
+		for _, f := range payload.Embeds[0].Fields {
+			values[f.Name] = f.Value
+		}
+		return values
+	}
+
+	n.NotifyDown(Server{
+		ID: "server-17", Label: "Lounge",
+		GuildID: "111", GuildName: "My Guild",
+		ChannelID: "222", ChannelName: "general",
+	}, "token invalid")
+	got := fieldValues()
+	want := map[string]string{
+		FieldServerID: "server-17",
+		FieldLabel:    "Lounge",
+		FieldGuild:    "My Guild (111)",
+		FieldChannel:  "#general (<#222>)",
+		"Reason":      "token invalid",
+	}
+	for name, value := range want {
+		if got[name] != value {
+			t.Errorf("field %q = %q, want %q", name, got[name], value)
+		}
+	}
+
+	// Without names, the IDs are still shown and no empty fields are added.
+	n.NotifyUp(Server{ID: "server-17", GuildID: "111", ChannelID: "222"})
+	got = fieldValues()
+	if _, ok := got[FieldLabel]; ok {
+		t.Errorf("expected no label field without a label, got %v", got)
+	}
+	if got[FieldGuild] != "111" || got[FieldChannel] != "<#222>" {
+		t.Errorf("expected raw IDs without names, got %v", got)
+	}
+
+	// Presence-only servers have no channel to mention.
+	n.NotifyConnected(Server{ID: "server-18", GuildID: "111", GuildName: "My Guild"})
+	if _, ok := fieldValues()[FieldChannel]; ok || !strings.Contains(payload.Embeds[0].Description, "My Guild") {
+		t.Errorf("expected the guild name instead of a channel, got %+v", payload.Embeds[0])
+	}
+}
+
+func TestNotifierFansOutToAllURLs(t *testing.T) {
+	var failing, slow, healthy atomic.Int32
+	failingServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		failing.Add(1)
+		time.Sleep(200 * time.Millisecond)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer failingServer.Close()
+	slowServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		slow.Add(1)
+		time.Sleep(200 * time.Millisecond)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer slowServer.Close()
+	healthyServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		healthy.Add(1)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer healthyServer.Close()
+
+	n := NewNotifier(strings.Join([]string{failingServer.URL, slowServer.URL, " ", healthyServer.URL, healthyServer.URL}, ","), nil)
+	start := time.Now()
+	n.NotifyUp(Server{ID: "server-1"})
+	n.NotifyUp(Server{ID: "server-1"})
+
+	if got := [3]int32{failing.Load(), slow.Load(), healthy.Load()}; got != [3]int32{2, 2, 2} {
+		t.Errorf("expected each distinct URL to get both notifications, got %v", got)
+	}
+	if stats := n.Stats(); stats.Sent != 4 || stats.Failed != 2 {
+		t.Errorf("expected the failing URL not to affect the others, got %+v", stats)
+	}
+	if elapsed := time.Since(start); elapsed > 700*time.Millisecond {
+		t.Errorf("expected the URLs to be notified concurrently, took %v", elapsed)
+	}
+}
+
+func TestNewNotifierWithoutURLs(t *testing.T) {
+	if n := NewNotifier(" , ", nil); n != nil {
+		t.Errorf("expected nil notifier without URLs, got %+v", n)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/coder/websocket"
+	"github.com/pyyupsk/discord-stayonline/internal/deadletter"
 )
 
 type Client struct {
@@ -25,7 +26,7 @@ func NewClient(conn *websocket.Conn, hub *Hub, logger *slog.Logger) *Client {
 		hub:        hub,
 		send:       make(chan []byte, 256),
 		logger:     logger,
-		subscribed: make(map[string]bool),
+		subscribed: map[string]bool{ChannelStatus: true},
 	}
 }
 
@@ -113,12 +114,31 @@ func (c *Client) handleMessage(_ context.Context, data []byte) {
 
 	switch msg.Type {
 	case "subscribe":
-		c.subscribe(msg.Channel)
+		if c.hub != nil {
+			c.hub.subscribe(c, msg.Channel)
+		} else {
+			c.subscribe(msg.Channel)
+		}
 	case "unsubscribe":
 		c.unsubscribe(msg.Channel)
 	case "action":
 		c.logger.Debug("Action via WebSocket not supported, use REST API")
+	case string(TypePing):
+		c.pong()
+	}
+}
+
+// pong answers a client ping unless the hub has pongs disabled.
+func (c *Client) pong() {
+	if c.hub != nil && c.hub.noPong {
+		return
+	}
+	data, err := json.Marshal(PongMessage{Type: TypePong, Timestamp: time.Now()})
+	if err != nil {
+		c.logger.Error("Failed to marshal pong", "error", err)
+		return
 	}
+	c.Send(data)
 }
 
 func (c *Client) subscribe(channel string) {
@@ -146,5 +166,8 @@ func (c *Client) Send(data []byte) {
 	case c.send <- data:
 	default:
 		c.logger.Warn("Client send buffer full, dropping message")
+		if c.hub != nil {
+			c.hub.deadLetters.Add(deadletter.SourceWebSocket, "client send buffer full", data)
+		}
 	}
 }
@@ -4,8 +4,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"slices"
+	"strings"
 	"sync"
 	"time"
+
+	"github.com/pyyupsk/discord-stayonline/internal/deadletter"
 )
 
 type MessageType string
@@ -18,6 +22,24 @@ const (
 	TypeAction        MessageType = "action"
 	TypeSubscribe     MessageType = "subscribe"
 	TypeUnsubscribe   MessageType = "unsubscribe"
+
+	// TypePing is sent by clients and answered with TypePong, so browser
+	// dashboards, which cannot see WebSocket control frames, can tell a dead
+	// connection from a quiet one.
+	TypePing MessageType = "ping"
+	TypePong MessageType = "pong"
+)
+
+// DefaultReplaySize is how many recent status and log messages are replayed
+// to a newly connected client.
+const DefaultReplaySize = 50
+
+// Channels a client can subscribe to. Clients start out subscribed to
+// ChannelStatus, so dashboards that never send a subscribe message keep
+// receiving status updates.
+const (
+	ChannelStatus = "status"
+	ChannelLogs   = "logs"
 )
 
 type LogLevel string
@@ -29,6 +51,30 @@ const (
 	LogError LogLevel = "error"
 )
 
+var logLevelSeverity = map[LogLevel]int{
+	LogDebug: 0,
+	LogInfo:  1,
+	LogWarn:  2,
+	LogError: 3,
+}
+
+// ParseLogLevel converts s to a LogLevel, reporting whether it is known.
+func ParseLogLevel(s string) (LogLevel, bool) {
+	level := LogLevel(strings.ToLower(strings.TrimSpace(s)))
+	_, ok := logLevelSeverity[level]
+	return level, ok
+}
+
+// AtLeast reports whether l is as severe as min. Unknown levels are treated
+// as the most severe so they are never silently dropped.
+func (l LogLevel) AtLeast(min LogLevel) bool {
+	severity, ok := logLevelSeverity[l]
+	if !ok {
+		return true
+	}
+	return severity >= logLevelSeverity[min]
+}
+
 type StatusUpdate struct {
 	Type      MessageType `json:"type"`
 	ServerID  string      `json:"server_id"`
@@ -52,6 +98,12 @@ type ErrorMessage struct {
 	Timestamp time.Time   `json:"timestamp"`
 }
 
+// PongMessage answers a client ping.
+type PongMessage struct {
+	Type      MessageType `json:"type"`
+	Timestamp time.Time   `json:"timestamp"`
+}
+
 const (
 	ErrCodeGatewayError     = "gateway_error"
 	ErrCodeConnectionFailed = "connection_failed"
@@ -100,6 +152,13 @@ type LogStore interface {
 	GetLogs(level string) ([]LogEntry, error)
 }
 
+// MilestoneNotifier is told when the number of connected dashboard clients
+// crosses between zero and one.
+type MilestoneNotifier interface {
+	NotifyDashboardConnected()
+	NotifyDashboardsDisconnected()
+}
+
 type Hub struct {
 	clients    map[*Client]bool
 	broadcast  chan []byte
@@ -108,6 +167,42 @@ type Hub struct {
 	mu         sync.RWMutex
 	logger     *slog.Logger
 	logStore   LogStore
+
+	// minPersistLevel is the lowest level written to logStore. Lower levels
+	// are still broadcast to dashboard clients.
+	minPersistLevel LogLevel
+
+	deadLetters *deadletter.Buffer
+	milestones  MilestoneNotifier
+
+	// noPong disables answering client pings.
+	noPong bool
+
+	// statusInterval is the minimum time between status broadcasts for one
+	// server. Zero broadcasts every update.
+	statusInterval time.Duration
+	statusMu       sync.Mutex
+	statusThrottle map[string]*statusThrottle
+
+	// replay holds the most recent status and log messages, oldest first,
+	// for clients that connect later. It is guarded by mu.
+	replay     []replayMessage
+	replaySize int
+}
+
+// replayMessage is a sent message with the channel it was sent on.
+type replayMessage struct {
+	channel string
+	data    []byte
+}
+
+// statusThrottle coalesces one server's status updates. While a timer is
+// pending, newer updates replace pending so the latest state is what gets
+// sent.
+type statusThrottle struct {
+	lastSent time.Time
+	pending  []byte
+	timer    *time.Timer
 }
 
 func NewHub(logger *slog.Logger, logStore LogStore) *Hub {
@@ -121,6 +216,10 @@ func NewHub(logger *slog.Logger, logStore LogStore) *Hub {
 		unregister: make(chan *Client),
 		logger:     logger.With("component", "ws-hub"),
 		logStore:   logStore,
+
+		minPersistLevel: LogDebug,
+		statusThrottle:  make(map[string]*statusThrottle),
+		replaySize:      DefaultReplaySize,
 	}
 }
 
@@ -129,18 +228,30 @@ func (h *Hub) Run() {
 		select {
 		case client := <-h.register:
 			h.mu.Lock()
+			h.replayTo(client, "")
 			h.clients[client] = true
+			total := len(h.clients)
 			h.mu.Unlock()
-			h.logger.Debug("Client registered", "total_clients", len(h.clients))
+			h.logger.Debug("Client registered", "total_clients", total)
+
+			if total == 1 && h.milestones != nil {
+				go h.milestones.NotifyDashboardConnected()
+			}
 
 		case client := <-h.unregister:
 			h.mu.Lock()
-			if _, ok := h.clients[client]; ok {
+			_, ok := h.clients[client]
+			if ok {
 				delete(h.clients, client)
 				close(client.send)
 			}
+			total := len(h.clients)
 			h.mu.Unlock()
-			h.logger.Debug("Client unregistered", "total_clients", len(h.clients))
+			h.logger.Debug("Client unregistered", "total_clients", total)
+
+			if ok && total == 0 && h.milestones != nil {
+				go h.milestones.NotifyDashboardsDisconnected()
+			}
 
 		case message := <-h.broadcast:
 			h.mu.RLock()
@@ -165,9 +276,60 @@ func (h *Hub) Broadcast(data []byte) {
 	case h.broadcast <- data:
 	default:
 		h.logger.Warn("Broadcast channel full, dropping message")
+		h.deadLetters.Add(deadletter.SourceWebSocket, "broadcast channel full", data)
 	}
 }
 
+// SetDeadLetters records messages the hub or its clients drop into buf.
+func (h *Hub) SetDeadLetters(buf *deadletter.Buffer) {
+	h.deadLetters = buf
+}
+
+// SetMilestoneNotifier enables notifications when the first dashboard client
+// connects and when the last one disconnects.
+func (h *Hub) SetMilestoneNotifier(n MilestoneNotifier) {
+	h.milestones = n
+}
+
+// SetAppPong sets whether client {"type":"ping"} messages are answered with
+// {"type":"pong"}. It is enabled by default.
+func (h *Hub) SetAppPong(enabled bool) {
+	h.noPong = !enabled
+}
+
+// SetMinPersistLevel sets the lowest log level written to the log store.
+func (h *Hub) SetMinPersistLevel(level LogLevel) {
+	h.minPersistLevel = level
+}
+
+// SetStatusInterval limits status broadcasts to one per server per interval.
+// Updates arriving sooner are coalesced and the latest is sent when the
+// interval elapses. Zero disables coalescing.
+func (h *Hub) SetStatusInterval(interval time.Duration) {
+	h.statusMu.Lock()
+	h.statusInterval = interval
+	h.statusMu.Unlock()
+}
+
+// SetReplaySize sets how many recent status and log messages are kept and
+// sent to each newly connected client. Zero disables the replay.
+func (h *Hub) SetReplaySize(size int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.replaySize = max(size, 0)
+	if len(h.replay) > h.replaySize {
+		h.replay = slices.Clone(h.replay[len(h.replay)-h.replaySize:])
+	}
+}
+
+func (h *Hub) shouldPersist(level LogLevel) bool {
+	return h.logStore != nil && level.AtLeast(h.minPersistLevel)
+}
+
+func (h *Hub) DeadLetters() *deadletter.Buffer {
+	return h.deadLetters
+}
+
 func (h *Hub) BroadcastStatus(serverID, status, message string) {
 	update := NewStatusUpdate(serverID, status, message)
 	data, err := json.Marshal(update)
@@ -175,9 +337,9 @@ func (h *Hub) BroadcastStatus(serverID, status, message string) {
 		h.logger.Error("Failed to marshal status update", "error", err)
 		return
 	}
-	h.Broadcast(data)
+	h.broadcastStatusData(serverID, data)
 
-	if h.logStore != nil && message != "" {
+	if message != "" && h.shouldPersist(LogInfo) {
 		logMsg := fmt.Sprintf("[%s] %s", serverID, message)
 		if err := h.logStore.AddLog("info", logMsg); err != nil {
 			h.logger.Error("Failed to store status log entry", "error", err)
@@ -185,10 +347,60 @@ func (h *Hub) BroadcastStatus(serverID, status, message string) {
 	}
 }
 
+// broadcastStatusData sends a status update to the clients subscribed to
+// ChannelStatus now, or queues it as the server's pending update if one was
+// sent within the status interval.
+func (h *Hub) broadcastStatusData(serverID string, data []byte) {
+	h.statusMu.Lock()
+	interval := h.statusInterval
+	if interval <= 0 {
+		h.statusMu.Unlock()
+		h.broadcastTo(ChannelStatus, data)
+		return
+	}
+
+	throttle, ok := h.statusThrottle[serverID]
+	if !ok {
+		throttle = &statusThrottle{}
+		h.statusThrottle[serverID] = throttle
+	}
+
+	if throttle.timer != nil {
+		throttle.pending = data
+		h.statusMu.Unlock()
+		return
+	}
+
+	wait := interval - time.Since(throttle.lastSent)
+	if wait <= 0 {
+		throttle.lastSent = time.Now()
+		h.statusMu.Unlock()
+		h.broadcastTo(ChannelStatus, data)
+		return
+	}
+
+	throttle.pending = data
+	throttle.timer = time.AfterFunc(wait, func() { h.flushStatus(throttle) })
+	h.statusMu.Unlock()
+}
+
+func (h *Hub) flushStatus(throttle *statusThrottle) {
+	h.statusMu.Lock()
+	data := throttle.pending
+	throttle.pending = nil
+	throttle.timer = nil
+	throttle.lastSent = time.Now()
+	h.statusMu.Unlock()
+
+	if data != nil {
+		h.broadcastTo(ChannelStatus, data)
+	}
+}
+
 func (h *Hub) BroadcastLog(level LogLevel, message string) {
 	logMsg := NewLogMessage(level, message)
 
-	if h.logStore != nil {
+	if h.shouldPersist(level) {
 		if err := h.logStore.AddLog(string(level), message); err != nil {
 			h.logger.Error("Failed to store log entry", "error", err)
 		}
@@ -200,13 +412,53 @@ func (h *Hub) BroadcastLog(level LogLevel, message string) {
 		return
 	}
 
-	h.mu.RLock()
+	h.broadcastTo(ChannelLogs, data)
+}
+
+// broadcastTo sends data to the clients subscribed to channel and keeps it
+// for replay.
+func (h *Hub) broadcastTo(channel string, data []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.replaySize > 0 {
+		if len(h.replay) == h.replaySize {
+			h.replay = slices.Delete(h.replay, 0, 1)
+		}
+		h.replay = append(h.replay, replayMessage{channel: channel, data: data})
+	}
 	for client := range h.clients {
-		if client.IsSubscribed("logs") {
+		if client.IsSubscribed(channel) {
 			client.Send(data)
 		}
 	}
-	h.mu.RUnlock()
+}
+
+// subscribe subscribes client to channel. A registered client is sent the
+// channel's recent messages, so a dashboard that subscribes to logs after
+// connecting still sees them.
+func (h *Hub) subscribe(client *Client, channel string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if client.IsSubscribed(channel) {
+		return
+	}
+	client.subscribe(channel)
+	if h.clients[client] {
+		h.replayTo(client, channel)
+	}
+}
+
+// replayTo sends client the recent messages of channel, or of every channel
+// it is subscribed to when channel is empty. h.mu must be held.
+func (h *Hub) replayTo(client *Client, channel string) {
+	for _, msg := range h.replay {
+		if channel != "" && msg.channel != channel {
+			continue
+		}
+		if client.IsSubscribed(msg.channel) {
+			client.Send(msg.data)
+		}
+	}
 }
 
 func (h *Hub) GetLogs(level string) []LogEntry {
@@ -0,0 +1,283 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/pyyupsk/discord-stayonline/internal/deadletter"
+)
+
+func TestBroadcastDropRecordedInDeadLetters(t *testing.T) {
+	hub := NewHub(nil, nil)
+	hub.SetDeadLetters(deadletter.New(10))
+
+	for range cap(hub.broadcast) {
+		hub.Broadcast([]byte("queued"))
+	}
+	hub.Broadcast([]byte("dropped"))
+
+	entries := hub.DeadLetters().Entries()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 dead letter, got %d", len(entries))
+	}
+	if entries[0].Payload != "dropped" || entries[0].Source != deadletter.SourceWebSocket {
+		t.Errorf("unexpected dead letter: %+v", entries[0])
+	}
+}
+
+func TestClientSendDropRecordedInDeadLetters(t *testing.T) {
+	hub := NewHub(nil, nil)
+	hub.SetDeadLetters(deadletter.New(10))
+	client := NewClient(nil, hub, slog.Default())
+
+	for range cap(client.send) {
+		client.Send([]byte("queued"))
+	}
+	client.Send([]byte("dropped"))
+
+	entries := hub.DeadLetters().Entries()
+	if len(entries) != 1 || entries[0].Payload != "dropped" {
+		t.Fatalf("expected dropped client message in dead letters, got %+v", entries)
+	}
+}
+
+func TestClientPingReceivesPong(t *testing.T) {
+	hub := NewHub(nil, nil)
+	client := NewClient(nil, hub, slog.Default())
+
+	client.handleMessage(context.Background(), []byte(`{"type":"ping"}`))
+	select {
+	case data := <-client.send:
+		var msg PongMessage
+		if err := json.Unmarshal(data, &msg); err != nil || msg.Type != TypePong || msg.Timestamp.IsZero() {
+			t.Errorf("expected a pong, got %s", data)
+		}
+	default:
+		t.Fatal("expected a pong to be queued")
+	}
+
+	hub.SetAppPong(false)
+	client.handleMessage(context.Background(), []byte(`{"type":"ping"}`))
+	if len(client.send) != 0 {
+		t.Error("expected no pong with pongs disabled")
+	}
+}
+
+type fakeMilestones struct {
+	events chan string
+}
+
+func (f *fakeMilestones) NotifyDashboardConnected()     { f.events <- "connected" }
+func (f *fakeMilestones) NotifyDashboardsDisconnected() { f.events <- "disconnected" }
+
+func TestMilestoneNotificationsFireOncePerTransition(t *testing.T) {
+	hub := NewHub(nil, nil)
+	milestones := &fakeMilestones{events: make(chan string, 10)}
+	hub.SetMilestoneNotifier(milestones)
+	go hub.Run()
+
+	first := NewClient(nil, hub, slog.Default())
+	second := NewClient(nil, hub, slog.Default())
+
+	hub.Register(first)
+	hub.Register(second)
+	hub.Unregister(first)
+	hub.Unregister(second)
+	hub.Register(first)
+
+	// Notifications are sent asynchronously, so only their counts are stable.
+	counts := make(map[string]int)
+	for range 3 {
+		select {
+		case got := <-milestones.events:
+			counts[got]++
+		case <-time.After(time.Second):
+			t.Fatalf("timeout waiting for notifications, got %v", counts)
+		}
+	}
+	if counts["connected"] != 2 || counts["disconnected"] != 1 {
+		t.Errorf("expected 2 connected and 1 disconnected notifications, got %v", counts)
+	}
+
+	select {
+	case extra := <-milestones.events:
+		t.Errorf("unexpected extra notification %q", extra)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+type fakeLogStore struct {
+	levels []string
+}
+
+func (f *fakeLogStore) AddLog(level, _ string) error {
+	f.levels = append(f.levels, level)
+	return nil
+}
+
+func (f *fakeLogStore) GetLogs(string) ([]LogEntry, error) { return nil, nil }
+
+func TestSubThresholdLogsBroadcastButNotPersisted(t *testing.T) {
+	store := &fakeLogStore{}
+	hub := NewHub(nil, store)
+	hub.SetMinPersistLevel(LogWarn)
+
+	client := NewClient(nil, hub, slog.Default())
+	client.unsubscribe(ChannelStatus)
+	client.subscribe(ChannelLogs)
+	hub.clients[client] = true
+
+	hub.BroadcastLog(LogDebug, "debug")
+	hub.BroadcastLog(LogInfo, "info")
+	hub.BroadcastLog(LogWarn, "warn")
+	hub.BroadcastLog(LogError, "error")
+	hub.BroadcastStatus("server-1", "connecting", "Connecting...")
+
+	if got := len(client.send); got != 4 {
+		t.Errorf("expected all 4 logs broadcast to the client, got %d", got)
+	}
+	if len(store.levels) != 2 || store.levels[0] != "warn" || store.levels[1] != "error" {
+		t.Errorf("expected only warn and error persisted, got %v", store.levels)
+	}
+}
+
+func TestParseLogLevel(t *testing.T) {
+	if level, ok := ParseLogLevel(" WARN "); !ok || level != LogWarn {
+		t.Errorf("ParseLogLevel(\" WARN \") = (%q, %v), want (warn, true)", level, ok)
+	}
+	if _, ok := ParseLogLevel("verbose"); ok {
+		t.Error("expected unknown level to be rejected")
+	}
+}
+
+func TestStatusBroadcastsCoalescedToLatest(t *testing.T) {
+	hub := NewHub(nil, nil)
+	hub.SetStatusInterval(50 * time.Millisecond)
+	client := registerClient(hub)
+
+	for _, status := range []string{"connecting", "error", "backoff", "connecting", "connected"} {
+		hub.BroadcastStatus("server-1", status, "")
+	}
+	hub.BroadcastStatus("server-2", "error", "")
+
+	first := receiveStatus(t, client)
+	second := receiveStatus(t, client)
+	if first.ServerID != "server-1" || first.Status != "connecting" {
+		t.Errorf("expected first update to go out immediately, got %+v", first)
+	}
+	if second.ServerID != "server-2" || second.Status != "error" {
+		t.Errorf("expected other servers not to be throttled, got %+v", second)
+	}
+
+	latest := receiveStatus(t, client)
+	if latest.ServerID != "server-1" || latest.Status != "connected" {
+		t.Errorf("expected coalesced update with the latest state, got %+v", latest)
+	}
+
+	select {
+	case data := <-client.send:
+		t.Errorf("expected intermediate states to be dropped, got %s", data)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestStatusBroadcastsUnthrottledByDefault(t *testing.T) {
+	hub := NewHub(nil, nil)
+	client := registerClient(hub)
+
+	hub.BroadcastStatus("server-1", "connecting", "")
+	hub.BroadcastStatus("server-1", "connected", "")
+
+	if got := len(client.send); got != 2 {
+		t.Errorf("expected 2 broadcasts, got %d", got)
+	}
+}
+
+func TestStatusBroadcastOnlyToSubscribers(t *testing.T) {
+	hub := NewHub(nil, nil)
+	statusClient := registerClient(hub)
+	logsClient := registerClient(hub)
+	logsClient.unsubscribe(ChannelStatus)
+	logsClient.subscribe(ChannelLogs)
+
+	hub.BroadcastStatus("server-1", "connected", "")
+	hub.BroadcastLog(LogInfo, "hello")
+
+	if update := receiveStatus(t, statusClient); update.Status != "connected" {
+		t.Errorf("expected the status update, got %+v", update)
+	}
+	if got := len(statusClient.send); got != 0 {
+		t.Errorf("expected no logs for the status subscriber, got %d messages", got)
+	}
+	if got := len(logsClient.send); got != 1 {
+		t.Fatalf("expected only the log for the logs subscriber, got %d messages", got)
+	}
+	var msg LogMessage
+	if err := json.Unmarshal(<-logsClient.send, &msg); err != nil || msg.Type != TypeLog {
+		t.Errorf("expected a log message, got %+v (err %v)", msg, err)
+	}
+}
+
+func TestRecentMessagesReplayedToNewClients(t *testing.T) {
+	hub := NewHub(nil, nil)
+	hub.SetReplaySize(3)
+	go hub.Run()
+
+	hub.BroadcastStatus("server-1", "connecting", "")
+	hub.BroadcastLog(LogInfo, "first")
+	hub.BroadcastLog(LogInfo, "second")
+	hub.BroadcastStatus("server-1", "connected", "")
+
+	client := NewClient(nil, hub, slog.Default())
+	hub.Register(client)
+	deadline := time.Now().Add(time.Second)
+	for hub.ClientCount() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("timeout waiting for the client to register")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// Only the status subscription is replayed on register; the oldest
+	// status update was evicted from the buffer.
+	if update := receiveStatus(t, client); update.Status != "connected" {
+		t.Errorf("expected the latest status replayed, got %+v", update)
+	}
+	if got := len(client.send); got != 0 {
+		t.Fatalf("expected only the buffered status, got %d more messages", got)
+	}
+
+	client.handleMessage(context.Background(), []byte(`{"type":"subscribe","channel":"logs"}`))
+	for _, want := range []string{"first", "second"} {
+		var msg LogMessage
+		if err := json.Unmarshal(<-client.send, &msg); err != nil || msg.Message != want {
+			t.Errorf("expected replayed log %q, got %+v (err %v)", want, msg, err)
+		}
+	}
+}
+
+// registerClient adds a client to hub without a connection, subscribed to
+// the default channels.
+func registerClient(hub *Hub) *Client {
+	client := NewClient(nil, hub, slog.Default())
+	hub.clients[client] = true
+	return client
+}
+
+func receiveStatus(t *testing.T, client *Client) StatusUpdate {
+	t.Helper()
+	select {
+	case data := <-client.send:
+		var update StatusUpdate
+		if err := json.Unmarshal(data, &update); err != nil {
+			t.Fatalf("unmarshal status update: %v", err)
+		}
+		return update
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for status broadcast")
+		return StatusUpdate{}
+	}
+}
@@ -0,0 +1,63 @@
+package tests
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/pyyupsk/discord-stayonline/internal/config"
+	"github.com/pyyupsk/discord-stayonline/internal/config/store"
+	"github.com/pyyupsk/discord-stayonline/internal/manager"
+)
+
+// allowGuilds sets the guild allowlist for the rest of the test.
+func allowGuilds(t *testing.T, ids ...string) {
+	t.Helper()
+	config.SetAllowedGuildIDs(ids)
+	t.Cleanup(func() { config.SetAllowedGuildIDs(nil) })
+}
+
+func TestParseGuildIDs(t *testing.T) {
+	got := config.ParseGuildIDs(" 111, ,222,")
+	if !slices.Equal(got, []string{"111", "222"}) {
+		t.Errorf("expected [111 222], got %v", got)
+	}
+}
+
+func TestAllowedGuildsOnSave(t *testing.T) {
+	s := store.NewFile(filepath.Join(t.TempDir(), testConfigFile))
+	cfg := createTestConfig()
+
+	allowGuilds(t, testGuildID1)
+	if err := s.Save(cfg); !errors.Is(err, config.ErrGuildNotAllowed) {
+		t.Fatalf("expected ErrGuildNotAllowed for the second server's guild, got %v", err)
+	}
+
+	allowGuilds(t, testGuildID1, cfg.Servers[1].GuildID)
+	if err := s.Save(cfg); err != nil {
+		t.Fatalf("expected save with every guild allowed to succeed, got %v", err)
+	}
+
+	allowGuilds(t)
+	if err := createTestConfig().Validate(); err != nil {
+		t.Errorf("expected every guild to be allowed without an allowlist, got %v", err)
+	}
+}
+
+func TestAllowedGuildsOnJoin(t *testing.T) {
+	mock := NewMockGatewayServer(t)
+	t.Cleanup(mock.Close)
+
+	// Saved before the allowlist, as after an operator tightens it.
+	mgr, _ := newTestManager(t, nil, withGateway(mock.URL()))
+	allowGuilds(t, testGuildID1)
+
+	if err := mgr.Join(createTestConfig().Servers[1].ID); !errors.Is(err, config.ErrGuildNotAllowed) {
+		t.Errorf("expected ErrGuildNotAllowed joining a disallowed guild, got %v", err)
+	}
+	if err := mgr.Join(testServerID1); err != nil {
+		t.Fatalf("expected join of an allowed guild to succeed, got %v", err)
+	}
+	waitForStatus(t, mgr, testServerID1, manager.StatusConnected)
+}
@@ -0,0 +1,127 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pyyupsk/discord-stayonline/internal/api/handlers"
+	"github.com/pyyupsk/discord-stayonline/internal/manager"
+)
+
+const overrideChannelID = "999999999999999999"
+
+func expectVoiceChannel(t *testing.T, ch <-chan string, want, what string) {
+	t.Helper()
+	select {
+	case got := <-ch:
+		if got != want {
+			t.Errorf("expected %s to channel %q, got %q", what, want, got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timeout waiting for %s", what)
+	}
+}
+
+func TestChannelOverrideSurvivesReconnectUntilRestored(t *testing.T) {
+	mock := NewMockGatewayServer(t)
+	t.Cleanup(mock.Close)
+
+	voiceUpdates := make(chan string, 10)
+	mock.onVoiceState = func(data json.RawMessage) {
+		var voice struct {
+			ChannelID *string `json:"channel_id"`
+		}
+		_ = json.Unmarshal(data, &voice)
+		if voice.ChannelID == nil {
+			voiceUpdates <- ""
+			return
+		}
+		voiceUpdates <- *voice.ChannelID
+	}
+
+	mgr, s := newTestManager(t, nil, withGateway(mock.URL()))
+
+	if err := mgr.Join(testServerID1); err != nil {
+		t.Fatalf("Join() error = %v", err)
+	}
+	expectVoiceChannel(t, voiceUpdates, testChannelID1, "initial voice join")
+
+	h := handlers.NewServersHandler(mgr, slog.Default())
+	send := func(method, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/servers/"+testServerID1+"/channel", strings.NewReader(body))
+		req.SetPathValue("id", testServerID1)
+		rec := httptest.NewRecorder()
+		if method == http.MethodDelete {
+			h.RestoreChannel(rec, req)
+		} else {
+			h.MoveChannel(rec, req)
+		}
+		return rec
+	}
+
+	if rec := send(http.MethodPut, `{"channel_id": "`+overrideChannelID+`"}`); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 moving channel, got %d: %s", rec.Code, rec.Body)
+	}
+	expectVoiceChannel(t, voiceUpdates, overrideChannelID, "voice state update for the move")
+
+	// An invalid session forces a fresh IDENTIFY and voice join on reconnect.
+	if err := mock.SendInvalidSession(context.Background(), false); err != nil {
+		t.Fatalf("SendInvalidSession() error = %v", err)
+	}
+	expectVoiceChannel(t, voiceUpdates, overrideChannelID, "voice join after reconnect")
+	waitForStatus(t, mgr, testServerID1, manager.StatusConnected)
+
+	explanation, err := mgr.Explain(testServerID1)
+	if err != nil {
+		t.Fatalf("Explain() error = %v", err)
+	}
+	if explanation.ChannelOverride != overrideChannelID {
+		t.Errorf("expected explain to report override %q, got %q", overrideChannelID, explanation.ChannelOverride)
+	}
+
+	if rec := send(http.MethodDelete, ""); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 restoring channel, got %d: %s", rec.Code, rec.Body)
+	}
+	expectVoiceChannel(t, voiceUpdates, testChannelID1, "voice state update restoring the configured channel")
+
+	loaded, err := s.Load()
+	if err != nil {
+		t.Fatalf(errLoadFormat, err)
+	}
+	if loaded.Servers[0].ChannelID != testChannelID1 {
+		t.Errorf("expected configured channel to be unchanged, got %q", loaded.Servers[0].ChannelID)
+	}
+}
+
+func TestChannelOverrideValidation(t *testing.T) {
+	mgr, _ := newTestManager(t, nil)
+	h := handlers.NewServersHandler(mgr, slog.Default())
+
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"missing channel", `{}`, http.StatusBadRequest},
+		{"non-numeric channel", `{"channel_id": "general"}`, http.StatusBadRequest},
+		{"not joined", `{"channel_id": "` + overrideChannelID + `"}`, http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/api/servers/"+testServerID1+"/channel", strings.NewReader(tt.body))
+			req.SetPathValue("id", testServerID1)
+			rec := httptest.NewRecorder()
+			h.MoveChannel(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("expected %d, got %d: %s", tt.want, rec.Code, rec.Body)
+			}
+		})
+	}
+}
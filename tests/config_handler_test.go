@@ -0,0 +1,253 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pyyupsk/discord-stayonline/internal/api/handlers"
+	"github.com/pyyupsk/discord-stayonline/internal/config"
+	"github.com/pyyupsk/discord-stayonline/internal/config/store"
+)
+
+func TestConfigUpdateRejectsStaleWrite(t *testing.T) {
+	s := store.NewFile(filepath.Join(t.TempDir(), testConfigFile))
+	if err := s.Save(createTestConfig()); err != nil {
+		t.Fatalf(errSaveFormat, err)
+	}
+	h := handlers.NewConfigHandler(s, slog.Default())
+
+	rec := httptest.NewRecorder()
+	h.GetConfig(rec, httptest.NewRequest(http.MethodGet, "/api/config", nil))
+	etag := rec.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("expected GET /api/config to return an ETag")
+	}
+
+	put := func(ifMatch, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/api/config", strings.NewReader(body))
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		rec := httptest.NewRecorder()
+		h.UpdateConfig(rec, req)
+		return rec
+	}
+
+	// First tab saves with the current ETag.
+	first := put(etag, `{"status": "dnd"}`)
+	if first.Code != http.StatusOK {
+		t.Fatalf("expected first write to succeed, got %d: %s", first.Code, first.Body)
+	}
+
+	// Second tab still holds the old ETag.
+	stale := put(etag, `{"status": "online"}`)
+	if stale.Code != http.StatusConflict {
+		t.Fatalf("expected stale write to be rejected with 409, got %d", stale.Code)
+	}
+
+	cfg, err := s.Load()
+	if err != nil {
+		t.Fatalf(errLoadFormat, err)
+	}
+	if cfg.Status != "dnd" {
+		t.Errorf("expected first write to be preserved, got status %q", cfg.Status)
+	}
+
+	// Retrying with the fresh ETag succeeds.
+	if retry := put(stale.Header().Get("ETag"), `{"status": "online"}`); retry.Code != http.StatusOK {
+		t.Errorf("expected write with fresh ETag to succeed, got %d", retry.Code)
+	}
+
+	// Writes without If-Match keep last-write-wins behavior.
+	if legacy := put("", `{"status": "idle"}`); legacy.Code != http.StatusOK {
+		t.Errorf("expected write without If-Match to succeed, got %d", legacy.Code)
+	}
+}
+
+func TestConfigReplaceServerIDs(t *testing.T) {
+	s := store.NewFile(filepath.Join(t.TempDir(), testConfigFile))
+	h := handlers.NewConfigHandler(s, slog.Default())
+
+	post := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ReplaceConfig(rec, httptest.NewRequest(http.MethodPost, "/api/config", strings.NewReader(body)))
+		return rec
+	}
+	server := func(id string) string {
+		return `{"servers": [{"id": "` + id + `", "guild_id": "` + testGuildID1 + `", "channel_id": "` + testChannelID1 + `", "priority": 1}]}`
+	}
+
+	if rec := post(server("a/action")); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected ID with a slash to be rejected, got %d", rec.Code)
+	}
+	if rec := post(server(strings.Repeat("a", 33))); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 33-character ID to be rejected, got %d", rec.Code)
+	}
+	if rec := post(server("")); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected empty ID to be rejected without GenerateIDs, got %d", rec.Code)
+	}
+
+	if rec := post(server("  " + testServerID1 + " ")); rec.Code != http.StatusOK {
+		t.Fatalf("expected padded ID to be trimmed and accepted, got %d: %s", rec.Code, rec.Body)
+	}
+	cfg, err := s.Load()
+	if err != nil {
+		t.Fatalf(errLoadFormat, err)
+	}
+	if cfg.Servers[0].ID != testServerID1 {
+		t.Errorf("expected stored ID %q, got %q", testServerID1, cfg.Servers[0].ID)
+	}
+
+	h.GenerateIDs = true
+	rec := post(server(""))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected empty ID to be generated, got %d: %s", rec.Code, rec.Body)
+	}
+	var resp struct {
+		Servers []config.ServerEntry `json:"servers"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(resp.Servers) != 1 || !config.ValidServerID(resp.Servers[0].ID) {
+		t.Errorf("expected one server with a generated valid ID, got %+v", resp.Servers)
+	}
+}
+
+func TestConfigUpdateSetsPerServerStatus(t *testing.T) {
+	s := store.NewFile(filepath.Join(t.TempDir(), testConfigFile))
+	if err := s.Save(createTestConfig()); err != nil {
+		t.Fatalf(errSaveFormat, err)
+	}
+	h := handlers.NewConfigHandler(s, slog.Default())
+
+	put := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.UpdateConfig(rec, httptest.NewRequest(http.MethodPut, "/api/config", strings.NewReader(body)))
+		return rec
+	}
+
+	if rec := put(`{"servers": [{"id": "` + testServerID1 + `", "status": "dnd"}]}`); rec.Code != http.StatusOK {
+		t.Fatalf("expected per-server status to be accepted, got %d: %s", rec.Code, rec.Body)
+	}
+	if rec := put(`{"servers": [{"id": "` + testServerID1 + `", "status": "away"}]}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected invalid per-server status to be rejected, got %d", rec.Code)
+	}
+
+	cfg, err := s.Load()
+	if err != nil {
+		t.Fatalf(errLoadFormat, err)
+	}
+	for _, entry := range cfg.Servers {
+		if entry.ID != testServerID1 {
+			continue
+		}
+		if entry.Status != config.StatusDND {
+			t.Errorf("expected per-server status %q, got %q", config.StatusDND, entry.Status)
+		}
+		if entry.StatusOverride != "" {
+			t.Errorf("expected no status override, got %q", entry.StatusOverride)
+		}
+		if entry.GuildID != testGuildID1 || entry.ChannelID != testChannelID1 {
+			t.Errorf("expected other fields to be kept, got %+v", entry)
+		}
+	}
+}
+
+func TestConfigUpdateSetsSelfDeaf(t *testing.T) {
+	s := store.NewFile(filepath.Join(t.TempDir(), testConfigFile))
+	if err := s.Save(createTestConfig()); err != nil {
+		t.Fatalf(errSaveFormat, err)
+	}
+	h := handlers.NewConfigHandler(s, slog.Default())
+
+	rec := httptest.NewRecorder()
+	body := `{"servers": [{"id": "` + testServerID1 + `", "self_deaf": false}]}`
+	h.UpdateConfig(rec, httptest.NewRequest(http.MethodPut, "/api/config", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected self_deaf to be accepted, got %d: %s", rec.Code, rec.Body)
+	}
+
+	cfg, err := s.Load()
+	if err != nil {
+		t.Fatalf(errLoadFormat, err)
+	}
+	for _, entry := range cfg.Servers {
+		if entry.ID == testServerID1 && (entry.SelfDeafened() || !entry.SelfMuted()) {
+			t.Errorf("expected muted but not deafened, got self_mute=%v self_deaf=%v", entry.SelfMute, entry.SelfDeaf)
+		}
+	}
+}
+
+func TestConfigLargeServerList(t *testing.T) {
+	const servers = 500
+	config.SetMaxServerEntries(servers)
+	t.Cleanup(func() { config.SetMaxServerEntries(0) })
+
+	s := store.NewFile(filepath.Join(t.TempDir(), testConfigFile))
+	if err := s.Save(createTestConfig()); err != nil {
+		t.Fatalf(errSaveFormat, err)
+	}
+	h := handlers.NewConfigHandler(s, slog.Default())
+
+	input := struct {
+		Servers []config.ServerEntry `json:"servers"`
+	}{}
+	for i := range servers {
+		input.Servers = append(input.Servers, config.ServerEntry{
+			ID:        fmt.Sprintf("srv-%d", i),
+			Label:     fmt.Sprintf("Server <%d>", i),
+			GuildID:   fmt.Sprintf("1%017d", i),
+			ChannelID: fmt.Sprintf("2%017d", i),
+			Priority:  i + 1,
+		})
+	}
+	// Indenting pushes the import past the default 1MB body limit.
+	body, err := json.MarshalIndent(input, "", strings.Repeat(" ", 256))
+	if err != nil {
+		t.Fatalf("marshal import: %v", err)
+	}
+	if len(body) <= 1<<20 {
+		t.Fatalf("expected the import to exceed 1MB, got %d bytes", len(body))
+	}
+
+	rec := httptest.NewRecorder()
+	h.ReplaceConfig(rec, httptest.NewRequest(http.MethodPost, "/api/config", strings.NewReader(string(body))))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected the large import to be accepted, got %d: %s", rec.Code, rec.Body)
+	}
+
+	cfg, err := s.Load()
+	if err != nil {
+		t.Fatalf(errLoadFormat, err)
+	}
+	want, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatalf("marshal config: %v", err)
+	}
+
+	rec = httptest.NewRecorder()
+	h.GetConfig(rec, httptest.NewRequest(http.MethodGet, "/api/config", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 from GET /api/config, got %d", rec.Code)
+	}
+	if got := rec.Body.String(); got != string(want)+"\n" {
+		t.Errorf("expected the streamed config to match the encoded configuration (%d bytes), got %d bytes", len(want)+1, len(got))
+	}
+	if len(cfg.Servers) != servers {
+		t.Errorf("expected %d servers saved, got %d", servers, len(cfg.Servers))
+	}
+
+	config.SetMaxServerEntries(servers - 1)
+	rec = httptest.NewRecorder()
+	h.ReplaceConfig(rec, httptest.NewRequest(http.MethodPost, "/api/config", strings.NewReader(string(body))))
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), fmt.Sprintf("Maximum %d", servers-1)) {
+		t.Errorf("expected 400 above the configured limit, got %d: %s", rec.Code, rec.Body)
+	}
+}
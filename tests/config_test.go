@@ -2,11 +2,14 @@ package tests
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/pyyupsk/discord-stayonline/internal/config"
 	"github.com/pyyupsk/discord-stayonline/internal/config/store"
@@ -53,6 +56,29 @@ func TestConfigStoreSaveAndLoad(t *testing.T) {
 	assertLoadedConfig(t, loaded)
 }
 
+func TestConfigStoreRoundTripsSelfMuteAndDeaf(t *testing.T) {
+	s := store.NewFile(filepath.Join(t.TempDir(), testConfigFile))
+	cfg := createTestConfig()
+	undeafened := false
+	cfg.Servers[0].SelfDeaf = &undeafened
+
+	if err := s.Save(cfg); err != nil {
+		t.Fatalf(errSaveFormat, err)
+	}
+	loaded, err := s.Load()
+	if err != nil {
+		t.Fatalf(errLoadFormat, err)
+	}
+
+	entry := loaded.Servers[0]
+	if entry.SelfDeaf == nil || entry.SelfDeafened() {
+		t.Errorf("expected self_deaf=false to round-trip, got %v", entry.SelfDeaf)
+	}
+	if entry.SelfMute != nil || !entry.SelfMuted() {
+		t.Errorf("expected unset self_mute to default to muted, got %v", entry.SelfMute)
+	}
+}
+
 func TestConfigStoreAtomicWrite(t *testing.T) {
 	tmpDir := t.TempDir()
 	configPath := filepath.Join(tmpDir, testConfigFile)
@@ -278,6 +304,26 @@ func TestServerEntryValidation(t *testing.T) {
 			},
 			wantErr: config.ErrEmptyID,
 		},
+		{
+			name: "ID with slash",
+			entry: config.ServerEntry{
+				ID:        "test/1",
+				GuildID:   testGuildID1,
+				ChannelID: testChannelID1,
+				Priority:  1,
+			},
+			wantErr: config.ErrInvalidID,
+		},
+		{
+			name: "ID too long",
+			entry: config.ServerEntry{
+				ID:        strings.Repeat("a", config.MaxServerIDLength+1),
+				GuildID:   testGuildID1,
+				ChannelID: testChannelID1,
+				Priority:  1,
+			},
+			wantErr: config.ErrInvalidID,
+		},
 		{
 			name: "empty guild ID",
 			entry: config.ServerEntry{
@@ -298,6 +344,27 @@ func TestServerEntryValidation(t *testing.T) {
 			},
 			wantErr: config.ErrEmptyChannelID,
 		},
+		{
+			name: "presence-only without channel",
+			entry: config.ServerEntry{
+				ID:           testServerID1,
+				GuildID:      testGuildID1,
+				PresenceOnly: true,
+				Priority:     1,
+			},
+			wantErr: nil,
+		},
+		{
+			name: "invalid status override",
+			entry: config.ServerEntry{
+				ID:             testServerID1,
+				GuildID:        testGuildID1,
+				ChannelID:      testChannelID1,
+				Priority:       1,
+				StatusOverride: "invisible",
+			},
+			wantErr: config.ErrInvalidStatus,
+		},
 		{
 			name: "zero priority",
 			entry: config.ServerEntry{
@@ -369,3 +436,158 @@ func TestConfigurationJSONFormat(t *testing.T) {
 		}
 	}
 }
+
+func TestNormalizeStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  config.Status
+		want   config.Status
+		wantOK bool
+	}{
+		{"empty defaults to online", "", config.StatusOnline, true},
+		{"whitespace defaults to online", "   ", config.StatusOnline, true},
+		{"surrounding whitespace trimmed", " idle\n", config.StatusIdle, true},
+		{"valid status unchanged", config.StatusDND, config.StatusDND, true},
+		{"invalid defaults to online", "busy", config.StatusOnline, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := config.NormalizeStatus(tt.input)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("NormalizeStatus(%q) = (%q, %v), want (%q, %v)", tt.input, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestConfigStoreSaveNormalizesStatus(t *testing.T) {
+	store := store.NewFile(filepath.Join(t.TempDir(), testConfigFile))
+
+	cfg := &config.Configuration{Servers: []config.ServerEntry{}, Status: "  dnd "}
+	if err := store.Save(cfg); err != nil {
+		t.Fatalf(errSaveFormat, err)
+	}
+
+	loaded, err := store.Load()
+	if err != nil {
+		t.Fatalf(errLoadFormat, err)
+	}
+	if loaded.Status != config.StatusDND {
+		t.Errorf("expected status 'dnd', got '%s'", loaded.Status)
+	}
+}
+
+func TestConfigStoreSaveSkipsUnchangedServers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), testConfigFile)
+	s := store.NewFile(path)
+	cfg := createTestConfig()
+	if err := s.Save(cfg); err != nil {
+		t.Fatalf(errSaveFormat, err)
+	}
+
+	// An unchanged save leaves the file alone.
+	old := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatalf("Chtimes() error = %v", err)
+	}
+	if err := s.Save(createTestConfig()); err != nil {
+		t.Fatalf(errSaveFormat, err)
+	}
+	if info, err := os.Stat(path); err != nil || !info.ModTime().Equal(old) {
+		t.Errorf("expected an unchanged save not to rewrite the file, got %v", info.ModTime())
+	}
+
+	// Tightening the allowlist only blocks saves that change the
+	// now-disallowed server.
+	allowGuilds(t, testGuildID1)
+	edited := createTestConfig()
+	edited.Servers[0].Label = "Renamed"
+	if err := s.Save(edited); err != nil {
+		t.Fatalf("expected editing an allowed server to skip the unchanged one, got %v", err)
+	}
+	if info, _ := os.Stat(path); info.ModTime().Equal(old) {
+		t.Error("expected a changed save to rewrite the file")
+	}
+	edited.Servers[1].Label = "Also renamed"
+	if err := s.Save(edited); !errors.Is(err, config.ErrGuildNotAllowed) {
+		t.Errorf("expected ErrGuildNotAllowed when changing a disallowed server, got %v", err)
+	}
+}
+
+func TestChangedServers(t *testing.T) {
+	muted, unmuted := true, false
+	prev := []config.ServerEntry{
+		{ID: "same", GuildID: "1", ChannelID: "2", SelfMute: &muted},
+		{ID: "edited", GuildID: "1", ChannelID: "2", SelfMute: &muted},
+		{ID: "removed", GuildID: "1", ChannelID: "2"},
+	}
+	sameMuted := true
+	next := []config.ServerEntry{
+		{ID: "added", GuildID: "1", ChannelID: "2"},
+		{ID: "edited", GuildID: "1", ChannelID: "2", SelfMute: &unmuted},
+		{ID: "same", GuildID: "1", ChannelID: "2", SelfMute: &sameMuted},
+	}
+
+	changed, removed := config.ChangedServers(prev, next)
+	var ids []string
+	for _, srv := range changed {
+		ids = append(ids, srv.ID)
+	}
+	if !slices.Equal(ids, []string{"added", "edited"}) {
+		t.Errorf("expected added and edited servers to change, got %v", ids)
+	}
+	if !slices.Equal(removed, []string{"removed"}) {
+		t.Errorf("expected the removed server, got %v", removed)
+	}
+}
+
+func TestServerEntryDisplayName(t *testing.T) {
+	tests := []struct {
+		name  string
+		entry config.ServerEntry
+		want  string
+	}{
+		{"label wins", config.ServerEntry{ID: testServerID1, Label: "Main lounge", GuildName: "Guild", ChannelName: "General"}, "Main lounge"},
+		{"guild and channel names", config.ServerEntry{ID: testServerID1, GuildName: "Guild", ChannelName: "General"}, "Guild / General"},
+		{"guild name only", config.ServerEntry{ID: testServerID1, GuildName: "Guild"}, "Guild"},
+		{"falls back to ID", config.ServerEntry{ID: testServerID1}, testServerID1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.entry.DisplayName(); got != tt.want {
+				t.Errorf("DisplayName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuietHoursValidation(t *testing.T) {
+	tests := []struct {
+		name  string
+		quiet config.QuietHours
+		valid bool
+	}{
+		{name: "overnight", quiet: config.QuietHours{Start: "23:00", End: "07:00", Status: config.StatusDND}, valid: true},
+		{name: "daytime", quiet: config.QuietHours{Start: "09:30", End: "17:00", Status: config.StatusIdle}, valid: true},
+		{name: "bad time", quiet: config.QuietHours{Start: "25:00", End: "07:00", Status: config.StatusDND}},
+		{name: "missing end", quiet: config.QuietHours{Start: "23:00", Status: config.StatusDND}},
+		{name: "empty window", quiet: config.QuietHours{Start: "08:00", End: "08:00", Status: config.StatusDND}},
+		{name: "bad status", quiet: config.QuietHours{Start: "23:00", End: "07:00", Status: "offline"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := createTestConfig()
+			cfg.Servers[0].QuietHours = []config.QuietHours{tt.quiet}
+			err := cfg.Validate()
+			if tt.valid && err != nil {
+				t.Errorf("expected valid quiet hours, got %v", err)
+			}
+			if !tt.valid && !errors.Is(err, config.ErrInvalidQuietHours) {
+				t.Errorf("expected ErrInvalidQuietHours, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pyyupsk/discord-stayonline/internal/config"
+	"github.com/pyyupsk/discord-stayonline/internal/config/store"
+	"github.com/pyyupsk/discord-stayonline/internal/manager"
+)
+
+// writeConfigFile writes cfg the way an operator's editor would, bypassing
+// the store.
+func writeConfigFile(t *testing.T, path string, cfg *config.Configuration) {
+	t.Helper()
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatalf("marshal config: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+}
+
+func TestFileWatchDebouncesWrites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), testConfigFile)
+	s := store.NewFile(path)
+	s.WatchDebounce = 100 * time.Millisecond
+	if err := s.Save(createTestConfig()); err != nil {
+		t.Fatalf(errSaveFormat, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	updates, err := s.Watch(ctx)
+	if err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+
+	cfg := createTestConfig()
+	for _, status := range []config.Status{config.StatusOnline, config.StatusIdle, config.StatusDND} {
+		cfg.Status = status
+		writeConfigFile(t, path, cfg)
+	}
+	select {
+	case got := <-updates:
+		if got.Status != config.StatusDND {
+			t.Errorf("expected the last write, got status %q", got.Status)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for the config change")
+	}
+	select {
+	case got := <-updates:
+		t.Errorf("expected one reload for the burst of writes, got another: %+v", got)
+	case <-time.After(300 * time.Millisecond):
+	}
+
+	cancel()
+	select {
+	case _, ok := <-updates:
+		if ok {
+			t.Error("expected the channel to be closed after cancel")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for the watch to stop")
+	}
+}
+
+func TestWatchConfigJoinsAddedAndStopsRemovedServers(t *testing.T) {
+	mock := NewMockGatewayServer(t)
+	defer mock.Close()
+
+	path := filepath.Join(t.TempDir(), testConfigFile)
+	s := store.NewFile(path)
+	s.WatchDebounce = 50 * time.Millisecond
+	cfg := createTestConfig()
+	cfg.Servers = cfg.Servers[1:]
+	if err := s.Save(cfg); err != nil {
+		t.Fatalf(errSaveFormat, err)
+	}
+
+	mgr := manager.NewSessionManager(testToken, s, nil, nil, nil)
+	mgr.GatewayURL = mock.URL()
+	defer mgr.Stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	updates, err := s.Watch(ctx)
+	if err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+	mgr.WatchConfig(updates)
+
+	writeConfigFile(t, path, createTestConfig())
+	waitForStatus(t, mgr, testServerID1, manager.StatusConnected)
+	if _, tracked := mgr.GetAllStatuses()["test-2"]; tracked {
+		t.Error("expected the added server without connect_on_start to stay disconnected")
+	}
+
+	cfg = createTestConfig()
+	cfg.Servers = cfg.Servers[1:]
+	writeConfigFile(t, path, cfg)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, tracked := mgr.GetAllStatuses()[testServerID1]; !tracked {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timeout waiting for the removed server's session to stop")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
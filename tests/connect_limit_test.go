@@ -0,0 +1,156 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+
+	"github.com/pyyupsk/discord-stayonline/internal/config"
+	"github.com/pyyupsk/discord-stayonline/internal/gateway"
+	"github.com/pyyupsk/discord-stayonline/internal/manager"
+)
+
+// slowReadyGateway is a Gateway that takes a while to answer IDENTIFY and
+// tracks how many connections are between dialing and READY at once.
+type slowReadyGateway struct {
+	server *httptest.Server
+
+	mu       sync.Mutex
+	conns    []*websocket.Conn
+	dials    int
+	inFlight int
+	peak     int
+}
+
+func newSlowReadyGateway(t *testing.T, readyDelay time.Duration) *slowReadyGateway {
+	g := &slowReadyGateway{}
+	g.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{OriginPatterns: []string{"*"}})
+		if err != nil {
+			t.Errorf("failed to accept websocket: %v", err)
+			return
+		}
+		g.mu.Lock()
+		g.conns = append(g.conns, conn)
+		g.dials++
+		g.inFlight++
+		g.peak = max(g.peak, g.inFlight)
+		g.mu.Unlock()
+
+		ctx := r.Context()
+		hello, _ := json.Marshal(map[string]any{"op": gateway.OpHello, "d": map[string]any{"heartbeat_interval": 45000}})
+		if err := conn.Write(ctx, websocket.MessageText, hello); err != nil {
+			g.finish()
+			return
+		}
+
+		identified := false
+		for {
+			_, data, err := conn.Read(ctx)
+			if err != nil {
+				if !identified {
+					g.finish()
+				}
+				return
+			}
+			var msg struct {
+				Op int `json:"op"`
+			}
+			if json.Unmarshal(data, &msg) != nil || msg.Op != gateway.OpIdentify || identified {
+				continue
+			}
+			identified = true
+
+			time.Sleep(readyDelay)
+			g.finish()
+			ready, _ := json.Marshal(map[string]any{
+				"op": gateway.OpDispatch, "t": "READY", "s": 1,
+				"d": map[string]any{"v": 10, "session_id": "slow-session"},
+			})
+			_ = conn.Write(ctx, websocket.MessageText, ready)
+		}
+	}))
+	t.Cleanup(g.server.Close)
+	return g
+}
+
+func (g *slowReadyGateway) finish() {
+	g.mu.Lock()
+	g.inFlight--
+	g.mu.Unlock()
+}
+
+func (g *slowReadyGateway) URL() string {
+	return "ws" + strings.TrimPrefix(g.server.URL, "http")
+}
+
+// dropAll closes every open connection with a resumable close code.
+func (g *slowReadyGateway) dropAll() {
+	g.mu.Lock()
+	conns := g.conns
+	g.conns = nil
+	g.mu.Unlock()
+	for _, conn := range conns {
+		_ = conn.Close(websocket.StatusCode(4000), "outage")
+	}
+}
+
+func (g *slowReadyGateway) stats() (dials, peak int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.dials, g.peak
+}
+
+func TestMaxConcurrentConnectsBoundsReconnectStorm(t *testing.T) {
+	const (
+		servers = 6
+		limit   = 2
+	)
+	gw := newSlowReadyGateway(t, 100*time.Millisecond)
+
+	entries := make([]config.ServerEntry, 0, servers)
+	for i := range servers {
+		entries = append(entries, config.ServerEntry{
+			ID: fmt.Sprintf("srv-%d", i), GuildID: testGuildID1, PresenceOnly: true, Priority: 1,
+		})
+	}
+	mgr, _ := newTestManager(t, entries, withGateway(gw.URL()), func(mgr *manager.SessionManager) {
+		mgr.MaxConcurrentConnects = limit
+	})
+
+	for _, entry := range entries {
+		if err := mgr.Join(entry.ID); err != nil {
+			t.Fatalf("Join(%s) error = %v", entry.ID, err)
+		}
+	}
+	for _, entry := range entries {
+		waitForStatus(t, mgr, entry.ID, manager.StatusConnected)
+	}
+
+	// Every session loses its connection at once and retries together.
+	gw.dropAll()
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		if dials, _ := gw.stats(); dials >= 2*servers {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timeout waiting for sessions to reconnect")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	for _, entry := range entries {
+		waitForStatus(t, mgr, entry.ID, manager.StatusConnected)
+	}
+
+	if _, peak := gw.stats(); peak > limit {
+		t.Errorf("expected at most %d sessions connecting at once, got %d", limit, peak)
+	}
+}
@@ -0,0 +1,135 @@
+package tests
+
+import (
+	"errors"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/pyyupsk/discord-stayonline/internal/config"
+	"github.com/pyyupsk/discord-stayonline/internal/config/store"
+	"github.com/pyyupsk/discord-stayonline/internal/manager"
+)
+
+var errStoreDown = errors.New("database unavailable")
+
+// flakyStores wraps a config store and a session store, failing every
+// operation while down is set.
+type flakyStores struct {
+	config   config.ConfigStore
+	sessions *memorySessionStore
+	down     atomic.Bool
+}
+
+func (f *flakyStores) Load() (*config.Configuration, error) {
+	if f.down.Load() {
+		return nil, errStoreDown
+	}
+	return f.config.Load()
+}
+
+func (f *flakyStores) Save(cfg *config.Configuration) error {
+	if f.down.Load() {
+		return errStoreDown
+	}
+	return f.config.Save(cfg)
+}
+
+func (f *flakyStores) SaveSession(state config.SessionState) error {
+	if f.down.Load() {
+		return errStoreDown
+	}
+	return f.sessions.SaveSession(state)
+}
+
+func (f *flakyStores) LoadSession(serverID string) (*config.SessionState, error) {
+	if f.down.Load() {
+		return nil, errStoreDown
+	}
+	return f.sessions.LoadSession(serverID)
+}
+
+func (f *flakyStores) DeleteSession(serverID string) error {
+	if f.down.Load() {
+		return errStoreDown
+	}
+	return f.sessions.DeleteSession(serverID)
+}
+
+func (f *flakyStores) UpdateSessionSequence(serverID string, sequence int64) error {
+	if f.down.Load() {
+		return errStoreDown
+	}
+	return f.sessions.UpdateSessionSequence(serverID, sequence)
+}
+
+func (f *flakyStores) ListSessionServerIDs() ([]string, error) {
+	if f.down.Load() {
+		return nil, errStoreDown
+	}
+	return f.sessions.ListSessionServerIDs()
+}
+
+func TestSessionsSurviveStoreOutage(t *testing.T) {
+	mock := NewMockGatewayServer(t)
+	defer mock.Close()
+
+	fileStore := store.NewFile(filepath.Join(t.TempDir(), testConfigFile))
+	if err := fileStore.Save(createTestConfig()); err != nil {
+		t.Fatalf(errSaveFormat, err)
+	}
+	stores := &flakyStores{config: fileStore, sessions: newMemorySessionStore()}
+
+	mgr := manager.NewSessionManager(testToken, stores, stores, nil, nil)
+	mgr.GatewayURL = mock.URL()
+	mgr.StoreRetryDelay = 50 * time.Millisecond
+	mgr.StoreRetryAttempts = 100
+	defer mgr.Stop()
+
+	if err := mgr.Join(testServerID1); err != nil {
+		t.Fatalf("Join() error = %v", err)
+	}
+	waitForStatus(t, mgr, testServerID1, manager.StatusConnected)
+
+	_ = stores.sessions.DeleteSession(testServerID1)
+	stores.down.Store(true)
+
+	// A dropped Gateway connection during the outage reconnects using the
+	// last loaded config, and the session write it makes fails.
+	if err := mock.CloseConnection(websocket.StatusCode(4000), "unknown error"); err != nil {
+		t.Fatalf("CloseConnection() error = %v", err)
+	}
+	waitForStatus(t, mgr, testServerID1, manager.StatusBackoff)
+	waitForStatus(t, mgr, testServerID1, manager.StatusConnected)
+
+	health := mgr.StoreHealth()
+	if health.Up || health.LastError == "" || health.DownSince.IsZero() {
+		t.Errorf("expected store to be reported down, got %+v", health)
+	}
+
+	time.Sleep(200 * time.Millisecond)
+	if status, _ := mgr.GetStatus(testServerID1); status != manager.StatusConnected {
+		t.Fatalf("expected session to stay connected during the outage, got %s", status)
+	}
+	if saved, _ := stores.sessions.LoadSession(testServerID1); saved != nil {
+		t.Fatalf("expected no session written while the store is down, got %+v", saved)
+	}
+
+	stores.down.Store(false)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !mgr.StoreHealth().Up {
+		if time.Now().After(deadline) {
+			t.Fatal("timeout waiting for the store to recover")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if saved, _ := stores.sessions.LoadSession(testServerID1); saved == nil || saved.SessionID != "test-session-123" {
+		t.Errorf("expected the failed session write to be retried, got %+v", saved)
+	}
+	if status, _ := mgr.GetStatus(testServerID1); status != manager.StatusConnected {
+		t.Errorf("expected session to stay connected after recovery, got %s", status)
+	}
+}
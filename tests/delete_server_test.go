@@ -0,0 +1,145 @@
+package tests
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pyyupsk/discord-stayonline/internal/api/handlers"
+	"github.com/pyyupsk/discord-stayonline/internal/config"
+	"github.com/pyyupsk/discord-stayonline/internal/config/store"
+	"github.com/pyyupsk/discord-stayonline/internal/manager"
+)
+
+func TestDeleteServerStopsSessionAndRemovesEntry(t *testing.T) {
+	mock := NewMockGatewayServer(t)
+	defer mock.Close()
+
+	s := store.NewFile(filepath.Join(t.TempDir(), testConfigFile))
+	if err := s.Save(createTestConfig()); err != nil {
+		t.Fatalf(errSaveFormat, err)
+	}
+	sessions := newMemorySessionStore()
+
+	mgr := manager.NewSessionManager(testToken, s, sessions, nil, nil)
+	mgr.GatewayURL = mock.URL()
+	defer mgr.Stop()
+
+	if err := mgr.Join(testServerID1); err != nil {
+		t.Fatalf("Join() error = %v", err)
+	}
+	waitForStatus(t, mgr, testServerID1, manager.StatusConnected)
+
+	h := handlers.NewServersHandler(mgr, slog.Default())
+	del := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodDelete, "/api/servers/"+testServerID1, nil)
+		req.SetPathValue("id", testServerID1)
+		rec := httptest.NewRecorder()
+		h.DeleteServer(rec, req)
+		return rec
+	}
+
+	rec := del()
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 deleting server, got %d: %s", rec.Code, rec.Body)
+	}
+	var resp struct {
+		Servers []config.ServerEntry `json:"servers"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(resp.Servers) != 1 || resp.Servers[0].ID == testServerID1 {
+		t.Errorf("expected only the other server in the response, got %+v", resp.Servers)
+	}
+
+	loaded, err := s.Load()
+	if err != nil {
+		t.Fatalf(errLoadFormat, err)
+	}
+	if len(loaded.Servers) != 1 || loaded.Servers[0].ID == testServerID1 {
+		t.Errorf("expected the server removed from storage, got %+v", loaded.Servers)
+	}
+	if _, tracked := mgr.GetAllStatuses()[testServerID1]; tracked {
+		t.Error("expected the session to be stopped")
+	}
+	if state, _ := sessions.LoadSession(testServerID1); state != nil {
+		t.Errorf("expected persisted session data to be deleted, got %+v", state)
+	}
+
+	if rec := del(); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 deleting a removed server, got %d", rec.Code)
+	}
+}
+
+// rendezvousStore holds each loaded configuration briefly until another Load
+// has read one too, so unserialized writers would both load the same
+// configuration and the later save would overwrite the earlier one.
+type rendezvousStore struct {
+	*store.File
+	arrived chan struct{}
+}
+
+func (s rendezvousStore) Load() (*config.Configuration, error) {
+	cfg, err := s.File.Load()
+	select {
+	case s.arrived <- struct{}{}:
+	case <-s.arrived:
+	case <-time.After(20 * time.Millisecond):
+	}
+	return cfg, err
+}
+
+func TestDeleteServerNotUndoneByConcurrentConfigWrite(t *testing.T) {
+	for range 20 {
+		file := store.NewFile(filepath.Join(t.TempDir(), testConfigFile))
+		if err := file.Save(createTestConfig()); err != nil {
+			t.Fatalf(errSaveFormat, err)
+		}
+		s := rendezvousStore{file, make(chan struct{})}
+		mgr := manager.NewSessionManager(testToken, s, newMemorySessionStore(), nil, nil)
+		servers := handlers.NewServersHandler(mgr, slog.Default())
+		cfgHandler := handlers.NewConfigHandler(s, slog.Default())
+
+		rec := httptest.NewRecorder()
+		cfgHandler.GetConfig(rec, httptest.NewRequest(http.MethodGet, "/api/config", nil))
+		etag := rec.Header().Get("ETag")
+
+		del := httptest.NewRecorder()
+		put := httptest.NewRecorder()
+		var wg sync.WaitGroup
+		wg.Go(func() {
+			req := httptest.NewRequest(http.MethodDelete, "/api/servers/"+testServerID1, nil)
+			req.SetPathValue("id", testServerID1)
+			servers.DeleteServer(del, req)
+		})
+		wg.Go(func() {
+			req := httptest.NewRequest(http.MethodPut, "/api/config", strings.NewReader(`{"status": "dnd"}`))
+			req.Header.Set("If-Match", etag)
+			cfgHandler.UpdateConfig(put, req)
+		})
+		wg.Wait()
+		mgr.Stop()
+
+		if del.Code != http.StatusOK {
+			t.Fatalf("expected 200 deleting server, got %d: %s", del.Code, del.Body)
+		}
+		if put.Code != http.StatusOK && put.Code != http.StatusConflict {
+			t.Fatalf("expected 200 or 409 for the config write, got %d: %s", put.Code, put.Body)
+		}
+		loaded, err := s.Load()
+		if err != nil {
+			t.Fatalf(errLoadFormat, err)
+		}
+		if slices.ContainsFunc(loaded.Servers, func(e config.ServerEntry) bool { return e.ID == testServerID1 }) {
+			t.Fatalf("expected the deleted server to stay deleted (config write got %d)", put.Code)
+		}
+	}
+}
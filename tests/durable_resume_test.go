@@ -0,0 +1,146 @@
+package tests
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pyyupsk/discord-stayonline/internal/config"
+	"github.com/pyyupsk/discord-stayonline/internal/config/store"
+	"github.com/pyyupsk/discord-stayonline/internal/manager"
+)
+
+// newRestartManager returns a manager for createTestConfig backed by
+// sessions, standing in for one process lifetime.
+func newRestartManager(t *testing.T, configPath string, sessions manager.SessionStore, mock *MockGatewayServer) *manager.SessionManager {
+	t.Helper()
+	mgr := manager.NewSessionManager(testToken, store.NewFile(configPath), sessions, nil, nil)
+	mgr.GatewayURL = mock.URL()
+	mgr.SessionResumeTTL = 2 * time.Minute
+	return mgr
+}
+
+func TestRestartResumesPersistedSession(t *testing.T) {
+	mock := NewMockGatewayServer(t)
+	defer mock.Close()
+
+	configPath := filepath.Join(t.TempDir(), testConfigFile)
+	if err := store.NewFile(configPath).Save(createTestConfig()); err != nil {
+		t.Fatalf(errSaveFormat, err)
+	}
+	sessions := newMemorySessionStore()
+
+	first := newRestartManager(t, configPath, sessions, mock)
+	if err := first.Join(testServerID1); err != nil {
+		t.Fatalf("Join() error = %v", err)
+	}
+	waitForStatus(t, first, testServerID1, manager.StatusConnected)
+	if mock.IdentifyPayload() == nil {
+		t.Fatal("expected the first process to IDENTIFY")
+	}
+	first.Stop()
+
+	saved, _ := sessions.LoadSession(testServerID1)
+	if saved == nil || saved.Sequence != 1 || saved.UpdatedAt.IsZero() {
+		t.Fatalf("expected persisted session with sequence 1, got %+v", saved)
+	}
+
+	identified := make(chan struct{}, 1)
+	mock.onIdentify = func(json.RawMessage) { identified <- struct{}{} }
+
+	second := newRestartManager(t, configPath, sessions, mock)
+	defer second.Stop()
+	if err := second.Join(testServerID1); err != nil {
+		t.Fatalf("Join() error = %v", err)
+	}
+	waitForStatus(t, second, testServerID1, manager.StatusConnected)
+
+	select {
+	case <-identified:
+		t.Fatal("expected RESUME after restart, got IDENTIFY")
+	default:
+	}
+
+	var resume struct {
+		SessionID string `json:"session_id"`
+		Sequence  int    `json:"seq"`
+	}
+	if err := json.Unmarshal(mock.ResumePayload(), &resume); err != nil {
+		t.Fatalf("expected a RESUME payload: %v", err)
+	}
+	if resume.SessionID != "test-session-123" || resume.Sequence != 1 {
+		t.Errorf("unexpected RESUME payload: %+v", resume)
+	}
+}
+
+func TestRestartIdentifiesWithStaleSession(t *testing.T) {
+	mock := NewMockGatewayServer(t)
+	defer mock.Close()
+
+	configPath := filepath.Join(t.TempDir(), testConfigFile)
+	if err := store.NewFile(configPath).Save(createTestConfig()); err != nil {
+		t.Fatalf(errSaveFormat, err)
+	}
+	sessions := newMemorySessionStore()
+	sessions.sessions[testServerID1] = config.SessionState{
+		ServerID:  testServerID1,
+		SessionID: "stale-session",
+		Sequence:  42,
+		ResumeURL: mock.URL(),
+		UpdatedAt: time.Now().Add(-10 * time.Minute),
+	}
+
+	mgr := newRestartManager(t, configPath, sessions, mock)
+	defer mgr.Stop()
+	if err := mgr.Join(testServerID1); err != nil {
+		t.Fatalf("Join() error = %v", err)
+	}
+	waitForStatus(t, mgr, testServerID1, manager.StatusConnected)
+
+	if mock.ResumePayload() != nil {
+		t.Error("expected stale session data not to be resumed")
+	}
+	if mock.IdentifyPayload() == nil {
+		t.Error("expected IDENTIFY for stale session data")
+	}
+}
+
+func TestSequenceFlushedWhileConnected(t *testing.T) {
+	mock := NewMockGatewayServer(t)
+	defer mock.Close()
+
+	configPath := filepath.Join(t.TempDir(), testConfigFile)
+	if err := store.NewFile(configPath).Save(createTestConfig()); err != nil {
+		t.Fatalf(errSaveFormat, err)
+	}
+	sessions := newMemorySessionStore()
+
+	mgr := newRestartManager(t, configPath, sessions, mock)
+	mgr.SequenceFlushInterval = 50 * time.Millisecond
+	defer mgr.Stop()
+	if err := mgr.Join(testServerID1); err != nil {
+		t.Fatalf("Join() error = %v", err)
+	}
+	waitForStatus(t, mgr, testServerID1, manager.StatusConnected)
+
+	// A dispatch advances the sequence to 2 without ending the connection.
+	if err := mock.SendVoiceStateUpdate(t.Context(), "voice-session", testGuildID1, ""); err != nil {
+		t.Fatalf("SendVoiceStateUpdate() error = %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		saved, _ := sessions.LoadSession(testServerID1)
+		if saved != nil && saved.Sequence == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the live sequence to be flushed, got %+v", saved)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if status, _ := mgr.GetStatus(testServerID1); status != manager.StatusConnected {
+		t.Errorf("expected the session to stay connected, got %s", status)
+	}
+}
@@ -0,0 +1,99 @@
+package tests
+
+import (
+	"database/sql/driver"
+	"strings"
+	"testing"
+
+	"github.com/pyyupsk/discord-stayonline/internal/config/store"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const testSessionID = "abcdef0123456789abcdef0123456789"
+
+func TestCipherRoundTrip(t *testing.T) {
+	c, err := store.NewCipher("test-encryption-key")
+	if err != nil {
+		t.Fatalf("NewCipher() error = %v", err)
+	}
+
+	encrypted, err := c.Encrypt(testSessionID)
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	if strings.Contains(encrypted, testSessionID) {
+		t.Fatalf("expected ciphertext, got %q", encrypted)
+	}
+
+	decrypted, err := c.Decrypt(encrypted)
+	if err != nil {
+		t.Fatalf("Decrypt() error = %v", err)
+	}
+	if decrypted != testSessionID {
+		t.Errorf("expected %q after round trip, got %q", testSessionID, decrypted)
+	}
+
+	if plain, err := c.Decrypt("legacy-plaintext"); err != nil || plain != "legacy-plaintext" {
+		t.Errorf("expected unprefixed values to pass through, got %q, %v", plain, err)
+	}
+
+	other, _ := store.NewCipher("another-key")
+	if _, err := other.Decrypt(encrypted); err == nil {
+		t.Error("expected decryption with the wrong key to fail")
+	}
+}
+
+func TestSessionColumnsStoredAsCiphertext(t *testing.T) {
+	c, err := store.NewCipher("test-encryption-key")
+	if err != nil {
+		t.Fatalf("NewCipher() error = %v", err)
+	}
+	store.UseEncryption(c)
+	t.Cleanup(func() { store.UseEncryption(nil) })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=127.0.0.1 port=1"}), &gorm.Config{
+		DryRun:                 true,
+		SkipDefaultTransaction: true,
+		DisableAutomaticPing:   true,
+		Logger:                 logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("gorm.Open() error = %v", err)
+	}
+
+	stmt := db.Create(&store.Session{
+		ServerID:  testServerID1,
+		SessionID: testSessionID,
+		ResumeURL: "wss://gateway.discord.gg",
+	}).Statement
+
+	var sawSession, sawURL bool
+	for _, v := range stmt.Vars {
+		// Serialized columns are bound as driver.Valuer wrappers.
+		if valuer, ok := v.(driver.Valuer); ok {
+			if v, err = valuer.Value(); err != nil {
+				t.Fatalf("Value() error = %v", err)
+			}
+		}
+		s, ok := v.(string)
+		if !ok {
+			continue
+		}
+		if s == testSessionID || s == "wss://gateway.discord.gg" {
+			t.Errorf("expected sensitive column to be encrypted, got plaintext %q", s)
+		}
+		if strings.HasPrefix(s, "enc:v1:") {
+			plain, err := c.Decrypt(s)
+			if err != nil {
+				t.Fatalf("Decrypt() error = %v", err)
+			}
+			sawSession = sawSession || plain == testSessionID
+			sawURL = sawURL || plain == "wss://gateway.discord.gg"
+		}
+	}
+	if !sawSession || !sawURL {
+		t.Errorf("expected encrypted session_id and resume_url in %v", stmt.Vars)
+	}
+}
@@ -0,0 +1,105 @@
+package tests
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/coder/websocket"
+
+	"github.com/pyyupsk/discord-stayonline/internal/config"
+	"github.com/pyyupsk/discord-stayonline/internal/manager"
+)
+
+const testGuildID2 = "345678901234567890"
+
+// silentGateway accepts Gateway connections and never sends HELLO, so
+// sessions stay connecting and count as active.
+func silentGateway(t *testing.T) string {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{OriginPatterns: []string{"*"}})
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.CloseNow() }()
+		for {
+			if _, _, err := conn.Read(r.Context()); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+	return "ws" + strings.TrimPrefix(server.URL, "http")
+}
+
+// guildLimitServers are a1, a2, and a3 in one guild and b1 in another.
+var guildLimitServers = []config.ServerEntry{
+	{ID: "a1", GuildID: testGuildID1, ChannelID: testChannelID1, Priority: 1},
+	{ID: "a2", GuildID: testGuildID1, ChannelID: testChannelID1, Priority: 1},
+	{ID: "a3", GuildID: testGuildID1, ChannelID: testChannelID1, Priority: 1},
+	{ID: "b1", GuildID: testGuildID2, ChannelID: testChannelID1, Priority: 1},
+}
+
+func joinConnecting(t *testing.T, mgr *manager.SessionManager, serverID string) {
+	t.Helper()
+	if err := mgr.Join(serverID); err != nil {
+		t.Fatalf("Join(%s) error = %v", serverID, err)
+	}
+	waitForStatus(t, mgr, serverID, manager.StatusConnecting)
+}
+
+func TestGuildConnectionLimit(t *testing.T) {
+	mgr, _ := newTestManager(t, guildLimitServers, withGateway(silentGateway(t)))
+	mgr.MaxConnectionsPerGuild = 1
+
+	joinConnecting(t, mgr, "a1")
+	if err := mgr.Join("a2"); !errors.Is(err, manager.ErrGuildConnectionLimit) {
+		t.Errorf("expected ErrGuildConnectionLimit for a second server in the guild, got %v", err)
+	}
+	joinConnecting(t, mgr, "b1")
+
+	e, err := mgr.Explain("a2")
+	if err != nil {
+		t.Fatalf("Explain() error = %v", err)
+	}
+	if e.Reason != manager.ReasonGuildLimit {
+		t.Errorf("expected reason %q, got %q (%s)", manager.ReasonGuildLimit, e.Reason, e.Message)
+	}
+
+	// Stopping the guild's session frees its slot.
+	if err := mgr.Exit("a1"); err != nil {
+		t.Fatalf("Exit(a1) error = %v", err)
+	}
+	joinConnecting(t, mgr, "a2")
+}
+
+func TestGuildConnectionLimitOverride(t *testing.T) {
+	mgr, _ := newTestManager(t, guildLimitServers, withGateway(silentGateway(t)))
+	mgr.MaxConnectionsPerGuild = 1
+	mgr.GuildConnectionLimits = map[string]int{testGuildID1: 2}
+
+	joinConnecting(t, mgr, "a1")
+	joinConnecting(t, mgr, "a2")
+	if err := mgr.Join("a3"); !errors.Is(err, manager.ErrGuildConnectionLimit) {
+		t.Errorf("expected ErrGuildConnectionLimit past the override, got %v", err)
+	}
+	joinConnecting(t, mgr, "b1")
+}
+
+func TestParseGuildLimits(t *testing.T) {
+	limits, err := manager.ParseGuildLimits(" 111:1, 222:3 ,")
+	if err != nil {
+		t.Fatalf("ParseGuildLimits() error = %v", err)
+	}
+	if len(limits) != 2 || limits["111"] != 1 || limits["222"] != 3 {
+		t.Errorf("unexpected limits: %v", limits)
+	}
+	for _, raw := range []string{"111", "111:x", ":2", "111:-1"} {
+		if _, err := manager.ParseGuildLimits(raw); !errors.Is(err, manager.ErrInvalidGuildLimit) {
+			t.Errorf("ParseGuildLimits(%q) error = %v, want ErrInvalidGuildLimit", raw, err)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pyyupsk/discord-stayonline/internal/manager"
+	"github.com/pyyupsk/discord-stayonline/internal/metrics"
+)
+
+func TestHeartbeatAgeAdvancesWhenAcksStop(t *testing.T) {
+	mock := NewMockGatewayServer(t)
+	t.Cleanup(mock.Close)
+
+	mgr, _ := newTestManager(t, nil, withGateway(mock.URL()), func(mgr *manager.SessionManager) {
+		// Keep the lagging connection open so its age can be observed.
+		mgr.MaxMissedHeartbeatAcks = 1000
+		mgr.Metrics = metrics.New()
+		mgr.Metrics.SetHeartbeatAges(mgr.HeartbeatAges)
+	})
+
+	if err := mgr.Join(testServerID1); err != nil {
+		t.Fatalf("Join() error = %v", err)
+	}
+	waitForStatus(t, mgr, testServerID1, manager.StatusConnected)
+
+	// The mock heartbeats every 100ms, so an ACK arrives promptly.
+	deadline := time.Now().Add(5 * time.Second)
+	var explanation manager.Explanation
+	for {
+		explanation, _ = mgr.Explain(testServerID1)
+		if explanation.LastHeartbeatAck != nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timeout waiting for a heartbeat age to be reported")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if explanation.HeartbeatAgeSeconds >= 0.5 {
+		t.Errorf("expected a fresh heartbeat age while ACKs flow, got %.3fs", explanation.HeartbeatAgeSeconds)
+	}
+
+	mock.WithholdAcks(true)
+	time.Sleep(200 * time.Millisecond)
+	explanation, _ = mgr.Explain(testServerID1)
+	lastAck := *explanation.LastHeartbeatAck
+	time.Sleep(500 * time.Millisecond)
+
+	explanation, _ = mgr.Explain(testServerID1)
+	if explanation.Status != manager.StatusConnected {
+		t.Fatalf("expected the session to stay connected, got %s", explanation.Status)
+	}
+	if !explanation.LastHeartbeatAck.Equal(lastAck) {
+		t.Errorf("expected no new ACK while withheld, last ACK moved from %v to %v", lastAck, explanation.LastHeartbeatAck)
+	}
+	if explanation.HeartbeatAgeSeconds < 0.5 {
+		t.Errorf("expected the heartbeat age to advance past 0.5s, got %.3fs", explanation.HeartbeatAgeSeconds)
+	}
+
+	families, err := mgr.Metrics.Registry().Gather()
+	if err != nil {
+		t.Fatalf("Gather() error = %v", err)
+	}
+	var age float64
+	for _, f := range families {
+		if f.GetName() != "discord_stayonline_gateway_heartbeat_age_seconds" {
+			continue
+		}
+		for _, metric := range f.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "server_id" && label.GetValue() == testServerID1 {
+					age = metric.GetGauge().GetValue()
+				}
+			}
+		}
+	}
+	if age < 0.5 {
+		t.Errorf("expected the heartbeat age gauge for %s to exceed 0.5s, got %.3f", testServerID1, age)
+	}
+}
@@ -0,0 +1,39 @@
+package tests
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/pyyupsk/discord-stayonline/internal/config"
+	"github.com/pyyupsk/discord-stayonline/internal/config/store"
+	"github.com/pyyupsk/discord-stayonline/internal/manager"
+)
+
+// newTestManager saves createTestConfig, with its servers replaced by servers
+// when non-nil, to a new file store and returns a manager for it with opts
+// applied. The manager is stopped when the test ends.
+func newTestManager(t *testing.T, servers []config.ServerEntry, opts ...func(*manager.SessionManager)) (*manager.SessionManager, *store.File) {
+	t.Helper()
+	s := store.NewFile(filepath.Join(t.TempDir(), testConfigFile))
+	cfg := createTestConfig()
+	if servers != nil {
+		cfg.Servers = servers
+	}
+	if err := s.Save(cfg); err != nil {
+		t.Fatalf(errSaveFormat, err)
+	}
+
+	mgr := manager.NewSessionManager(testToken, s, nil, nil, nil)
+	for _, opt := range opts {
+		opt(mgr)
+	}
+	t.Cleanup(mgr.Stop)
+	return mgr, s
+}
+
+// withGateway points a test manager at the Gateway at url.
+func withGateway(url string) func(*manager.SessionManager) {
+	return func(mgr *manager.SessionManager) {
+		mgr.GatewayURL = url
+	}
+}
@@ -0,0 +1,84 @@
+package tests
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+
+	"github.com/pyyupsk/discord-stayonline/internal/manager"
+)
+
+func TestMaintenanceCloseResumesQuickly(t *testing.T) {
+	mock := NewMockGatewayServer(t)
+	t.Cleanup(mock.Close)
+
+	// No session store: the resume must come from the dropped connection.
+	mgr, _ := newTestManager(t, nil, withGateway(mock.URL()))
+	mgr.MaintenanceReconnectDelay = 100 * time.Millisecond
+
+	if err := mgr.Join(testServerID1); err != nil {
+		t.Fatalf("Join() error = %v", err)
+	}
+	waitForStatus(t, mgr, testServerID1, manager.StatusConnected)
+
+	resumed := make(chan json.RawMessage, 1)
+	mock.onResume = func(data json.RawMessage) { resumed <- data }
+	identified := make(chan struct{}, 1)
+	mock.onIdentify = func(json.RawMessage) { identified <- struct{}{} }
+
+	closedAt := time.Now()
+	if err := mock.CloseConnection(websocket.StatusGoingAway, "rolling deploy"); err != nil {
+		t.Fatalf("CloseConnection() error = %v", err)
+	}
+
+	// Normal backoff waits at least two seconds before the first retry.
+	select {
+	case data := <-resumed:
+		if elapsed := time.Since(closedAt); elapsed > time.Second {
+			t.Errorf("expected a prompt reconnect, resumed after %v", elapsed)
+		}
+		var resume struct {
+			SessionID string `json:"session_id"`
+			Seq       int    `json:"seq"`
+		}
+		if err := json.Unmarshal(data, &resume); err != nil {
+			t.Fatalf("decode RESUME: %v", err)
+		}
+		if resume.SessionID != "test-session-123" || resume.Seq != 1 {
+			t.Errorf("expected RESUME of the dropped session, got %+v", resume)
+		}
+	case <-identified:
+		t.Fatal("expected RESUME after a maintenance close, got IDENTIFY")
+	case <-time.After(2 * time.Second):
+		t.Fatal("timeout waiting for RESUME after a maintenance close")
+	}
+	waitForStatus(t, mgr, testServerID1, manager.StatusConnected)
+}
+
+func TestRateLimitCloseUsesNormalBackoff(t *testing.T) {
+	mock := NewMockGatewayServer(t)
+	t.Cleanup(mock.Close)
+
+	mgr, _ := newTestManager(t, nil, withGateway(mock.URL()))
+	mgr.MaintenanceReconnectDelay = 100 * time.Millisecond
+
+	if err := mgr.Join(testServerID1); err != nil {
+		t.Fatalf("Join() error = %v", err)
+	}
+	waitForStatus(t, mgr, testServerID1, manager.StatusConnected)
+
+	reconnected := make(chan struct{}, 2)
+	mock.onResume = func(json.RawMessage) { reconnected <- struct{}{} }
+	mock.onIdentify = func(json.RawMessage) { reconnected <- struct{}{} }
+
+	if err := mock.CloseConnection(websocket.StatusCode(4008), "rate limited"); err != nil {
+		t.Fatalf("CloseConnection() error = %v", err)
+	}
+	select {
+	case <-reconnected:
+		t.Fatal("expected a rate-limited session to back off normally")
+	case <-time.After(time.Second):
+	}
+}
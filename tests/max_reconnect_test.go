@@ -0,0 +1,65 @@
+package tests
+
+import (
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pyyupsk/discord-stayonline/internal/config/store"
+	"github.com/pyyupsk/discord-stayonline/internal/manager"
+	"github.com/pyyupsk/discord-stayonline/internal/webhook"
+)
+
+func TestMaxReconnectAttemptsStopsSession(t *testing.T) {
+	url, dials := failingDials(t)
+
+	recorder := &webhookRecorder{}
+	hook := httptest.NewServer(recorder)
+	defer hook.Close()
+
+	s := store.NewFile(filepath.Join(t.TempDir(), testConfigFile))
+	if err := s.Save(createTestConfig()); err != nil {
+		t.Fatalf(errSaveFormat, err)
+	}
+	mgr := manager.NewSessionManager(testToken, s, nil, webhook.NewNotifier(hook.URL, nil), nil)
+	mgr.GatewayURL = url
+	mgr.MaxReconnectAttempts = 1
+	defer mgr.Stop()
+
+	if err := mgr.Join(testServerID1); err != nil {
+		t.Fatalf("Join() error = %v", err)
+	}
+
+	// The first failure backs off once; the second exceeds the cap.
+	deadline := time.Now().Add(10 * time.Second)
+	var detail *manager.SessionDetail
+	for {
+		detail, _ = mgr.GetSessionDetail(testServerID1)
+		if detail != nil && strings.HasPrefix(detail.LastError, "Gave up") {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout waiting for reconnects to stop, last state %+v", detail)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	if detail.ConnectionStatus != manager.StatusError {
+		t.Errorf("expected status %q, got %q", manager.StatusError, detail.ConnectionStatus)
+	}
+	if detail.BackoffAttempt != 2 || detail.MaxReconnectAttempts != 1 {
+		t.Errorf("expected attempt 2 of max 1 in the status, got %d of %d", detail.BackoffAttempt, detail.MaxReconnectAttempts)
+	}
+
+	// Notifications are sent asynchronously.
+	time.Sleep(200 * time.Millisecond)
+	if titles := recorder.Titles(); !slices.Contains(titles, "🔴 Connection Lost") {
+		t.Errorf("expected the down notification, got %v", titles)
+	}
+	if got := dials(); got != 2 {
+		t.Errorf("expected no dials after giving up, got %d", got)
+	}
+}
@@ -0,0 +1,56 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pyyupsk/discord-stayonline/internal/api/handlers"
+	"github.com/pyyupsk/discord-stayonline/internal/config"
+	"github.com/pyyupsk/discord-stayonline/internal/manager"
+)
+
+// nilConfigStore violates the ConfigStore contract by returning (nil, nil).
+type nilConfigStore struct{}
+
+func (nilConfigStore) Load() (*config.Configuration, error) { return nil, nil }
+func (nilConfigStore) Save(*config.Configuration) error     { return nil }
+
+func TestLoadConfigNilFallsBackToDefault(t *testing.T) {
+	cfg, err := config.LoadConfig(nilConfigStore{})
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	assertDefaultConfig(t, cfg)
+}
+
+func TestManagerHandlesNilConfig(t *testing.T) {
+	mgr := manager.NewSessionManager("", nilConfigStore{}, nil, nil, nil)
+	defer mgr.Stop()
+
+	if err := mgr.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if err := mgr.Join("unknown"); !errors.Is(err, manager.ErrTOSNotAcknowledged) {
+		t.Errorf("expected ErrTOSNotAcknowledged, got %v", err)
+	}
+}
+
+func TestConfigHandlerHandlesNilConfig(t *testing.T) {
+	h := handlers.NewConfigHandler(nilConfigStore{}, slog.Default())
+
+	rec := httptest.NewRecorder()
+	h.GetConfig(rec, httptest.NewRequest(http.MethodGet, "/api/config", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	var cfg config.Configuration
+	if err := json.Unmarshal(rec.Body.Bytes(), &cfg); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	assertDefaultConfig(t, &cfg)
+}
@@ -0,0 +1,121 @@
+package tests
+
+import (
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/pyyupsk/discord-stayonline/internal/config/store"
+	"github.com/pyyupsk/discord-stayonline/internal/manager"
+	"github.com/pyyupsk/discord-stayonline/internal/webhook"
+)
+
+// reconnectWithEvents joins testServerID1, drops its connection once and
+// waits for the reconnect. It returns the webhook titles seen.
+func reconnectWithEvents(t *testing.T, events manager.NotifyEvents) []string {
+	t.Helper()
+	mock := NewMockGatewayServer(t)
+	defer mock.Close()
+
+	recorder := &webhookRecorder{}
+	hook := httptest.NewServer(recorder)
+	defer hook.Close()
+
+	s := store.NewFile(filepath.Join(t.TempDir(), testConfigFile))
+	if err := s.Save(createTestConfig()); err != nil {
+		t.Fatalf(errSaveFormat, err)
+	}
+
+	mgr := manager.NewSessionManager(testToken, s, nil, webhook.NewNotifier(hook.URL, nil), nil)
+	mgr.GatewayURL = mock.URL()
+	mgr.NotifyEvents = events
+	defer mgr.Stop()
+
+	if err := mgr.Join(testServerID1); err != nil {
+		t.Fatalf("Join() error = %v", err)
+	}
+	waitForStatus(t, mgr, testServerID1, manager.StatusConnected)
+
+	if err := mock.CloseConnection(websocket.StatusCode(4000), "unknown error"); err != nil {
+		t.Fatalf("CloseConnection() error = %v", err)
+	}
+	waitForStatus(t, mgr, testServerID1, manager.StatusBackoff)
+	waitForStatus(t, mgr, testServerID1, manager.StatusConnected)
+
+	// Notifications are sent asynchronously.
+	time.Sleep(200 * time.Millisecond)
+	return recorder.Titles()
+}
+
+func TestDefaultNotifyEvents(t *testing.T) {
+	titles := reconnectWithEvents(t, manager.DefaultNotifyEvents())
+
+	for _, want := range []string{"🟡 Reconnecting", "🟢 Connection Restored"} {
+		if !slices.Contains(titles, want) {
+			t.Errorf("expected %q notification, got %v", want, titles)
+		}
+	}
+	if slices.Contains(titles, "✅ Connected") {
+		t.Errorf("expected no connected notification by default, got %v", titles)
+	}
+}
+
+func TestDisabledNotifyEventsAreSilent(t *testing.T) {
+	titles := reconnectWithEvents(t, manager.NotifyEvents{Up: true, Connected: true})
+
+	if slices.Contains(titles, "🟡 Reconnecting") {
+		t.Errorf("expected reconnecting notification to be disabled, got %v", titles)
+	}
+	for _, want := range []string{"✅ Connected", "🟢 Connection Restored"} {
+		if !slices.Contains(titles, want) {
+			t.Errorf("expected %q notification, got %v", want, titles)
+		}
+	}
+}
+
+func TestConnectedNotificationSentOnce(t *testing.T) {
+	mock := NewMockGatewayServer(t)
+	defer mock.Close()
+
+	recorder := &webhookRecorder{}
+	hook := httptest.NewServer(recorder)
+	defer hook.Close()
+
+	s := store.NewFile(filepath.Join(t.TempDir(), testConfigFile))
+	if err := s.Save(createTestConfig()); err != nil {
+		t.Fatalf(errSaveFormat, err)
+	}
+
+	mgr := manager.NewSessionManager(testToken, s, nil, webhook.NewNotifier(hook.URL, nil), nil)
+	mgr.GatewayURL = mock.URL()
+	mgr.NotifyEvents = manager.NotifyEvents{Up: true, Connected: true}
+	defer mgr.Stop()
+
+	if err := mgr.Join(testServerID1); err != nil {
+		t.Fatalf("Join() error = %v", err)
+	}
+	waitForStatus(t, mgr, testServerID1, manager.StatusConnected)
+
+	// A rejoin starts over with a fresh IDENTIFY but is not a first connect.
+	if err := mgr.Rejoin(testServerID1); err != nil {
+		t.Fatalf("Rejoin() error = %v", err)
+	}
+	waitForStatus(t, mgr, testServerID1, manager.StatusConnected)
+
+	if err := mock.CloseConnection(websocket.StatusCode(4000), "unknown error"); err != nil {
+		t.Fatalf("CloseConnection() error = %v", err)
+	}
+	waitForStatus(t, mgr, testServerID1, manager.StatusBackoff)
+	waitForStatus(t, mgr, testServerID1, manager.StatusConnected)
+
+	// Notifications are sent asynchronously.
+	time.Sleep(200 * time.Millisecond)
+	titles := recorder.Titles()
+	want := []string{"✅ Connected", "🟢 Connection Restored"}
+	if !slices.Equal(titles, want) {
+		t.Errorf("expected notifications %v, got %v", want, titles)
+	}
+}
@@ -0,0 +1,104 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pyyupsk/discord-stayonline/internal/config/store"
+	"github.com/pyyupsk/discord-stayonline/internal/manager"
+	"github.com/pyyupsk/discord-stayonline/internal/webhook"
+)
+
+// webhookRecorder collects the embeds posted to a test webhook.
+type webhookRecorder struct {
+	mu     sync.Mutex
+	embeds []webhook.Embed
+}
+
+func (r *webhookRecorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	var payload webhook.WebhookPayload
+	_ = json.NewDecoder(req.Body).Decode(&payload)
+	r.mu.Lock()
+	r.embeds = append(r.embeds, payload.Embeds...)
+	r.mu.Unlock()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (r *webhookRecorder) Embeds() []webhook.Embed {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]webhook.Embed(nil), r.embeds...)
+}
+
+func (r *webhookRecorder) Titles() []string {
+	var titles []string
+	for _, embed := range r.Embeds() {
+		titles = append(titles, embed.Title)
+	}
+	return titles
+}
+
+// recoverFromInvalidSession joins testServerID1, invalidates its session and
+// waits for the manager to re-identify. It returns the webhook titles seen.
+func recoverFromInvalidSession(t *testing.T, grace time.Duration) []string {
+	t.Helper()
+	mock := NewMockGatewayServer(t)
+	defer mock.Close()
+
+	recorder := &webhookRecorder{}
+	hook := httptest.NewServer(recorder)
+	defer hook.Close()
+
+	s := store.NewFile(filepath.Join(t.TempDir(), testConfigFile))
+	if err := s.Save(createTestConfig()); err != nil {
+		t.Fatalf(errSaveFormat, err)
+	}
+
+	mgr := manager.NewSessionManager(testToken, s, nil, webhook.NewNotifier(hook.URL, nil), nil)
+	mgr.GatewayURL = mock.URL()
+	mgr.ResumeFailureGrace = grace
+	defer mgr.Stop()
+
+	if err := mgr.Join(testServerID1); err != nil {
+		t.Fatalf("Join() error = %v", err)
+	}
+	waitForStatus(t, mgr, testServerID1, manager.StatusConnected)
+
+	if err := mock.SendInvalidSession(context.Background(), false); err != nil {
+		t.Fatalf("SendInvalidSession() error = %v", err)
+	}
+	waitForStatus(t, mgr, testServerID1, manager.StatusBackoff)
+	waitForStatus(t, mgr, testServerID1, manager.StatusConnected)
+
+	// Notifications are sent asynchronously.
+	time.Sleep(200 * time.Millisecond)
+	return recorder.Titles()
+}
+
+func TestInvalidSessionRecoveryWithinGraceIsSilent(t *testing.T) {
+	titles := recoverFromInvalidSession(t, 10*time.Second)
+	if len(titles) != 0 {
+		t.Errorf("expected no webhook notifications, got %v", titles)
+	}
+}
+
+func TestInvalidSessionRecoveryWithoutGraceNotifies(t *testing.T) {
+	titles := recoverFromInvalidSession(t, 0)
+	want := map[string]bool{"🟡 Reconnecting": false, "🟢 Connection Restored": false}
+	for _, title := range titles {
+		if _, ok := want[title]; ok {
+			want[title] = true
+		}
+	}
+	for title, seen := range want {
+		if !seen {
+			t.Errorf("expected %q notification, got %v", title, titles)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/pyyupsk/discord-stayonline/internal/config"
+	"github.com/pyyupsk/discord-stayonline/internal/manager"
+)
+
+// preemptionServers are "low" (priority 2) and "high" (priority 1) servers
+// competing for a manager limited to one connection.
+var preemptionServers = []config.ServerEntry{
+	{ID: "low", GuildID: testGuildID1, ChannelID: testChannelID1, Priority: 2},
+	{ID: "high", GuildID: testGuildID1, ChannelID: testChannelID1, Priority: 1},
+}
+
+func TestHighPriorityJoinPreemptsLowestPriority(t *testing.T) {
+	mock := NewMockGatewayServer(t)
+	t.Cleanup(mock.Close)
+	mgr, _ := newTestManager(t, preemptionServers, withGateway(mock.URL()))
+	mgr.MaxConnections = 1
+	mgr.PreemptLowerPriority = true
+
+	if err := mgr.Join("low"); err != nil {
+		t.Fatalf("Join(low) error = %v", err)
+	}
+	waitForStatus(t, mgr, "low", manager.StatusConnected)
+
+	if err := mgr.Join("high"); err != nil {
+		t.Fatalf("expected high-priority join to preempt, got %v", err)
+	}
+	waitForStatus(t, mgr, "high", manager.StatusConnected)
+
+	if _, ok := mgr.GetAllStatuses()["low"]; ok {
+		t.Error("expected the low-priority session to be disconnected")
+	}
+
+	if err := mgr.Join("low"); !errors.Is(err, manager.ErrTooManyConnections) {
+		t.Errorf("expected low-priority join not to preempt, got %v", err)
+	}
+}
+
+func TestJoinAtCapacityFailsWithoutPreemption(t *testing.T) {
+	mock := NewMockGatewayServer(t)
+	t.Cleanup(mock.Close)
+	mgr, _ := newTestManager(t, preemptionServers, withGateway(mock.URL()))
+	mgr.MaxConnections = 1
+	mgr.PreemptLowerPriority = false
+
+	if err := mgr.Join("low"); err != nil {
+		t.Fatalf("Join(low) error = %v", err)
+	}
+	waitForStatus(t, mgr, "low", manager.StatusConnected)
+
+	if err := mgr.Join("high"); !errors.Is(err, manager.ErrTooManyConnections) {
+		t.Errorf("expected ErrTooManyConnections, got %v", err)
+	}
+}
+
+func TestConcurrentPreemptingJoinsStartOneSession(t *testing.T) {
+	mock := NewMockGatewayServer(t)
+	t.Cleanup(mock.Close)
+	mgr, _ := newTestManager(t, preemptionServers, withGateway(mock.URL()))
+	mgr.MaxConnections = 1
+	mgr.PreemptLowerPriority = true
+
+	if err := mgr.Join("low"); err != nil {
+		t.Fatalf("Join(low) error = %v", err)
+	}
+	waitForStatus(t, mgr, "low", manager.StatusConnected)
+
+	const joins = 8
+	errs := make(chan error, joins)
+	for range joins {
+		go func() { errs <- mgr.Join("high") }()
+	}
+	succeeded := 0
+	for range joins {
+		switch err := <-errs; {
+		case err == nil:
+			succeeded++
+		case !errors.Is(err, manager.ErrAlreadyConnected):
+			t.Errorf("expected ErrAlreadyConnected for a duplicate join, got %v", err)
+		}
+	}
+	if succeeded != 1 {
+		t.Errorf("expected exactly one join to start the session, got %d", succeeded)
+	}
+	waitForStatus(t, mgr, "high", manager.StatusConnected)
+}
@@ -0,0 +1,69 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pyyupsk/discord-stayonline/internal/manager"
+)
+
+// failingDials starts a Gateway that rejects every WebSocket upgrade and
+// returns its URL and a function reporting the dial attempts so far.
+func failingDials(t *testing.T) (string, func() int) {
+	t.Helper()
+	var mu sync.Mutex
+	dials := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		dials++
+		mu.Unlock()
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(server.Close)
+	return "ws" + strings.TrimPrefix(server.URL, "http"), func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return dials
+	}
+}
+
+func TestQuickRetriesBeforeBackoff(t *testing.T) {
+	tests := []struct {
+		name    string
+		retries int
+		want    int
+	}{
+		{name: "disabled", retries: 0, want: 1},
+		{name: "two quick retries", retries: 2, want: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url, dials := failingDials(t)
+
+			mgr, _ := newTestManager(t, nil, withGateway(url), func(mgr *manager.SessionManager) {
+				mgr.QuickConnectRetries = tt.retries
+				mgr.QuickConnectRetryDelay = 50 * time.Millisecond
+			})
+
+			if err := mgr.Join(testServerID1); err != nil {
+				t.Fatalf("Join() error = %v", err)
+			}
+
+			// The quick retries finish well within a second; the first
+			// exponential backoff waits at least two.
+			waitForStatus(t, mgr, testServerID1, manager.StatusBackoff)
+			time.Sleep(time.Second)
+			if got := dials(); got != tt.want {
+				t.Errorf("expected %d dials before backoff, got %d", tt.want, got)
+			}
+			if explanation, _ := mgr.Explain(testServerID1); explanation.BackoffAttempt != 1 {
+				t.Errorf("expected quick retries not to escalate backoff, got attempt %d", explanation.BackoffAttempt)
+			}
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package tests
+
+import (
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pyyupsk/discord-stayonline/internal/config"
+	"github.com/pyyupsk/discord-stayonline/internal/config/store"
+	"github.com/pyyupsk/discord-stayonline/internal/manager"
+)
+
+// memorySessionStore is an in-memory manager.SessionStore.
+type memorySessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]config.SessionState
+}
+
+func newMemorySessionStore(ids ...string) *memorySessionStore {
+	s := &memorySessionStore{sessions: make(map[string]config.SessionState)}
+	for _, id := range ids {
+		s.sessions[id] = config.SessionState{ServerID: id, SessionID: "session-" + id}
+	}
+	return s
+}
+
+func (s *memorySessionStore) SaveSession(state config.SessionState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state.UpdatedAt = time.Now()
+	s.sessions[state.ServerID] = state
+	return nil
+}
+
+func (s *memorySessionStore) LoadSession(serverID string) (*config.SessionState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, ok := s.sessions[serverID]
+	if !ok {
+		return nil, nil
+	}
+	return &state, nil
+}
+
+func (s *memorySessionStore) DeleteSession(serverID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, serverID)
+	return nil
+}
+
+func (s *memorySessionStore) UpdateSessionSequence(serverID string, sequence int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if state, ok := s.sessions[serverID]; ok {
+		state.Sequence = sequence
+		state.UpdatedAt = time.Now()
+		s.sessions[serverID] = state
+	}
+	return nil
+}
+
+func (s *memorySessionStore) ListSessionServerIDs() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := make([]string, 0, len(s.sessions))
+	for id := range s.sessions {
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (s *memorySessionStore) has(serverID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.sessions[serverID]
+	return ok
+}
+
+func TestStartDeletesOrphanedSessionData(t *testing.T) {
+	cfgStore := store.NewFile(filepath.Join(t.TempDir(), testConfigFile))
+	cfg := createTestConfig()
+	cfg.TOSAcknowledged = false
+	if err := cfgStore.Save(cfg); err != nil {
+		t.Fatalf(errSaveFormat, err)
+	}
+
+	sessions := newMemorySessionStore(testServerID1, "removed")
+	mgr := manager.NewSessionManager("", cfgStore, sessions, nil, nil)
+	mgr.CleanupOrphanedSessions = true
+	defer mgr.Stop()
+
+	if err := mgr.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	if sessions.has("removed") {
+		t.Error("expected orphaned session data to be deleted")
+	}
+	if !sessions.has(testServerID1) {
+		t.Error("expected session data for a configured server to be kept")
+	}
+}
+
+func TestReconcileStopsSessionsForRemovedServers(t *testing.T) {
+	cfgStore := store.NewFile(filepath.Join(t.TempDir(), testConfigFile))
+	cfg := createTestConfig()
+	if err := cfgStore.Save(cfg); err != nil {
+		t.Fatalf(errSaveFormat, err)
+	}
+
+	sessions := newMemorySessionStore()
+	mgr := manager.NewSessionManager("", cfgStore, sessions, nil, nil)
+	// Point at a closed port so the session stays in backoff without
+	// reaching Discord.
+	mgr.GatewayURL = "ws://127.0.0.1:1"
+	defer mgr.Stop()
+
+	if err := mgr.Join(testServerID1); err != nil {
+		t.Fatalf("Join() error = %v", err)
+	}
+	if _, ok := mgr.GetAllStatuses()[testServerID1]; !ok {
+		t.Fatal("expected an in-memory session after Join")
+	}
+
+	cfg.Servers = cfg.Servers[1:]
+	if err := cfgStore.Save(cfg); err != nil {
+		t.Fatalf(errSaveFormat, err)
+	}
+	_ = sessions.SaveSession(config.SessionState{ServerID: testServerID1})
+
+	if err := mgr.Reconcile(); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, ok := mgr.GetAllStatuses()[testServerID1]; !ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected session for removed server to be torn down")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if sessions.has(testServerID1) {
+		t.Error("expected persisted session data for removed server to be deleted")
+	}
+}
+
+// listCountingStore counts ListSessionServerIDs calls.
+type listCountingStore struct {
+	*memorySessionStore
+	lists atomic.Int32
+}
+
+func (s *listCountingStore) ListSessionServerIDs() ([]string, error) {
+	s.lists.Add(1)
+	return s.memorySessionStore.ListSessionServerIDs()
+}
+
+func TestReconcileSkipsWhenNoServerRemoved(t *testing.T) {
+	cfgStore := store.NewFile(filepath.Join(t.TempDir(), testConfigFile))
+	cfg := createTestConfig()
+	cfg.TOSAcknowledged = false
+	if err := cfgStore.Save(cfg); err != nil {
+		t.Fatalf(errSaveFormat, err)
+	}
+
+	sessions := &listCountingStore{memorySessionStore: newMemorySessionStore()}
+	mgr := manager.NewSessionManager("", cfgStore, sessions, nil, nil)
+	mgr.CleanupOrphanedSessions = true
+	defer mgr.Stop()
+	if err := mgr.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if got := sessions.lists.Load(); got != 1 {
+		t.Fatalf("expected the startup reconcile to scan persisted sessions once, got %d", got)
+	}
+
+	cfg.Servers[0].Label = "Renamed"
+	if err := cfgStore.Save(cfg); err != nil {
+		t.Fatalf(errSaveFormat, err)
+	}
+	if err := mgr.Reconcile(); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if got := sessions.lists.Load(); got != 1 {
+		t.Errorf("expected no scan after only editing a server, got %d scans", got)
+	}
+
+	cfg.Servers = cfg.Servers[1:]
+	if err := cfgStore.Save(cfg); err != nil {
+		t.Fatalf(errSaveFormat, err)
+	}
+	if err := mgr.Reconcile(); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if got := sessions.lists.Load(); got != 2 {
+		t.Errorf("expected a scan after removing a server, got %d scans", got)
+	}
+}
@@ -0,0 +1,93 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pyyupsk/discord-stayonline/internal/config"
+	"github.com/pyyupsk/discord-stayonline/internal/manager"
+)
+
+const (
+	presenceServer1 = "presence-1"
+	presenceServer2 = "presence-2"
+)
+
+// presenceOnlyServers are two presence-only entries in the same guild.
+var presenceOnlyServers = []config.ServerEntry{
+	{ID: presenceServer1, GuildID: testGuildID1, PresenceOnly: true, Priority: 1},
+	{ID: presenceServer2, GuildID: testGuildID1, PresenceOnly: true, Priority: 2},
+}
+
+func waitForCount(t *testing.T, counter *atomic.Int32, want int32, what string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for counter.Load() != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d %s, got %d", want, what, counter.Load())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSharedPresenceEntriesUseOneConnection(t *testing.T) {
+	mock := NewMockGatewayServer(t)
+	defer mock.Close()
+	var connects, voiceUpdates atomic.Int32
+	mock.onConnect = func() { connects.Add(1) }
+	mock.onVoiceState = func(json.RawMessage) { voiceUpdates.Add(1) }
+	mgr, _ := newTestManager(t, presenceOnlyServers, withGateway(mock.URL()))
+	mgr.SharedPresenceConnection = true
+
+	if err := mgr.Join(presenceServer1); err != nil {
+		t.Fatalf("Join() error = %v", err)
+	}
+	waitForStatus(t, mgr, presenceServer1, manager.StatusConnected)
+	if err := mgr.Join(presenceServer2); err != nil {
+		t.Fatalf("Join() error = %v", err)
+	}
+	waitForStatus(t, mgr, presenceServer2, manager.StatusConnected)
+
+	time.Sleep(200 * time.Millisecond)
+	if got := connects.Load(); got != 1 {
+		t.Errorf("expected presence-only entries to share 1 connection, got %d", got)
+	}
+	if got := voiceUpdates.Load(); got != 0 {
+		t.Errorf("expected no voice state updates for presence-only entries, got %d", got)
+	}
+
+	if _, err := mgr.SetStatusOverride(presenceServer2, config.StatusDND); !errors.Is(err, manager.ErrSharedConnection) {
+		t.Errorf("expected ErrSharedConnection for a per-server override, got %v", err)
+	}
+
+	// Exiting the entry that owns the connection moves the other one to a
+	// new connection instead of dropping it.
+	if err := mgr.Exit(presenceServer1); err != nil {
+		t.Fatalf("Exit() error = %v", err)
+	}
+	waitForCount(t, &connects, 2, "connections after handover")
+	waitForStatus(t, mgr, presenceServer2, manager.StatusConnected)
+	if status, _ := mgr.GetStatus(presenceServer1); status != manager.StatusDisconnected {
+		t.Errorf("expected exited entry to be disconnected, got %s", status)
+	}
+}
+
+func TestPresenceEntriesWithoutSharedModeConnectSeparately(t *testing.T) {
+	mock := NewMockGatewayServer(t)
+	defer mock.Close()
+	var connects atomic.Int32
+	mock.onConnect = func() { connects.Add(1) }
+	mgr, _ := newTestManager(t, presenceOnlyServers, withGateway(mock.URL()))
+
+	if err := mgr.Join(presenceServer1); err != nil {
+		t.Fatalf("Join() error = %v", err)
+	}
+	waitForStatus(t, mgr, presenceServer1, manager.StatusConnected)
+	if err := mgr.Join(presenceServer2); err != nil {
+		t.Fatalf("Join() error = %v", err)
+	}
+	waitForCount(t, &connects, 2, "connections")
+}
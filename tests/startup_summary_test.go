@@ -0,0 +1,94 @@
+package tests
+
+import (
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pyyupsk/discord-stayonline/internal/config/store"
+	"github.com/pyyupsk/discord-stayonline/internal/manager"
+	"github.com/pyyupsk/discord-stayonline/internal/webhook"
+)
+
+func TestStartupSummarySentOnceAfterSessionsSettle(t *testing.T) {
+	mock := NewMockGatewayServer(t)
+	defer mock.Close()
+
+	recorder := &webhookRecorder{}
+	hook := httptest.NewServer(recorder)
+	defer hook.Close()
+
+	s := store.NewFile(filepath.Join(t.TempDir(), testConfigFile))
+	cfg := createTestConfig()
+	cfg.Servers[0].Label = "Lounge"
+	if err := s.Save(cfg); err != nil {
+		t.Fatalf(errSaveFormat, err)
+	}
+
+	mgr := manager.NewSessionManager(testToken, s, nil, webhook.NewNotifier(hook.URL, nil), nil)
+	mgr.GatewayURL = mock.URL()
+	mgr.StartupSummary = true
+	mgr.StartupSummarySettle = 300 * time.Millisecond
+	defer mgr.Stop()
+
+	if err := mgr.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	waitForStatus(t, mgr, testServerID1, manager.StatusConnected)
+
+	time.Sleep(time.Second)
+
+	var started, summaries []webhook.Embed
+	for _, embed := range recorder.Embeds() {
+		switch embed.Title {
+		case "🚀 Service Started":
+			started = append(started, embed)
+		case "📊 Status Summary":
+			summaries = append(summaries, embed)
+		}
+	}
+
+	if len(started) != 1 || !strings.Contains(started[0].Description, "1 server(s) connecting") {
+		t.Errorf("expected one startup notice for 1 server, got %+v", started)
+	}
+	if len(summaries) != 1 {
+		t.Fatalf("expected exactly one status summary, got %d", len(summaries))
+	}
+	if !strings.Contains(summaries[0].Description, "Lounge: connected") {
+		t.Errorf("expected summary to report the settled session, got %q", summaries[0].Description)
+	}
+	if strings.Contains(summaries[0].Description, "test-2") {
+		t.Errorf("expected summary to cover only auto-connected servers, got %q", summaries[0].Description)
+	}
+}
+
+func TestStartupSummaryIsOptIn(t *testing.T) {
+	mock := NewMockGatewayServer(t)
+	defer mock.Close()
+
+	recorder := &webhookRecorder{}
+	hook := httptest.NewServer(recorder)
+	defer hook.Close()
+
+	s := store.NewFile(filepath.Join(t.TempDir(), testConfigFile))
+	if err := s.Save(createTestConfig()); err != nil {
+		t.Fatalf(errSaveFormat, err)
+	}
+
+	mgr := manager.NewSessionManager(testToken, s, nil, webhook.NewNotifier(hook.URL, nil), nil)
+	mgr.GatewayURL = mock.URL()
+	mgr.StartupSummarySettle = 100 * time.Millisecond
+	defer mgr.Stop()
+
+	if err := mgr.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	waitForStatus(t, mgr, testServerID1, manager.StatusConnected)
+	time.Sleep(500 * time.Millisecond)
+
+	if titles := recorder.Titles(); len(titles) != 0 {
+		t.Errorf("expected no webhooks without StartupSummary, got %v", titles)
+	}
+}
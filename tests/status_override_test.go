@@ -0,0 +1,136 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pyyupsk/discord-stayonline/internal/api/handlers"
+	"github.com/pyyupsk/discord-stayonline/internal/manager"
+)
+
+func expectStatus(t *testing.T, ch <-chan string, want, what string) {
+	t.Helper()
+	select {
+	case got := <-ch:
+		if got != want {
+			t.Errorf("expected %s with status %q, got %q", what, want, got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timeout waiting for %s", what)
+	}
+}
+
+func TestStatusOverridePersistsAcrossReconnectUntilCleared(t *testing.T) {
+	mock := NewMockGatewayServer(t)
+	t.Cleanup(mock.Close)
+
+	identifies := make(chan string, 10)
+	presences := make(chan string, 10)
+	mock.onIdentify = func(data json.RawMessage) {
+		var identify struct {
+			Presence struct {
+				Status string `json:"status"`
+			} `json:"presence"`
+		}
+		_ = json.Unmarshal(data, &identify)
+		identifies <- identify.Presence.Status
+	}
+	mock.onPresence = func(data json.RawMessage) {
+		var presence struct {
+			Status string `json:"status"`
+		}
+		_ = json.Unmarshal(data, &presence)
+		presences <- presence.Status
+	}
+
+	mgr, s := newTestManager(t, nil, withGateway(mock.URL()))
+
+	if err := mgr.Join(testServerID1); err != nil {
+		t.Fatalf("Join() error = %v", err)
+	}
+	expectStatus(t, identifies, "idle", "initial IDENTIFY")
+	waitForStatus(t, mgr, testServerID1, manager.StatusConnected)
+
+	h := handlers.NewServersHandler(mgr, slog.Default())
+	send := func(method, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/servers/"+testServerID1+"/status", strings.NewReader(body))
+		req.SetPathValue("id", testServerID1)
+		rec := httptest.NewRecorder()
+		if method == http.MethodDelete {
+			h.ClearStatusOverride(rec, req)
+		} else {
+			h.SetStatusOverride(rec, req)
+		}
+		return rec
+	}
+
+	if rec := send(http.MethodPut, `{"status": "dnd"}`); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 setting override, got %d: %s", rec.Code, rec.Body)
+	}
+	expectStatus(t, presences, "dnd", "presence update for the override")
+
+	// Global status changes must not touch the overridden session.
+	mgr.ApplyStatus("online")
+	select {
+	case got := <-presences:
+		t.Errorf("expected no presence update for overridden session, got %q", got)
+	case <-time.After(300 * time.Millisecond):
+	}
+
+	// An invalid session forces a fresh IDENTIFY on reconnect.
+	if err := mock.SendInvalidSession(context.Background(), false); err != nil {
+		t.Fatalf("SendInvalidSession() error = %v", err)
+	}
+	expectStatus(t, identifies, "dnd", "IDENTIFY after reconnect")
+	waitForStatus(t, mgr, testServerID1, manager.StatusConnected)
+
+	if rec := send(http.MethodDelete, ""); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 clearing override, got %d: %s", rec.Code, rec.Body)
+	}
+	expectStatus(t, presences, "idle", "presence update reverting to global status")
+
+	loaded, err := s.Load()
+	if err != nil {
+		t.Fatalf(errLoadFormat, err)
+	}
+	if loaded.Servers[0].StatusOverride != "" {
+		t.Errorf("expected override to be cleared in storage, got %q", loaded.Servers[0].StatusOverride)
+	}
+}
+
+func TestStatusOverrideValidation(t *testing.T) {
+	mgr, s := newTestManager(t, nil)
+	h := handlers.NewServersHandler(mgr, slog.Default())
+
+	req := httptest.NewRequest(http.MethodPut, "/api/servers/"+testServerID1+"/status", strings.NewReader(`{"status": "invisible"}`))
+	req.SetPathValue("id", testServerID1)
+	rec := httptest.NewRecorder()
+	h.SetStatusOverride(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for invalid status, got %d", rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPut, "/api/servers/missing/status", strings.NewReader(`{"status": "dnd"}`))
+	req.SetPathValue("id", "missing")
+	rec = httptest.NewRecorder()
+	h.SetStatusOverride(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown server, got %d", rec.Code)
+	}
+
+	loaded, err := s.Load()
+	if err != nil {
+		t.Fatalf(errLoadFormat, err)
+	}
+	for _, entry := range loaded.Servers {
+		if entry.StatusOverride != "" {
+			t.Errorf("expected no override to be stored, got %+v", entry)
+		}
+	}
+}
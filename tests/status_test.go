@@ -0,0 +1,142 @@
+package tests
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pyyupsk/discord-stayonline/internal/api/handlers"
+	"github.com/pyyupsk/discord-stayonline/internal/config"
+	"github.com/pyyupsk/discord-stayonline/internal/config/store"
+	"github.com/pyyupsk/discord-stayonline/internal/manager"
+)
+
+func TestSetGlobalStatusPropagatesToLiveSessions(t *testing.T) {
+	mock := NewMockGatewayServer(t)
+	t.Cleanup(mock.Close)
+	presences := make(chan string, 10)
+	mock.onPresence = func(data json.RawMessage) {
+		var presence struct {
+			Status string `json:"status"`
+		}
+		_ = json.Unmarshal(data, &presence)
+		presences <- presence.Status
+	}
+
+	mgr, s := newTestManager(t, nil, withGateway(mock.URL()))
+
+	if err := mgr.Join(testServerID1); err != nil {
+		t.Fatalf("Join() error = %v", err)
+	}
+	waitForStatus(t, mgr, testServerID1, manager.StatusConnected)
+
+	h := handlers.NewStatusHandler(s, mgr, slog.Default())
+	rec := httptest.NewRecorder()
+	h.SetStatus(rec, httptest.NewRequest(http.MethodPut, "/api/status", strings.NewReader(`{"status": "dnd"}`)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+
+	select {
+	case status := <-presences:
+		if status != "dnd" {
+			t.Errorf("expected presence update with status 'dnd', got %q", status)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected a presence update on the live session")
+	}
+
+	loaded, err := s.Load()
+	if err != nil {
+		t.Fatalf(errLoadFormat, err)
+	}
+	if loaded.Status != config.StatusDND {
+		t.Errorf("expected stored status 'dnd', got %q", loaded.Status)
+	}
+	if len(loaded.Servers) != len(createTestConfig().Servers) || loaded.Servers[0].ID != testServerID1 {
+		t.Errorf("expected server entries to be untouched, got %+v", loaded.Servers)
+	}
+
+	rec = httptest.NewRecorder()
+	h.GetStatus(rec, httptest.NewRequest(http.MethodGet, "/api/status", nil))
+	if !strings.Contains(rec.Body.String(), `"status":"dnd"`) {
+		t.Errorf("expected GET /api/status to report dnd, got %s", rec.Body)
+	}
+}
+
+func TestSetGlobalStatusRejectsInvalidValue(t *testing.T) {
+	s := store.NewFile(filepath.Join(t.TempDir(), testConfigFile))
+	h := handlers.NewStatusHandler(s, nil, slog.Default())
+
+	for _, body := range []string{`{"status": "busy"}`, `{"status": ""}`} {
+		rec := httptest.NewRecorder()
+		h.SetStatus(rec, httptest.NewRequest(http.MethodPut, "/api/status", strings.NewReader(body)))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("expected 400 for %s, got %d", body, rec.Code)
+		}
+	}
+}
+
+// waitForStatus polls until the session for serverID reports want.
+func waitForStatus(t *testing.T, mgr *manager.SessionManager, serverID string, want manager.ConnectionStatus) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if status, _ := mgr.GetStatus(serverID); status == want {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout waiting for %s to be %s", serverID, want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestServerStatusDetail(t *testing.T) {
+	mock := NewMockGatewayServer(t)
+	t.Cleanup(mock.Close)
+
+	mgr, _ := newTestManager(t, nil, withGateway(mock.URL()))
+
+	if err := mgr.Join(testServerID1); err != nil {
+		t.Fatalf("Join() error = %v", err)
+	}
+	waitForStatus(t, mgr, testServerID1, manager.StatusConnected)
+
+	h := handlers.NewServersHandler(mgr, slog.Default())
+	get := func(id string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/servers/"+id+"/status", nil)
+		req.SetPathValue("id", id)
+		rec := httptest.NewRecorder()
+		h.GetStatusDetail(rec, req)
+		return rec
+	}
+
+	rec := get(testServerID1)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	var detail struct {
+		ServerID        string    `json:"server_id"`
+		Status          string    `json:"status"`
+		SessionID       string    `json:"session_id"`
+		LastConnectTime time.Time `json:"last_connect_time"`
+		BackoffAttempt  int       `json:"backoff_attempt"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &detail); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if detail.ServerID != testServerID1 || detail.Status != string(manager.StatusConnected) ||
+		detail.SessionID != "test-session-123" || detail.LastConnectTime.IsZero() || detail.BackoffAttempt != 0 {
+		t.Errorf("unexpected session detail: %s", rec.Body)
+	}
+
+	if rec := get("test-2"); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for a server without a session, got %d", rec.Code)
+	}
+}
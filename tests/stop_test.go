@@ -0,0 +1,37 @@
+package tests
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pyyupsk/discord-stayonline/internal/api/handlers"
+	"github.com/pyyupsk/discord-stayonline/internal/manager"
+)
+
+func TestJoinAfterStopIsRejected(t *testing.T) {
+	mgr, _ := newTestManager(t, nil)
+	mgr.Stop()
+
+	if err := mgr.Join(testServerID1); !errors.Is(err, manager.ErrServiceStopping) {
+		t.Fatalf("expected ErrServiceStopping, got %v", err)
+	}
+	if err := mgr.Rejoin(testServerID1); !errors.Is(err, manager.ErrServiceStopping) {
+		t.Fatalf("expected ErrServiceStopping from Rejoin, got %v", err)
+	}
+	if statuses := mgr.GetAllStatuses(); len(statuses) != 0 {
+		t.Errorf("expected no session left behind, got %v", statuses)
+	}
+
+	h := handlers.NewServersHandler(mgr, slog.Default())
+	req := httptest.NewRequest(http.MethodPost, "/api/servers/"+testServerID1+"/action", strings.NewReader(`{"action": "join"}`))
+	rec := httptest.NewRecorder()
+	h.ExecuteAction(rec, req)
+
+	if rec.Code != http.StatusServiceUnavailable || !strings.Contains(rec.Body.String(), "service_stopping") {
+		t.Errorf("expected 503 service_stopping, got %d: %s", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,51 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/pyyupsk/discord-stayonline/internal/manager"
+	"github.com/pyyupsk/discord-stayonline/internal/telemetry"
+)
+
+func TestTelemetryTracesGatewayConnect(t *testing.T) {
+	mock := NewMockGatewayServer(t)
+	t.Cleanup(mock.Close)
+
+	spans := tracetest.NewSpanRecorder()
+	tel, err := telemetry.NewWithProviders(
+		sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)),
+		sdkmetric.NewMeterProvider(),
+	)
+	if err != nil {
+		t.Fatalf("NewWithProviders() error = %v", err)
+	}
+
+	mgr, _ := newTestManager(t, nil, withGateway(mock.URL()), func(mgr *manager.SessionManager) {
+		mgr.Telemetry = tel
+	})
+
+	if err := mgr.Join(testServerID1); err != nil {
+		t.Fatalf("Join() error = %v", err)
+	}
+	waitForStatus(t, mgr, testServerID1, manager.StatusConnected)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		names := make(map[string]bool)
+		for _, span := range spans.Ended() {
+			names[span.Name()] = true
+		}
+		if names["gateway.connect"] && names["gateway.dial"] && names["gateway.identify"] {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected connect, dial and identify spans, got %v", names)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
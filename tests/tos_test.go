@@ -0,0 +1,48 @@
+package tests
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/pyyupsk/discord-stayonline/internal/config"
+	"github.com/pyyupsk/discord-stayonline/internal/config/store"
+	"github.com/pyyupsk/discord-stayonline/internal/manager"
+)
+
+func TestAcknowledgeTOS(t *testing.T) {
+	s := store.NewFile(filepath.Join(t.TempDir(), testConfigFile))
+
+	mgr := manager.NewSessionManager("", s, nil, nil, nil)
+	if err := mgr.Join("unknown"); !errors.Is(err, manager.ErrTOSNotAcknowledged) {
+		t.Fatalf("expected ErrTOSNotAcknowledged before acknowledgment, got %v", err)
+	}
+
+	changed, err := config.AcknowledgeTOS(s)
+	if err != nil {
+		t.Fatalf("AcknowledgeTOS() error = %v", err)
+	}
+	if !changed {
+		t.Error("expected first acknowledgment to update the store")
+	}
+
+	cfg, err := s.Load()
+	if err != nil {
+		t.Fatalf(errLoadFormat, err)
+	}
+	if !cfg.TOSAcknowledged {
+		t.Error("expected TOSAcknowledged to be true")
+	}
+
+	if err := mgr.Join("unknown"); !errors.Is(err, manager.ErrServerNotFound) {
+		t.Errorf("expected TOS gate to pass after acknowledgment, got %v", err)
+	}
+
+	changed, err = config.AcknowledgeTOS(s)
+	if err != nil {
+		t.Fatalf("AcknowledgeTOS() error = %v", err)
+	}
+	if changed {
+		t.Error("expected repeated acknowledgment to be a no-op")
+	}
+}
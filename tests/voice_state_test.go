@@ -0,0 +1,120 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/pyyupsk/discord-stayonline/internal/gateway"
+	"github.com/pyyupsk/discord-stayonline/internal/manager"
+)
+
+func TestVoiceStateConfirmation(t *testing.T) {
+	mock := NewMockGatewayServer(t)
+	t.Cleanup(mock.Close)
+	mock.onVoiceState = func(json.RawMessage) {
+		// Another member's update must not count as ours.
+		_ = mock.SendVoiceStateUpdate(context.Background(), "someone-else", testGuildID1, testChannelID1)
+		_ = mock.SendVoiceStateUpdate(context.Background(), "test-session-123", testGuildID1, testChannelID1)
+	}
+
+	mgr, _ := newTestManager(t, nil, withGateway(mock.URL()))
+	mgr.ConfirmVoiceState = true
+	if err := mgr.Join(testServerID1); err != nil {
+		t.Fatalf("Join() error = %v", err)
+	}
+	waitForStatus(t, mgr, testServerID1, manager.StatusInVoice)
+
+	if err := mock.SendVoiceStateUpdate(context.Background(), "test-session-123", testGuildID1, ""); err != nil {
+		t.Fatalf("SendVoiceStateUpdate() error = %v", err)
+	}
+	waitForStatus(t, mgr, testServerID1, manager.StatusConnected)
+}
+
+func TestVoiceStateIgnoresOtherMembers(t *testing.T) {
+	mock := NewMockGatewayServer(t)
+	t.Cleanup(mock.Close)
+	sent := make(chan struct{})
+	mock.onVoiceState = func(json.RawMessage) {
+		_ = mock.SendVoiceStateUpdate(context.Background(), "someone-else", testGuildID1, testChannelID1)
+		close(sent)
+	}
+
+	mgr, _ := newTestManager(t, nil, withGateway(mock.URL()))
+	mgr.ConfirmVoiceState = true
+	if err := mgr.Join(testServerID1); err != nil {
+		t.Fatalf("Join() error = %v", err)
+	}
+	waitForStatus(t, mgr, testServerID1, manager.StatusConnected)
+	<-sent
+	time.Sleep(100 * time.Millisecond)
+
+	if status, _ := mgr.GetStatus(testServerID1); status != manager.StatusConnected {
+		t.Errorf("expected another member's voice state to be ignored, got %s", status)
+	}
+}
+
+func TestVoiceStateConfirmationDisabledByDefault(t *testing.T) {
+	mock := NewMockGatewayServer(t)
+	t.Cleanup(mock.Close)
+	sent := make(chan struct{})
+	mock.onVoiceState = func(json.RawMessage) {
+		_ = mock.SendVoiceStateUpdate(context.Background(), "test-session-123", testGuildID1, testChannelID1)
+		close(sent)
+	}
+
+	mgr, _ := newTestManager(t, nil, withGateway(mock.URL()))
+	mgr.ConfirmVoiceState = false
+	if err := mgr.Join(testServerID1); err != nil {
+		t.Fatalf("Join() error = %v", err)
+	}
+	waitForStatus(t, mgr, testServerID1, manager.StatusConnected)
+	<-sent
+	time.Sleep(100 * time.Millisecond)
+
+	if status, _ := mgr.GetStatus(testServerID1); status != manager.StatusConnected {
+		t.Errorf("expected connected without confirmation, got %s", status)
+	}
+}
+
+func TestVoiceJoinUsesSelfMuteAndDeaf(t *testing.T) {
+	listening := false
+	tests := []struct {
+		name         string
+		selfDeaf     *bool
+		wantMute     bool
+		wantDeafened bool
+	}{
+		{name: "default", selfDeaf: nil, wantMute: true, wantDeafened: true},
+		{name: "undeafened", selfDeaf: &listening, wantMute: true, wantDeafened: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := NewMockGatewayServer(t)
+			t.Cleanup(mock.Close)
+			voice := make(chan gateway.VoiceStateData, 1)
+			mock.onVoiceState = func(data json.RawMessage) {
+				var update gateway.VoiceStateData
+				_ = json.Unmarshal(data, &update)
+				voice <- update
+			}
+
+			servers := createTestConfig().Servers
+			servers[0].SelfDeaf = tt.selfDeaf
+			mgr, _ := newTestManager(t, servers, withGateway(mock.URL()))
+
+			if err := mgr.Join(testServerID1); err != nil {
+				t.Fatalf("Join() error = %v", err)
+			}
+			select {
+			case update := <-voice:
+				if update.SelfMute != tt.wantMute || update.SelfDeaf != tt.wantDeafened {
+					t.Errorf("expected self_mute=%v self_deaf=%v, got %+v", tt.wantMute, tt.wantDeafened, update)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("timeout waiting for voice state update")
+			}
+		})
+	}
+}
@@ -11,3 +11,8 @@ var WebFS embed.FS
 func GetWebFS() (fs.FS, error) {
 	return fs.Sub(WebFS, "web/dist")
 }
+
+// OpenAPISpec is the OpenAPI 3 description of the REST API.
+//
+//go:embed docs/openapi.json
+var OpenAPISpec []byte
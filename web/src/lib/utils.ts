@@ -1,6 +1,13 @@
 import { type ClassValue, clsx } from "clsx";
 import { twMerge } from "tailwind-merge";
 
+import type { ConnectionStatus } from "@/types";
+
 export function cn(...inputs: ClassValue[]) {
   return twMerge(clsx(inputs));
 }
+
+// isOnline reports a live connection, with or without confirmed voice.
+export function isOnline(status?: ConnectionStatus): boolean {
+  return status === "connected" || status === "in_voice";
+}
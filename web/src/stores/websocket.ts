@@ -372,6 +372,8 @@ function getFriendlyStatusMessage(
       return `Connecting to ${name}...`;
     case "disconnected":
       return `${name} disconnected`;
+    case "in_voice":
+      return `${name} joined the voice channel`;
     case "error":
       return originalMessage ? `${name}: ${originalMessage}` : `${name} encountered an error`;
     default:
@@ -395,6 +397,7 @@ function mapStatusToAction(status: ConnectionStatus): LogEntry["action"] {
     case "backoff":
       return "backoff";
     case "connected":
+    case "in_voice":
       return "connected";
     case "connecting":
       return "connecting";
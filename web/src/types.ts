@@ -4,11 +4,18 @@ export type Configuration = {
   tos_acknowledged: boolean;
 };
 
-export type ConnectionStatus = "backoff" | "connected" | "connecting" | "disconnected" | "error";
+export type ConnectionStatus =
+  | "backoff"
+  | "connected"
+  | "connecting"
+  | "disconnected"
+  | "error"
+  | "in_voice";
 
 export type GuildInfo = {
   icon?: string;
   id: string;
+  label?: string;
   name: string;
 };
 
@@ -37,13 +44,19 @@ export type ServerEntry = {
   guild_id: string;
   guild_name?: string;
   id: string;
+  label?: string;
+  presence_only?: boolean;
   priority: number;
+  self_deaf?: boolean;
+  self_mute?: boolean;
+  status_override?: Status;
 };
 
 // Server groups for organization
 export type ServerGroup = {
   collapsed: boolean;
   id: string;
+  label?: string;
   name: string;
   serverIds: string[];
 };
@@ -64,11 +77,13 @@ export type UserInfo = {
   discriminator: string;
   global_name?: string;
   id: string;
+  label?: string;
   username: string;
 };
 
 export type VoiceChannelInfo = {
   id: string;
+  label?: string;
   name: string;
   position: number;
 };